@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxProblemBody bounds admin problem requests, whose content is HTML
+const maxProblemBody = 1 << 20
+
+// AdminHandler serves the problem management endpoints under /api/admin.
+// Routes pick the role they require with middleware.RequireRole.
+type AdminHandler struct {
+	problemStore *models.ProblemStore
+	userStore    *models.UserStore
+	auditStore   *models.AuditStore
+}
+
+func NewAdminHandler(problemStore *models.ProblemStore, userStore *models.UserStore, auditStore *models.AuditStore) *AdminHandler {
+	return &AdminHandler{problemStore: problemStore, userStore: userStore, auditStore: auditStore}
+}
+
+func isValidDifficulty(difficulty string) bool {
+	return difficulty == "Easy" || difficulty == "Medium" || difficulty == "Hard"
+}
+
+// GetProblems lists problems like GET /api/problems, also accepting a
+// lifecycle filter (comma separated, e.g. "hidden,retired")
+func (h *AdminHandler) GetProblems(w http.ResponseWriter, r *http.Request) {
+	options, err := problemListOptionsFromQuery(r)
+	if err != nil {
+		response.ValidationError(w, "tags_mode", err.Error())
+		return
+	}
+
+	options.Filter.Lifecycles = splitList(r.URL.Query().Get("lifecycle"))
+	for _, lifecycle := range options.Filter.Lifecycles {
+		if !models.IsValidProblemLifecycle(lifecycle) {
+			response.ValidationError(w, "lifecycle", "lifecycle must be active, hidden or retired")
+			return
+		}
+	}
+
+	result, err := h.problemStore.ListProblems(options)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get problems list")
+		return
+	}
+
+	page := (options.Offset / options.Limit) + 1
+	response.JSONWithFacets(w, http.StatusOK, result.Problems, result.Total, page, options.Limit, result.Facets)
+}
+
+// GetProblem returns a problem in any lifecycle, with its solution approach
+func (h *AdminHandler) GetProblem(w http.ResponseWriter, r *http.Request) {
+	id, ok := problemIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	problem, err := h.problemStore.GetProblemForAdmin(id)
+	if writeProblemError(w, err) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, problem)
+}
+
+func (h *AdminHandler) CreateProblem(w http.ResponseWriter, r *http.Request) {
+	actorID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	var problem models.Problem
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxProblemBody)).Decode(&problem); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	switch {
+	case problem.ID <= 0:
+		response.ValidationError(w, "id", "id must be a positive LeetCode question ID")
+		return
+	case problem.FrontendID <= 0:
+		response.ValidationError(w, "frontend_id", "frontend_id must be positive")
+		return
+	case strings.TrimSpace(problem.Title) == "":
+		response.ValidationError(w, "title", "Title is required")
+		return
+	case strings.TrimSpace(problem.TitleSlug) == "":
+		response.ValidationError(w, "title_slug", "Problem title slug is required")
+		return
+	case !isValidDifficulty(problem.Difficulty):
+		response.ValidationError(w, "difficulty", "difficulty must be Easy, Medium or Hard")
+		return
+	}
+	for _, tag := range problem.TopicTags {
+		if tag.Slug == "" || tag.Name == "" {
+			response.ValidationError(w, "topic_tags", "Each topic tag needs a name and a slug")
+			return
+		}
+	}
+
+	if err := h.problemStore.CreateProblem(actorID, &problem); writeProblemError(w, err) {
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, problem)
+}
+
+// UpdateProblem changes the fields present in the body, including the
+// solution approach
+func (h *AdminHandler) UpdateProblem(w http.ResponseWriter, r *http.Request) {
+	actorID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	id, ok := problemIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var update models.ProblemUpdate
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxProblemBody)).Decode(&update); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	switch {
+	case update.FrontendID != nil && *update.FrontendID <= 0:
+		response.ValidationError(w, "frontend_id", "frontend_id must be positive")
+		return
+	case update.Title != nil && strings.TrimSpace(*update.Title) == "":
+		response.ValidationError(w, "title", "Title cannot be empty")
+		return
+	case update.TitleSlug != nil && strings.TrimSpace(*update.TitleSlug) == "":
+		response.ValidationError(w, "title_slug", "Problem title slug cannot be empty")
+		return
+	case update.Difficulty != nil && !isValidDifficulty(*update.Difficulty):
+		response.ValidationError(w, "difficulty", "difficulty must be Easy, Medium or Hard")
+		return
+	}
+
+	problem, err := h.problemStore.UpdateProblem(actorID, id, update)
+	if writeProblemError(w, err) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, problem)
+}
+
+// SetProblemLifecycle hides, retires or reactivates a problem
+func (h *AdminHandler) SetProblemLifecycle(w http.ResponseWriter, r *http.Request) {
+	actorID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	id, ok := problemIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Lifecycle string `json:"lifecycle"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if !models.IsValidProblemLifecycle(req.Lifecycle) {
+		response.ValidationError(w, "lifecycle", "lifecycle must be active, hidden or retired")
+		return
+	}
+
+	problem, err := h.problemStore.SetProblemLifecycle(actorID, id, req.Lifecycle)
+	if writeProblemError(w, err) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, problem)
+}
+
+// SetProblemTopics replaces the topic tags of a problem
+func (h *AdminHandler) SetProblemTopics(w http.ResponseWriter, r *http.Request) {
+	actorID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	id, ok := problemIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		TopicTags models.TopicTags `json:"topic_tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if req.TopicTags == nil {
+		req.TopicTags = models.TopicTags{}
+	}
+	for _, tag := range req.TopicTags {
+		if tag.Slug == "" || tag.Name == "" {
+			response.ValidationError(w, "topic_tags", "Each topic tag needs a name and a slug")
+			return
+		}
+	}
+
+	problem, err := h.problemStore.SetProblemTopics(actorID, id, req.TopicTags)
+	if writeProblemError(w, err) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, problem)
+}
+
+// SetUserRole grants a role to a user
+func (h *AdminHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	actorID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if !models.IsValidRole(req.Role) {
+		response.ValidationError(w, "role", "role must be user, editor or admin")
+		return
+	}
+	if userID == actorID && req.Role != models.RoleAdmin {
+		response.ValidationError(w, "role", "Admins cannot take their own admin role away")
+		return
+	}
+
+	if err := h.userStore.SetUserRole(actorID, userID, req.Role); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, "not_found", "User not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to update user role")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"user_id": userID.String(), "role": req.Role})
+}
+
+// GetAuditLog lists the latest audit entries, optionally for one entity or
+// actor
+func (h *AdminHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := models.AuditFilter{
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+	}
+	if actor := query.Get("actor_id"); actor != "" {
+		actorID, err := uuid.Parse(actor)
+		if err != nil {
+			response.ValidationError(w, "actor_id", "actor_id must be a UUID")
+			return
+		}
+		filter.ActorID = actorID
+	}
+
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 50
+	}
+
+	entries, err := h.auditStore.ListAuditEntries(filter)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get audit log")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, entries)
+}
+
+func problemIDFromURL(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid problem ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// writeProblemError answers an error from an admin problem change, reporting
+// whether there was one
+func writeProblemError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, models.ErrProblemNotFound):
+		response.Error(w, http.StatusNotFound, "not_found", "Problem not found")
+	case errors.Is(err, models.ErrProblemExists):
+		response.Error(w, http.StatusConflict, "conflict", "A problem with this ID, frontend ID or slug already exists")
+	case errors.Is(err, models.ErrProblemSlugChange):
+		response.ValidationError(w, "title_slug", "The title slug of an existing problem cannot change")
+	default:
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to update problem")
+	}
+	return true
+}
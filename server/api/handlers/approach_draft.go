@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxDraftApproaches bounds the approaches an editor approves from one draft
+const maxDraftApproaches = 10
+
+// ApproachDraftHandler serves the review queue of generated approaches, see
+// the generate-approaches subcommand
+type ApproachDraftHandler struct {
+	store *models.ApproachDraftStore
+}
+
+func NewApproachDraftHandler(s *models.ApproachDraftStore) *ApproachDraftHandler {
+	return &ApproachDraftHandler{store: s}
+}
+
+// GetDrafts lists drafts for review, pending ones unless a status is given
+func (h *ApproachDraftHandler) GetDrafts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := models.DraftFilter{Status: query.Get("status")}
+	if filter.Status == "" {
+		filter.Status = models.DraftPending
+	}
+	if !models.IsValidDraftStatus(filter.Status) {
+		response.ValidationError(w, "status", "status must be pending, approved, rejected or failed")
+		return
+	}
+	filter.RunID, _ = strconv.Atoi(query.Get("run_id"))
+	filter.ProblemID, _ = strconv.Atoi(query.Get("problem_id"))
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 50
+	}
+
+	drafts, err := h.store.ListDrafts(filter)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get approach drafts")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, drafts)
+}
+
+func (h *ApproachDraftHandler) GetDraft(w http.ResponseWriter, r *http.Request) {
+	id, ok := draftIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	draft, err := h.store.GetDraft(id)
+	if err != nil {
+		writeDraftError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, draft)
+}
+
+// ApproveDraft publishes the approaches of a pending draft, or the edited
+// approaches given instead of them
+func (h *ApproachDraftHandler) ApproveDraft(w http.ResponseWriter, r *http.Request) {
+	reviewerID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	id, ok := draftIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Note       string                 `json:"note"`
+		Approaches []models.DraftApproach `json:"approaches"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+			return
+		}
+	}
+	if req.Approaches != nil && !validateDraftApproaches(w, req.Approaches) {
+		return
+	}
+
+	draft, err := h.store.ApproveDraft(id, reviewerID, strings.TrimSpace(req.Note), req.Approaches)
+	if err != nil {
+		writeDraftError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, draft)
+}
+
+// RejectDraft closes a pending draft without publishing it
+func (h *ApproachDraftHandler) RejectDraft(w http.ResponseWriter, r *http.Request) {
+	reviewerID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	id, ok := draftIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+			return
+		}
+	}
+
+	draft, err := h.store.RejectDraft(id, reviewerID, strings.TrimSpace(req.Note))
+	if err != nil {
+		writeDraftError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, draft)
+}
+
+// GetRuns lists the latest generation runs, only unfinished ones with
+// running=true
+func (h *ApproachDraftHandler) GetRuns(w http.ResponseWriter, r *http.Request) {
+	running, _ := strconv.ParseBool(r.URL.Query().Get("running"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	runs, err := h.store.ListRuns(running, limit)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get approach generation runs")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, runs)
+}
+
+// validateDraftApproaches checks edited approaches like approaches written
+// directly by editors, which also need an explanation
+func validateDraftApproaches(w http.ResponseWriter, approaches []models.DraftApproach) bool {
+	if len(approaches) == 0 || len(approaches) > maxDraftApproaches {
+		response.ValidationError(w, "approaches", "approaches must have between 1 and 10 entries")
+		return false
+	}
+	for i := range approaches {
+		approach := &approaches[i]
+		if strings.TrimSpace(approach.Explanation) == "" {
+			response.ValidationError(w, "explanation", "explanation is required")
+			return false
+		}
+		if approach.Hints == nil {
+			approach.Hints = []models.Hint{}
+		}
+		update := models.ApproachUpdate{
+			Kind:            &approach.Kind,
+			Title:           &approach.Title,
+			Explanation:     &approach.Explanation,
+			TimeComplexity:  &approach.TimeComplexity,
+			SpaceComplexity: &approach.SpaceComplexity,
+			Hints:           &approach.Hints,
+		}
+		if !validateApproach(w, update) {
+			return false
+		}
+	}
+	return true
+}
+
+func draftIDFromURL(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid draft ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// writeDraftError answers an error from reading or reviewing a draft
+func writeDraftError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrDraftNotFound):
+		response.Error(w, http.StatusNotFound, "not_found", "Approach draft not found")
+	case errors.Is(err, models.ErrDraftReviewed):
+		response.Error(w, http.StatusConflict, "conflict", "Approach draft is not pending")
+	case errors.Is(err, models.ErrApproachNotFound):
+		response.Error(w, http.StatusNotFound, "not_found", "Solution approach not found")
+	default:
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to review approach draft")
+	}
+}
@@ -19,6 +19,7 @@ type AuthStatusResponse struct {
 	ProfileExists    bool   `json:"profile_exists"`
 	ProfileComplete  bool   `json:"profile_complete"`
 	LeetcodeUsername string `json:"leetcode_username"`
+	LeetcodeRegion   string `json:"leetcode_region"`
 }
 
 func NewAuthStatusHandler(s *models.UserStore) *AuthStatusHandler {
@@ -60,11 +61,13 @@ func (h *AuthStatusHandler) GetUserAuthStatus(w http.ResponseWriter, r *http.Req
 	}
 
 	leetcodeUsername := ""
+	leetcodeRegion := ""
 	if exists {
 		// Attempt to get user details only if exists is true after potential retry
 		user, getUserErr := h.store.GetUserByID(userID)
 		if getUserErr == nil {
 			leetcodeUsername = user.LeetcodeUsername
+			leetcodeRegion = user.LeetcodeRegion
 		} else {
 			// Log if getting user details failed even if exists=true (shouldn't happen often)
 			println("AuthStatus: Warning - User reported as existing, but GetUserByID failed:", getUserErr.Error())
@@ -77,6 +80,7 @@ func (h *AuthStatusHandler) GetUserAuthStatus(w http.ResponseWriter, r *http.Req
 		ProfileExists:    exists,
 		ProfileComplete:  exists, // initially complete means it exists - can be enhanced later
 		LeetcodeUsername: leetcodeUsername,
+		LeetcodeRegion:   leetcodeRegion,
 	}
 
 	response.JSON(w, http.StatusOK, auth_response)
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/internal/runner"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+)
+
+// maxCodeSize bounds the solutions accepted for running, in bytes
+const maxCodeSize = 64 << 10
+
+// CodeRunHandler runs solutions against problem examples for re-solve
+// reviews and turns them into submissions
+type CodeRunHandler struct {
+	runner        *runner.Runner // nil when the sandbox is unavailable
+	runStore      *models.CodeRunStore
+	problemStore  *models.ProblemStore
+	testCaseStore *models.TestCaseStore
+}
+
+func NewCodeRunHandler(r *runner.Runner, runStore *models.CodeRunStore, problemStore *models.ProblemStore, testCaseStore *models.TestCaseStore) *CodeRunHandler {
+	return &CodeRunHandler{runner: r, runStore: runStore, problemStore: problemStore, testCaseStore: testCaseStore}
+}
+
+// exampleCases turns the examples with a known output into runner cases
+func exampleCases(problem models.Problem) []runner.Case {
+	var cases []runner.Case
+	for _, example := range problem.Examples {
+		if example.Expected == nil {
+			continue
+		}
+		c := runner.Case{Expected: example.Expected}
+		for _, param := range example.Params {
+			c.Args = append(c.Args, example.Inputs[param.Name])
+		}
+		cases = append(cases, c)
+	}
+	return cases
+}
+
+// customCases turns the user's test cases into runner cases, skipping those
+// whose inputs no longer match the problem's parameters
+func customCases(problem models.Problem, testCases []models.TestCase) []runner.Case {
+	params := problem.Params()
+	var cases []runner.Case
+	for _, testCase := range testCases {
+		args, ok := testCase.Args(params)
+		if !ok {
+			continue
+		}
+		cases = append(cases, runner.Case{Args: args, Expected: testCase.Expected, TestCaseID: testCase.ID})
+	}
+	return cases
+}
+
+// CreateCodeRun runs a solution against the examples of a problem, the
+// user's own test cases of it or both, and stores the result
+func (h *CodeRunHandler) CreateCodeRun(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	var req struct {
+		TitleSlug string `json:"title_slug"`
+		Language  string `json:"language"`
+		Code      string `json:"code"`
+		Function  string `json:"function,omitempty"`
+		Cases     string `json:"cases,omitempty"` // examples, custom or all
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*maxCodeSize)).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if req.TitleSlug == "" {
+		response.ValidationError(w, "title_slug", "Problem title slug is required")
+		return
+	}
+	if !runner.IsValidLanguage(req.Language) {
+		response.ValidationError(w, "language", "language must be python or go")
+		return
+	}
+	if strings.TrimSpace(req.Code) == "" || len(req.Code) > maxCodeSize {
+		response.ValidationError(w, "code", "code must be between 1 byte and 64 KB")
+		return
+	}
+	if req.Cases == "" {
+		req.Cases = models.RunCasesExamples
+	}
+	if req.Cases != models.RunCasesExamples && req.Cases != models.RunCasesCustom && req.Cases != models.RunCasesAll {
+		response.ValidationError(w, "cases", "cases must be examples, custom or all")
+		return
+	}
+
+	if h.runner == nil {
+		response.Error(w, http.StatusServiceUnavailable, "runner_unavailable", "Running code is not available on this server")
+		return
+	}
+
+	problem, err := h.problemStore.GetProblemBySlug(req.TitleSlug)
+	if err != nil {
+		if errors.Is(err, models.ErrProblemNotFound) {
+			response.Error(w, http.StatusNotFound, "not_found", "Problem not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get problem")
+		return
+	}
+
+	var cases []runner.Case
+	if req.Cases != models.RunCasesCustom {
+		cases = exampleCases(problem)
+	}
+	if req.Cases != models.RunCasesExamples {
+		testCases, err := h.testCaseStore.ListTestCases(userID, problem.ID)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get test cases")
+			return
+		}
+		cases = append(cases, customCases(problem, testCases)...)
+	}
+	if len(cases) == 0 {
+		response.Error(w, http.StatusUnprocessableEntity, "no_examples", "This problem has no examples to run against")
+		return
+	}
+
+	// solutions written from LeetCode's starter code define the function its
+	// metadata names; others are still matched by their arity
+	if name := problem.Metadata.FunctionName(); req.Function == "" && name != "" && strings.Contains(req.Code, name) {
+		req.Function = name
+	}
+
+	result, err := h.runner.Run(r.Context(), runner.Program{Language: req.Language, Code: req.Code, Function: req.Function}, cases)
+	if err != nil {
+		if errors.Is(err, runner.ErrUnavailable) {
+			response.Error(w, http.StatusServiceUnavailable, "runner_unavailable", err.Error())
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to run code")
+		return
+	}
+
+	run := models.NewCodeRun(userID, problem, req.Language, req.Code, req.Cases, result)
+	if err := h.runStore.CreateCodeRun(&run); err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to save code run")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, run)
+}
+
+// GetCodeRuns lists the user's latest runs of the problem in title_slug
+func (h *CodeRunHandler) GetCodeRuns(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	titleSlug := r.URL.Query().Get("title_slug")
+	if titleSlug == "" {
+		response.ValidationError(w, "title_slug", "Problem title slug is required")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	runs, err := h.runStore.ListCodeRuns(userID, titleSlug, limit)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get code runs")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, runs)
+}
+
+func (h *CodeRunHandler) GetCodeRun(w http.ResponseWriter, r *http.Request) {
+	run, ok := h.codeRunFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, run)
+}
+
+// SubmitCodeRun records a run as an internal submission and schedules the
+// problem's review, rating it from the run unless a rating is given
+func (h *CodeRunHandler) SubmitCodeRun(w http.ResponseWriter, r *http.Request) {
+	run, ok := h.codeRunFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Rating int `json:"rating,omitempty"` // 1=Again, 2=Hard, 3=Good, 4=Easy, derived when 0
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+			return
+		}
+	}
+	if req.Rating < 0 || req.Rating > 4 {
+		response.ValidationError(w, "rating", "Rating must be between 1 and 4")
+		return
+	}
+
+	if run.SubmissionID != nil {
+		response.Error(w, http.StatusConflict, "conflict", "Code run was already submitted")
+		return
+	}
+
+	failedAttempts, err := h.runStore.CountFailedAttempts(run)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to count earlier runs")
+		return
+	}
+
+	rating := fsrs.Rating(req.Rating)
+	derived := req.Rating == 0
+	if derived {
+		var ok bool
+		if rating, ok = models.DeriveRating(run, failedAttempts); !ok {
+			response.ValidationError(w, "rating", "Rating is required for runs without the problem's examples")
+			return
+		}
+	}
+
+	problem, err := h.problemStore.GetProblemBySlug(run.TitleSlug)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get problem")
+		return
+	}
+
+	now := time.Now().UTC()
+	sub := models.Submission{
+		ID:          newInternalSubmissionID(),
+		UserID:      run.UserID,
+		Title:       problem.Title,
+		TitleSlug:   problem.TitleSlug,
+		SubmittedAt: now,
+		CreatedAt:   now,
+	}
+
+	review, err := h.runStore.SubmitCodeRun(&run, sub, rating)
+	if err != nil {
+		if errors.Is(err, models.ErrCodeRunSubmitted) {
+			response.Error(w, http.StatusConflict, "conflict", "Code run was already submitted")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to submit code run")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"run":               run,
+		"submission_id":     sub.ID,
+		"rating":            int(rating),
+		"rating_derived":    derived,
+		"failed_attempts":   failedAttempts,
+		"next_review_at":    review.NextReviewAt,
+		"days_until_review": int(review.ScheduledDays),
+	})
+}
+
+func (h *CodeRunHandler) codeRunFromURL(w http.ResponseWriter, r *http.Request) (models.CodeRun, bool) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return models.CodeRun{}, false
+	}
+
+	runID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid code run ID")
+		return models.CodeRun{}, false
+	}
+
+	run, err := h.runStore.GetCodeRun(runID, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrCodeRunNotFound) {
+			response.Error(w, http.StatusNotFound, "not_found", "Code run not found")
+			return models.CodeRun{}, false
+		}
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get code run")
+		return models.CodeRun{}, false
+	}
+
+	return run, true
+}
+
+// newInternalSubmissionID makes the ID of a submission made in the app rather
+// than on LeetCode
+func newInternalSubmissionID() string {
+	id := strings.Replace(uuid.New().String(), "-", "", -1)[:12]
+	return fmt.Sprintf("internal-user-%s", id)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/internal/leetcode"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+	"time"
+)
+
+type DailyChallengeHandler struct {
+	challengeStore *models.DailyChallengeStore
+	userStore      *models.UserStore
+}
+
+func NewDailyChallengeHandler(challengeStore *models.DailyChallengeStore, userStore *models.UserStore) *DailyChallengeHandler {
+	return &DailyChallengeHandler{
+		challengeStore: challengeStore,
+		userStore:      userStore,
+	}
+}
+
+type DailyChallengeResponse struct {
+	models.DailyChallenge
+	Enrolled bool       `json:"enrolled"`
+	Solved   bool       `json:"solved"`
+	SolvedAt *time.Time `json:"solved_at,omitempty"`
+}
+
+// GetDailyChallenge returns the latest daily challenge of the user's region and
+// whether the user solved it on the day
+func (h *DailyChallengeHandler) GetDailyChallenge(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	user, err := h.userStore.GetUserByID(userID)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "not_found", "User not found")
+		return
+	}
+
+	region, err := leetcode.ParseRegion(user.LeetcodeRegion)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Invalid LeetCode region")
+		return
+	}
+
+	challenge, err := h.challengeStore.GetLatestChallenge(string(region))
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "not_found", "No daily challenge recorded yet")
+		return
+	}
+
+	enrolled, err := h.userStore.IsDailyChallengeEnrolled(userID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to fetch enrollment")
+		return
+	}
+
+	solvedAt, err := h.challengeStore.GetSolvedAt(userID, challenge)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to check daily challenge status")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, DailyChallengeResponse{
+		DailyChallenge: challenge,
+		Enrolled:       enrolled,
+		Solved:         solvedAt != nil,
+		SolvedAt:       solvedAt,
+	})
+}
+
+// UpdateEnrollment opts the user in or out of getting every daily challenge
+// added to their "Daily Challenges" deck
+func (h *DailyChallengeHandler) UpdateEnrollment(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if req.Enabled == nil {
+		response.ValidationError(w, "enabled", "enabled is required")
+		return
+	}
+
+	if err := h.userStore.SetDailyChallengeEnrollment(userID, *req.Enabled); err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to update enrollment")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]bool{"enrolled": *req.Enabled})
+}
@@ -167,13 +167,27 @@ func (h *DeckHandler) GetDeckProblems(w http.ResponseWriter, r *http.Request) {
 		offset = 0 // Default offset
 	}
 
-	problems, err := h.store.GetProblemsInDeck(deckID, limit, offset)
+	keyset, cursor, err := keysetFromQuery(r)
+	if writeCursorError(w, err) {
+		return
+	}
+
+	problems, info, err := h.store.GetProblemsInDeck(deckID, models.Page{Limit: limit, Offset: offset, Keyset: keyset, Cursor: cursor, CountTotal: countTotalFromQuery(r)})
 	if err != nil {
+		if writeCursorError(w, err) {
+			return
+		}
 		errorMessage := fmt.Sprintf("Failed to get deck problems (deckID: %d, limit: %d, offset: %d): %v", deckID, limit, offset, err)
 		response.Error(w, http.StatusInternalServerError, "server_error", errorMessage)
 		return
 	}
 
+	// offset requests keep the bare list response older clients expect
+	if keyset {
+		response.JSONWithCursor(w, http.StatusOK, problems, cursorTotal(countTotalFromQuery(r), info.Total), limit, info.NextCursor, info.HasMore, nil)
+		return
+	}
+
 	response.JSON(w, http.StatusOK, problems)
 }
 
@@ -211,10 +225,16 @@ func (h *DeckHandler) AddProblemToDeckAndCreateFlashcard(w http.ResponseWriter,
 	}
 
 	// Add problem to the deck
-	if err := h.store.AddProblemToDeck(deckID, req.ProblemID); err != nil {
+	added, err := h.store.AddProblemToDeck(nil, deckID, req.ProblemID)
+	if err != nil {
 		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to add problem to deck")
 		return
 	}
+	if !added {
+		// already in the deck with its flashcard
+		response.JSON(w, http.StatusAccepted, nil)
+		return
+	}
 
 	// Automatically create a flashcard review for the added problem
 	now := time.Now().UTC()
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"go-leetcode/backend/api/middleware"
 	"go-leetcode/backend/models"
 	"go-leetcode/backend/pkg/response"
@@ -15,20 +16,29 @@ import (
 )
 
 type FlashcardHandler struct {
-	store        *models.FlashcardReviewStore
-	problemStore *models.ProblemStore
-	deckStore    *models.DeckStore
+	store         *models.FlashcardReviewStore
+	problemStore  *models.ProblemStore
+	deckStore     *models.DeckStore
+	solutionStore *models.SolutionStore
+	noteStore     *models.NoteStore
+	testCaseStore *models.TestCaseStore
 }
 
 func NewFlashcardHandler(
 	store *models.FlashcardReviewStore,
 	problemStore *models.ProblemStore,
 	deckStore *models.DeckStore,
+	solutionStore *models.SolutionStore,
+	noteStore *models.NoteStore,
+	testCaseStore *models.TestCaseStore,
 ) *FlashcardHandler {
 	return &FlashcardHandler{
-		store:        store,
-		problemStore: problemStore,
-		deckStore:    deckStore,
+		store:         store,
+		problemStore:  problemStore,
+		deckStore:     deckStore,
+		solutionStore: solutionStore,
+		noteStore:     noteStore,
+		testCaseStore: testCaseStore,
 	}
 }
 
@@ -72,8 +82,16 @@ func (h *FlashcardHandler) GetFlashcardReviews(w http.ResponseWriter, r *http.Re
 		}
 	}
 
-	reviews, total, err := h.store.GetDueFlashcardReviews(userID, deckID, limit, offset)
+	keyset, cursor, err := keysetFromQuery(r)
+	if writeCursorError(w, err) {
+		return
+	}
+
+	reviews, info, err := h.store.GetDueFlashcardReviews(userID, deckID, models.Page{Limit: limit, Offset: offset, Keyset: keyset, Cursor: cursor, CountTotal: countTotalFromQuery(r)})
 	if err != nil {
+		if writeCursorError(w, err) {
+			return
+		}
 		// Include detailed error in the response message
 		errorMessage := fmt.Sprintf("Failed to get flashcard reviews: %v", err)
 		response.Error(w, http.StatusInternalServerError, "server_error", errorMessage)
@@ -81,11 +99,15 @@ func (h *FlashcardHandler) GetFlashcardReviews(w http.ResponseWriter, r *http.Re
 	}
 
 	response.JSON(w, http.StatusOK, struct {
-		Reviews []models.FlashcardReviewWithProblem `json:"reviews"`
-		Total   int                                 `json:"total"`
+		Reviews    []models.FlashcardReviewWithProblem `json:"reviews"`
+		Total      *int                                `json:"total,omitempty"`
+		NextCursor string                              `json:"next_cursor,omitempty"`
+		HasMore    bool                                `json:"has_more"`
 	}{
-		Reviews: reviews,
-		Total:   total,
+		Reviews:    reviews,
+		Total:      cursorTotal(!keyset || countTotalFromQuery(r), info.Total),
+		NextCursor: info.NextCursor,
+		HasMore:    info.HasMore,
 	})
 }
 
@@ -123,42 +145,68 @@ func (h *FlashcardHandler) SubmitFlashcardReview(w http.ResponseWriter, r *http.
 		return
 	}
 
+	card, rating, rated := h.rateFlashcard(w, &review, req.Rating)
+	if !rated {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, struct {
+		Success         bool      `json:"success"`
+		Rating          int       `json:"rating"`
+		RatingCapped    bool      `json:"rating_capped"`
+		NextReviewAt    time.Time `json:"next_review_at"`
+		DaysUntilReview int       `json:"days_until_review"`
+	}{
+		Success:         true,
+		Rating:          rating,
+		RatingCapped:    rating < req.Rating,
+		NextReviewAt:    card.Due,
+		DaysUntilReview: int(card.ScheduledDays),
+	})
+}
+
+// rateFlashcard schedules the next review with FSRS and logs the rating,
+// answering the request when that fails. The rating is lowered to what the
+// hints revealed during the review allow, and the rating applied returned. A
+// hint revealed meanwhile fails the request with 409 rather than going
+// uncapped.
+func (h *FlashcardHandler) rateFlashcard(w http.ResponseWriter, review *models.FlashcardReview, rating int) (fsrs.Card, int, bool) {
+	if review.HintsRevealed > 0 {
+		hints, err := h.solutionStore.GetProblemHints(review.ProblemID)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get hints")
+			return fsrs.Card{}, 0, false
+		}
+		rating = min(rating, models.RatingCap(hints[:min(review.HintsRevealed, len(hints))]))
+	}
+
 	// Process with FSRS
 	fsrsScheduler := fsrs.NewFSRS(fsrs.DefaultParam())
 	now := time.Now().UTC()
-	result := fsrsScheduler.Next(review.FsrsCard, now, fsrs.Rating(req.Rating))
+	result := fsrsScheduler.Next(review.FsrsCard, now, fsrs.Rating(rating))
 
-	// Update review
+	// Update review and log it
 	review.FsrsCard = result.Card
 	review.FsrsCard.LastReview = now
-	if err := h.store.UpdateFlashcardReview(&review); err != nil {
-		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to update review")
-		return
-	}
-
-	// Create review log
 	log := models.FlashcardReviewLog{
 		FlashcardReviewID: review.ID,
-		Rating:            req.Rating,
+		Rating:            rating,
 		ReviewDate:        now,
 		ElapsedDays:       int(result.Card.ElapsedDays),
 		ScheduledDays:     int(result.Card.ScheduledDays),
 		State:             int(result.Card.State),
+		HintsRevealed:     review.HintsRevealed,
 	}
-	if err := h.store.CreateFlashcardReviewLog(&log); err != nil {
-		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to create review log")
-		return
+	if err := h.store.RateFlashcardReview(review, &log); err != nil {
+		if errors.Is(err, models.ErrHintsRevealedChanged) {
+			response.Error(w, http.StatusConflict, "hints_changed", "A hint was revealed while rating; rate the review again")
+			return fsrs.Card{}, 0, false
+		}
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to update review")
+		return fsrs.Card{}, 0, false
 	}
 
-	response.JSON(w, http.StatusOK, struct {
-		Success         bool      `json:"success"`
-		NextReviewAt    time.Time `json:"next_review_at"`
-		DaysUntilReview int       `json:"days_until_review"`
-	}{
-		Success:         true,
-		NextReviewAt:    result.Card.Due,
-		DaysUntilReview: int(result.Card.ScheduledDays),
-	})
+	return result.Card, rating, true
 }
 
 func (h *FlashcardHandler) AddDeckToFlashcards(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// hintsResponse shows the hints revealed so far in a review
+type hintsResponse struct {
+	Hints     []models.Hint `json:"hints"`
+	Revealed  int           `json:"revealed"`
+	Total     int           `json:"total"`
+	RatingCap int           `json:"rating_cap"`
+}
+
+func newHintsResponse(hints []models.Hint, revealed int) hintsResponse {
+	revealed = min(revealed, len(hints))
+	return hintsResponse{
+		Hints:     hints[:revealed],
+		Revealed:  revealed,
+		Total:     len(hints),
+		RatingCap: models.RatingCap(hints[:revealed]),
+	}
+}
+
+// GetHints returns the hints revealed so far in the review and how many
+// there are
+func (h *FlashcardHandler) GetHints(w http.ResponseWriter, r *http.Request) {
+	review, ok := h.userReviewFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	hints, err := h.solutionStore.GetProblemHints(review.ProblemID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get hints")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newHintsResponse(hints, review.HintsRevealed))
+}
+
+// RevealHint reveals the next hint of the review. Each revealed hint lowers
+// the highest rating the review can get until the card is rated.
+func (h *FlashcardHandler) RevealHint(w http.ResponseWriter, r *http.Request) {
+	review, ok := h.userReviewFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	hints, err := h.solutionStore.GetProblemHints(review.ProblemID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get hints")
+		return
+	}
+	if len(hints) == 0 {
+		response.Error(w, http.StatusUnprocessableEntity, "no_hints", "This problem has no hints")
+		return
+	}
+
+	revealed, err := h.store.RevealHint(review.ID, len(hints))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to reveal hint")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newHintsResponse(hints, revealed))
+}
+
+// userReviewFromURL loads the flashcard review in the URL, answering the
+// request unless it belongs to the signed in user
+func (h *FlashcardHandler) userReviewFromURL(w http.ResponseWriter, r *http.Request) (models.FlashcardReview, bool) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return models.FlashcardReview{}, false
+	}
+
+	reviewID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid review ID")
+		return models.FlashcardReview{}, false
+	}
+
+	review, err := h.store.GetReviewByID(reviewID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get review")
+		return models.FlashcardReview{}, false
+	}
+	if review.UserID != userID.String() {
+		response.Error(w, http.StatusForbidden, "forbidden", "Forbidden")
+		return models.FlashcardReview{}, false
+	}
+	return review, true
+}
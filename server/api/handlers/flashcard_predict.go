@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/internal/runner"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+)
+
+// PredictTestCase checks an answer to the test case prompt of a flashcard,
+// the output predicted for one of the user's test cases of its problem, and
+// reveals the expected output
+func (h *FlashcardHandler) PredictTestCase(w http.ResponseWriter, r *http.Request) {
+	review, ok := h.userReviewFromURL(w, r)
+	if !ok {
+		return
+	}
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	var req struct {
+		TestCaseID int             `json:"test_case_id"`
+		Answer     json.RawMessage `json:"answer"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTestCaseSize)).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if req.TestCaseID <= 0 {
+		response.ValidationError(w, "test_case_id", "test_case_id is required")
+		return
+	}
+	if len(req.Answer) == 0 {
+		response.ValidationError(w, "answer", "answer is required")
+		return
+	}
+
+	testCase, err := h.testCaseStore.GetTestCase(userID, req.TestCaseID)
+	if err != nil {
+		if errors.Is(err, models.ErrTestCaseNotFound) {
+			response.Error(w, http.StatusNotFound, "not_found", "Test case not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get test case")
+		return
+	}
+	if testCase.ProblemID != review.ProblemID {
+		response.ValidationError(w, "test_case_id", "test case is not of this flashcard's problem")
+		return
+	}
+	if len(testCase.Expected) == 0 {
+		response.Error(w, http.StatusUnprocessableEntity, "no_expected_output", "This test case has no expected output to compare against")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"test_case_id": testCase.ID,
+		"correct":      runner.EqualJSON(req.Answer, testCase.Expected),
+		"expected":     testCase.Expected,
+	})
+}
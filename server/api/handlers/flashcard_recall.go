@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-leetcode/backend/internal/recall"
+	"go-leetcode/backend/internal/textdiff"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxRecallCodeSize bounds the code typed in a recall review, in bytes
+const maxRecallCodeSize = 16 << 10
+
+// What a recall attempt is compared against
+const (
+	recallAgainstAuto      = "auto"
+	recallAgainstNote      = "note"
+	recallAgainstReference = "reference"
+)
+
+// recallTarget is one solution a recall attempt can be compared against
+type recallTarget struct {
+	against    string
+	approachID int
+	language   string
+	code       string
+}
+
+// RecallFlashcard scores a solution typed from memory against the user's own
+// code in their note of the problem, or else the reference solutions, and
+// suggests a rating from the score. With submit it also rates the flashcard,
+// with the suggested rating unless one is given.
+func (h *FlashcardHandler) RecallFlashcard(w http.ResponseWriter, r *http.Request) {
+	review, ok := h.userReviewFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Language string `json:"language"`
+		Code     string `json:"code"`
+		Against  string `json:"against,omitempty"`
+		Submit   bool   `json:"submit,omitempty"`
+		Rating   int    `json:"rating,omitempty"` // overrides the suggested rating when submitting
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*maxRecallCodeSize)).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(req.Language) == "" {
+		response.ValidationError(w, "language", "language is required")
+		return
+	}
+	if len(req.Code) > maxRecallCodeSize {
+		response.ValidationError(w, "code", "code must be at most 16 KB")
+		return
+	}
+	if req.Against == "" {
+		req.Against = recallAgainstAuto
+	}
+	if req.Against != recallAgainstAuto && req.Against != recallAgainstNote && req.Against != recallAgainstReference {
+		response.ValidationError(w, "against", "against must be auto, note or reference")
+		return
+	}
+	if req.Rating != 0 && (req.Rating < 1 || req.Rating > 4) {
+		response.ValidationError(w, "rating", "Rating must be between 1 and 4")
+		return
+	}
+
+	targets, ok := h.recallTargets(w, review, req.Language, req.Against)
+	if !ok {
+		return
+	}
+	if len(targets) == 0 {
+		response.Error(w, http.StatusUnprocessableEntity, "no_solution", "There is no stored solution in this language to compare with")
+		return
+	}
+
+	// with several reference approaches the attempt is scored against the
+	// one it is closest to
+	var best recallTarget
+	var result recall.Result
+	for i, target := range targets {
+		compared := recall.Compare(target.language, target.code, req.Code)
+		if i == 0 || compared.Score > result.Score {
+			best, result = target, compared
+		}
+	}
+
+	resp := struct {
+		Against         string        `json:"against"`
+		ApproachID      int           `json:"approach_id,omitempty"`
+		Language        string        `json:"language"`
+		Score           float64       `json:"score"`
+		SuggestedRating int           `json:"suggested_rating"`
+		Diff            textdiff.Diff `json:"diff"`
+		Unified         string        `json:"unified"`
+		Rating          int           `json:"rating,omitempty"`
+		NextReviewAt    *time.Time    `json:"next_review_at,omitempty"`
+		DaysUntilReview *int          `json:"days_until_review,omitempty"`
+	}{
+		Against:         best.against,
+		ApproachID:      best.approachID,
+		Language:        best.language,
+		Score:           result.Score,
+		SuggestedRating: result.Rating,
+		Diff:            result.Diff,
+		Unified:         result.Diff.Unified(3),
+	}
+
+	if req.Submit {
+		rating := req.Rating
+		if rating == 0 {
+			rating = result.Rating
+		}
+		card, rating, ok := h.rateFlashcard(w, &review, rating)
+		if !ok {
+			return
+		}
+		days := int(card.ScheduledDays)
+		resp.Rating = rating
+		resp.NextReviewAt = &card.Due
+		resp.DaysUntilReview = &days
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// recallTargets collects the solutions in language a recall attempt of the
+// review's problem can be compared against, answering the request when
+// loading them fails
+func (h *FlashcardHandler) recallTargets(w http.ResponseWriter, review models.FlashcardReview, language, against string) ([]recallTarget, bool) {
+	if against != recallAgainstReference {
+		userID, err := uuid.Parse(review.UserID)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get note")
+			return nil, false
+		}
+		note, err := h.noteStore.GetNote(userID, review.ProblemID)
+		if err != nil && !errors.Is(err, models.ErrNoteNotFound) {
+			response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get note")
+			return nil, false
+		}
+		for noteLanguage, code := range note.Code {
+			if recall.SameLanguage(noteLanguage, language) {
+				return []recallTarget{{against: recallAgainstNote, language: noteLanguage, code: code}}, true
+			}
+		}
+		if against == recallAgainstNote {
+			return nil, true
+		}
+	}
+
+	approaches, err := h.solutionStore.ListApproaches(review.ProblemID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get solutions")
+		return nil, false
+	}
+	var targets []recallTarget
+	for _, approach := range approaches {
+		for solutionLanguage, code := range approach.Code {
+			if recall.SameLanguage(solutionLanguage, language) {
+				targets = append(targets, recallTarget{against: recallAgainstReference, approachID: approach.ID, language: solutionLanguage, code: code})
+			}
+		}
+	}
+	return targets, true
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/internal/cryptoutils"
+	"go-leetcode/backend/internal/worker"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HistoryImportHandler struct {
+	sessionStore *models.LeetcodeSessionStore
+	importStore  *models.SubmissionImportStore
+	job          *worker.HistoryImportJob
+}
+
+func NewHistoryImportHandler(
+	sessionStore *models.LeetcodeSessionStore,
+	importStore *models.SubmissionImportStore,
+	job *worker.HistoryImportJob,
+) *HistoryImportHandler {
+	return &HistoryImportHandler{
+		sessionStore: sessionStore,
+		importStore:  importStore,
+		job:          job,
+	}
+}
+
+// SaveSession stores the user's LEETCODE_SESSION cookie, encrypted, replacing any previous one
+func (h *HistoryImportHandler) SaveSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Session string `json:"session"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	// accept both the bare cookie value and "LEETCODE_SESSION=..."
+	session := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(req.Session), "LEETCODE_SESSION="))
+	if session == "" {
+		response.ValidationError(w, "session", "LEETCODE_SESSION cookie is required")
+		return
+	}
+
+	encrypted, err := cryptoutils.EncryptString(session)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to encrypt leetcode session for user %s: %v\n", userID, err)
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to store session")
+		return
+	}
+
+	stored := models.LeetcodeSession{
+		UserID:           userID,
+		EncryptedSession: encrypted,
+	}
+
+	if err := h.sessionStore.SaveSession(&stored); err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to store session")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, stored)
+}
+
+// DeleteSession removes the stored LEETCODE_SESSION cookie and stops the
+// imports using it
+func (h *HistoryImportHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	if err := h.sessionStore.DeleteSession(userID); err != nil {
+		response.Error(w, http.StatusNotFound, "not_found", "No stored session")
+		return
+	}
+
+	// imports running with the deleted session fail instead of finishing
+	h.job.CancelUser(userID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StartImport queues a full history import for the authenticated user
+func (h *HistoryImportHandler) StartImport(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	if _, err := h.sessionStore.GetSession(userID); err != nil {
+		response.Error(w, http.StatusPreconditionFailed, "missing_session", "Store a LEETCODE_SESSION cookie before importing")
+		return
+	}
+
+	if active, err := h.importStore.GetActiveImport(userID); err == nil {
+		response.Error(w, http.StatusConflict, "import_in_progress", fmt.Sprintf("Import %d is already in progress", active.ID))
+		return
+	}
+
+	imp := models.SubmissionImport{UserID: userID}
+	if err := h.importStore.CreateImport(&imp); err != nil {
+		if errors.Is(err, models.ErrImportInProgress) {
+			response.Error(w, http.StatusConflict, "import_in_progress", "An import is already in progress")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to create import")
+		return
+	}
+
+	h.job.Start(imp.ID)
+
+	response.JSON(w, http.StatusAccepted, imp)
+}
+
+// GetImport reports the progress of one of the user's imports
+func (h *HistoryImportHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	importID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid import ID")
+		return
+	}
+
+	imp, err := h.importStore.GetImportByID(importID)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "not_found", "Import not found")
+		return
+	}
+
+	if imp.UserID != userID {
+		response.Error(w, http.StatusForbidden, "forbidden", "Forbidden")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, imp)
+}
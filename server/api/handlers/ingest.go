@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/internal/cryptoutils"
+	"go-leetcode/backend/internal/leetcode"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+)
+
+const (
+	ingestTokenPrefix         = "lci_"
+	ingestSigningSecretPrefix = "lcs_"
+)
+
+type IngestHandler struct {
+	tokenStore  *models.IngestTokenStore
+	reviewStore *models.ReviewScheduleStore
+}
+
+func NewIngestHandler(tokenStore *models.IngestTokenStore, reviewStore *models.ReviewScheduleStore) *IngestHandler {
+	return &IngestHandler{
+		tokenStore:  tokenStore,
+		reviewStore: reviewStore,
+	}
+}
+
+// CreateToken issues a new ingest token and the secret requests are signed
+// with. Both are only returned here.
+func (h *IngestHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		response.ValidationError(w, "name", "Token name is required")
+		return
+	}
+
+	rawToken, err := cryptoutils.GenerateToken(ingestTokenPrefix)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to generate token")
+		return
+	}
+
+	signingSecret, err := cryptoutils.GenerateToken(ingestSigningSecretPrefix)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to generate token")
+		return
+	}
+
+	encryptedSecret, err := cryptoutils.EncryptString(signingSecret)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to store signing secret")
+		return
+	}
+
+	token := models.IngestToken{
+		UserID:                 userID,
+		Name:                   req.Name,
+		TokenPrefix:            rawToken[:len(ingestTokenPrefix)+8],
+		TokenHash:              cryptoutils.HashToken(rawToken),
+		EncryptedSigningSecret: encryptedSecret,
+	}
+
+	if err := h.tokenStore.CreateToken(&token); err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to create token")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, struct {
+		models.IngestToken
+		Token         string `json:"token"`
+		SigningSecret string `json:"signing_secret"`
+	}{token, rawToken, signingSecret})
+}
+
+func (h *IngestHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	tokens, err := h.tokenStore.ListTokens(userID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to list tokens")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, tokens)
+}
+
+func (h *IngestHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	tokenID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid token ID")
+		return
+	}
+
+	if err := h.tokenStore.RevokeToken(tokenID, userID); err != nil {
+		response.Error(w, http.StatusNotFound, "not_found", "Token not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// IngestSubmission accepts an accepted submission pushed by an extension or
+// userscript, authenticated by IngestTokenMiddleware. The submission and the
+// review of its problem are stored together; repeated pushes of the same
+// LeetCode submission by the same user are acknowledged without reviewing
+// the problem again.
+func (h *IngestHandler) IngestSubmission(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	var req struct {
+		LeetcodeSubmissionID string `json:"leetcode_submission_id"`
+		Title                string `json:"title"`
+		TitleSlug            string `json:"title_slug"`
+		SubmittedAt          string `json:"submitted_at"`
+		Rating               int    `json:"rating,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if req.LeetcodeSubmissionID == "" {
+		response.ValidationError(w, "leetcode_submission_id", "LeetCode submission ID is required")
+		return
+	}
+	if req.TitleSlug == "" {
+		response.ValidationError(w, "title_slug", "Problem title slug is required")
+		return
+	}
+
+	submittedAt, err := time.Parse(time.RFC3339, req.SubmittedAt)
+	if err != nil {
+		response.ValidationError(w, "submitted_at", "Invalid time format (expected RFC3339)")
+		return
+	}
+
+	rating := fsrs.Rating(req.Rating)
+	if req.Rating < 1 || req.Rating > 4 {
+		rating = fsrs.Good
+	}
+
+	sub := models.Submission{
+		ID:          leetcode.SubmissionID(req.LeetcodeSubmissionID),
+		UserID:      userID,
+		Title:       req.Title,
+		TitleSlug:   req.TitleSlug,
+		CreatedAt:   time.Now().UTC(),
+		SubmittedAt: submittedAt,
+	}
+
+	review, duplicate, err := h.reviewStore.IngestSubmission(&sub, rating)
+	if errors.Is(err, models.ErrSubmissionTaken) {
+		response.Error(w, http.StatusConflict, "conflict", "This submission was recorded for another user")
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", fmt.Sprintf("Failed to process submission: %v", err))
+		return
+	}
+
+	if duplicate {
+		response.JSON(w, http.StatusOK, map[string]interface{}{
+			"success":       true,
+			"duplicate":     true,
+			"submission_id": sub.ID,
+		})
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"success":           true,
+		"submission_id":     sub.ID,
+		"next_review_at":    review.NextReviewAt,
+		"days_until_review": int(review.ScheduledDays),
+		"is_due":            time.Now().UTC().After(review.NextReviewAt),
+		"title":             sub.Title,
+		"title_slug":        sub.TitleSlug,
+	})
+}
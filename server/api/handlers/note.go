@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Limits on the content of a note, in bytes
+const (
+	maxNoteBodySize  = 64 << 10
+	maxNoteCodeSize  = 64 << 10
+	maxNoteLanguages = 10
+)
+
+// NoteHandler serves users' own notes on problems and their revisions. Notes
+// are addressed by problem ID, a user has at most one per problem.
+type NoteHandler struct {
+	store *models.NoteStore
+}
+
+func NewNoteHandler(store *models.NoteStore) *NoteHandler {
+	return &NoteHandler{store: store}
+}
+
+// GetNotes lists the user's notes, only those with the tag in tag when given
+func (h *NoteHandler) GetNotes(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+	tag := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("tag")))
+
+	notes, err := h.store.ListNotes(userID, tag, limit, offset)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get notes")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, notes)
+}
+
+func (h *NoteHandler) GetNote(w http.ResponseWriter, r *http.Request) {
+	userID, problemID, ok := noteFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	note, err := h.store.GetNote(userID, problemID)
+	if writeNoteError(w, err) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, note)
+}
+
+// SaveNote replaces the content of the user's note, creating it when
+// missing. A base_revision other than the note's current one answers 409 so
+// edits from another tab or device are not overwritten.
+func (h *NoteHandler) SaveNote(w http.ResponseWriter, r *http.Request) {
+	userID, problemID, ok := noteFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Body         string            `json:"body"`
+		Code         map[string]string `json:"code"`
+		Tags         []string          `json:"tags"`
+		BaseRevision int               `json:"base_revision,omitempty"`
+	}
+	limit := int64(maxNoteBodySize + maxNoteLanguages*maxNoteCodeSize + 4096)
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit)).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if len(req.Body) > maxNoteBodySize {
+		response.ValidationError(w, "body", "body must be at most 64 KB")
+		return
+	}
+	if len(req.Code) > maxNoteLanguages {
+		response.ValidationError(w, "code", fmt.Sprintf("code can hold at most %d languages", maxNoteLanguages))
+		return
+	}
+	code := models.NoteCode{}
+	for language, snippet := range req.Code {
+		language = strings.ToLower(strings.TrimSpace(language))
+		if language == "" {
+			response.ValidationError(w, "code", "code languages must not be empty")
+			return
+		}
+		if len(snippet) > maxNoteCodeSize {
+			response.ValidationError(w, "code", "code must be at most 64 KB per language")
+			return
+		}
+		if strings.TrimSpace(snippet) != "" {
+			code[language] = snippet
+		}
+	}
+	tags, ok := models.NormalizeNoteTags(req.Tags)
+	if !ok {
+		response.ValidationError(w, "tags", fmt.Sprintf("at most %d tags of up to %d characters", models.MaxNoteTags, models.MaxNoteTagLength))
+		return
+	}
+	if req.BaseRevision < 0 {
+		response.ValidationError(w, "base_revision", "base_revision must not be negative")
+		return
+	}
+
+	note, err := h.store.SaveNote(userID, problemID, models.NoteContent{Body: req.Body, Code: code, Tags: tags}, req.BaseRevision)
+	if writeNoteError(w, err) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, note)
+}
+
+func (h *NoteHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
+	userID, problemID, ok := noteFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if writeNoteError(w, h.store.DeleteNote(userID, problemID)) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetRevisions lists the revisions of the user's note, newest first
+func (h *NoteHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	userID, problemID, ok := noteFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.store.ListNoteRevisions(userID, problemID)
+	if writeNoteError(w, err) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, revisions)
+}
+
+func (h *NoteHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	userID, problemID, ok := noteFromURL(w, r)
+	if !ok {
+		return
+	}
+	revision, ok := revisionFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	rev, err := h.store.GetNoteRevision(userID, problemID, revision)
+	if writeNoteError(w, err) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, rev)
+}
+
+// RestoreRevision makes the content of an older revision the note's current
+// content, as a new revision
+func (h *NoteHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	userID, problemID, ok := noteFromURL(w, r)
+	if !ok {
+		return
+	}
+	revision, ok := revisionFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	note, err := h.store.RestoreNoteRevision(userID, problemID, revision)
+	if writeNoteError(w, err) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, note)
+}
+
+// GetDiff compares revision from with revision to, the current one when to
+// is missing. Pass format=unified for diff -u text instead of line lists.
+func (h *NoteHandler) GetDiff(w http.ResponseWriter, r *http.Request) {
+	userID, problemID, ok := noteFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	from, err := strconv.Atoi(query.Get("from"))
+	if err != nil || from <= 0 {
+		response.ValidationError(w, "from", "from must be a revision number")
+		return
+	}
+	var to int
+	if query.Get("to") == "" {
+		note, err := h.store.GetNote(userID, problemID)
+		if writeNoteError(w, err) {
+			return
+		}
+		to = note.Revision
+	} else if to, err = strconv.Atoi(query.Get("to")); err != nil || to <= 0 {
+		response.ValidationError(w, "to", "to must be a revision number")
+		return
+	}
+	format := query.Get("format")
+	if format != "" && format != "lines" && format != "unified" {
+		response.ValidationError(w, "format", "format must be lines or unified")
+		return
+	}
+
+	fromRev, err := h.store.GetNoteRevision(userID, problemID, from)
+	if writeNoteError(w, err) {
+		return
+	}
+	toRev, err := h.store.GetNoteRevision(userID, problemID, to)
+	if writeNoteError(w, err) {
+		return
+	}
+
+	diff := models.DiffNoteRevisions(fromRev, toRev)
+	if format != "unified" {
+		response.JSON(w, http.StatusOK, diff)
+		return
+	}
+
+	code := map[string]string{}
+	for language, d := range diff.Code {
+		code[language] = d.Unified(3)
+	}
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"from":         diff.From,
+		"to":           diff.To,
+		"body":         diff.Body.Unified(3),
+		"code":         code,
+		"tags_added":   diff.TagsAdded,
+		"tags_removed": diff.TagsRemoved,
+	})
+}
+
+// noteFromURL reads the signed in user and the problem ID of a note route,
+// answering the request when either is missing
+func noteFromURL(w http.ResponseWriter, r *http.Request) (uuid.UUID, int, bool) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return uuid.Nil, 0, false
+	}
+
+	problemID, err := strconv.Atoi(chi.URLParam(r, "problem_id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid problem ID")
+		return uuid.Nil, 0, false
+	}
+	return userID, problemID, true
+}
+
+func revisionFromURL(w http.ResponseWriter, r *http.Request) (int, bool) {
+	revision, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil || revision <= 0 {
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid revision")
+		return 0, false
+	}
+	return revision, true
+}
+
+// writeNoteError answers an error from the note store, reporting whether
+// there was one
+func writeNoteError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, models.ErrNoteNotFound):
+		response.Error(w, http.StatusNotFound, "not_found", "Note not found")
+	case errors.Is(err, models.ErrNoteRevisionNotFound):
+		response.Error(w, http.StatusNotFound, "not_found", "Note revision not found")
+	case errors.Is(err, models.ErrProblemNotFound):
+		response.Error(w, http.StatusNotFound, "not_found", "Problem not found")
+	case errors.Is(err, models.ErrNoteConflict):
+		response.Error(w, http.StatusConflict, "conflict", "The note was changed since base_revision, reload it and save again")
+	default:
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to process note")
+	}
+	return true
+}
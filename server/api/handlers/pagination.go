@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+)
+
+// keysetFromQuery switches a list to cursor pagination when the request has a
+// cursor parameter; an empty cursor asks for the first page. Without it the
+// list keeps paging by offset.
+func keysetFromQuery(r *http.Request) (bool, *models.Cursor, error) {
+	query := r.URL.Query()
+	if !query.Has("cursor") {
+		return false, nil, nil
+	}
+
+	encoded := query.Get("cursor")
+	if encoded == "" {
+		return true, nil, nil
+	}
+
+	cursor, err := models.DecodeCursor(encoded)
+	if err != nil {
+		return false, nil, err
+	}
+	return true, cursor, nil
+}
+
+// countTotalFromQuery reports whether a keyset page should also count every
+// matching row, which only with_total=true asks for; offset pages always do
+func countTotalFromQuery(r *http.Request) bool {
+	return r.URL.Query().Get("with_total") == "true"
+}
+
+// cursorTotal is the total reported on a keyset page, nil when not counted
+func cursorTotal(counted bool, total int) *int {
+	if !counted {
+		return nil
+	}
+	return &total
+}
+
+// writeCursorError reports cursor problems as validation errors and reports
+// whether err was one
+func writeCursorError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, models.ErrInvalidCursor):
+		response.ValidationError(w, "cursor", "Cursor is invalid or was issued for a different ordering")
+		return true
+	case errors.Is(err, models.ErrCursorUnsupported):
+		response.ValidationError(w, "cursor", "Cursor pagination is not available for relevance ordered searches, pass order_by or use offset")
+		return true
+	}
+	return false
+}
@@ -1,24 +1,35 @@
 package handlers
 
 import (
+	"fmt"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/internal/content"
 	"go-leetcode/backend/models"
 	"go-leetcode/backend/pkg/response"
 	"net/http"
 	"strconv"
 	"strings"
+
+	"github.com/google/uuid"
 )
 
 type ProblemHandler struct {
-	store *models.ProblemStore
+	store         *models.ProblemStore
+	testCaseStore *models.TestCaseStore
 }
 
-func NewProblemHandler(s *models.ProblemStore)(*ProblemHandler) {
-	return &ProblemHandler{store: s}
+func NewProblemHandler(s *models.ProblemStore, testCaseStore *models.TestCaseStore)(*ProblemHandler) {
+	return &ProblemHandler{store: s, testCaseStore: testCaseStore}
 }
 
 func (h *ProblemHandler) GetProblemByID(w http.ResponseWriter, r *http.Request) {
 	reqID, _ := strconv.Atoi(r.URL.Query().Get("id"))
 
+	format, ok := contentFormatFromQuery(w, r)
+	if !ok {
+		return
+	}
+
 	res, err := h.store.GetProblemByID(reqID)
 
 	if err != nil {
@@ -26,12 +37,27 @@ func (h *ProblemHandler) GetProblemByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	response.JSON(w, http.StatusOK, res)
+	problems := []models.Problem{res}
+	if err := h.store.ApplyContentFormat(problems, format); err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to render problem content")
+		return
+	}
+
+	if !h.attachTestCases(w, r, &problems[0]) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, problems[0])
 }
 
 func (h *ProblemHandler) GetProblemByFrontendID(w http.ResponseWriter, r *http.Request) {
 	reqFrontendID, _ := strconv.Atoi(r.URL.Query().Get("frontend_id"))
 
+	format, ok := contentFormatFromQuery(w, r)
+	if !ok {
+		return
+	}
+
 	res, err := h.store.GetProblemByFrontendID(reqFrontendID)
 
 	if err != nil {
@@ -39,12 +65,27 @@ func (h *ProblemHandler) GetProblemByFrontendID(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	response.JSON(w, http.StatusOK, res)
+	problems := []models.Problem{res}
+	if err := h.store.ApplyContentFormat(problems, format); err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to render problem content")
+		return
+	}
+
+	if !h.attachTestCases(w, r, &problems[0]) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, problems[0])
 }
 
 func (h *ProblemHandler) GetProblemBySlug(w http.ResponseWriter, r *http.Request) {
 	req_slug := r.URL.Query().Get("slug")
 
+	format, ok := contentFormatFromQuery(w, r)
+	if !ok {
+		return
+	}
+
 	res, err := h.store.GetProblemBySlug(req_slug)
 
 	if err != nil {
@@ -52,58 +93,209 @@ func (h *ProblemHandler) GetProblemBySlug(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	response.JSON(w, http.StatusOK, res)
+	problems := []models.Problem{res}
+	if err := h.store.ApplyContentFormat(problems, format); err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to render problem content")
+		return
+	}
+
+	if !h.attachTestCases(w, r, &problems[0]) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, problems[0])
+}
+
+// attachTestCases adds the signed in user's own test cases to a problem,
+// answering the request when loading them fails
+func (h *ProblemHandler) attachTestCases(w http.ResponseWriter, r *http.Request, problem *models.Problem) bool {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		return true
+	}
+	if err := h.testCaseStore.AttachTestCases(userID, problem); err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get test cases")
+		return false
+	}
+	return true
 }
 
 func (h *ProblemHandler) GetProblemList(w http.ResponseWriter, r *http.Request) {
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	options, err := problemListOptionsFromQuery(r)
+	if err != nil {
+		response.ValidationError(w, "tags_mode", err.Error())
+		return
+	}
+
+	options.Keyset, options.Cursor, err = keysetFromQuery(r)
+	if writeCursorError(w, err) {
+		return
+	}
+	options.CountTotal = countTotalFromQuery(r)
+
+	var ok bool
+	if options.ContentFormat, ok = contentFormatFromQuery(w, r); !ok {
+		return
+	}
+
+	// Calculate page number from offset and limit
+	page := (options.Offset / options.Limit) + 1
+
+	result, err := h.store.ListProblems(options)
+
+	if err != nil {
+		if writeCursorError(w, err) {
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get problems list")
+		return
+	}
+
+	if options.Keyset {
+		response.JSONWithCursor(w, http.StatusOK, result.Problems, cursorTotal(options.CountTotal, result.Total), options.Limit, result.NextCursor, result.HasMore, result.Facets)
+		return
+	}
+
+	response.JSONWithFacets(w, http.StatusOK, result.Problems, result.Total, page, options.Limit, result.Facets)
+}
+
+// GetRandomProblems picks random problems matching the list filters. Signed in
+// users can also filter by status and exclude problems they solved or have in review.
+func (h *ProblemHandler) GetRandomProblems(w http.ResponseWriter, r *http.Request) {
+	options, err := problemListOptionsFromQuery(r)
+	if err != nil {
+		response.ValidationError(w, "tags_mode", err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+
+	count := 1
+	if countParam := query.Get("count"); countParam != "" {
+		count, err = strconv.Atoi(countParam)
+		if err != nil || count < 1 || count > models.MaxRandomProblems {
+			response.ValidationError(w, "count", fmt.Sprintf("count must be between 1 and %d", models.MaxRandomProblems))
+			return
+		}
+	}
+
+	// userID stays uuid.Nil for anonymous requests
+	userID, _ := middleware.GetUserUUIDFromContext(r.Context())
+	needsUser := false
+
+	options.Filter.Status = query.Get("status")
+	if options.Filter.Status != "" {
+		if !models.IsValidProblemStatus(options.Filter.Status) {
+			response.ValidationError(w, "status", "status must be one of solved, unsolved, due, in_review, never_seen")
+			return
+		}
+		needsUser = true
+	}
+
+	options.Filter.Exclude = splitList(query.Get("exclude"))
+	for _, exclude := range options.Filter.Exclude {
+		switch exclude {
+		case models.ProblemExcludePaid:
+		case models.ProblemStatusSolved, models.ProblemStatusInReview:
+			needsUser = true
+		default:
+			response.ValidationError(w, "exclude", "exclude must be a comma separated list of solved, in_review and paid")
+			return
+		}
+	}
+
+	if needsUser && userID == uuid.Nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Sign in to filter by your own progress")
+		return
+	}
+
+	format, ok := contentFormatFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	problems, err := h.store.RandomProblems(options.Filter, userID, count)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to pick random problems")
+		return
+	}
+
+	if err := h.store.ApplyContentFormat(problems, format); err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to render problem content")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, problems)
+}
+
+// problemListOptionsFromQuery reads the filtering, ordering and pagination
+// parameters shared by the problem list endpoints
+func problemListOptionsFromQuery(r *http.Request) (models.ListProblemOptions, error) {
+	query := r.URL.Query()
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
 	if limit <= 0 || limit > 100 {
 		limit = 20
 	}
 
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
 	if offset < 0 {
 		offset = 0
 	}
 
-	difficulty := r.URL.Query().Get("difficulty")
-	orderBy := r.URL.Query().Get("order_by")
-	orderDir := r.URL.Query().Get("order_dir")
-	searchKeyword := r.URL.Query().Get("search")
-
-	var tags []string
-	if tagsParam := r.URL.Query().Get("tags"); tagsParam != "" {
-		tags = strings.Split(tagsParam, ",")
+	tagsMode := query.Get("tags_mode")
+	if tagsMode == "" {
+		tagsMode = models.TagsModeAny
+	}
+	if tagsMode != models.TagsModeAny && tagsMode != models.TagsModeAll {
+		return models.ListProblemOptions{}, fmt.Errorf("tags_mode must be %q or %q", models.TagsModeAll, models.TagsModeAny)
 	}
 
 	var paidOnly *bool
-	if paidParam := r.URL.Query().Get("paid_only"); paidParam != "" {
+	if paidParam := query.Get("paid_only"); paidParam != "" {
 		boolVal := paidParam == "true"
 		paidOnly = &boolVal
 	}
 
-	options := models.ListProblemOptions {
+	return models.ListProblemOptions{
 		Filter: models.ProblemFilter{
-			Difficulty: difficulty,
-			Tags: tags,
-			SearchKeyword: searchKeyword,
-			PaidOnly: paidOnly,
+			// difficulty accepts a comma separated list, e.g. "Medium,Hard"
+			Difficulties:  splitList(query.Get("difficulty")),
+			Tags:          splitList(query.Get("tags")),
+			TagsMode:      tagsMode,
+			ExcludeTags:   splitList(query.Get("exclude_tags")),
+			SearchKeyword: query.Get("search"),
+			Query:         query.Get("q"),
+			PaidOnly:      paidOnly,
 		},
-		Limit: limit,
-		Offset: offset,
-		OrderBy: orderBy,
-		OrderDir: orderDir,
-	}
-
-	// Calculate page number from offset and limit
-	page := (offset / limit) + 1
-
-	result, err := h.store.ListProblems(options)
+		Limit:         limit,
+		Offset:        offset,
+		OrderBy:       query.Get("order_by"),
+		OrderDir:      query.Get("order_dir"),
+		IncludeFacets: true,
+	}, nil
+}
 
-	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get problems list")
-		return
+// contentFormatFromQuery reads the format parameter selecting how problem
+// content is returned, writing a validation error when it is unknown
+func contentFormatFromQuery(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		return content.FormatRaw, true
+	}
+	if !content.IsValidFormat(format) {
+		response.ValidationError(w, "format", "format must be one of raw, html, markdown, summary")
+		return "", false
 	}
+	return format, true
+}
 
-	response.JSONWithPagination(w, http.StatusOK, result.Problems, result.Total, page, limit)
-}
\ No newline at end of file
+func splitList(param string) []string {
+	var values []string
+	for _, v := range strings.Split(param, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetRelatedProblems walks the similar problem graph from the problem in the URL
+func (h *ProblemHandler) GetRelatedProblems(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	depth := 1
+	if depthParam := query.Get("depth"); depthParam != "" {
+		var err error
+		depth, err = strconv.Atoi(depthParam)
+		if err != nil || depth < 1 || depth > models.MaxRelatedDepth {
+			response.ValidationError(w, "depth", "depth must be between 1 and 3")
+			return
+		}
+	}
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	related, err := h.store.GetRelatedProblems(chi.URLParam(r, "slug"), depth, splitList(query.Get("difficulty")), limit)
+	if err != nil {
+		if errors.Is(err, models.ErrProblemNotFound) {
+			response.Error(w, http.StatusNotFound, "not_found", "Problem not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get related problems")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, related)
+}
+
+// GetRecommendations suggests unsolved problems for the authenticated user
+func (h *ProblemHandler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	recommendations, err := h.store.RecommendProblems(userID, splitList(query.Get("difficulty")), query.Get("include_paid") == "true", limit)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get recommendations")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, recommendations)
+}
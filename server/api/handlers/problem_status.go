@@ -6,8 +6,6 @@ import (
 	"go-leetcode/backend/models"
 	"go-leetcode/backend/pkg/response"
 	"net/http"
-	"strconv"
-	"strings"
 )
 
 type ProblemStatusHandler struct {
@@ -23,47 +21,25 @@ func NewProblemStatusHandler(ps *models.ProblemStore, ss *models.SubmissionStore
 }
 
 func (h *ProblemStatusHandler) GetProblemsWithStatus(w http.ResponseWriter, r *http.Request) {
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit <= 0 || limit > 100 {
-		limit = 20
-	}
-
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-	if offset < 0 {
-		offset = 0
-	}
-
-	difficulty := r.URL.Query().Get("difficulty")
-	orderBy := r.URL.Query().Get("order_by")
-	orderDir := r.URL.Query().Get("order_dir")
-	searchKeyword := r.URL.Query().Get("search")
-
-	var tags []string
-	if tagsParam := r.URL.Query().Get("tags"); tagsParam != "" {
-		tags = strings.Split(tagsParam, ",")
+	options, err := problemListOptionsFromQuery(r)
+	if err != nil {
+		response.ValidationError(w, "tags_mode", err.Error())
+		return
 	}
 
-	var paidOnly *bool
-	if paidParam := r.URL.Query().Get("paid_only"); paidParam != "" {
-		boolVal := paidParam == "true"
-		paidOnly = &boolVal
+	options.Keyset, options.Cursor, err = keysetFromQuery(r)
+	if writeCursorError(w, err) {
+		return
 	}
+	options.CountTotal = countTotalFromQuery(r)
 
-	options := models.ListProblemOptions{
-		Filter: models.ProblemFilter{
-			Difficulty:    difficulty,
-			Tags:          tags,
-			SearchKeyword: searchKeyword,
-			PaidOnly:      paidOnly,
-		},
-		Limit:    limit,
-		Offset:   offset,
-		OrderBy:  orderBy,
-		OrderDir: orderDir,
+	var ok bool
+	if options.ContentFormat, ok = contentFormatFromQuery(w, r); !ok {
+		return
 	}
 
 	// Calculate page number from offset and limit
-	page := (offset / limit) + 1
+	page := (options.Offset / options.Limit) + 1
 
 	userID, err := middleware.GetUserUUIDFromContext(r.Context())
 
@@ -73,43 +49,28 @@ func (h *ProblemStatusHandler) GetProblemsWithStatus(w http.ResponseWriter, r *h
 	}
 	fmt.Printf("DEBUG GetProblemsWithStatus: Processing request for userID: %s\n", userID.String()) // Log UserID
 
-	// Get filtered problems
-	problemsList, err := h.problemStore.ListProblems(options)
+	options.Filter.Status = r.URL.Query().Get("status")
+	if options.Filter.Status != "" && !models.IsValidProblemStatus(options.Filter.Status) {
+		response.ValidationError(w, "status", "status must be one of solved, unsolved, due, in_review, never_seen")
+		return
+	}
+
+	// Filter, sort and attach the user's status in a single query
+	result, err := h.problemStore.ListProblemsWithStatus(options, userID)
 	if err != nil {
+		if writeCursorError(w, err) {
+			return
+		}
 		// Log the specific error on the server for debugging
 		fmt.Printf("ERROR: Failed to list problems: %v\n", err)
 		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get problems list")
 		return
 	}
 
-	// Get user's submissions
-	submissions, err := h.submissionStore.GetSubmissionsByUserID(userID)
-	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get user submissions")
+	if options.Keyset {
+		response.JSONWithCursor(w, http.StatusOK, result.Problems, cursorTotal(options.CountTotal, result.Total), options.Limit, result.NextCursor, result.HasMore, result.Facets)
 		return
 	}
-	fmt.Printf("DEBUG GetProblemsWithStatus: Found %d submissions for userID: %s\n", len(submissions), userID.String()) // Log submission count
-
-	// Create a lookup map of completed problems
-	completedMap := make(map[string]bool)
-	for _, sub := range submissions {
-		completedMap[sub.TitleSlug] = true
-	}
-	fmt.Printf("DEBUG GetProblemsWithStatus: Created completedMap with %d entries for userID: %s\n", len(completedMap), userID.String()) // Log map size
-
-	// Combine data
-	var problemsWithStatus []models.ProblemWithStatus
-	for _, problem := range problemsList.Problems {
-		problemsWithStatus = append(problemsWithStatus, models.ProblemWithStatus{
-			Problem:   problem,
-			Completed: completedMap[problem.TitleSlug],
-		})
-	}
-
-	result := models.ProblemListWithStatus{
-		Problems: problemsWithStatus,
-		Total:    problemsList.Total,
-	}
 
-	response.JSONWithPagination(w, http.StatusOK, result.Problems, result.Total, page, limit)
+	response.JSONWithFacets(w, http.StatusOK, result.Problems, result.Total, page, options.Limit, result.Facets)
 }
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"go-leetcode/backend/internal/leetcode"
 	"io"
 	"net/http"
 )
 
-// LeetCodeProxyHandler forwards requests to LeetCode GraphQL API
+// LeetCodeProxyHandler forwards requests to LeetCode GraphQL API.
+// The optional region query parameter ("com" or "cn") picks which site to forward to.
 func LeetCodeProxyHandler(w http.ResponseWriter, r *http.Request) {
 	// Only allow POST requests
 	if r.Method != http.MethodPost {
@@ -13,10 +15,16 @@ func LeetCodeProxyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	region, err := leetcode.ParseRegion(r.URL.Query().Get("region"))
+	if err != nil {
+		http.Error(w, "Unknown LeetCode region", http.StatusBadRequest)
+		return
+	}
+
 	// Create a new request to the LeetCode API
 	proxyReq, err := http.NewRequest(
 		http.MethodPost,
-		"https://leetcode.com/graphql/",
+		region.Endpoint(),
 		r.Body,
 	)
 	if err != nil {
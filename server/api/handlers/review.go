@@ -92,53 +92,45 @@ func (h *ReviewHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
 	// Calculate offset
 	offset := (page - 1) * perPage
 
+	keyset, cursor, err := keysetFromQuery(r)
+	if writeCursorError(w, err) {
+		return
+	}
+	listPage := models.Page{Limit: perPage, Offset: offset, Keyset: keyset, Cursor: cursor, CountTotal: countTotalFromQuery(r)}
+
 	// Get reviews based on status parameter
 	status := r.URL.Query().Get("status")
 	var reviews []models.ReviewSchedule
-	var total int
+	var info models.PageInfo
 
 	switch status {
 	case "due":
 		// Get only due reviews with pagination
-		reviews, total, err = h.store.GetDueReviews(userID, perPage, offset)
+		reviews, info, err = h.store.GetDueReviews(userID, listPage)
 	case "upcoming":
 		// Get only upcoming reviews with pagination
-		reviews, total, err = h.store.GetUpcomingReviews(userID, perPage, offset)
+		reviews, info, err = h.store.GetUpcomingReviews(userID, listPage)
 	default:
-		// Get all reviews (both due and upcoming)
-		// For combined results, we need to handle pagination specially
-		dueReviews, dueTotal, dueErr := h.store.GetDueReviews(userID, perPage, offset)
-		
-		if dueErr != nil {
-			err = dueErr
-		} else {
-			reviews = dueReviews
-			total = dueTotal
-			
-			// If we haven't filled the page with due reviews, get some upcoming reviews
-			if len(dueReviews) < perPage {
-				remainingItems := perPage - len(dueReviews)
-				upcomingReviews, upcomingTotal, upcomingErr := h.store.GetUpcomingReviews(userID, remainingItems, 0)
-				
-				if upcomingErr != nil {
-					err = upcomingErr
-				} else {
-					// Combine both lists and totals
-					reviews = append(reviews, upcomingReviews...)
-					total += upcomingTotal
-				}
-			}
-		}
+		// Get all reviews (both due and upcoming), due ones first
+		reviews, info, err = h.store.GetScheduledReviews(userID, listPage)
 	}
 
 	if err != nil {
+		if writeCursorError(w, err) {
+			return
+		}
 		// Include detailed error in the response message
 		errorMessage := fmt.Sprintf("Failed to get reviews: %v", err)
 		response.Error(w, http.StatusInternalServerError, "server_error", errorMessage)
 		return
 	}
 
-	response.JSONWithPagination(w, http.StatusOK, reviews, total, page, perPage)
+	if keyset {
+		response.JSONWithCursor(w, http.StatusOK, reviews, cursorTotal(listPage.CountTotal, info.Total), perPage, info.NextCursor, info.HasMore, nil)
+		return
+	}
+
+	response.JSONWithPagination(w, http.StatusOK, reviews, info.Total, page, perPage)
 }
 
 func (h *ReviewHandler) UpdateReviewSchedule(w http.ResponseWriter, r *http.Request) {
@@ -205,7 +197,7 @@ func (h *ReviewHandler) UpdateOrCreateReview(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	review, err := h.store.UpdateOrCreateReviewForSubmission(&req)
+	review, err := h.store.UpdateOrCreateReviewForSubmission(&req, fsrs.Good)
 	
 	if err != nil{
 		err_string := fmt.Sprintf("Failed to update or create review: %v", err)
@@ -2,10 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"go-leetcode/backend/api/middleware"
 	"go-leetcode/backend/models"
 	"go-leetcode/backend/pkg/response"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
 type SolutionHandler struct {
@@ -32,6 +39,18 @@ func (h *SolutionHandler) GetSolutions(w http.ResponseWriter, r *http.Request) {
 
 	// Check if language filter is specified
 	language := r.URL.Query().Get("language")
+
+	// version 2 returns every approach, version 1 (the default) the
+	// language -> code map of the primary approach
+	switch r.URL.Query().Get("version") {
+	case "", "1":
+	case "2":
+		h.getApproaches(w, id, language)
+		return
+	default:
+		response.ValidationError(w, "version", "version must be 1 or 2")
+		return
+	}
 	
 	// If language is specified, get solution for that specific language
 	if language != "" {
@@ -82,9 +101,44 @@ func (h *SolutionHandler) GetSolutions(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonData)
 }
 
+// getApproaches writes the structured solutions of a problem, keeping only
+// the code in language when it is given
+func (h *SolutionHandler) getApproaches(w http.ResponseWriter, problemID int, language string) {
+	approaches, err := h.store.ListApproaches(problemID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get solutions")
+		return
+	}
+
+	if language != "" {
+		for i := range approaches {
+			code := map[string]string{}
+			for l, c := range approaches[i].Code {
+				if strings.EqualFold(l, language) {
+					code[l] = c
+				}
+			}
+			approaches[i].Code = code
+		}
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"version":    2,
+		"problem_id": problemID,
+		"approaches": approaches,
+	})
+}
+
+// CreateSolution adds a reference solution, for editors
 func (h *SolutionHandler) CreateSolution(w http.ResponseWriter, r *http.Request) {
+	actorID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
 	var solution models.Solution
-	decoder := json.NewDecoder(r.Body)
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*maxCodeSize))
 	if err := decoder.Decode(&solution); err != nil {
 		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
@@ -95,81 +149,471 @@ func (h *SolutionHandler) CreateSolution(w http.ResponseWriter, r *http.Request)
 		response.Error(w, http.StatusBadRequest, "missing_problem_id", "Problem ID is required")
 		return
 	}
-
-	createdSolution, err := h.store.CreateSolution(solution)
-	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to create solution")
+	if !validateSolutionCode(w, &solution.Language, solution.SolutionCode) {
 		return
 	}
 
-	jsonData, err := json.Marshal(createdSolution)
+	createdSolution, err := h.store.CreateSolution(actorID, solution)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to marshal created solution")
+		writeSolutionError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	w.Write(jsonData)
+	response.JSON(w, http.StatusCreated, createdSolution)
 }
 
+// UpdateSolution replaces the code of any reference solution, for editors
 func (h *SolutionHandler) UpdateSolution(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Query().Get("id")
-	if idStr == "" {
-		response.Error(w, http.StatusBadRequest, "missing_id", "Solution ID is required")
+	actorID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
 		return
 	}
-	
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		response.Error(w, http.StatusBadRequest, "invalid_id", "Invalid solution ID")
+
+	id, ok := solutionIDFromQuery(w, r)
+	if !ok {
 		return
 	}
 
 	var solution models.Solution
-	decoder := json.NewDecoder(r.Body)
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*maxCodeSize))
 	if err := decoder.Decode(&solution); err != nil {
 		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
+	if strings.TrimSpace(solution.SolutionCode) == "" || len(solution.SolutionCode) > maxCodeSize {
+		response.ValidationError(w, "solution_code", "solution_code must be between 1 byte and 64 KB")
+		return
+	}
 
 	solution.ID = id
 
-	err = h.store.UpdateSolution(solution)
+	updated, err := h.store.UpdateSolution(actorID, solution)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to update solution")
+		writeSolutionError(w, err)
 		return
 	}
 
-	jsonData, err := json.Marshal(solution)
+	response.JSON(w, http.StatusOK, updated)
+}
+
+// DeleteSolution removes a reference solution, for editors
+func (h *SolutionHandler) DeleteSolution(w http.ResponseWriter, r *http.Request) {
+	actorID, err := middleware.GetUserUUIDFromContext(r.Context())
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to marshal updated solution")
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(jsonData)
+	id, ok := solutionIDFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.DeleteSolution(actorID, id); err != nil {
+		writeSolutionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *SolutionHandler) DeleteSolution(w http.ResponseWriter, r *http.Request) {
+// CreateProposal queues a solution proposed by the user for moderation
+func (h *SolutionHandler) CreateProposal(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	var proposal models.SolutionProposal
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*maxCodeSize)).Decode(&proposal); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if proposal.ProblemID <= 0 {
+		response.ValidationError(w, "problem_id", "Problem ID is required")
+		return
+	}
+	if !validateSolutionCode(w, &proposal.Language, proposal.SolutionCode) {
+		return
+	}
+	proposal.AuthorID = userID
+
+	if err := h.store.CreateProposal(&proposal); err != nil {
+		writeSolutionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, proposal)
+}
+
+// GetMyProposals lists the user's proposals, optionally by status
+func (h *SolutionHandler) GetMyProposals(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	filter, ok := proposalFilterFromQuery(w, r)
+	if !ok {
+		return
+	}
+	filter.AuthorID = userID
+
+	proposals, err := h.store.ListProposals(filter)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get solution proposals")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, proposals)
+}
+
+// UpdateProposal changes the code of one of the user's pending proposals
+func (h *SolutionHandler) UpdateProposal(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	id, ok := proposalIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		SolutionCode string `json:"solution_code"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*maxCodeSize)).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.SolutionCode) == "" || len(req.SolutionCode) > maxCodeSize {
+		response.ValidationError(w, "solution_code", "solution_code must be between 1 byte and 64 KB")
+		return
+	}
+
+	proposal, err := h.store.UpdateProposal(id, userID, req.SolutionCode)
+	if err != nil {
+		writeSolutionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, proposal)
+}
+
+// DeleteProposal withdraws one of the user's pending proposals
+func (h *SolutionHandler) DeleteProposal(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	id, ok := proposalIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.DeleteProposal(id, userID); err != nil {
+		writeSolutionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProposalQueue lists proposals for moderation, pending ones unless a
+// status is given
+func (h *SolutionHandler) GetProposalQueue(w http.ResponseWriter, r *http.Request) {
+	filter, ok := proposalFilterFromQuery(w, r)
+	if !ok {
+		return
+	}
+	if filter.Status == "" {
+		filter.Status = models.ProposalPending
+	}
+
+	proposals, err := h.store.ListProposals(filter)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get solution proposals")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, proposals)
+}
+
+// ApproveProposal makes a pending proposal the reference solution in its
+// language
+func (h *SolutionHandler) ApproveProposal(w http.ResponseWriter, r *http.Request) {
+	h.reviewProposal(w, r, h.store.ApproveProposal)
+}
+
+// RejectProposal closes a pending proposal with a note for its author
+func (h *SolutionHandler) RejectProposal(w http.ResponseWriter, r *http.Request) {
+	h.reviewProposal(w, r, h.store.RejectProposal)
+}
+
+func (h *SolutionHandler) reviewProposal(w http.ResponseWriter, r *http.Request, review func(int, uuid.UUID, string) (models.SolutionProposal, error)) {
+	reviewerID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	id, ok := proposalIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+			return
+		}
+	}
+
+	proposal, err := review(id, reviewerID, strings.TrimSpace(req.Note))
+	if err != nil {
+		writeSolutionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, proposal)
+}
+
+// CreateApproach adds an approach with its code to a problem, for editors
+func (h *SolutionHandler) CreateApproach(w http.ResponseWriter, r *http.Request) {
+	actorID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	var approach models.Approach
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8*maxCodeSize)).Decode(&approach); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if approach.ProblemID <= 0 {
+		response.ValidationError(w, "problem_id", "Problem ID is required")
+		return
+	}
+	if approach.Kind == "" {
+		approach.Kind = models.ApproachOptimal
+	}
+	hints := []models.Hint(approach.Hints)
+	update := models.ApproachUpdate{
+		Kind:            &approach.Kind,
+		Title:           &approach.Title,
+		TimeComplexity:  &approach.TimeComplexity,
+		SpaceComplexity: &approach.SpaceComplexity,
+		Code:            approach.Code,
+		Hints:           &hints,
+	}
+	if !validateApproach(w, update) {
+		return
+	}
+	for language, code := range approach.Code {
+		if code == "" {
+			delete(approach.Code, language)
+		}
+	}
+
+	if err := h.store.CreateApproach(actorID, &approach); err != nil {
+		writeSolutionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, approach)
+}
+
+// UpdateApproach changes the fields present in the body, for editors
+func (h *SolutionHandler) UpdateApproach(w http.ResponseWriter, r *http.Request) {
+	actorID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid approach ID")
+		return
+	}
+
+	var update models.ApproachUpdate
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8*maxCodeSize)).Decode(&update); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if !validateApproach(w, update) {
+		return
+	}
+
+	approach, err := h.store.UpdateApproach(actorID, id, update)
+	if err != nil {
+		writeSolutionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, approach)
+}
+
+// DeleteApproach removes an approach and its code, for editors
+func (h *SolutionHandler) DeleteApproach(w http.ResponseWriter, r *http.Request) {
+	actorID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid approach ID")
+		return
+	}
+
+	if err := h.store.DeleteApproach(actorID, id); err != nil {
+		writeSolutionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateApproach checks the fields present in an approach change
+func validateApproach(w http.ResponseWriter, update models.ApproachUpdate) bool {
+	switch {
+	case update.Kind != nil && !models.IsValidApproachKind(*update.Kind):
+		response.ValidationError(w, "kind", "kind must be brute_force, optimal or alternative")
+		return false
+	case update.Title != nil && (strings.TrimSpace(*update.Title) == "" || len(*update.Title) > 200):
+		response.ValidationError(w, "title", "Title must be between 1 and 200 characters")
+		return false
+	case update.TimeComplexity != nil && len(*update.TimeComplexity) > 100:
+		response.ValidationError(w, "time_complexity", "time_complexity must be at most 100 characters")
+		return false
+	case update.SpaceComplexity != nil && len(*update.SpaceComplexity) > 100:
+		response.ValidationError(w, "space_complexity", "space_complexity must be at most 100 characters")
+		return false
+	case update.Position != nil && *update.Position < 0:
+		response.ValidationError(w, "position", "position cannot be negative")
+		return false
+	}
+	if update.Hints != nil {
+		hints := *update.Hints
+		if len(hints) > models.MaxHints {
+			response.ValidationError(w, "hints", fmt.Sprintf("An approach can have at most %d hints", models.MaxHints))
+			return false
+		}
+		for _, hint := range hints {
+			if !models.IsValidHintKind(hint.Kind) {
+				response.ValidationError(w, "hints", "Hint kinds must be pattern, insight, algorithm or complexity")
+				return false
+			}
+			if strings.TrimSpace(hint.Text) == "" || len(hint.Text) > 2000 {
+				response.ValidationError(w, "hints", "Hint texts must be between 1 and 2000 characters")
+				return false
+			}
+		}
+		if !models.HintsInOrder(hints) {
+			response.ValidationError(w, "hints", "Hints must go from pattern to insight, algorithm and complexity")
+			return false
+		}
+	}
+	for language, code := range update.Code {
+		if strings.TrimSpace(language) == "" || len(language) > 32 {
+			response.ValidationError(w, "code", "Code languages must be between 1 and 32 characters")
+			return false
+		}
+		if len(code) > maxCodeSize {
+			response.ValidationError(w, "code", "Code must be at most 64 KB per language")
+			return false
+		}
+	}
+	return true
+}
+
+// validateSolutionCode checks the language and code of a new solution,
+// trimming the language
+func validateSolutionCode(w http.ResponseWriter, language *string, code string) bool {
+	*language = strings.TrimSpace(*language)
+	if *language == "" || len(*language) > 32 {
+		response.ValidationError(w, "language", "Language is required")
+		return false
+	}
+	if strings.TrimSpace(code) == "" || len(code) > maxCodeSize {
+		response.ValidationError(w, "solution_code", "solution_code must be between 1 byte and 64 KB")
+		return false
+	}
+	return true
+}
+
+func solutionIDFromQuery(w http.ResponseWriter, r *http.Request) (int, bool) {
 	idStr := r.URL.Query().Get("id")
 	if idStr == "" {
 		response.Error(w, http.StatusBadRequest, "missing_id", "Solution ID is required")
-		return
+		return 0, false
 	}
-	
+
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		response.Error(w, http.StatusBadRequest, "invalid_id", "Invalid solution ID")
-		return
+		return 0, false
 	}
+	return id, true
+}
 
-	err = h.store.DeleteSolution(id)
+func proposalIDFromURL(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to delete solution")
-		return
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid proposal ID")
+		return 0, false
 	}
+	return id, true
+}
 
-	w.WriteHeader(http.StatusNoContent)
+func proposalFilterFromQuery(w http.ResponseWriter, r *http.Request) (models.ProposalFilter, bool) {
+	query := r.URL.Query()
+
+	filter := models.ProposalFilter{Status: query.Get("status")}
+	if filter.Status != "" && !models.IsValidProposalStatus(filter.Status) {
+		response.ValidationError(w, "status", "status must be pending, approved or rejected")
+		return filter, false
+	}
+
+	filter.ProblemID, _ = strconv.Atoi(query.Get("problem_id"))
+
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 50
+	}
+	return filter, true
+}
+
+// writeSolutionError answers an error from a solution or proposal change
+func writeSolutionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrSolutionNotFound):
+		response.Error(w, http.StatusNotFound, "not_found", "Solution not found")
+	case errors.Is(err, models.ErrApproachNotFound):
+		response.Error(w, http.StatusNotFound, "not_found", "Solution approach not found")
+	case errors.Is(err, models.ErrProposalNotFound):
+		response.Error(w, http.StatusNotFound, "not_found", "Solution proposal not found")
+	case errors.Is(err, models.ErrProblemNotFound):
+		response.Error(w, http.StatusNotFound, "not_found", "Problem not found")
+	case errors.Is(err, models.ErrSolutionExists):
+		response.Error(w, http.StatusConflict, "conflict", "The problem already has a solution in this language")
+	case errors.Is(err, models.ErrProposalReviewed):
+		response.Error(w, http.StatusConflict, "conflict", "Solution proposal was already reviewed")
+	default:
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to update solution")
+	}
 }
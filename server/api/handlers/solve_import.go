@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/internal/importfile"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const maxImportFileSize = 5 << 20
+
+type SolveImportHandler struct {
+	store *models.SolveImportStore
+}
+
+func NewSolveImportHandler(store *models.SolveImportStore) *SolveImportHandler {
+	return &SolveImportHandler{store: store}
+}
+
+// ImportFile imports past solves from an uploaded JSON or CSV file, sent either
+// as the "file" field of a multipart form or as the raw request body
+func (h *SolveImportHandler) ImportFile(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize)
+
+	var content []byte
+	var filename string
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			response.ValidationError(w, "file", "A file upload is required")
+			return
+		}
+		defer file.Close()
+
+		filename = header.Filename
+		contentType = header.Header.Get("Content-Type")
+		content, err = io.ReadAll(file)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid_request", "Failed to read uploaded file")
+			return
+		}
+	} else {
+		content, err = io.ReadAll(r.Body)
+		if err != nil {
+			response.Error(w, http.StatusRequestEntityTooLarge, "file_too_large", fmt.Sprintf("File must be smaller than %d MB", maxImportFileSize>>20))
+			return
+		}
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = importfile.DetectFormat(filename, contentType, content)
+	}
+
+	records, invalid, err := importfile.Parse(bytes.NewReader(content), format)
+	if err != nil {
+		response.ValidationError(w, "file", err.Error())
+		return
+	}
+
+	summary, err := h.store.ImportSolves(userID, records)
+	if err != nil {
+		fmt.Printf("ERROR: Solve import failed for user %s: %v\n", userID, err)
+		response.Error(w, http.StatusInternalServerError, "server_error", "Import failed, no rows were imported")
+		return
+	}
+
+	summary.AddInvalidRows(invalid)
+
+	response.JSON(w, http.StatusOK, summary)
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/internal/examples"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxTestCaseSize bounds the inputs and expected value of a test case, in
+// bytes
+const maxTestCaseSize = 64 << 10
+
+// TestCaseHandler manages users' own test cases of problems
+type TestCaseHandler struct {
+	store        *models.TestCaseStore
+	problemStore *models.ProblemStore
+}
+
+func NewTestCaseHandler(s *models.TestCaseStore, problemStore *models.ProblemStore) *TestCaseHandler {
+	return &TestCaseHandler{store: s, problemStore: problemStore}
+}
+
+// testCaseRequest is the body of creating and replacing a test case
+type testCaseRequest struct {
+	ProblemID int                   `json:"problem_id"`
+	Name      string                `json:"name"`
+	Inputs    models.TestCaseInputs `json:"inputs"`
+	Expected  json.RawMessage       `json:"expected,omitempty"`
+}
+
+// GetTestCases lists the user's test cases of the problem in problem_id
+func (h *TestCaseHandler) GetTestCases(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	problemID, err := strconv.Atoi(r.URL.Query().Get("problem_id"))
+	if err != nil {
+		response.ValidationError(w, "problem_id", "problem_id is required")
+		return
+	}
+
+	cases, err := h.store.ListTestCases(userID, problemID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get test cases")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, cases)
+}
+
+func (h *TestCaseHandler) GetTestCase(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := testCaseFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	testCase, err := h.store.GetTestCase(userID, id)
+	if err != nil {
+		writeTestCaseError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, testCase)
+}
+
+// CreateTestCase adds a test case to a problem. Its inputs must name every
+// parameter of the problem's solution function.
+func (h *TestCaseHandler) CreateTestCase(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return
+	}
+
+	var req testCaseRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*maxTestCaseSize)).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if req.ProblemID == 0 {
+		response.ValidationError(w, "problem_id", "problem_id is required")
+		return
+	}
+	if !h.validateTestCase(w, req.ProblemID, &req) {
+		return
+	}
+
+	testCase := models.TestCase{
+		UserID:    userID,
+		ProblemID: req.ProblemID,
+		Name:      req.Name,
+		Inputs:    req.Inputs,
+		Expected:  req.Expected,
+	}
+	if err := h.store.CreateTestCase(&testCase); err != nil {
+		writeTestCaseError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, testCase)
+}
+
+// UpdateTestCase replaces the name, inputs and expected value of a test case
+func (h *TestCaseHandler) UpdateTestCase(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := testCaseFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	existing, err := h.store.GetTestCase(userID, id)
+	if err != nil {
+		writeTestCaseError(w, err)
+		return
+	}
+
+	var req testCaseRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*maxTestCaseSize)).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if !h.validateTestCase(w, existing.ProblemID, &req) {
+		return
+	}
+
+	testCase, err := h.store.UpdateTestCase(userID, id, req.Name, req.Inputs, req.Expected)
+	if err != nil {
+		writeTestCaseError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, testCase)
+}
+
+func (h *TestCaseHandler) DeleteTestCase(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := testCaseFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.DeleteTestCase(userID, id); err != nil {
+		writeTestCaseError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateTestCase checks a test case against the parameters of its problem,
+// trimming its name
+func (h *TestCaseHandler) validateTestCase(w http.ResponseWriter, problemID int, req *testCaseRequest) bool {
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) > 100 {
+		response.ValidationError(w, "name", "name must be at most 100 characters")
+		return false
+	}
+
+	size := len(req.Expected)
+	for _, value := range req.Inputs {
+		size += len(value)
+	}
+	if size > maxTestCaseSize {
+		response.ValidationError(w, "inputs", "inputs and expected must be at most 64 KB together")
+		return false
+	}
+
+	problem, err := h.problemStore.GetProblemByID(problemID)
+	if err != nil {
+		if errors.Is(err, models.ErrProblemNotFound) {
+			response.Error(w, http.StatusNotFound, "not_found", "Problem not found")
+			return false
+		}
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get problem")
+		return false
+	}
+
+	params := problem.Params()
+	if len(params) == 0 {
+		response.Error(w, http.StatusUnprocessableEntity, "unknown_parameters", "The parameters of this problem are not known")
+		return false
+	}
+	if !sameParams(params, req.Inputs) {
+		response.ValidationError(w, "inputs", fmt.Sprintf("inputs must have exactly the parameters %s", paramNames(params)))
+		return false
+	}
+	return true
+}
+
+// sameParams reports whether inputs has a value for each of params and
+// nothing else
+func sameParams(params []examples.Param, inputs models.TestCaseInputs) bool {
+	if len(inputs) != len(params) {
+		return false
+	}
+	for _, param := range params {
+		if _, ok := inputs[param.Name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func paramNames(params []examples.Param) string {
+	names := make([]string, 0, len(params))
+	for _, param := range params {
+		names = append(names, param.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func testCaseFromURL(w http.ResponseWriter, r *http.Request) (uuid.UUID, int, bool) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+		return uuid.Nil, 0, false
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "bad_request", "Invalid test case ID")
+		return uuid.Nil, 0, false
+	}
+	return userID, id, true
+}
+
+// writeTestCaseError answers an error from reading or changing a test case
+func writeTestCaseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrTestCaseNotFound):
+		response.Error(w, http.StatusNotFound, "not_found", "Test case not found")
+	case errors.Is(err, models.ErrTooManyTestCases):
+		response.Error(w, http.StatusUnprocessableEntity, "too_many_test_cases", fmt.Sprintf("A problem can have at most %d of your test cases", models.MaxTestCases))
+	default:
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to save test case")
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"fmt"
+	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+)
+
+type TopicHandler struct {
+	store *models.TopicStore
+}
+
+func NewTopicHandler(store *models.TopicStore) *TopicHandler {
+	return &TopicHandler{store: store}
+}
+
+// GetTopics lists every topic with its problem counts. Authenticated users also
+// get their solved count, due reviews and average stability per topic.
+func (h *TopicHandler) GetTopics(w http.ResponseWriter, r *http.Request) {
+	topics, err := h.store.ListTopics()
+	if err != nil {
+		fmt.Printf("ERROR: Failed to list topics: %v\n", err)
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get topics")
+		return
+	}
+
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		// anonymous request, catalog only
+		response.JSON(w, http.StatusOK, topics)
+		return
+	}
+
+	progress, err := h.store.GetUserTopicProgress(userID)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to get topic progress for user %s: %v\n", userID, err)
+		response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get topic progress")
+		return
+	}
+
+	for i := range topics {
+		p := progress[topics[i].Slug]
+		topics[i].UserProgress = &p
+	}
+
+	response.JSON(w, http.StatusOK, topics)
+}
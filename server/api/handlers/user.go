@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/internal/leetcode"
 	"go-leetcode/backend/models"
 	"go-leetcode/backend/pkg/response"
 	"net/http"
@@ -113,6 +114,13 @@ func (h *UserHandler) CompleteProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	region, err := leetcode.ParseRegion(user.LeetcodeRegion)
+	if err != nil {
+		response.ValidationError(w, "leetcode_region", "leetcode region must be 'com' or 'cn'")
+		return
+	}
+	user.LeetcodeRegion = string(region)
+
 	// check if uuid is already there
 
 	if exists, _ := h.store.CheckUserExistsByID(userID); exists {
@@ -144,4 +152,41 @@ func (h *UserHandler) CompleteProfile(w http.ResponseWriter, r *http.Request) {
 
 	// return success response + user profile
 	response.JSON(w, http.StatusOK, user)
-}
\ No newline at end of file
+}
+
+// UpdateLeetcodeRegion switches which LeetCode site (leetcode.com or leetcode.cn)
+// the authenticated user's submissions are synced from
+func (h *UserHandler) UpdateLeetcodeRegion(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "invalid_context", "Could not read user ID from the context")
+		return
+	}
+
+	var req struct {
+		LeetcodeRegion string `json:"leetcode_region"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if req.LeetcodeRegion == "" {
+		response.ValidationError(w, "leetcode_region", "leetcode region cannot be empty")
+		return
+	}
+
+	region, err := leetcode.ParseRegion(req.LeetcodeRegion)
+	if err != nil {
+		response.ValidationError(w, "leetcode_region", "leetcode region must be 'com' or 'cn'")
+		return
+	}
+
+	if err := h.store.UpdateLeetcodeRegion(userID, string(region)); err != nil {
+		response.Error(w, http.StatusNotFound, "not_found", "User profile not found")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"leetcode_region": string(region)})
+}
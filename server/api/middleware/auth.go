@@ -84,4 +84,19 @@ func AuthMiddleware() func(next http.Handler) http.Handler {
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
-}
\ No newline at end of file
+}
+// OptionalAuthMiddleware authenticates the request like AuthMiddleware when an
+// Authorization header is present and lets anonymous requests through untouched
+func OptionalAuthMiddleware() func(next http.Handler) http.Handler {
+	auth := AuthMiddleware()
+	return func(next http.Handler) http.Handler {
+		authenticated := auth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
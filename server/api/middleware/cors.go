@@ -14,7 +14,7 @@ func CorsMiddleware(next http.Handler) http.Handler {
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		
 		// Allow common headers
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Ingest-Timestamp, X-Ingest-Signature")
 		
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
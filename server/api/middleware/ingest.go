@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go-leetcode/backend/internal/cryptoutils"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	IngestTimestampHeader = "X-Ingest-Timestamp"
+	IngestSignatureHeader = "X-Ingest-Signature"
+
+	// maxIngestSkew bounds how old a signed request may be, limiting replays
+	maxIngestSkew = 5 * time.Minute
+	maxIngestBody = 64 << 10
+)
+
+// IngestTokenMiddleware authenticates requests carrying a per-user ingest
+// token instead of a Supabase JWT. The body must be signed with
+// HMAC-SHA256(signing secret, "<timestamp>.<body>"), using the secret issued
+// with the token. On success the token owner is put in the context under the
+// same key AuthMiddleware uses.
+func IngestTokenMiddleware(store *models.IngestTokenStore) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parts := strings.Split(r.Header.Get("Authorization"), " ")
+			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" || parts[1] == "" {
+				response.Error(w, http.StatusUnauthorized, "missing_token", "Authorization format must be 'Bearer {ingest_token}'")
+				return
+			}
+			rawToken := parts[1]
+
+			timestamp := r.Header.Get(IngestTimestampHeader)
+			unix, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				response.Error(w, http.StatusUnauthorized, "invalid_signature", fmt.Sprintf("%s must be a unix timestamp", IngestTimestampHeader))
+				return
+			}
+
+			skew := time.Since(time.Unix(unix, 0))
+			if skew > maxIngestSkew || skew < -maxIngestSkew {
+				response.Error(w, http.StatusUnauthorized, "stale_request", "Request timestamp is too far from the server time")
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIngestBody))
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "invalid_request", "Failed to read request body")
+				return
+			}
+
+			token, err := store.GetActiveTokenByHash(cryptoutils.HashToken(rawToken))
+			if err != nil {
+				response.Error(w, http.StatusUnauthorized, "invalid_token", "Invalid or revoked ingest token")
+				return
+			}
+
+			signingSecret, err := cryptoutils.DecryptString(token.EncryptedSigningSecret)
+			if err != nil {
+				fmt.Printf("ERROR: Failed to decrypt signing secret of ingest token %d: %v\n", token.ID, err)
+				response.Error(w, http.StatusInternalServerError, "server_error", "Failed to verify request signature")
+				return
+			}
+
+			if !cryptoutils.VerifyPayloadSignature(signingSecret, timestamp, body, r.Header.Get(IngestSignatureHeader)) {
+				response.Error(w, http.StatusUnauthorized, "invalid_signature", "Request signature does not match")
+				return
+			}
+
+			if err := store.TouchToken(token.ID); err != nil {
+				fmt.Printf("WARN: Failed to update last use of ingest token %d: %v\n", token.ID, err)
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			ctx := context.WithValue(r.Context(), UserUUIDKey, token.UserID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
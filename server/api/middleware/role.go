@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"go-leetcode/backend/models"
+	"go-leetcode/backend/pkg/response"
+	"net/http"
+)
+
+const UserRoleKey contextKey = "userRole"
+
+// RequireRole lets through users whose role grants at least required, see
+// models.RoleAtLeast, and puts their role in the context. It must run after
+// AuthMiddleware.
+func RequireRole(store *models.UserStore, required string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := GetUserUUIDFromContext(r.Context())
+			if err != nil {
+				response.Error(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
+				return
+			}
+
+			role, err := store.GetUserRole(userID)
+			if err != nil && !errors.Is(err, models.ErrUserNotFound) {
+				response.Error(w, http.StatusInternalServerError, "server_error", "Failed to get user role")
+				return
+			}
+
+			if !models.RoleAtLeast(role, required) {
+				response.Error(w, http.StatusForbidden, "forbidden", "This requires the "+required+" role")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserRoleKey, role)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-leetcode/backend/internal/authutils"
+	"go-leetcode/backend/internal/database"
+	"go-leetcode/backend/internal/testutils"
+	"go-leetcode/backend/internal/worker"
+	"go-leetcode/backend/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const testJwtSecret = "admin-routes-test-secret"
+
+func signedToken(t *testing.T, user *models.User) string {
+	t.Helper()
+	claims := authutils.CustomClaims{
+		UserID: user.ID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{"authenticated"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJwtSecret))
+	testutils.CheckErr(t, err, "Failed to sign token")
+	return token
+}
+
+func TestAdminRoutesRequireRole(t *testing.T) {
+	t.Setenv("SUPABASE_JWT_SECRET", testJwtSecret)
+	t.Setenv("GO_ENV", "test")
+
+	testDB := database.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	user := &models.User{Username: "testuser", LeetcodeUsername: "leetcode_testuser"}
+	err := models.NewUserStore(testDB.DB).CreateUser(user)
+	testutils.CheckErr(t, err, "Failed to create test user")
+	token := signedToken(t, user)
+
+	historyImportJob := worker.NewHistoryImportJob(
+		models.NewUserStore(testDB.DB),
+		models.NewLeetcodeSessionStore(testDB.DB),
+		models.NewSubmissionImportStore(testDB.DB),
+		models.NewSubmissionStore(testDB.DB),
+		models.NewReviewScheduleStore(testDB.DB),
+		zap.NewNop(),
+	)
+	router := SetupRoutes(testDB.DB, zap.NewNop(), historyImportJob)
+
+	request := func(method, path string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	editorRoutes := []struct{ method, path string }{
+		{http.MethodGet, "/api/admin/problems"},
+		{http.MethodGet, "/api/admin/problems/1"},
+		{http.MethodPatch, "/api/admin/problems/1"},
+		{http.MethodPut, "/api/admin/problems/1/topics"},
+		{http.MethodGet, "/api/admin/solution-proposals"},
+		{http.MethodPost, "/api/admin/solution-proposals/1/approve"},
+		{http.MethodGet, "/api/admin/approach-drafts"},
+		{http.MethodGet, "/api/admin/approach-generation/runs"},
+	}
+	adminRoutes := []struct{ method, path string }{
+		{http.MethodPost, "/api/admin/problems"},
+		{http.MethodPut, "/api/admin/problems/1/lifecycle"},
+		{http.MethodPut, "/api/admin/users/" + user.ID.String() + "/role"},
+		{http.MethodGet, "/api/admin/audit"},
+	}
+
+	for _, route := range append(editorRoutes, adminRoutes...) {
+		if code := request(route.method, route.path); code != http.StatusForbidden {
+			t.Errorf("%s %s as user: expected status %d, got %d", route.method, route.path, http.StatusForbidden, code)
+		}
+	}
+
+	_, err = testDB.DB.Exec(`UPDATE users SET role = $1 WHERE id = $2`, models.RoleEditor, user.ID)
+	testutils.CheckErr(t, err, "Failed to make the test user an editor")
+
+	if code := request(http.MethodGet, "/api/admin/problems"); code != http.StatusOK {
+		t.Errorf("GET /api/admin/problems as editor: expected status %d, got %d", http.StatusOK, code)
+	}
+	for _, route := range adminRoutes {
+		if code := request(route.method, route.path); code != http.StatusForbidden {
+			t.Errorf("%s %s as editor: expected status %d, got %d", route.method, route.path, http.StatusForbidden, code)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"go-leetcode/backend/api/handlers"
 	"go-leetcode/backend/api/middleware"
+	"go-leetcode/backend/internal/runner"
+	"go-leetcode/backend/internal/worker"
 	"go-leetcode/backend/models"
 
 	"github.com/go-chi/chi/v5"
@@ -11,7 +13,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func SetupRoutes(db *sql.DB, logger *zap.Logger) chi.Router {
+func SetupRoutes(db *sql.DB, logger *zap.Logger, historyImportJob *worker.HistoryImportJob) chi.Router {
 	router := chi.NewRouter()
 
 	router.Use(chimiddleware.RequestID)
@@ -31,14 +33,40 @@ func SetupRoutes(db *sql.DB, logger *zap.Logger) chi.Router {
 
 	userHandler := handlers.NewUserHandler(userStore)
 	reviewHandler := handlers.NewReviewHandler(reviewStore, submissionStore)
-	problemHandler := handlers.NewProblemHandler(problemStore)
+	testCaseStore := models.NewTestCaseStore(db)
+	problemHandler := handlers.NewProblemHandler(problemStore, testCaseStore)
 	problemStatusHandler := handlers.NewProblemStatusHandler(problemStore, submissionStore)
 	submissionHandler := handlers.NewSubmissionHandler(submissionStore)
 	solutionStore := models.NewSolutionStore(db)
 	solutionHandler := handlers.NewSolutionHandler(solutionStore)
 	authStatusHandler := handlers.NewAuthStatusHandler(userStore)
 	deckHandler := handlers.NewDeckHandler(deckStore, problemStore, flashcardStore)
-	flashcardHandler := handlers.NewFlashcardHandler(flashcardStore, problemStore, deckStore)
+	noteStore := models.NewNoteStore(db)
+	flashcardHandler := handlers.NewFlashcardHandler(flashcardStore, problemStore, deckStore, solutionStore, noteStore, testCaseStore)
+	leetcodeSessionStore := models.NewLeetcodeSessionStore(db)
+	submissionImportStore := models.NewSubmissionImportStore(db)
+	historyImportHandler := handlers.NewHistoryImportHandler(leetcodeSessionStore, submissionImportStore, historyImportJob)
+	dailyChallengeStore := models.NewDailyChallengeStore(db, deckStore)
+	dailyChallengeHandler := handlers.NewDailyChallengeHandler(dailyChallengeStore, userStore)
+	ingestTokenStore := models.NewIngestTokenStore(db)
+	solveImportHandler := handlers.NewSolveImportHandler(models.NewSolveImportStore(db))
+	topicHandler := handlers.NewTopicHandler(models.NewTopicStore(db))
+	ingestHandler := handlers.NewIngestHandler(ingestTokenStore, reviewStore)
+
+	// running code needs Linux namespaces, without them the run endpoints
+	// answer 503
+	codeRunner, err := runner.New(runner.ConfigFromEnv())
+	if err != nil {
+		logger.Warn("Code runner unavailable", zap.Error(err))
+		codeRunner = nil
+	} else if !codeRunner.Isolated() {
+		logger.Warn("Code runner running without namespaces or cgroups, solutions can reach the network and the server's files")
+	}
+	codeRunHandler := handlers.NewCodeRunHandler(codeRunner, models.NewCodeRunStore(db), problemStore, testCaseStore)
+	approachDraftHandler := handlers.NewApproachDraftHandler(models.NewApproachDraftStore(db))
+	adminHandler := handlers.NewAdminHandler(problemStore, userStore, models.NewAuditStore(db))
+	noteHandler := handlers.NewNoteHandler(noteStore)
+	testCaseHandler := handlers.NewTestCaseHandler(testCaseStore, problemStore)
 
 
 	router.Get("/health", handlers.HealthCheck)
@@ -49,10 +77,31 @@ func SetupRoutes(db *sql.DB, logger *zap.Logger) chi.Router {
 	})
 
 	router.Route("/api/problems", func(router chi.Router) {
-		router.Get("/by-id", problemHandler.GetProblemByID)
-		router.Get("/by-frontend-id", problemHandler.GetProblemByFrontendID)
-		router.Get("/by-slug", problemHandler.GetProblemBySlug)
+		// with the user's own test cases when a token is sent
+		router.Group(func(r chi.Router) {
+			r.Use(middleware.OptionalAuthMiddleware())
+
+			r.Get("/by-id", problemHandler.GetProblemByID)
+			r.Get("/by-frontend-id", problemHandler.GetProblemByFrontendID)
+			r.Get("/by-slug", problemHandler.GetProblemBySlug)
+		})
 		router.Get("/list", problemHandler.GetProblemList)
+		router.Get("/{slug}/related", problemHandler.GetRelatedProblems)
+	})
+
+	// public, with per-user progress when a token is sent
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.OptionalAuthMiddleware())
+
+		r.Get("/api/topics", topicHandler.GetTopics)
+		r.Get("/api/problems/random", problemHandler.GetRandomProblems)
+	})
+
+	// pushed by browser extensions and userscripts with a signed ingest token
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.IngestTokenMiddleware(ingestTokenStore))
+
+		r.Post("/api/ingest/submission", ingestHandler.IngestSubmission)
 	})
 
 	router.Group(func(r chi.Router) {
@@ -65,6 +114,7 @@ func SetupRoutes(db *sql.DB, logger *zap.Logger) chi.Router {
 
 		r.Get("/api/auth/status", authStatusHandler.GetUserAuthStatus)
 		r.Post("/api/users/profile", userHandler.CompleteProfile)
+		r.Put("/api/users/region", userHandler.UpdateLeetcodeRegion)
 
 		r.Route("/api/reviews", func(reviewsRouter chi.Router) {
 			reviewsRouter.Get("/", reviewHandler.GetReviews)
@@ -75,6 +125,37 @@ func SetupRoutes(db *sql.DB, logger *zap.Logger) chi.Router {
 		})
 
 		r.Get("/api/problems/with-status", problemStatusHandler.GetProblemsWithStatus)
+		r.Get("/api/problems/recommendations", problemHandler.GetRecommendations)
+
+		r.Route("/api/leetcode/session", func(sessionRouter chi.Router) {
+			sessionRouter.Put("/", historyImportHandler.SaveSession)
+			sessionRouter.Delete("/", historyImportHandler.DeleteSession)
+		})
+
+		r.Route("/api/ingest/tokens", func(tokenRouter chi.Router) {
+			tokenRouter.Get("/", ingestHandler.ListTokens)
+			tokenRouter.Post("/", ingestHandler.CreateToken)
+			tokenRouter.Delete("/{id}", ingestHandler.RevokeToken)
+		})
+
+		r.Route("/api/imports/history", func(importRouter chi.Router) {
+			importRouter.Post("/", historyImportHandler.StartImport)
+			importRouter.Get("/{id}", historyImportHandler.GetImport)
+		})
+
+		r.Post("/api/imports/file", solveImportHandler.ImportFile)
+
+		r.Route("/api/runs", func(runRouter chi.Router) {
+			runRouter.Get("/", codeRunHandler.GetCodeRuns)
+			runRouter.Post("/", codeRunHandler.CreateCodeRun)
+			runRouter.Get("/{id}", codeRunHandler.GetCodeRun)
+			runRouter.Post("/{id}/submit", codeRunHandler.SubmitCodeRun)
+		})
+
+		r.Route("/api/daily-challenge", func(dailyRouter chi.Router) {
+			dailyRouter.Get("/", dailyChallengeHandler.GetDailyChallenge)
+			dailyRouter.Put("/enrollment", dailyChallengeHandler.UpdateEnrollment)
+		})
 
 		r.Route("/api/decks", func(deckRouter chi.Router) {
 			deckRouter.Get("/", deckHandler.GetAllDecks)
@@ -90,8 +171,59 @@ func SetupRoutes(db *sql.DB, logger *zap.Logger) chi.Router {
 		r.Route("/api/flashcards", func(flashcardRouter chi.Router) {
 			flashcardRouter.Get("/reviews", flashcardHandler.GetFlashcardReviews)
 			flashcardRouter.Post("/reviews", flashcardHandler.SubmitFlashcardReview)
+			flashcardRouter.Post("/reviews/{id}/recall", flashcardHandler.RecallFlashcard)
+			flashcardRouter.Post("/reviews/{id}/predict", flashcardHandler.PredictTestCase)
+			flashcardRouter.Get("/reviews/{id}/hints", flashcardHandler.GetHints)
+			flashcardRouter.Post("/reviews/{id}/hints", flashcardHandler.RevealHint)
 			flashcardRouter.Post("/decks/{deck_id}", flashcardHandler.AddDeckToFlashcards)
 		})
+
+		r.Route("/api/notes", func(noteRouter chi.Router) {
+			noteRouter.Get("/", noteHandler.GetNotes)
+			noteRouter.Get("/{problem_id}", noteHandler.GetNote)
+			noteRouter.Put("/{problem_id}", noteHandler.SaveNote)
+			noteRouter.Delete("/{problem_id}", noteHandler.DeleteNote)
+			noteRouter.Get("/{problem_id}/revisions", noteHandler.GetRevisions)
+			noteRouter.Get("/{problem_id}/revisions/{revision}", noteHandler.GetRevision)
+			noteRouter.Post("/{problem_id}/revisions/{revision}/restore", noteHandler.RestoreRevision)
+			noteRouter.Get("/{problem_id}/diff", noteHandler.GetDiff)
+		})
+
+		r.Route("/api/test-cases", func(testCaseRouter chi.Router) {
+			testCaseRouter.Get("/", testCaseHandler.GetTestCases)
+			testCaseRouter.Post("/", testCaseHandler.CreateTestCase)
+			testCaseRouter.Get("/{id}", testCaseHandler.GetTestCase)
+			testCaseRouter.Put("/{id}", testCaseHandler.UpdateTestCase)
+			testCaseRouter.Delete("/{id}", testCaseHandler.DeleteTestCase)
+		})
+
+		// editors maintain problem content and reference solutions, admins
+		// create, hide and retire problems and grant roles
+		r.Route("/api/admin", func(adminRouter chi.Router) {
+			adminRouter.Group(func(editorRouter chi.Router) {
+				editorRouter.Use(middleware.RequireRole(userStore, models.RoleEditor))
+				editorRouter.Get("/problems", adminHandler.GetProblems)
+				editorRouter.Get("/problems/{id}", adminHandler.GetProblem)
+				editorRouter.Patch("/problems/{id}", adminHandler.UpdateProblem)
+				editorRouter.Put("/problems/{id}/topics", adminHandler.SetProblemTopics)
+				editorRouter.Get("/solution-proposals", solutionHandler.GetProposalQueue)
+				editorRouter.Post("/solution-proposals/{id}/approve", solutionHandler.ApproveProposal)
+				editorRouter.Post("/solution-proposals/{id}/reject", solutionHandler.RejectProposal)
+				editorRouter.Get("/approach-drafts", approachDraftHandler.GetDrafts)
+				editorRouter.Get("/approach-drafts/{id}", approachDraftHandler.GetDraft)
+				editorRouter.Post("/approach-drafts/{id}/approve", approachDraftHandler.ApproveDraft)
+				editorRouter.Post("/approach-drafts/{id}/reject", approachDraftHandler.RejectDraft)
+				editorRouter.Get("/approach-generation/runs", approachDraftHandler.GetRuns)
+			})
+
+			adminRouter.Group(func(adminOnlyRouter chi.Router) {
+				adminOnlyRouter.Use(middleware.RequireRole(userStore, models.RoleAdmin))
+				adminOnlyRouter.Post("/problems", adminHandler.CreateProblem)
+				adminOnlyRouter.Put("/problems/{id}/lifecycle", adminHandler.SetProblemLifecycle)
+				adminOnlyRouter.Put("/users/{id}/role", adminHandler.SetUserRole)
+				adminOnlyRouter.Get("/audit", adminHandler.GetAuditLog)
+			})
+		})
 	})
 
 	// LeetCode API proxy endpoint
@@ -99,11 +231,26 @@ func SetupRoutes(db *sql.DB, logger *zap.Logger) chi.Router {
 
 	router.Route("/api/solutions", func(router chi.Router) {
 		router.Get("/", solutionHandler.GetSolutions)
-		
-		// probably wanna put this on auth
-		// router.Post("/", solutionHandler.CreateSolution)
-		// router.Put("/", solutionHandler.UpdateSolution)
-		// router.Delete("/", solutionHandler.DeleteSolution)
+
+		router.Group(func(r chi.Router) {
+			r.Use(middleware.AuthMiddleware())
+
+			// anyone signed in can propose solutions, see /api/admin/solution-proposals
+			r.Get("/proposals", solutionHandler.GetMyProposals)
+			r.Post("/proposals", solutionHandler.CreateProposal)
+			r.Put("/proposals/{id}", solutionHandler.UpdateProposal)
+			r.Delete("/proposals/{id}", solutionHandler.DeleteProposal)
+
+			r.Group(func(editorRouter chi.Router) {
+				editorRouter.Use(middleware.RequireRole(userStore, models.RoleEditor))
+				editorRouter.Post("/", solutionHandler.CreateSolution)
+				editorRouter.Put("/", solutionHandler.UpdateSolution)
+				editorRouter.Delete("/", solutionHandler.DeleteSolution)
+				editorRouter.Post("/approaches", solutionHandler.CreateApproach)
+				editorRouter.Patch("/approaches/{id}", solutionHandler.UpdateApproach)
+				editorRouter.Delete("/approaches/{id}", solutionHandler.DeleteApproach)
+			})
+		})
 	})
 
 
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go-leetcode/backend/internal/approachgen"
+	"go-leetcode/backend/internal/database"
+	"go-leetcode/backend/internal/worker"
+	"go-leetcode/backend/models"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// runGenerateApproaches implements the generate-approaches subcommand, which
+// drafts approaches and hints for problems lacking explained approaches and
+// queues them for review in /api/admin/approach-drafts. Interrupting it
+// leaves the run to be continued with -resume:
+//
+//	go run . generate-approaches [-limit n] [-provider openai|stub] [-model name]
+//	go run . generate-approaches -resume <run id>|all
+func runGenerateApproaches(args []string) int {
+	cfg := approachgen.ConfigFromEnv()
+
+	flags := flag.NewFlagSet("generate-approaches", flag.ContinueOnError)
+	limitFlag := flags.Int("limit", 0, "number of problems to draft, 0 for all")
+	resumeFlag := flags.String("resume", "", "ID of an interrupted run to continue, or all")
+	flags.StringVar(&cfg.Provider, "provider", cfg.Provider, "openai or stub")
+	flags.StringVar(&cfg.Model, "model", cfg.Model, "model of the openai provider")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *limitFlag < 0 || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: generate-approaches [-limit n] [-provider openai|stub] [-model name] [-resume <run id>|all]")
+		return 2
+	}
+
+	provider, err := approachgen.NewProvider(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	db, err := database.NewConnection(dbConfigFromEnv())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+		return 1
+	}
+	defer logger.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	store := models.NewApproachDraftStore(db)
+	job := worker.NewApproachGenerationJob(store, provider, logger)
+
+	var runID int
+	switch *resumeFlag {
+	case "":
+		run, err := job.StartRun(ctx, *limitFlag)
+		runID = run.ID
+		if err != nil {
+			return reportGenerationError(runID, err)
+		}
+	case "all":
+		if err := job.ResumeUnfinished(ctx); err != nil {
+			return reportGenerationError(0, err)
+		}
+		fmt.Fprintln(os.Stderr, "resumed all unfinished runs")
+		return 0
+	default:
+		if _, err := fmt.Sscan(*resumeFlag, &runID); err != nil {
+			fmt.Fprintln(os.Stderr, "-resume takes a run ID or all")
+			return 2
+		}
+		if err := job.Run(ctx, runID); err != nil {
+			return reportGenerationError(runID, err)
+		}
+	}
+
+	run, err := store.GetRun(runID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "run %d %s: drafted %d, failed %d (prompt %s, model %s)\n",
+		run.ID, run.Status, run.Drafted, run.Failed, run.PromptVersion, run.Model)
+	return 0
+}
+
+func reportGenerationError(runID int, err error) int {
+	if runID != 0 {
+		fmt.Fprintf(os.Stderr, "Run %d stopped: %v\nContinue it with: generate-approaches -resume %d\n", runID, err, runID)
+	} else {
+		fmt.Fprintf(os.Stderr, "Generation stopped: %v\n", err)
+	}
+	return 1
+}
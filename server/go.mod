@@ -6,7 +6,7 @@ require go.uber.org/zap v1.27.0
 
 require github.com/open-spaced-repetition/go-fsrs/v3 v3.3.1
 
-require github.com/golang-jwt/jwt/v5 v5.2.2 // indirect
+require github.com/golang-jwt/jwt/v5 v5.2.2
 
 require (
 	github.com/go-chi/chi/v5 v5.2.1
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go-leetcode/backend/internal/database"
+	"go-leetcode/backend/internal/leetcode"
+	"go-leetcode/backend/models"
+	"os"
+	"time"
+)
+
+// runImportSnippets implements the import-snippets subcommand, which stores
+// LeetCode's starter code per language and the solution function metadata of
+// problems that have none yet, or of every problem with -all:
+//
+//	go run . import-snippets [-all] [-limit n] [-delay 2s]
+func runImportSnippets(args []string) int {
+	flags := flag.NewFlagSet("import-snippets", flag.ContinueOnError)
+	allFlag := flags.Bool("all", false, "import again for problems that already have snippets")
+	limitFlag := flags.Int("limit", 0, "number of problems to import, 0 for all")
+	delayFlag := flags.Duration("delay", 2*time.Second, "pause between requests to LeetCode")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *limitFlag < 0 || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: import-snippets [-all] [-limit n] [-delay 2s]")
+		return 2
+	}
+
+	db, err := database.NewConnection(dbConfigFromEnv())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	store := models.NewProblemStore(db)
+	// snippets and metadata are the same on both sites
+	client := leetcode.NewClient(leetcode.RegionCOM)
+
+	imported, failed, afterID := 0, 0, 0
+	for *limitFlag == 0 || imported+failed < *limitFlag {
+		batch := 100
+		if *limitFlag > 0 {
+			batch = min(batch, *limitFlag-imported-failed)
+		}
+
+		targets, err := store.ListProblemsForCodeImport(afterID, batch, *allFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		if len(targets) == 0 {
+			break
+		}
+
+		for _, target := range targets {
+			afterID = target.ID
+			if imported+failed > 0 {
+				time.Sleep(*delayFlag)
+			}
+
+			code, err := client.GetQuestionCode(target.TitleSlug)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", target.TitleSlug, err)
+				failed++
+				continue
+			}
+
+			snippets := make(models.CodeSnippets, 0, len(code.CodeSnippets))
+			for _, snippet := range code.CodeSnippets {
+				snippets = append(snippets, models.CodeSnippet{Lang: snippet.Lang, LangSlug: snippet.LangSlug, Code: snippet.Code})
+			}
+			if err := store.SetProblemCode(target.ID, snippets, code.MetaData); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", target.TitleSlug, err)
+				failed++
+				continue
+			}
+			imported++
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "imported snippets of %d problems, %d failed\n", imported, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
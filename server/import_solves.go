@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go-leetcode/backend/internal/database"
+	"go-leetcode/backend/internal/importfile"
+	"go-leetcode/backend/models"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// runImportSolves implements the import-solves subcommand, the command line
+// counterpart of POST /api/imports/file:
+//
+//	go run . import-solves -user <uuid> -file solves.csv [-format csv|json]
+func runImportSolves(args []string) int {
+	flags := flag.NewFlagSet("import-solves", flag.ContinueOnError)
+	userFlag := flags.String("user", "", "ID of the user to import solves for")
+	fileFlag := flags.String("file", "", "path to a JSON or CSV export of past solves")
+	formatFlag := flags.String("format", "", "csv or json, detected from the file when omitted")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	userID, err := uuid.Parse(*userFlag)
+	if err != nil || *fileFlag == "" {
+		fmt.Fprintln(os.Stderr, "usage: import-solves -user <uuid> -file <path> [-format csv|json]")
+		return 2
+	}
+
+	content, err := os.ReadFile(*fileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", *fileFlag, err)
+		return 1
+	}
+
+	format := *formatFlag
+	if format == "" {
+		format = importfile.DetectFormat(filepath.Base(*fileFlag), "", content)
+	}
+
+	records, invalid, err := importfile.Parse(bytes.NewReader(content), format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse %s: %v\n", *fileFlag, err)
+		return 1
+	}
+
+	db, err := database.NewConnection(dbConfigFromEnv())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	if _, err := models.NewUserStore(db).GetUserByID(userID); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	summary, err := models.NewSolveImportStore(db).ImportSolves(userID, records)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Import failed, no rows were imported: %v\n", err)
+		return 1
+	}
+	summary.AddInvalidRows(invalid)
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	if err := out.Encode(summary); err != nil {
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "imported %d, duplicates %d, invalid %d, reviews created %d, updated %d\n",
+		summary.Imported, summary.Duplicates, summary.Invalid, summary.ReviewsCreated, summary.ReviewsUpdated)
+	return 0
+}
@@ -3,6 +3,23 @@ import psycopg2
 import glob
 from dotenv import load_dotenv
 
+def primary_approach_id(cursor, problem_id):
+    """Return the problem's first approach, creating one when it has none yet.
+    Solution code belongs to an approach."""
+    cursor.execute(
+        'SELECT id FROM solution_approaches WHERE problem_id = %s ORDER BY "position", id LIMIT 1',
+        (problem_id,),
+    )
+    result = cursor.fetchone()
+    if result:
+        return result[0]
+
+    cursor.execute(
+        "INSERT INTO solution_approaches (problem_id, kind, title) VALUES (%s, 'optimal', 'Solution') RETURNING id",
+        (problem_id,),
+    )
+    return cursor.fetchone()[0]
+
 def insert_leetcode_solutions():
     print("Starting insert_leetcode_solutions function")
     load_dotenv()
@@ -61,9 +78,10 @@ def insert_leetcode_solutions():
 
                 # Insert the solution into the problem_solutions table
                 try:
+                    approach_id = primary_approach_id(cursor, problem_id)
                     cursor.execute(
-                        "INSERT INTO problem_solutions (problem_id, language, solution_code) VALUES (%s, %s, %s)",
-                        (problem_id, language, solution_code),
+                        "INSERT INTO problem_solutions (problem_id, approach_id, language, solution_code) VALUES (%s, %s, %s, %s)",
+                        (problem_id, approach_id, language, solution_code),
                     )
                     conn.commit()
                     print(f"Inserted solution for {title_slug} ({language})")
@@ -99,9 +117,10 @@ def insert_leetcode_solutions():
 
                 # Insert the solution into the problem_solutions table
                 try:
+                    approach_id = primary_approach_id(cursor, problem_id)
                     cursor.execute(
-                        "INSERT INTO problem_solutions (problem_id, language, solution_code) VALUES (%s, %s, %s)",
-                        (problem_id, language, solution_code),
+                        "INSERT INTO problem_solutions (problem_id, approach_id, language, solution_code) VALUES (%s, %s, %s, %s)",
+                        (problem_id, approach_id, language, solution_code),
                     )
                     conn.commit()
                     print(f"Inserted solution for {title_slug} ({language})")
@@ -0,0 +1,35 @@
+package approachgen
+
+import (
+	"context"
+	"go-leetcode/backend/models"
+)
+
+// Generate drafts the approaches of a problem. An answer that cannot be used
+// gives a failed draft recording why; only errors reaching the provider are
+// returned, so a run can stop and be resumed at the same problem.
+func Generate(ctx context.Context, provider Provider, input models.GenerationInput) (models.ApproachDraft, error) {
+	answer, err := provider.Complete(ctx, BuildPrompt(input))
+	if err != nil {
+		return models.ApproachDraft{}, err
+	}
+
+	draft := models.ApproachDraft{
+		ProblemID:     input.ProblemID,
+		TitleSlug:     input.TitleSlug,
+		PromptVersion: PromptVersion,
+		Model:         provider.Model(),
+	}
+
+	parsed, err := ParseDraft(answer)
+	if err != nil {
+		draft.Status = models.DraftFailed
+		draft.Error = err.Error()
+		return draft, nil
+	}
+
+	draft.Status = models.DraftPending
+	draft.Summary = parsed.Summary
+	draft.Approaches = parsed.Approaches
+	return draft, nil
+}
@@ -0,0 +1,105 @@
+package approachgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider talks to any API implementing OpenAI's chat completions,
+// such as OpenRouter
+type OpenAIProvider struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+func NewOpenAIProvider(baseURL, apiKey, model string) *OpenAIProvider {
+	return &OpenAIProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return ProviderOpenAI
+}
+
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model:       p.model,
+		Messages:    []chatMessage{{Role: "user", Content: prompt}},
+		MaxTokens:   4000,
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	var completion chatResponse
+	if err := json.Unmarshal(raw, &completion); err != nil {
+		return "", fmt.Errorf("%s responded with status %d and an unreadable body", p.baseURL, resp.StatusCode)
+	}
+	if completion.Error != nil {
+		return "", fmt.Errorf("%s responded with status %d: %s", p.baseURL, resp.StatusCode, completion.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s responded with status %d", p.baseURL, resp.StatusCode)
+	}
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("%s returned no completion", p.baseURL)
+	}
+
+	return completion.Choices[0].Message.Content, nil
+}
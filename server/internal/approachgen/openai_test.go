@@ -0,0 +1,50 @@
+package approachgen
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIProviderComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("expected /chat/completions, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("expected the API key, got %q", r.Header.Get("Authorization"))
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "test-model" {
+			t.Errorf("unexpected request %+v (%v)", req, err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "answer"}}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL+"/", "key", "test-model")
+	answer, err := provider.Complete(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if answer != "answer" {
+		t.Errorf("expected answer, got %q", answer)
+	}
+}
+
+func TestOpenAIProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"message": "rate limited"}}`))
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAIProvider(server.URL, "key", "m").Complete(context.Background(), "prompt")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
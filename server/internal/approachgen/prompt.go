@@ -0,0 +1,193 @@
+package approachgen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-leetcode/backend/models"
+	"sort"
+	"strings"
+)
+
+// PromptVersion identifies the prompt and the answer format drafts were
+// generated with. Bump it whenever BuildPrompt or ParseDraft change.
+const PromptVersion = "approaches-v1"
+
+// maxApproaches bounds the approaches kept from one answer
+const maxApproaches = 3
+
+// maxPromptContent and maxPromptCode bound the problem statement and the
+// reference code sent in a prompt, in bytes
+const (
+	maxPromptContent = 12 << 10
+	maxPromptCode    = 8 << 10
+)
+
+// preferredLanguages are the languages of reference code sent in a prompt,
+// most preferred first; only one solution is sent
+var preferredLanguages = []string{"python3", "python", "java", "cpp", "c++", "go", "javascript", "typescript"}
+
+// Draft is a parsed answer of the model
+type Draft struct {
+	Summary    string                 `json:"summary"`
+	Approaches []models.DraftApproach `json:"approaches"`
+}
+
+// BuildPrompt asks for the approaches of a problem as a JSON document
+func BuildPrompt(input models.GenerationInput) string {
+	var b strings.Builder
+
+	b.WriteString("You are an expert competitive programmer writing study notes for a LeetCode practice app.\n\n")
+	fmt.Fprintf(&b, "Title: %s\n", input.Title)
+	fmt.Fprintf(&b, "Difficulty: %s\n", input.Difficulty)
+	if len(input.Topics) > 0 {
+		fmt.Fprintf(&b, "Topics: %s\n", strings.Join(input.Topics, ", "))
+	}
+	fmt.Fprintf(&b, "\nPROBLEM:\n%s\n", truncate(strings.TrimSpace(input.Content), maxPromptContent))
+
+	if language, code := referenceCode(input.Code); code != "" {
+		fmt.Fprintf(&b, "\nREFERENCE SOLUTION (%s):\n%s\n", language, truncate(code, maxPromptCode))
+	}
+
+	fmt.Fprintf(&b, `
+Describe between 1 and %d ways of solving the problem, from the simplest to the most efficient.
+Answer with a single JSON object and nothing else, in this format:
+
+{
+  "summary": "one line naming the key technique of the best approach, e.g. Sliding window with a hash map of last positions",
+  "approaches": [
+    {
+      "kind": "brute_force" | "optimal" | "alternative",
+      "title": "short name of the approach",
+      "explanation": "Markdown explanation: a first paragraph with the key insight, then the algorithm step by step",
+      "time_complexity": "e.g. O(n log n)",
+      "space_complexity": "e.g. O(n)",
+      "hints": [
+        {"kind": "pattern", "text": "the general pattern, without details"},
+        {"kind": "insight", "text": "the key observation"},
+        {"kind": "algorithm", "text": "the algorithm in a few sentences"},
+        {"kind": "complexity", "text": "Time: ..., Space: ..."}
+      ]
+    }
+  ]
+}
+
+Exactly one approach must have kind "optimal". Hints must be in the order pattern, insight, algorithm, complexity,
+each giving away more than the one before. Do not include code.
+`, maxApproaches)
+
+	return b.String()
+}
+
+// referenceCode picks the reference solution sent in a prompt
+func referenceCode(code map[string]string) (string, string) {
+	for _, preferred := range preferredLanguages {
+		for language, c := range code {
+			if strings.EqualFold(language, preferred) && strings.TrimSpace(c) != "" {
+				return language, c
+			}
+		}
+	}
+
+	languages := make([]string, 0, len(code))
+	for language := range code {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	for _, language := range languages {
+		if strings.TrimSpace(code[language]) != "" {
+			return language, code[language]
+		}
+	}
+	return "", ""
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	// don't cut a UTF-8 sequence in half
+	for limit > 0 && limit < len(s) && s[limit]&0xC0 == 0x80 {
+		limit--
+	}
+	return s[:limit] + "\n[truncated]"
+}
+
+// ParseDraft reads the model's answer to BuildPrompt, tolerating a Markdown
+// code fence or text around the JSON object. Answers breaking the limits an
+// editor's approach must respect are rejected.
+func ParseDraft(answer string) (Draft, error) {
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return Draft{}, errors.New("answer has no JSON object")
+	}
+
+	var draft Draft
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &draft); err != nil {
+		return Draft{}, fmt.Errorf("answer is not valid JSON: %v", err)
+	}
+
+	draft.Summary = strings.TrimSpace(strings.SplitN(strings.TrimSpace(draft.Summary), "\n", 2)[0])
+	if len(draft.Summary) > 300 {
+		return Draft{}, errors.New("summary is longer than 300 characters")
+	}
+	if len(draft.Approaches) == 0 {
+		return Draft{}, errors.New("answer has no approaches")
+	}
+	if len(draft.Approaches) > maxApproaches {
+		draft.Approaches = draft.Approaches[:maxApproaches]
+	}
+
+	for i := range draft.Approaches {
+		if err := normalizeApproach(&draft.Approaches[i]); err != nil {
+			return Draft{}, fmt.Errorf("approach %d: %v", i+1, err)
+		}
+	}
+
+	return draft, nil
+}
+
+// normalizeApproach trims the fields of a generated approach and checks them
+// against the limits on approaches written by editors
+func normalizeApproach(approach *models.DraftApproach) error {
+	approach.Kind = strings.TrimSpace(approach.Kind)
+	approach.Title = strings.TrimSpace(approach.Title)
+	approach.Explanation = strings.TrimSpace(approach.Explanation)
+	approach.TimeComplexity = strings.TrimSpace(approach.TimeComplexity)
+	approach.SpaceComplexity = strings.TrimSpace(approach.SpaceComplexity)
+
+	switch {
+	case !models.IsValidApproachKind(approach.Kind):
+		return fmt.Errorf("unknown kind %q", approach.Kind)
+	case approach.Title == "" || len(approach.Title) > 200:
+		return errors.New("title must be between 1 and 200 characters")
+	case approach.Explanation == "":
+		return errors.New("explanation is empty")
+	case len(approach.TimeComplexity) > 100 || len(approach.SpaceComplexity) > 100:
+		return errors.New("complexities must be at most 100 characters")
+	}
+
+	hints := approach.Hints[:0]
+	for _, hint := range approach.Hints {
+		hint.Kind, hint.Text = strings.TrimSpace(hint.Kind), strings.TrimSpace(hint.Text)
+		if hint.Text == "" {
+			continue
+		}
+		if !models.IsValidHintKind(hint.Kind) {
+			return fmt.Errorf("unknown hint kind %q", hint.Kind)
+		}
+		if len(hint.Text) > 2000 {
+			return errors.New("hints must be at most 2000 characters")
+		}
+		hints = append(hints, hint)
+	}
+	if len(hints) > models.MaxHints {
+		return fmt.Errorf("more than %d hints", models.MaxHints)
+	}
+	if !models.HintsInOrder(hints) {
+		return errors.New("hints are not in reveal order")
+	}
+	approach.Hints = hints
+
+	return nil
+}
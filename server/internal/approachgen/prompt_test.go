@@ -0,0 +1,97 @@
+package approachgen
+
+import (
+	"context"
+	"go-leetcode/backend/models"
+	"strings"
+	"testing"
+)
+
+func TestParseDraft(t *testing.T) {
+	answer := "Here you go:\n```json\n" + `{
+		"summary": "Two pointers\nextra line",
+		"approaches": [{
+			"kind": "optimal",
+			"title": " Two pointers ",
+			"explanation": "Sort, then move inwards.",
+			"time_complexity": "O(n log n)",
+			"space_complexity": "O(1)",
+			"hints": [
+				{"kind": "pattern", "text": "Two pointers"},
+				{"kind": "insight", "text": ""},
+				{"kind": "complexity", "text": "Time: O(n log n)"}
+			]
+		}]
+	}` + "\n```"
+
+	draft, err := ParseDraft(answer)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if draft.Summary != "Two pointers" {
+		t.Errorf("expected the first summary line, got %q", draft.Summary)
+	}
+	if len(draft.Approaches) != 1 || draft.Approaches[0].Title != "Two pointers" {
+		t.Fatalf("expected one trimmed approach, got %+v", draft.Approaches)
+	}
+	if hints := draft.Approaches[0].Hints; len(hints) != 2 || hints[1].Kind != models.HintComplexity {
+		t.Errorf("expected empty hints to be dropped, got %+v", hints)
+	}
+}
+
+func TestParseDraftRejectsInvalidAnswers(t *testing.T) {
+	tests := map[string]string{
+		"no json":       "I cannot help with that.",
+		"bad json":      `{"summary": }`,
+		"no approaches": `{"summary": "x", "approaches": []}`,
+		"bad kind":      `{"approaches": [{"kind": "clever", "title": "t", "explanation": "e"}]}`,
+		"no title":      `{"approaches": [{"kind": "optimal", "title": " ", "explanation": "e"}]}`,
+		"hint order": `{"approaches": [{"kind": "optimal", "title": "t", "explanation": "e",
+			"hints": [{"kind": "algorithm", "text": "a"}, {"kind": "pattern", "text": "p"}]}]}`,
+	}
+
+	for name, answer := range tests {
+		if _, err := ParseDraft(answer); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestBuildPrompt(t *testing.T) {
+	prompt := BuildPrompt(models.GenerationInput{
+		Title:      "Two Sum",
+		Difficulty: "Easy",
+		Content:    "Find two numbers adding up to target.",
+		Topics:     []string{"Array", "Hash Table"},
+		Code:       map[string]string{"Java": "class Solution {}", "Python3": "class Solution: pass"},
+	})
+
+	for _, want := range []string{"Title: Two Sum", "Topics: Array, Hash Table", "REFERENCE SOLUTION (Python3)", "class Solution: pass"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected the prompt to contain %q", want)
+		}
+	}
+	if strings.Contains(prompt, "class Solution {}") {
+		t.Error("expected only one reference solution in the prompt")
+	}
+}
+
+func TestGenerateWithStub(t *testing.T) {
+	input := models.GenerationInput{ProblemID: 1, TitleSlug: "two-sum", Title: "Two Sum"}
+
+	draft, err := Generate(context.Background(), StubProvider{}, input)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if draft.Status != models.DraftPending || draft.PromptVersion != PromptVersion || draft.Model != "stub" {
+		t.Errorf("unexpected draft %+v", draft)
+	}
+	if len(draft.Approaches) != 1 || !strings.Contains(draft.Approaches[0].Explanation, "Two Sum") {
+		t.Errorf("expected the stub approach for Two Sum, got %+v", draft.Approaches)
+	}
+
+	again, _ := Generate(context.Background(), StubProvider{}, input)
+	if again.Approaches[0].Explanation != draft.Approaches[0].Explanation {
+		t.Error("expected the stub to be deterministic")
+	}
+}
@@ -0,0 +1,68 @@
+// Package approachgen drafts solution approaches and hints for problems with a
+// language model, for editors to review before they are published
+package approachgen
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Provider names accepted by NewProvider
+const (
+	ProviderOpenAI = "openai"
+	ProviderStub   = "stub"
+)
+
+// Provider completes a prompt with a language model
+type Provider interface {
+	Name() string
+	Model() string
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// Config selects and configures a provider
+type Config struct {
+	Provider string
+	BaseURL  string // of an OpenAI-compatible API
+	APIKey   string
+	Model    string
+}
+
+// ConfigFromEnv reads the provider configuration from APPROACH_GEN_*
+// variables. The defaults talk to DeepSeek through OpenRouter, with the
+// OPENROUTER_API_KEY the old Python script used.
+func ConfigFromEnv() Config {
+	apiKey := os.Getenv("APPROACH_GEN_API_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENROUTER_API_KEY")
+	}
+	return Config{
+		Provider: envOr("APPROACH_GEN_PROVIDER", ProviderOpenAI),
+		BaseURL:  envOr("APPROACH_GEN_BASE_URL", "https://openrouter.ai/api/v1"),
+		APIKey:   apiKey,
+		Model:    envOr("APPROACH_GEN_MODEL", "deepseek/deepseek-chat-v3-0324"),
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// NewProvider returns the provider named in cfg
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case ProviderOpenAI:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("APPROACH_GEN_API_KEY or OPENROUTER_API_KEY is required for the %s provider", ProviderOpenAI)
+		}
+		return NewOpenAIProvider(cfg.BaseURL, cfg.APIKey, cfg.Model), nil
+	case ProviderStub:
+		return StubProvider{}, nil
+	default:
+		return nil, fmt.Errorf("unknown approach generation provider %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,56 @@
+package approachgen
+
+import (
+	"context"
+	"encoding/json"
+	"go-leetcode/backend/models"
+	"strings"
+)
+
+// StubProvider answers every prompt with the same approach, naming the
+// problem from the prompt's title line. It needs no network access, for
+// tests and for trying the review flow locally.
+type StubProvider struct{}
+
+func (StubProvider) Name() string {
+	return ProviderStub
+}
+
+func (StubProvider) Model() string {
+	return "stub"
+}
+
+func (StubProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	title := "the problem"
+	for _, line := range strings.Split(prompt, "\n") {
+		if t, ok := strings.CutPrefix(line, "Title: "); ok {
+			title = strings.TrimSpace(t)
+			break
+		}
+	}
+
+	answer, err := json.Marshal(Draft{
+		Summary: "Single pass with a hash map",
+		Approaches: []models.DraftApproach{{
+			Kind:            models.ApproachOptimal,
+			Title:           "Hash map",
+			Explanation:     "Solve " + title + " in one pass, remembering what was seen.\n\nWalk the input once and look up each complement in the map before storing the current element.",
+			TimeComplexity:  "O(n)",
+			SpaceComplexity: "O(n)",
+			Hints: []models.Hint{
+				{Kind: models.HintPattern, Text: "Hash map lookups"},
+				{Kind: models.HintInsight, Text: "Remember what was seen so far"},
+				{Kind: models.HintAlgorithm, Text: "One pass, checking the map before inserting"},
+				{Kind: models.HintComplexity, Text: "Time: O(n), Space: O(n)"},
+			},
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return "```json\n" + string(answer) + "\n```", nil
+}
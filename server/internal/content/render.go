@@ -0,0 +1,471 @@
+// Package content renders LeetCode problem statements, which arrive as raw
+// HTML, into sanitized HTML, Markdown and a short plain text summary for
+// clients that cannot display the original markup.
+package content
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	FormatRaw      = "raw"
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+	FormatSummary  = "summary"
+
+	// Version changes whenever the output of Render changes, so cached renders
+	// made by an older version are redone
+	Version = 2
+
+	// SummaryLength is the longest summary in runes, ellipsis included
+	SummaryLength = 280
+)
+
+func IsValidFormat(format string) bool {
+	switch format {
+	case FormatRaw, FormatHTML, FormatMarkdown, FormatSummary:
+		return true
+	}
+	return false
+}
+
+// Rendered holds every derived format of one problem statement
+type Rendered struct {
+	HTML     string
+	Markdown string
+	Summary  string
+}
+
+func Render(src string) Rendered {
+	tokens := tokenize(src)
+	return Rendered{
+		HTML:     sanitize(tokens),
+		Markdown: markdown(tokens),
+		Summary:  summary(tokens),
+	}
+}
+
+type tokenKind int
+
+const (
+	textToken tokenKind = iota
+	startTagToken
+	endTagToken
+)
+
+type token struct {
+	kind  tokenKind
+	name  string // lower case tag name
+	attrs map[string]string
+	text  string // unescaped text
+}
+
+var (
+	tagPattern  = regexp.MustCompile(`(?s)<!--.*?-->|<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:\s+[^\s/>"'=]+(?:\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'>]+))?)*)\s*/?>`)
+	attrPattern = regexp.MustCompile(`([^\s/>"'=]+)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
+	spaceRun    = regexp.MustCompile(`\s+`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+)
+
+// tokenize splits HTML into text and tags. Statements are machine generated
+// and simple, so a tag pattern is enough; anything that does not look like a
+// tag is kept as text.
+func tokenize(src string) []token {
+	var tokens []token
+	last := 0
+
+	for _, m := range tagPattern.FindAllStringSubmatchIndex(src, -1) {
+		if m[0] > last {
+			tokens = append(tokens, token{kind: textToken, text: html.UnescapeString(src[last:m[0]])})
+		}
+		last = m[1]
+
+		if m[4] < 0 {
+			continue // comment
+		}
+
+		t := token{kind: startTagToken, name: strings.ToLower(src[m[4]:m[5]])}
+		if m[3] > m[2] {
+			t.kind = endTagToken
+		} else if m[6] < m[7] {
+			t.attrs = parseAttrs(src[m[6]:m[7]])
+		}
+		tokens = append(tokens, t)
+	}
+
+	if last < len(src) {
+		tokens = append(tokens, token{kind: textToken, text: html.UnescapeString(src[last:])})
+	}
+	return tokens
+}
+
+func parseAttrs(src string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range attrPattern.FindAllStringSubmatch(src, -1) {
+		value := m[2]
+		if value == "" {
+			value = m[3]
+		}
+		if value == "" {
+			value = m[4]
+		}
+		attrs[strings.ToLower(m[1])] = html.UnescapeString(value)
+	}
+	return attrs
+}
+
+// droppedTags are removed along with everything inside them
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true,
+	"embed": true, "noscript": true, "textarea": true, "title": true,
+}
+
+// allowedTags maps the tags kept by sanitize to the attributes they keep
+var allowedTags = map[string][]string{
+	"p": nil, "div": nil, "span": nil, "br": nil, "blockquote": nil,
+	"strong": nil, "b": nil, "em": nil, "i": nil, "u": nil,
+	"code": nil, "pre": nil, "sup": nil, "sub": nil,
+	"ul": nil, "ol": nil, "li": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil,
+	"th": {"colspan", "rowspan"}, "td": {"colspan", "rowspan"},
+	"a":   {"href"},
+	"img": {"src", "alt", "width", "height"},
+}
+
+var voidTags = map[string]bool{"br": true, "img": true}
+
+var urlAttrs = map[string]bool{"href": true, "src": true}
+
+// safeURL accepts absolute http(s) URLs and site relative paths
+func safeURL(raw string) bool {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "/") && !strings.HasPrefix(raw, "//") {
+		return true
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// sanitize rebuilds the markup from allowed tags and attributes only,
+// dropping scripts, styles and event handlers and balancing the tags
+func sanitize(tokens []token) string {
+	var b strings.Builder
+	var open []string
+	dropping := ""
+
+	for _, t := range tokens {
+		if dropping != "" {
+			if t.kind == endTagToken && t.name == dropping {
+				dropping = ""
+			}
+			continue
+		}
+
+		switch t.kind {
+		case textToken:
+			b.WriteString(html.EscapeString(t.text))
+		case startTagToken:
+			if droppedTags[t.name] {
+				dropping = t.name
+				continue
+			}
+			allowed, ok := allowedTags[t.name]
+			if !ok {
+				continue
+			}
+			if t.name == "img" && !safeURL(t.attrs["src"]) {
+				continue
+			}
+
+			b.WriteString("<" + t.name)
+			for _, name := range allowed {
+				value, ok := t.attrs[name]
+				if !ok || (urlAttrs[name] && !safeURL(value)) {
+					continue
+				}
+				b.WriteString(" " + name + `="` + html.EscapeString(value) + `"`)
+			}
+			if t.name == "a" {
+				b.WriteString(` rel="nofollow noopener noreferrer"`)
+			}
+			b.WriteString(">")
+
+			if !voidTags[t.name] {
+				open = append(open, t.name)
+			}
+		case endTagToken:
+			// close up to the matching open tag, ignoring strays
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != t.name {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+type markdownList struct {
+	ordered bool
+	n       int
+}
+
+// markdownEscaper escapes the characters Markdown renderers pass through as
+// HTML. Code spans and blocks show text literally, so they are left alone.
+var markdownEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// markdown converts the statement to CommonMark. Examples in <pre> become
+// fenced code blocks and superscripts, common in constraints, become "^".
+func markdown(tokens []token) string {
+	var b strings.Builder
+	var lists []markdownList
+	var links []string
+	inPre, preStart := false, false
+	inCode := 0
+	dropping := ""
+
+	// block starts a new paragraph unless one was just started
+	block := func() {
+		out := strings.TrimRight(b.String(), " ")
+		b.Reset()
+		b.WriteString(out)
+		if out != "" && !strings.HasSuffix(out, "\n\n") {
+			if strings.HasSuffix(out, "\n") {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+	}
+	newline := func() {
+		out := strings.TrimRight(b.String(), " ")
+		b.Reset()
+		b.WriteString(out)
+		if out != "" && !strings.HasSuffix(out, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	atLineStart := func() bool {
+		out := b.String()
+		return out == "" || strings.HasSuffix(out, "\n")
+	}
+
+	for _, t := range tokens {
+		if dropping != "" {
+			if t.kind == endTagToken && t.name == dropping {
+				dropping = ""
+			}
+			continue
+		}
+
+		if t.kind == textToken {
+			if inPre {
+				text := t.text
+				if preStart {
+					// like browsers, ignore the newline right after <pre>
+					text = strings.TrimPrefix(text, "\n")
+					preStart = false
+				}
+				b.WriteString(text)
+				continue
+			}
+			text := spaceRun.ReplaceAllString(strings.ReplaceAll(t.text, "\u00a0", " "), " ")
+			if atLineStart() {
+				text = strings.TrimLeft(text, " ")
+			}
+			if inCode == 0 {
+				// decoded entities must not turn back into markup
+				text = markdownEscaper.Replace(text)
+			}
+			b.WriteString(text)
+			continue
+		}
+
+		start := t.kind == startTagToken
+		if start && droppedTags[t.name] {
+			dropping = t.name
+			continue
+		}
+		preStart = false
+
+		switch t.name {
+		case "p", "div", "blockquote", "table", "tr":
+			if len(lists) == 0 {
+				block()
+			} else {
+				newline()
+			}
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			block()
+			if start {
+				b.WriteString(strings.Repeat("#", int(t.name[1]-'0')) + " ")
+			}
+		case "br":
+			if inPre {
+				b.WriteString("\n")
+			} else {
+				newline()
+			}
+		case "strong", "b":
+			if !inPre {
+				b.WriteString("**")
+			}
+		case "em", "i":
+			if !inPre {
+				b.WriteString("*")
+			}
+		case "code":
+			if !inPre {
+				b.WriteString("`")
+				if start {
+					inCode++
+				} else if inCode > 0 {
+					inCode--
+				}
+			}
+		case "sup":
+			if start && !inPre {
+				b.WriteString("^")
+			}
+		case "pre":
+			if start {
+				block()
+				b.WriteString("```\n")
+				inPre, preStart = true, true
+			} else {
+				inPre = false
+				newline()
+				b.WriteString("```")
+				block()
+			}
+		case "ul", "ol":
+			if start {
+				if len(lists) == 0 {
+					block()
+				} else {
+					newline()
+				}
+				lists = append(lists, markdownList{ordered: t.name == "ol"})
+			} else if len(lists) > 0 {
+				lists = lists[:len(lists)-1]
+				if len(lists) == 0 {
+					block()
+				}
+			}
+		case "li":
+			if start && len(lists) > 0 {
+				newline()
+				list := &lists[len(lists)-1]
+				b.WriteString(strings.Repeat("  ", len(lists)-1))
+				if list.ordered {
+					list.n++
+					b.WriteString(strconv.Itoa(list.n) + ". ")
+				} else {
+					b.WriteString("- ")
+				}
+			}
+		case "td", "th":
+			if !start {
+				b.WriteString(" ")
+			}
+		case "a":
+			if start {
+				href := t.attrs["href"]
+				if !safeURL(href) {
+					href = ""
+				}
+				links = append(links, href)
+				if href != "" {
+					b.WriteString("[")
+				}
+			} else if len(links) > 0 {
+				href := links[len(links)-1]
+				links = links[:len(links)-1]
+				if href != "" {
+					b.WriteString("](" + href + ")")
+				}
+			}
+		case "img":
+			if src := t.attrs["src"]; safeURL(src) {
+				b.WriteString("![" + markdownEscaper.Replace(t.attrs["alt"]) + "](" + src + ")")
+			}
+		}
+	}
+
+	out := blankLines.ReplaceAllString(b.String(), "\n\n")
+	return strings.TrimSpace(out)
+}
+
+// blockTags end the first paragraph taken as the summary
+var blockTags = map[string]bool{
+	"p": true, "div": true, "pre": true, "ul": true, "ol": true, "li": true,
+	"table": true, "blockquote": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true,
+}
+
+// summary is the plain text of the first block with any text in it,
+// shortened at a word boundary to SummaryLength runes
+func summary(tokens []token) string {
+	var b strings.Builder
+	dropping := ""
+
+	for _, t := range tokens {
+		if dropping != "" {
+			if t.kind == endTagToken && t.name == dropping {
+				dropping = ""
+			}
+			continue
+		}
+
+		switch {
+		case t.kind == textToken:
+			b.WriteString(strings.ReplaceAll(t.text, "\u00a0", " "))
+		case t.kind == startTagToken && droppedTags[t.name]:
+			dropping = t.name
+		case t.name == "sup" && t.kind == startTagToken:
+			b.WriteString("^")
+		case t.name == "br":
+			b.WriteString(" ")
+		case blockTags[t.name]:
+			if strings.TrimSpace(b.String()) != "" {
+				return truncate(collapse(b.String()), SummaryLength)
+			}
+			b.Reset()
+		}
+	}
+
+	return truncate(collapse(b.String()), SummaryLength)
+}
+
+func collapse(text string) string {
+	return strings.TrimSpace(spaceRun.ReplaceAllString(text, " "))
+}
+
+func truncate(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	runes := []rune(text)[:limit-1]
+	cut := string(runes)
+	if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
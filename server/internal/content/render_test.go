@@ -0,0 +1,105 @@
+package content
+
+import (
+	"strings"
+	"testing"
+)
+
+const twoSum = `<p>Given an array of integers <code>nums</code>&nbsp;and an integer <code>target</code>, return <em>indices of the two numbers such that they add up to <code>target</code></em>.</p>
+
+<p>&nbsp;</p>
+<p><strong class="example">Example 1:</strong></p>
+
+<pre>
+<strong>Input:</strong> nums = [2,7,11,15], target = 9
+<strong>Output:</strong> [0,1]
+</pre>
+
+<ul>
+	<li><code>2 &lt;= nums.length &lt;= 10<sup>4</sup></code></li>
+	<li>Only one valid answer exists.</li>
+</ul>
+<img alt="graph" src="https://assets.leetcode.com/uploads/graph.png" onerror="alert(1)" style="width: 300px;" />
+<script>alert(1)</script><a href="javascript:alert(1)" onclick="x()">link</a>`
+
+func TestSanitize(t *testing.T) {
+	got := Render(twoSum).HTML
+
+	for _, banned := range []string{"<script", "alert(1)", "onerror", "onclick", "style=", "class=", "javascript:"} {
+		if strings.Contains(got, banned) {
+			t.Errorf("sanitized HTML contains %q:\n%s", banned, got)
+		}
+	}
+
+	for _, kept := range []string{
+		"<code>nums</code>",
+		"10<sup>4</sup>",
+		`<img src="https://assets.leetcode.com/uploads/graph.png" alt="graph">`,
+		"2 &lt;= nums.length",
+		`<a rel="nofollow noopener noreferrer">link</a>`,
+	} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("sanitized HTML is missing %q:\n%s", kept, got)
+		}
+	}
+}
+
+func TestSanitizeBalancesTags(t *testing.T) {
+	got := Render(`<p><strong>bold <em>both</p> tail</div>`).HTML
+	if want := "<p><strong>bold <em>both</em></strong></p> tail"; got != want {
+		t.Errorf("sanitize() = %q, want %q", got, want)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	got := Render(twoSum).Markdown
+
+	want := "Given an array of integers `nums` and an integer `target`, return *indices of the two numbers such that they add up to `target`*.\n\n" +
+		"**Example 1:**\n\n" +
+		"```\nInput: nums = [2,7,11,15], target = 9\nOutput: [0,1]\n```\n\n" +
+		"- `2 <= nums.length <= 10^4`\n" +
+		"- Only one valid answer exists.\n\n" +
+		"![graph](https://assets.leetcode.com/uploads/graph.png) link"
+	if got != want {
+		t.Errorf("markdown() =\n%s\n\nwant\n%s", got, want)
+	}
+}
+
+func TestMarkdownOrderedNestedLists(t *testing.T) {
+	got := Render(`<ol><li>one<ul><li>inner</li></ul></li><li>two</li></ol>`).Markdown
+	if want := "1. one\n  - inner\n2. two"; got != want {
+		t.Errorf("markdown() = %q, want %q", got, want)
+	}
+}
+
+func TestMarkdownEscapesHTML(t *testing.T) {
+	got := Render(`<p>Return &lt;script&gt;alert(1)&lt;/script&gt; &amp; <code>a &lt; b</code></p>`).Markdown
+	if want := "Return &lt;script&gt;alert(1)&lt;/script&gt; &amp; `a < b`"; got != want {
+		t.Errorf("markdown() = %q, want %q", got, want)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	got := Render(twoSum).Summary
+	want := "Given an array of integers nums and an integer target, return indices of the two numbers such that they add up to target."
+	if got != want {
+		t.Errorf("summary() = %q, want %q", got, want)
+	}
+
+	long := "<p>" + strings.Repeat("word ", 100) + "</p>"
+	got = Render(long).Summary
+	if n := len([]rune(got)); n > SummaryLength || !strings.HasSuffix(got, "word…") {
+		t.Errorf("summary() of a long paragraph = %q (%d runes)", got, n)
+	}
+}
+
+func TestIsValidFormat(t *testing.T) {
+	for _, format := range []string{FormatRaw, FormatHTML, FormatMarkdown, FormatSummary} {
+		if !IsValidFormat(format) {
+			t.Errorf("IsValidFormat(%q) = false", format)
+		}
+	}
+	if IsValidFormat("pdf") {
+		t.Error(`IsValidFormat("pdf") = true`)
+	}
+}
@@ -0,0 +1,87 @@
+package cryptoutils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// getEncryptionKey derives a 256-bit AES key from SECRETS_ENCRYPTION_KEY so
+// any sufficiently random string can be used as the configured secret
+func getEncryptionKey() ([]byte, error) {
+	secret := os.Getenv("SECRETS_ENCRYPTION_KEY")
+	if secret == "" {
+		return nil, errors.New("SECRETS_ENCRYPTION_KEY environment variable not set or empty")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	return key[:], nil
+}
+
+// EncryptString seals plaintext with AES-GCM and returns it base64 encoded,
+// with the random nonce prepended
+func EncryptString(plaintext string) (string, error) {
+	key, err := getEncryptionKey()
+	if err != nil {
+		return "", fmt.Errorf("config_error: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString reverses EncryptString
+func DecryptString(encoded string) (string, error) {
+	key, err := getEncryptionKey()
+	if err != nil {
+		return "", fmt.Errorf("config_error: %w", err)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid ciphertext encoding: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decryption failed: %w", err)
+	}
+
+	return string(plaintext), nil
+}
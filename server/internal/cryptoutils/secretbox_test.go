@@ -0,0 +1,49 @@
+package cryptoutils
+
+import (
+	"testing"
+)
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "test-secret")
+
+	encrypted, err := EncryptString("my-session-cookie")
+	if err != nil {
+		t.Fatalf("expected no error encrypting, got %v", err)
+	}
+
+	if encrypted == "my-session-cookie" {
+		t.Error("expected ciphertext to differ from plaintext")
+	}
+
+	decrypted, err := DecryptString(encrypted)
+	if err != nil {
+		t.Fatalf("expected no error decrypting, got %v", err)
+	}
+
+	if decrypted != "my-session-cookie" {
+		t.Errorf("expected %q, got %q", "my-session-cookie", decrypted)
+	}
+}
+
+func TestDecryptWithWrongKey(t *testing.T) {
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "test-secret")
+
+	encrypted, err := EncryptString("my-session-cookie")
+	if err != nil {
+		t.Fatalf("expected no error encrypting, got %v", err)
+	}
+
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "another-secret")
+	if _, err := DecryptString(encrypted); err == nil {
+		t.Error("expected decrypting with a different key to fail")
+	}
+}
+
+func TestEncryptWithoutKey(t *testing.T) {
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "")
+
+	if _, err := EncryptString("my-session-cookie"); err == nil {
+		t.Error("expected error when SECRETS_ENCRYPTION_KEY is not set")
+	}
+}
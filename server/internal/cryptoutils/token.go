@@ -0,0 +1,42 @@
+package cryptoutils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// GenerateToken returns a random 256-bit token, hex encoded behind the given prefix
+func GenerateToken(prefix string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating token: %v", err)
+	}
+
+	return prefix + hex.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex sha256 of a token. Tokens are high entropy, so a
+// plain digest is enough to store them without keeping the token itself.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignPayload computes the "sha256=<hex>" HMAC of "<timestamp>.<body>" keyed with secret
+func SignPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyPayloadSignature checks a signature produced by SignPayload in constant time
+func VerifyPayloadSignature(secret, timestamp string, body []byte, signature string) bool {
+	expected := SignPayload(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature)))
+}
@@ -0,0 +1,51 @@
+package cryptoutils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateToken(t *testing.T) {
+	first, err := GenerateToken("lci_")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.HasPrefix(first, "lci_") || len(first) != len("lci_")+64 {
+		t.Errorf("unexpected token format: %q", first)
+	}
+
+	second, _ := GenerateToken("lci_")
+	if first == second {
+		t.Error("expected two generated tokens to differ")
+	}
+
+	if HashToken(first) != HashToken(first) || HashToken(first) == HashToken(second) {
+		t.Error("expected token hashes to be deterministic and distinct")
+	}
+}
+
+func TestVerifyPayloadSignature(t *testing.T) {
+	body := []byte(`{"leetcode_submission_id":"123"}`)
+	signature := SignPayload("lci_secret", "1700000000", body)
+
+	if !strings.HasPrefix(signature, "sha256=") {
+		t.Errorf("expected sha256= prefix, got %q", signature)
+	}
+
+	if !VerifyPayloadSignature("lci_secret", "1700000000", body, signature) {
+		t.Error("expected signature to verify")
+	}
+
+	if VerifyPayloadSignature("lci_secret", "1700000001", body, signature) {
+		t.Error("expected signature with a different timestamp to fail")
+	}
+
+	if VerifyPayloadSignature("lci_other", "1700000000", body, signature) {
+		t.Error("expected signature with a different secret to fail")
+	}
+
+	if VerifyPayloadSignature("lci_secret", "1700000000", []byte(`{}`), signature) {
+		t.Error("expected signature over a different body to fail")
+	}
+}
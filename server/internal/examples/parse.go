@@ -0,0 +1,280 @@
+// Package examples turns a problem's example_testcases, the newline joined
+// argument values LeetCode prefills its editor with, into structured examples
+// by pairing them with the parameter names and the "Output:" values of the
+// examples in the problem statement.
+package examples
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Version changes whenever Parse output changes, so stored examples parsed by
+// an older version are parsed again
+const Version = 1
+
+// Param is one argument of the solution function
+type Param struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Signature describes the solution function when problem metadata is known
+type Signature struct {
+	Params     []Param
+	ReturnType string
+}
+
+type Example struct {
+	Inputs      map[string]json.RawMessage `json:"inputs"`
+	Params      []Param                    `json:"params"` // inputs in call order
+	Expected    json.RawMessage            `json:"expected,omitempty"`
+	Explanation string                     `json:"explanation,omitempty"`
+}
+
+var (
+	blockTag       = regexp.MustCompile(`(?i)<(br|/p|/div|/pre|/li|p|div|pre)\b[^>]*>`)
+	tagPattern     = regexp.MustCompile(`<[^>]*>`)
+	spaceRun       = regexp.MustCompile(`[ \t\x{a0}]+`)
+	sectionPattern = regexp.MustCompile(`(?m)^ ?(Input|Output|Explanation|Example \d+|Constraints|Note|Follow[- ]up) ?:`)
+)
+
+// statementExample is an example as written in the problem statement
+type statementExample struct {
+	input       string
+	output      string
+	explanation string
+}
+
+// Parse builds the examples. Parameter names come from sig when given,
+// otherwise from the "name = value" pairs of the first "Input:" line; types
+// come from sig or are inferred from the values. Examples without a matching
+// "Output:" have no expected value.
+func Parse(testcases, content string, sig *Signature) ([]Example, error) {
+	lines := splitLines(testcases)
+	if len(lines) == 0 {
+		return nil, errors.New("no example test cases")
+	}
+
+	described := statementExamples(content)
+
+	var names []string
+	if sig != nil && len(sig.Params) > 0 {
+		for _, p := range sig.Params {
+			names = append(names, p.Name)
+		}
+	} else {
+		for _, ex := range described {
+			if names = inputNames(ex.input); len(names) > 0 {
+				break
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		if len(described) == 0 || len(lines)%len(described) != 0 {
+			return nil, errors.New("cannot tell how many arguments each example has")
+		}
+		for i := 0; i < len(lines)/len(described); i++ {
+			names = append(names, fmt.Sprintf("arg%d", i+1))
+		}
+	}
+
+	if len(lines)%len(names) != 0 {
+		return nil, fmt.Errorf("%d test case lines do not split into examples of %d arguments", len(lines), len(names))
+	}
+
+	var examples []Example
+	for start := 0; start < len(lines); start += len(names) {
+		ex := Example{Inputs: make(map[string]json.RawMessage, len(names))}
+
+		for i, name := range names {
+			value := jsonValue(lines[start+i])
+			ex.Inputs[name] = value
+
+			param := Param{Name: name}
+			if sig != nil && i < len(sig.Params) {
+				param.Type = sig.Params[i].Type
+			} else {
+				param.Type = inferType(value)
+			}
+			ex.Params = append(ex.Params, param)
+		}
+
+		if n := len(examples); n < len(described) {
+			if output := described[n].output; output != "" {
+				ex.Expected = jsonValue(output)
+			}
+			ex.Explanation = described[n].explanation
+		}
+
+		examples = append(examples, ex)
+	}
+
+	return examples, nil
+}
+
+func splitLines(testcases string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(testcases, "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// statementExamples reads the Input/Output/Explanation sections of the
+// statement, in both the older <pre> layout and the newer example blocks
+func statementExamples(content string) []statementExample {
+	// block level tags become line breaks so every section starts a line
+	text := blockTag.ReplaceAllString(content, "\n")
+	text = html.UnescapeString(tagPattern.ReplaceAllString(text, ""))
+	text = spaceRun.ReplaceAllString(text, " ")
+
+	matches := sectionPattern.FindAllStringSubmatchIndex(text, -1)
+
+	var examples []statementExample
+	var current *statementExample
+	for i, m := range matches {
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		body := strings.TrimSpace(text[m[1]:end])
+
+		switch section := text[m[2]:m[3]]; section {
+		case "Input":
+			examples = append(examples, statementExample{input: firstLine(body)})
+			current = &examples[len(examples)-1]
+		case "Output":
+			if current != nil && current.output == "" {
+				current.output = firstLine(body)
+			}
+		case "Explanation":
+			if current != nil {
+				current.explanation = strings.Join(strings.Fields(body), " ")
+			}
+		default:
+			current = nil
+		}
+	}
+
+	return examples
+}
+
+func firstLine(text string) string {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(text)
+}
+
+// inputNames reads the argument names of an input such as
+// `nums = [2,7,11,15], target = 9`, skipping commas inside values
+func inputNames(input string) []string {
+	var names []string
+	depth := 0
+	inString := false
+	segmentStart := 0
+
+	flush := func(segment string) bool {
+		eq := strings.Index(segment, "=")
+		if eq < 0 {
+			return false
+		}
+		name := strings.TrimSpace(segment[:eq])
+		if !isIdentifier(name) {
+			return false
+		}
+		names = append(names, name)
+		return true
+	}
+
+	for i, r := range input {
+		switch {
+		case inString:
+			if r == '"' && (i == 0 || input[i-1] != '\\') {
+				inString = false
+			}
+		case r == '"':
+			inString = true
+		case r == '[' || r == '{' || r == '(':
+			depth++
+		case r == ']' || r == '}' || r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			if !flush(input[segmentStart:i]) {
+				return nil
+			}
+			segmentStart = i + 1
+		}
+	}
+	if !flush(input[segmentStart:]) {
+		return nil
+	}
+	return names
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		letter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !letter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonValue keeps values that are valid JSON, as nearly all test case values
+// are, and quotes the rest as strings
+func jsonValue(value string) json.RawMessage {
+	value = strings.TrimSpace(value)
+	if json.Valid([]byte(value)) {
+		return json.RawMessage(value)
+	}
+	quoted, _ := json.Marshal(value)
+	return json.RawMessage(quoted)
+}
+
+// inferType names the type of a value the way LeetCode metadata does, e.g.
+// "integer", "string" or "integer[][]"
+func inferType(value json.RawMessage) string {
+	var decoded interface{}
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return ""
+	}
+	return typeOf(decoded)
+}
+
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return "boolean"
+	case string:
+		if len([]rune(v)) == 1 {
+			return "character"
+		}
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "double"
+	case []interface{}:
+		for _, item := range v {
+			if item != nil {
+				return typeOf(item) + "[]"
+			}
+		}
+		return "[]"
+	}
+	return ""
+}
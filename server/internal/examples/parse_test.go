@@ -0,0 +1,129 @@
+package examples
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const twoSum = `<p>Given an array of integers <code>nums</code>&nbsp;and an integer <code>target</code>, return indices.</p>
+
+<p><strong class="example">Example 1:</strong></p>
+
+<pre>
+<strong>Input:</strong> nums = [2,7,11,15], target = 9
+<strong>Output:</strong> [0,1]
+<strong>Explanation:</strong> Because nums[0] + nums[1] == 9, we return [0, 1].
+</pre>
+
+<p><strong class="example">Example 2:</strong></p>
+
+<pre>
+<strong>Input:</strong> nums = [3,2,4], target = 6
+<strong>Output:</strong> [1,2]
+</pre>
+
+<p><strong>Constraints:</strong></p>
+<ul>
+	<li><code>2 &lt;= nums.length &lt;= 10<sup>4</sup></code></li>
+</ul>`
+
+// exampleBlocks uses the newer layout of LeetCode statements
+const exampleBlocks = `<p><strong class="example">Example 1:</strong></p>
+<div class="example-block">
+<p><strong>Input:</strong> <span class="example-io">s = &quot;abc&quot;, k = 2</span></p>
+<p><strong>Output:</strong> <span class="example-io">true</span></p>
+</div>`
+
+func TestParse(t *testing.T) {
+	examples, err := Parse("[2,7,11,15]\n9\n[3,2,4]\n6", twoSum, nil)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(examples) != 2 {
+		t.Fatalf("expected 2 examples, got %d", len(examples))
+	}
+
+	got, _ := json.Marshal(examples[0])
+	want := `{"inputs":{"nums":[2,7,11,15],"target":9},"params":[{"name":"nums","type":"integer[]"},{"name":"target","type":"integer"}],"expected":[0,1],"explanation":"Because nums[0] + nums[1] == 9, we return [0, 1]."}`
+	if string(got) != want {
+		t.Errorf("unexpected first example:\n got %s\nwant %s", got, want)
+	}
+
+	if string(examples[1].Expected) != "[1,2]" || examples[1].Explanation != "" {
+		t.Errorf("unexpected second example: %+v", examples[1])
+	}
+}
+
+func TestParseExampleBlocks(t *testing.T) {
+	examples, err := Parse(`"abc"`+"\n2", exampleBlocks, nil)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(examples) != 1 {
+		t.Fatalf("expected 1 example, got %d", len(examples))
+	}
+
+	ex := examples[0]
+	if string(ex.Inputs["s"]) != `"abc"` || string(ex.Inputs["k"]) != "2" || string(ex.Expected) != "true" {
+		t.Errorf("unexpected example: %+v", ex)
+	}
+	if ex.Params[0].Type != "string" {
+		t.Errorf("expected s to be a string, got %q", ex.Params[0].Type)
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	sig := &Signature{Params: []Param{{Name: "nums", Type: "integer[]"}, {Name: "target", Type: "integer"}}}
+
+	// the signature decides the split even without a statement
+	examples, err := Parse("[]\n0\n[1]\n1", "", sig)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(examples) != 2 || examples[0].Params[0].Type != "integer[]" || examples[1].Expected != nil {
+		t.Errorf("unexpected examples: %+v", examples)
+	}
+}
+
+func TestParseUnnamed(t *testing.T) {
+	// design problems have no "name = value" inputs, so each example takes
+	// as many lines as there are test case lines per statement example
+	design := `<pre><strong>Input</strong>: ["LRUCache","put"] [[2],[1,1]]` + "\n" + `<strong>Output:</strong> [null,null]</pre>`
+
+	examples, err := Parse(`["LRUCache","put"]`+"\n[[2],[1,1]]", design, nil)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(examples) != 1 || len(examples[0].Params) != 2 || examples[0].Params[1].Name != "arg2" || string(examples[0].Expected) != "[null,null]" {
+		t.Errorf("unexpected examples: %+v", examples)
+	}
+
+	if _, err := Parse("1\n2\n3", design+design, nil); err == nil {
+		t.Error("expected an error for test cases that do not split per example")
+	}
+	if _, err := Parse("", twoSum, nil); err == nil {
+		t.Error("expected an error without test cases")
+	}
+}
+
+func TestInputNames(t *testing.T) {
+	tests := map[string][]string{
+		`nums = [2,7,11,15], target = 9`:   {"nums", "target"},
+		`s = "a,b = c", words = ["x","y"]`: {"s", "words"},
+		`grid = [[1,0],[0,1]]`:             {"grid"},
+		`["LRUCache","put"] [[2],[1,1]]`:   nil,
+	}
+
+	for input, want := range tests {
+		got := inputNames(input)
+		if len(got) != len(want) {
+			t.Errorf("inputNames(%q) = %v, want %v", input, got, want)
+			continue
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("inputNames(%q) = %v, want %v", input, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Package importfile reads solve histories exported from LeetCode or kept by
+// hand, as JSON or CSV, into records the solve import understands.
+package importfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-leetcode/backend/models"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+
+	// MaxRows bounds a single import so it fits comfortably in one transaction
+	MaxRows = 5000
+)
+
+// column aliases accepted in CSV headers and JSON keys, so exports from
+// LeetCode itself (camelCase) and spreadsheets (snake_case) both work
+var fieldAliases = map[string]string{
+	"slug":         "slug",
+	"title_slug":   "slug",
+	"titleslug":    "slug",
+	"timestamp":    "timestamp",
+	"submitted_at": "timestamp",
+	"submittedat":  "timestamp",
+	"date":         "timestamp",
+	"language":     "language",
+	"lang":         "language",
+	"runtime":      "runtime",
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// DetectFormat guesses the format from a file name or content type, falling
+// back to sniffing the first non blank byte of the content
+func DetectFormat(name, contentType string, content []byte) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".json"), strings.Contains(contentType, "json"):
+		return FormatJSON
+	case strings.HasSuffix(name, ".csv"), strings.Contains(contentType, "csv"):
+		return FormatCSV
+	}
+
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return FormatJSON
+	}
+	return FormatCSV
+}
+
+// Parse reads records in the given format. Rows that cannot be parsed are
+// returned as invalid row results rather than failing the whole file.
+func Parse(r io.Reader, format string) ([]models.SolveRecord, []models.SolveRowResult, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatJSON:
+		return parseJSON(r)
+	default:
+		return nil, nil, fmt.Errorf("unsupported format %q, expected csv or json", format)
+	}
+}
+
+func parseCSV(r io.Reader) ([]models.SolveRecord, []models.SolveRowResult, error) {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil, errors.New("file is empty")
+		}
+		return nil, nil, fmt.Errorf("error reading csv header: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := fieldAliases[key]; ok {
+			columns[field] = i
+		}
+	}
+
+	if _, ok := columns["slug"]; !ok {
+		return nil, nil, errors.New("csv header must contain a slug or title_slug column")
+	}
+	if _, ok := columns["timestamp"]; !ok {
+		return nil, nil, errors.New("csv header must contain a timestamp or submitted_at column")
+	}
+
+	var records []models.SolveRecord
+	var invalid []models.SolveRowResult
+
+	line := 1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			invalid = append(invalid, invalidRow(line, "", err.Error()))
+			continue
+		}
+
+		get := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		record, err := buildRecord(line, get("slug"), get("timestamp"), get("language"), get("runtime"))
+		if err != nil {
+			invalid = append(invalid, invalidRow(line, get("slug"), err.Error()))
+			continue
+		}
+		records = append(records, record)
+
+		if len(records)+len(invalid) > MaxRows {
+			return nil, nil, fmt.Errorf("file has more than %d rows", MaxRows)
+		}
+	}
+
+	return records, invalid, nil
+}
+
+func parseJSON(r io.Reader) ([]models.SolveRecord, []models.SolveRowResult, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid json: %v", err)
+	}
+
+	// accept a bare array or an object wrapping it, as export tools often
+	// produce; other fields of the object, such as export metadata, are ignored
+	var items []map[string]interface{}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		var wrapped map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &wrapped); err != nil {
+			return nil, nil, errors.New("json must be an array of solves or an object with a solves, submissions or data array")
+		}
+		found := false
+		for _, key := range []string{"solves", "submissions", "submissions_dump", "data"} {
+			if list, ok := wrapped[key]; ok {
+				if err := json.Unmarshal(list, &items); err != nil {
+					return nil, nil, fmt.Errorf("%s must be an array of solves", key)
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, errors.New("json object has no solves, submissions or data array")
+		}
+	}
+
+	if len(items) > MaxRows {
+		return nil, nil, fmt.Errorf("file has more than %d rows", MaxRows)
+	}
+
+	var records []models.SolveRecord
+	var invalid []models.SolveRowResult
+
+	for i, item := range items {
+		// JSON rows are numbered from 1 in the order they appear
+		line := i + 1
+
+		fields := make(map[string]string)
+		for key, value := range item {
+			if field, ok := fieldAliases[strings.ToLower(key)]; ok {
+				fields[field] = jsonString(value)
+			}
+		}
+
+		record, err := buildRecord(line, fields["slug"], fields["timestamp"], fields["language"], fields["runtime"])
+		if err != nil {
+			invalid = append(invalid, invalidRow(line, fields["slug"], err.Error()))
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, invalid, nil
+}
+
+func buildRecord(line int, slug, timestamp, language, runtime string) (models.SolveRecord, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if slug == "" {
+		return models.SolveRecord{}, errors.New("slug is required")
+	}
+
+	submittedAt, err := ParseTimestamp(timestamp)
+	if err != nil {
+		return models.SolveRecord{}, err
+	}
+
+	if submittedAt.After(time.Now().Add(24 * time.Hour)) {
+		return models.SolveRecord{}, errors.New("timestamp is in the future")
+	}
+
+	return models.SolveRecord{
+		Line:        line,
+		TitleSlug:   slug,
+		SubmittedAt: submittedAt,
+		Language:    strings.TrimSpace(language),
+		Runtime:     strings.TrimSpace(runtime),
+	}, nil
+}
+
+// ParseTimestamp accepts unix seconds or milliseconds, RFC3339 and plain
+// "YYYY-MM-DD[ HH:MM:SS]" dates, which are read as UTC
+func ParseTimestamp(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, errors.New("timestamp is required")
+	}
+
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if unix > 1e12 {
+			return time.UnixMilli(unix).UTC(), nil
+		}
+		return time.Unix(unix, 0).UTC(), nil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognised timestamp %q", value)
+}
+
+func jsonString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func invalidRow(line int, slug, message string) models.SolveRowResult {
+	return models.SolveRowResult{
+		Line:      line,
+		TitleSlug: slug,
+		Status:    models.SolveRowInvalid,
+		Error:     message,
+	}
+}
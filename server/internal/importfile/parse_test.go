@@ -0,0 +1,121 @@
+package importfile
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go-leetcode/backend/models"
+)
+
+func TestParseCSV(t *testing.T) {
+	input := "title_slug,submitted_at,lang,runtime\n" +
+		"two-sum,2024-01-02T10:00:00Z,python3,52 ms\n" +
+		"Valid-Parentheses,1704189600,,\n" +
+		",2024-01-02,,\n" +
+		"lru-cache,yesterday,,\n"
+
+	records, invalid, err := Parse(strings.NewReader(input), FormatCSV)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	first := records[0]
+	if first.Line != 2 || first.TitleSlug != "two-sum" || first.Language != "python3" || first.Runtime != "52 ms" {
+		t.Errorf("unexpected first record: %+v", first)
+	}
+	if !first.SubmittedAt.Equal(time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected first timestamp: %v", first.SubmittedAt)
+	}
+
+	if records[1].TitleSlug != "valid-parentheses" || records[1].SubmittedAt.Unix() != 1704189600 {
+		t.Errorf("unexpected second record: %+v", records[1])
+	}
+
+	if len(invalid) != 2 {
+		t.Fatalf("expected 2 invalid rows, got %d", len(invalid))
+	}
+	if invalid[0].Line != 4 || invalid[0].Status != models.SolveRowInvalid {
+		t.Errorf("unexpected invalid row: %+v", invalid[0])
+	}
+	if invalid[1].Line != 5 || invalid[1].TitleSlug != "lru-cache" {
+		t.Errorf("unexpected invalid row: %+v", invalid[1])
+	}
+}
+
+func TestParseCSVMissingColumn(t *testing.T) {
+	_, _, err := Parse(strings.NewReader("slug,language\ntwo-sum,go\n"), FormatCSV)
+	if err == nil {
+		t.Fatal("expected an error for a header without a timestamp column")
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	input := `{"submissions": [
+		{"titleSlug": "two-sum", "timestamp": "1704189600", "lang": "golang"},
+		{"slug": "lru-cache", "timestamp": 1704189600000},
+		{"slug": "valid-parentheses"}
+	]}`
+
+	records, invalid, err := Parse(strings.NewReader(input), FormatJSON)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	if records[0].TitleSlug != "two-sum" || records[0].Language != "golang" || records[0].SubmittedAt.Unix() != 1704189600 {
+		t.Errorf("unexpected first record: %+v", records[0])
+	}
+
+	// millisecond timestamps are detected
+	if records[1].SubmittedAt.Unix() != 1704189600 {
+		t.Errorf("expected millisecond timestamp to be converted, got %v", records[1].SubmittedAt)
+	}
+
+	if len(invalid) != 1 || invalid[0].Line != 3 {
+		t.Errorf("expected the third row to be invalid, got %+v", invalid)
+	}
+}
+
+func TestParseJSONWrapper(t *testing.T) {
+	input := `{"exported_at": "2024-01-02", "count": 1, "data": [{"slug": "two-sum", "timestamp": 1704189600}]}`
+	records, _, err := Parse(strings.NewReader(input), FormatJSON)
+	if err != nil {
+		t.Fatalf("expected metadata fields to be ignored, got %v", err)
+	}
+	if len(records) != 1 || records[0].TitleSlug != "two-sum" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+
+	if _, _, err := Parse(strings.NewReader(`{"items": [{"slug": "two-sum"}]}`), FormatJSON); err == nil {
+		t.Error("expected an error for an object without a known array")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		content     string
+		want        string
+	}{
+		{"solves.json", "", "", FormatJSON},
+		{"solves.CSV", "", "", FormatCSV},
+		{"", "application/json", "", FormatJSON},
+		{"", "", "  [{\"slug\": \"two-sum\"}]", FormatJSON},
+		{"", "", "slug,timestamp", FormatCSV},
+	}
+
+	for _, tt := range tests {
+		if got := DetectFormat(tt.name, tt.contentType, []byte(tt.content)); got != tt.want {
+			t.Errorf("DetectFormat(%q, %q, %q) = %q, want %q", tt.name, tt.contentType, tt.content, got, tt.want)
+		}
+	}
+}
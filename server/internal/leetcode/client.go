@@ -1,13 +1,15 @@
-package leetcode 
+package leetcode
 
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 )
 
 type Client struct {
 	endpoint string
+	region   Region
 }
 
 type GraphQLRequest struct {
@@ -15,23 +17,25 @@ type GraphQLRequest struct {
 	Variables map[string]interface{} `json:"variables"`
 }
 
-func (c* Client) GetRecentSubmission(username string, limit int) ([]Submission, error) {
-	query := `
-		query recentAcSubmissions($username: String!, $limit: Int!) {
-			recentAcSubmissionList(username: $username, limit: $limit) {
-				id
-				title
-				titleSlug
-				timestamp
-			}
-		}
-	`
+// NewClient returns a client talking to the GraphQL endpoint of the given region
+func NewClient(region Region) *Client {
+	return &Client{endpoint: region.Endpoint(), region: region}
+}
 
-	variables := map[string]interface{}{
-		"username": username,
-		"limit": limit,
+func (c *Client) Region() Region {
+	if c.region == "" {
+		return RegionCOM
 	}
+	return c.region
+}
+
+func (c *Client) post(query string, variables map[string]interface{}, out interface{}) error {
+	return c.postWithSession(query, variables, "", out)
+}
 
+// postWithSession sends the query authenticated as the owner of the
+// LEETCODE_SESSION cookie. An empty session sends an anonymous request.
+func (c *Client) postWithSession(query string, variables map[string]interface{}, session string, out interface{}) error {
 	reqBody := GraphQLRequest{
 		Query: query,
 		Variables: variables,
@@ -39,29 +43,327 @@ func (c* Client) GetRecentSubmission(username string, limit int) ([]Submission,
 
 	jsonBody, err := json.Marshal(reqBody)
 	if (err != nil) {
-		return nil, err
+		return err
 	}
 
 	req, err := http.NewRequest("POST", c.endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if session != "" {
+		req.AddCookie(&http.Cookie{Name: "LEETCODE_SESSION", Value: session})
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("leetcode %s responded with status %d", c.Region(), resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c* Client) GetRecentSubmission(username string, limit int) ([]Submission, error) {
+	if c.Region() == RegionCN {
+		return c.getRecentSubmissionCN(username, limit)
+	}
+
+	query := `
+		query recentAcSubmissions($username: String!, $limit: Int!) {
+			recentAcSubmissionList(username: $username, limit: $limit) {
+				id
+				title
+				titleSlug
+				timestamp
+			}
+		}
+	`
+
+	variables := map[string]interface{}{
+		"username": username,
+		"limit": limit,
+	}
+
 	var result submissionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := c.post(query, variables, &result); err != nil {
 		return nil, err
 	}
 
 	return result.Data.RecentAcSubmissionList, nil
 }
 
+// leetcode.cn has no limit argument and keys users by their slug,
+// so we trim the list ourselves
+func (c *Client) getRecentSubmissionCN(userSlug string, limit int) ([]Submission, error) {
+	query := `
+		query recentAcSubmissions($userSlug: String!) {
+			recentACSubmissions(userSlug: $userSlug) {
+				submissionId
+				submitTime
+				question {
+					title
+					translatedTitle
+					titleSlug
+				}
+			}
+		}
+	`
+
+	variables := map[string]interface{}{
+		"userSlug": userSlug,
+	}
+
+	var result cnSubmissionResponse
+	if err := c.post(query, variables, &result); err != nil {
+		return nil, err
+	}
+
+	var submissions []Submission
+	for _, sub := range result.Data.RecentACSubmissions {
+		if limit > 0 && len(submissions) >= limit {
+			break
+		}
+
+		submissions = append(submissions, Submission{
+			ID:        sub.SubmissionID,
+			Title:     sub.Question.Title,
+			TitleSlug: sub.Question.TitleSlug,
+			Timestamp: sub.SubmitTime,
+		})
+	}
+
+	return submissions, nil
+}
+
+// GetQuestion fetches a question's metadata. On leetcode.cn the topic tags and
+// similar questions come back with their translated names filled in.
+func (c *Client) GetQuestion(titleSlug string) (Question, error) {
+	translatedFields := ""
+	tagTranslatedField := ""
+	if c.Region() == RegionCN {
+		translatedFields = "translatedTitle"
+		tagTranslatedField = "translatedName"
+	}
+
+	query := fmt.Sprintf(`
+		query questionData($titleSlug: String!) {
+			question(titleSlug: $titleSlug) {
+				questionId
+				questionFrontendId
+				title
+				%s
+				titleSlug
+				difficulty
+				topicTags {
+					name
+					slug
+					%s
+				}
+				similarQuestions
+			}
+		}
+	`, translatedFields, tagTranslatedField)
+
+	variables := map[string]interface{}{
+		"titleSlug": titleSlug,
+	}
+
+	var result questionResponse
+	if err := c.post(query, variables, &result); err != nil {
+		return Question{}, err
+	}
+
+	if result.Data.Question == nil {
+		return Question{}, fmt.Errorf("question %s not found on leetcode %s", titleSlug, c.Region())
+	}
+
+	question := result.Data.Question.Question
+	if raw := result.Data.Question.SimilarQuestions; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &question.SimilarQuestions); err != nil {
+			return Question{}, fmt.Errorf("error parsing similar questions for %s: %v", titleSlug, err)
+		}
+	}
+
+	return question, nil
+}
+
+// GetQuestionCode fetches the starter code LeetCode shows per language and
+// the metadata of the solution function
+func (c *Client) GetQuestionCode(titleSlug string) (QuestionCode, error) {
+	query := `
+		query questionCode($titleSlug: String!) {
+			question(titleSlug: $titleSlug) {
+				codeSnippets {
+					lang
+					langSlug
+					code
+				}
+				metaData
+			}
+		}
+	`
+
+	variables := map[string]interface{}{
+		"titleSlug": titleSlug,
+	}
+
+	var result questionCodeResponse
+	if err := c.post(query, variables, &result); err != nil {
+		return QuestionCode{}, err
+	}
+
+	if result.Data.Question == nil {
+		return QuestionCode{}, fmt.Errorf("question %s not found on leetcode %s", titleSlug, c.Region())
+	}
+
+	code := QuestionCode{CodeSnippets: result.Data.Question.CodeSnippets}
+	// metaData is a JSON encoded string, empty for some old questions
+	if raw := result.Data.Question.MetaData; raw != "" {
+		if !json.Valid([]byte(raw)) {
+			return QuestionCode{}, fmt.Errorf("error parsing metadata for %s: invalid JSON", titleSlug)
+		}
+		code.MetaData = json.RawMessage(raw)
+	}
+
+	return code, nil
+}
+
+// GetSubmissionList returns one page of the session owner's full submission
+// history, newest first. Pass the previous page's LastKey to continue paging.
+func (c *Client) GetSubmissionList(session string, offset, limit int, lastKey string) (SubmissionPage, error) {
+	if session == "" {
+		return SubmissionPage{}, fmt.Errorf("a LEETCODE_SESSION cookie is required to list submissions")
+	}
+
+	query := `
+		query submissionList($offset: Int!, $limit: Int!, $lastKey: String) {
+			submissionList(offset: $offset, limit: $limit, lastKey: $lastKey) {
+				lastKey
+				hasNext
+				submissions {
+					id
+					title
+					titleSlug
+					statusDisplay
+					lang
+					timestamp
+				}
+			}
+		}
+	`
+
+	variables := map[string]interface{}{
+		"offset": offset,
+		"limit": limit,
+	}
+	if lastKey != "" {
+		variables["lastKey"] = lastKey
+	}
+
+	var result submissionListResponse
+	if err := c.postWithSession(query, variables, session, &result); err != nil {
+		return SubmissionPage{}, err
+	}
+
+	if len(result.Errors) > 0 {
+		return SubmissionPage{}, fmt.Errorf("leetcode %s rejected submissionList: %s", c.Region(), result.Errors[0].Message)
+	}
+
+	if result.Data.SubmissionList == nil {
+		// leetcode answers with a null list when the session is invalid or expired
+		return SubmissionPage{}, ErrSessionExpired
+	}
+
+	return *result.Data.SubmissionList, nil
+}
+
+// GetDailyChallenge returns today's daily coding challenge for the client's region
+func (c *Client) GetDailyChallenge() (DailyChallenge, error) {
+	if c.Region() == RegionCN {
+		return c.getDailyChallengeCN()
+	}
+
+	query := `
+		query questionOfToday {
+			activeDailyCodingChallengeQuestion {
+				date
+				link
+				question {
+					questionFrontendId
+					title
+					titleSlug
+					difficulty
+				}
+			}
+		}
+	`
+
+	var result dailyChallengeResponse
+	if err := c.post(query, map[string]interface{}{}, &result); err != nil {
+		return DailyChallenge{}, err
+	}
+
+	daily := result.Data.ActiveDailyCodingChallengeQuestion
+	if daily == nil {
+		return DailyChallenge{}, fmt.Errorf("leetcode %s returned no daily challenge", c.Region())
+	}
+
+	return DailyChallenge{
+		Date:       daily.Date,
+		Link:       daily.Link,
+		FrontendID: daily.Question.FrontendID,
+		Title:      daily.Question.Title,
+		TitleSlug:  daily.Question.TitleSlug,
+		Difficulty: daily.Question.Difficulty,
+	}, nil
+}
+
+func (c *Client) getDailyChallengeCN() (DailyChallenge, error) {
+	query := `
+		query questionOfToday {
+			todayRecord {
+				date
+				question {
+					questionFrontendId
+					title
+					titleSlug
+					difficulty
+				}
+			}
+		}
+	`
+
+	var result cnDailyChallengeResponse
+	if err := c.post(query, map[string]interface{}{}, &result); err != nil {
+		return DailyChallenge{}, err
+	}
+
+	if len(result.Data.TodayRecord) == 0 {
+		return DailyChallenge{}, fmt.Errorf("leetcode %s returned no daily challenge", c.Region())
+	}
+
+	daily := result.Data.TodayRecord[0]
+	return DailyChallenge{
+		Date:       daily.Date,
+		Link:       "/problems/" + daily.Question.TitleSlug + "/",
+		FrontendID: daily.Question.FrontendID,
+		Title:      daily.Question.Title,
+		TitleSlug:  daily.Question.TitleSlug,
+		Difficulty: daily.Question.Difficulty,
+	}, nil
+}
+
+// SubmissionID returns the id we store for a LeetCode submission, matching
+// the "leetcode-<id>" format used by the submission handlers
+func SubmissionID(id string) string {
+	return "leetcode-" + id
+}
+
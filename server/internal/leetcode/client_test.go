@@ -1,6 +1,7 @@
 package leetcode
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -47,4 +48,290 @@ func TestGetRecentSubmission(t *testing.T) {
 	if submissions[0] != expected {
 		t.Errorf("expected %+v, got %+v", expected, submissions[0])
 	}
-}
\ No newline at end of file
+}
+func TestGetRecentSubmissionCN(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+            "data": {
+                "recentACSubmissions": [
+                    {
+                        "submissionId": "456",
+                        "submitTime": 1624512345,
+                        "question": {
+                            "title": "Two Sum",
+                            "translatedTitle": "两数之和",
+                            "titleSlug": "two-sum"
+                        }
+                    },
+                    {
+                        "submissionId": "457",
+                        "submitTime": 1624512400,
+                        "question": {
+                            "title": "3Sum",
+                            "translatedTitle": "三数之和",
+                            "titleSlug": "3sum"
+                        }
+                    }
+                ]
+            }
+		}`))
+	}))
+
+	defer server.Close()
+
+	client := &Client{endpoint: server.URL, region: RegionCN}
+
+	submissions, err := client.GetRecentSubmission("testuser", 1)
+
+	if (err != nil) {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if len(submissions) != 1 {
+		t.Fatalf("expected 1 submission, got %d", len(submissions))
+	}
+
+	expected := Submission{
+		ID: "456",
+		Title: "Two Sum",
+		TitleSlug: "two-sum",
+		Timestamp: 1624512345,
+	}
+
+	if submissions[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, submissions[0])
+	}
+}
+
+func TestGetQuestionCN(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+            "data": {
+                "question": {
+                    "questionId": "1",
+                    "questionFrontendId": "1",
+                    "title": "Two Sum",
+                    "translatedTitle": "两数之和",
+                    "titleSlug": "two-sum",
+                    "difficulty": "Easy",
+                    "topicTags": [
+                        {"name": "Array", "slug": "array", "translatedName": "数组"}
+                    ],
+                    "similarQuestions": "[{\"title\": \"3Sum\", \"titleSlug\": \"3sum\", \"difficulty\": \"Medium\", \"translatedTitle\": \"三数之和\"}]"
+                }
+            }
+		}`))
+	}))
+
+	defer server.Close()
+
+	client := &Client{endpoint: server.URL, region: RegionCN}
+
+	question, err := client.GetQuestion("two-sum")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(question.TopicTags) != 1 || question.TopicTags[0].TranslatedName == nil || *question.TopicTags[0].TranslatedName != "数组" {
+		t.Errorf("expected translated topic tag, got %+v", question.TopicTags)
+	}
+
+	if len(question.SimilarQuestions) != 1 || question.SimilarQuestions[0].TranslatedTitle == nil || *question.SimilarQuestions[0].TranslatedTitle != "三数之和" {
+		t.Errorf("expected translated similar question, got %+v", question.SimilarQuestions)
+	}
+}
+
+func TestParseRegion(t *testing.T) {
+	if region, err := ParseRegion(""); err != nil || region != RegionCOM {
+		t.Errorf("expected empty region to default to com, got %q (%v)", region, err)
+	}
+
+	if region, err := ParseRegion("cn"); err != nil || region.Endpoint() != "https://leetcode.cn/graphql/" {
+		t.Errorf("expected cn region endpoint, got %q (%v)", region.Endpoint(), err)
+	}
+
+	if _, err := ParseRegion("jp"); err == nil {
+		t.Error("expected error for unknown region")
+	}
+}
+
+func TestGetSubmissionList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("LEETCODE_SESSION")
+		if err != nil || cookie.Value != "session-cookie" {
+			w.Write([]byte(`{"data": {"submissionList": null}}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+            "data": {
+                "submissionList": {
+                    "lastKey": "abc",
+                    "hasNext": true,
+                    "submissions": [
+                        {
+                            "id": "123",
+                            "title": "Two Sum",
+                            "titleSlug": "two-sum",
+                            "statusDisplay": "Accepted",
+                            "lang": "python3",
+                            "timestamp": "1624512345"
+                        },
+                        {
+                            "id": "122",
+                            "title": "Two Sum",
+                            "titleSlug": "two-sum",
+                            "statusDisplay": "Wrong Answer",
+                            "lang": "python3",
+                            "timestamp": "1624512300"
+                        }
+                    ]
+                }
+            }
+		}`))
+	}))
+
+	defer server.Close()
+
+	client := &Client{endpoint: server.URL}
+
+	page, err := client.GetSubmissionList("session-cookie", 0, 20, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !page.HasNext || page.LastKey != "abc" {
+		t.Errorf("expected another page with last key abc, got %+v", page)
+	}
+
+	if len(page.Submissions) != 2 {
+		t.Fatalf("expected 2 submissions, got %d", len(page.Submissions))
+	}
+
+	if !page.Submissions[0].Accepted() || page.Submissions[1].Accepted() {
+		t.Errorf("expected only the first submission to be accepted, got %+v", page.Submissions)
+	}
+
+	if page.Submissions[0].Timestamp != 1624512345 {
+		t.Errorf("expected timestamp 1624512345, got %d", page.Submissions[0].Timestamp)
+	}
+
+	if _, err := client.GetSubmissionList("expired", 0, 20, ""); err != ErrSessionExpired {
+		t.Errorf("expected ErrSessionExpired for a rejected session, got %v", err)
+	}
+}
+
+func TestGetDailyChallenge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+            "data": {
+                "activeDailyCodingChallengeQuestion": {
+                    "date": "2025-03-11",
+                    "link": "/problems/two-sum/",
+                    "question": {
+                        "questionFrontendId": "1",
+                        "title": "Two Sum",
+                        "titleSlug": "two-sum",
+                        "difficulty": "Easy"
+                    }
+                }
+            }
+		}`))
+	}))
+
+	defer server.Close()
+
+	client := &Client{endpoint: server.URL}
+
+	daily, err := client.GetDailyChallenge()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := DailyChallenge{
+		Date:       "2025-03-11",
+		Link:       "/problems/two-sum/",
+		FrontendID: "1",
+		Title:      "Two Sum",
+		TitleSlug:  "two-sum",
+		Difficulty: "Easy",
+	}
+
+	if daily != expected {
+		t.Errorf("expected %+v, got %+v", expected, daily)
+	}
+}
+
+func TestGetDailyChallengeCN(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+            "data": {
+                "todayRecord": [
+                    {
+                        "date": "2025-03-12",
+                        "question": {
+                            "questionFrontendId": "15",
+                            "title": "3Sum",
+                            "titleSlug": "3sum",
+                            "difficulty": "Medium"
+                        }
+                    }
+                ]
+            }
+		}`))
+	}))
+
+	defer server.Close()
+
+	client := &Client{endpoint: server.URL, region: RegionCN}
+
+	daily, err := client.GetDailyChallenge()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if daily.Date != "2025-03-12" || daily.TitleSlug != "3sum" || daily.Link != "/problems/3sum/" {
+		t.Errorf("unexpected daily challenge %+v", daily)
+	}
+}
+
+func TestGetQuestionCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+            "data": {
+                "question": {
+                    "codeSnippets": [
+                        {"lang": "Python3", "langSlug": "python3", "code": "class Solution:\n    def twoSum(self, nums: List[int], target: int) -> List[int]:\n        "}
+                    ],
+                    "metaData": "{\n  \"name\": \"twoSum\",\n  \"params\": [{\"name\": \"nums\", \"type\": \"integer[]\"}],\n  \"return\": {\"type\": \"integer[]\"}\n}"
+                }
+            }
+		}`))
+	}))
+
+	defer server.Close()
+
+	client := &Client{endpoint: server.URL}
+
+	code, err := client.GetQuestionCode("two-sum")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(code.CodeSnippets) != 1 || code.CodeSnippets[0].LangSlug != "python3" {
+		t.Errorf("expected the python3 snippet, got %+v", code.CodeSnippets)
+	}
+
+	var meta struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(code.MetaData, &meta); err != nil || meta.Name != "twoSum" {
+		t.Errorf("expected metadata naming twoSum, got %s (%v)", code.MetaData, err)
+	}
+}
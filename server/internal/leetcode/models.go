@@ -1,5 +1,50 @@
 package leetcode
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrSessionExpired is returned when LeetCode does not accept a LEETCODE_SESSION cookie
+var ErrSessionExpired = errors.New("leetcode session is invalid or expired")
+
+// Region identifies which LeetCode site a user practices on. The two sites
+// expose different GraphQL schemas behind different endpoints.
+type Region string
+
+const (
+	RegionCOM Region = "com"
+	RegionCN  Region = "cn"
+)
+
+var regionEndpoints = map[Region]string{
+	RegionCOM: "https://leetcode.com/graphql/",
+	RegionCN:  "https://leetcode.cn/graphql/",
+}
+
+// ParseRegion validates a region string, treating an empty value as leetcode.com
+func ParseRegion(s string) (Region, error) {
+	if s == "" {
+		return RegionCOM, nil
+	}
+
+	region := Region(s)
+	if _, ok := regionEndpoints[region]; !ok {
+		return "", fmt.Errorf("unknown leetcode region: %s", s)
+	}
+
+	return region, nil
+}
+
+// Endpoint returns the GraphQL endpoint for the region
+func (r Region) Endpoint() string {
+	if endpoint, ok := regionEndpoints[r]; ok {
+		return endpoint
+	}
+	return regionEndpoints[RegionCOM]
+}
+
 type Submission struct {
 	ID string `json:"id"`
 	Title string `json:"title"`
@@ -7,8 +52,155 @@ type Submission struct {
 	Timestamp int64 `json:"timestamp"`
 }
 
+// HistorySubmission is an entry of the authenticated submissionList query,
+// which unlike recentAcSubmissionList also includes non accepted attempts
+type HistorySubmission struct {
+	ID            string `json:"id"`
+	Title         string `json:"title"`
+	TitleSlug     string `json:"titleSlug"`
+	StatusDisplay string `json:"statusDisplay"`
+	Lang          string `json:"lang"`
+	Timestamp     int64  `json:"timestamp,string"`
+}
+
+func (s HistorySubmission) Accepted() bool {
+	return s.StatusDisplay == "Accepted"
+}
+
+type SubmissionPage struct {
+	LastKey     string              `json:"lastKey"`
+	HasNext     bool                `json:"hasNext"`
+	Submissions []HistorySubmission `json:"submissions"`
+}
+
+type TopicTag struct {
+	Name           string  `json:"name"`
+	Slug           string  `json:"slug"`
+	TranslatedName *string `json:"translatedName"`
+}
+
+type SimilarQuestion struct {
+	Title           string  `json:"title"`
+	TitleSlug       string  `json:"titleSlug"`
+	Difficulty      string  `json:"difficulty"`
+	TranslatedTitle *string `json:"translatedTitle"`
+}
+
+// Question is the subset of a LeetCode question we read back into our problems table
+type Question struct {
+	QuestionID       string            `json:"questionId"`
+	FrontendID       string            `json:"questionFrontendId"`
+	Title            string            `json:"title"`
+	TranslatedTitle  *string           `json:"translatedTitle"`
+	TitleSlug        string            `json:"titleSlug"`
+	Difficulty       string            `json:"difficulty"`
+	TopicTags        []TopicTag        `json:"topicTags"`
+	SimilarQuestions []SimilarQuestion `json:"-"`
+}
+
+// CodeSnippet is the starter code of a question in one language
+type CodeSnippet struct {
+	Lang     string `json:"lang"`
+	LangSlug string `json:"langSlug"`
+	Code     string `json:"code"`
+}
+
+// QuestionCode is what a question's editor starts from: the starter code per
+// language and the metadata naming the solution function and its parameter
+// and return types
+type QuestionCode struct {
+	CodeSnippets []CodeSnippet
+	MetaData     json.RawMessage // nil when the question has none
+}
+
 type submissionResponse struct {
 	Data struct {
 		RecentAcSubmissionList []Submission `json:"recentAcSubmissionList"`
 	} `json:"data"`
-}
\ No newline at end of file
+}
+
+// leetcode.cn returns recent accepted submissions in its own shape,
+// which we map back into Submission
+type cnSubmission struct {
+	SubmissionID string `json:"submissionId"`
+	SubmitTime   int64  `json:"submitTime"`
+	Question     struct {
+		Title           string `json:"title"`
+		TranslatedTitle string `json:"translatedTitle"`
+		TitleSlug       string `json:"titleSlug"`
+	} `json:"question"`
+}
+
+type cnSubmissionResponse struct {
+	Data struct {
+		RecentACSubmissions []cnSubmission `json:"recentACSubmissions"`
+	} `json:"data"`
+}
+
+// DailyChallenge is the question of the day on a LeetCode site
+type DailyChallenge struct {
+	Date       string `json:"date"` // YYYY-MM-DD, in the site's own timezone
+	Link       string `json:"link"`
+	FrontendID string `json:"questionFrontendId"`
+	Title      string `json:"title"`
+	TitleSlug  string `json:"titleSlug"`
+	Difficulty string `json:"difficulty"`
+}
+
+type dailyChallengeQuestion struct {
+	FrontendID string `json:"questionFrontendId"`
+	Title      string `json:"title"`
+	TitleSlug  string `json:"titleSlug"`
+	Difficulty string `json:"difficulty"`
+}
+
+type dailyChallengeResponse struct {
+	Data struct {
+		ActiveDailyCodingChallengeQuestion *struct {
+			Date     string                 `json:"date"`
+			Link     string                 `json:"link"`
+			Question dailyChallengeQuestion `json:"question"`
+		} `json:"activeDailyCodingChallengeQuestion"`
+	} `json:"data"`
+}
+
+// leetcode.cn exposes its daily question through todayRecord instead
+type cnDailyChallengeResponse struct {
+	Data struct {
+		TodayRecord []struct {
+			Date     string                 `json:"date"`
+			Question dailyChallengeQuestion `json:"question"`
+		} `json:"todayRecord"`
+	} `json:"data"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+type submissionListResponse struct {
+	Data struct {
+		SubmissionList *SubmissionPage `json:"submissionList"`
+	} `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+type questionResponse struct {
+	Data struct {
+		Question *struct {
+			Question
+			// similarQuestions is a JSON encoded string on both sites
+			SimilarQuestions string `json:"similarQuestions"`
+		} `json:"question"`
+	} `json:"data"`
+}
+
+type questionCodeResponse struct {
+	Data struct {
+		Question *struct {
+			CodeSnippets []CodeSnippet `json:"codeSnippets"`
+			// metaData is a JSON encoded string
+			MetaData string `json:"metaData"`
+		} `json:"question"`
+	} `json:"data"`
+}
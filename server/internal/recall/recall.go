@@ -0,0 +1,296 @@
+// Package recall scores code typed from memory against a known solution.
+// Both are normalized first, so comments, formatting and the names picked
+// for variables do not count, and the score is the share of tokens the two
+// have in common.
+package recall
+
+import (
+	"go-leetcode/backend/internal/textdiff"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Ratings suggested for a score, matching FSRS ratings
+const (
+	RatingAgain = 1
+	RatingHard  = 2
+	RatingGood  = 3
+	RatingEasy  = 4
+)
+
+// Lowest scores for each rating above Again
+const (
+	EasyScore = 0.95
+	GoodScore = 0.8
+	HardScore = 0.6
+)
+
+// Result is how well an attempt matches a solution
+type Result struct {
+	// Score is 2*common/(len(solution)+len(attempt)) over normalized
+	// tokens, from 0 to 1
+	Score float64 `json:"score"`
+	// Rating is the FSRS rating suggested for Score
+	Rating int `json:"suggested_rating"`
+	// Diff compares the solution with the attempt line by line, without
+	// comments, indentation or blank lines
+	Diff textdiff.Diff `json:"diff"`
+}
+
+// Compare scores attempt against solution, both in language
+func Compare(language, solution, attempt string) Result {
+	style := commentStyleOf(language)
+	solution = stripComments(style, solution)
+	attempt = stripComments(style, attempt)
+
+	score := Similarity(Tokens(solution), Tokens(attempt))
+	return Result{
+		Score:  score,
+		Rating: SuggestRating(score),
+		Diff:   textdiff.CompareLines(displayLines(solution), displayLines(attempt)),
+	}
+}
+
+// SuggestRating maps a similarity score to an FSRS rating
+func SuggestRating(score float64) int {
+	switch {
+	case score >= EasyScore:
+		return RatingEasy
+	case score >= GoodScore:
+		return RatingGood
+	case score >= HardScore:
+		return RatingHard
+	default:
+		return RatingAgain
+	}
+}
+
+// Similarity is the share of tokens in the longest common subsequence of a
+// and b, 1 when both are empty
+func Similarity(a, b []string) float64 {
+	if len(a)+len(b) == 0 {
+		return 1
+	}
+	common := textdiff.LCSLength(a, b)
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
+
+// languageAliases maps the names languages go by in LeetCode's editor and in
+// stored solutions to one name
+var languageAliases = map[string]string{
+	"python3":    "python",
+	"golang":     "go",
+	"c++":        "cpp",
+	"js":         "javascript",
+	"ts":         "typescript",
+	"postgresql": "sql",
+	"mysql":      "sql",
+}
+
+// SameLanguage reports whether two language names name the same language
+func SameLanguage(a, b string) bool {
+	return canonicalLanguage(a) == canonicalLanguage(b)
+}
+
+func canonicalLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if alias, ok := languageAliases[language]; ok {
+		return alias
+	}
+	return language
+}
+
+type commentStyle int
+
+const (
+	cStyle    commentStyle = iota // // and /* */
+	hashStyle                     // #
+	sqlStyle                      // -- and /* */
+)
+
+func commentStyleOf(language string) commentStyle {
+	switch canonicalLanguage(language) {
+	case "python", "ruby", "bash", "shell", "r", "elixir", "pandas":
+		return hashStyle
+	case "sql", "mssql", "oraclesql":
+		return sqlStyle
+	default:
+		return cStyle
+	}
+}
+
+// stripComments removes comments from code, keeping line breaks and the
+// contents of string literals
+func stripComments(style commentStyle, code string) string {
+	var sb strings.Builder
+	rs := []rune(code)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		next := rune(0)
+		if i+1 < len(rs) {
+			next = rs[i+1]
+		}
+
+		switch {
+		case r == '"' || r == '\'' || r == '`':
+			end := stringEnd(rs, i)
+			sb.WriteString(string(rs[i:end]))
+			i = end - 1
+		case style == hashStyle && r == '#',
+			style == cStyle && r == '/' && next == '/',
+			style == sqlStyle && r == '-' && next == '-':
+			for i+1 < len(rs) && rs[i+1] != '\n' {
+				i++
+			}
+		case style != hashStyle && r == '/' && next == '*':
+			i += 2
+			for i < len(rs) && !(rs[i] == '*' && i+1 < len(rs) && rs[i+1] == '/') {
+				if rs[i] == '\n' {
+					sb.WriteRune('\n')
+				}
+				i++
+			}
+			i++
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// stringEnd returns the index after the string literal starting at rs[start],
+// taking backslash escapes and Python's triple quotes into account
+func stringEnd(rs []rune, start int) int {
+	quote := rs[start]
+	triple := start+2 < len(rs) && rs[start+1] == quote && rs[start+2] == quote
+	i := start + 1
+	if triple {
+		i = start + 3
+	}
+	for i < len(rs) {
+		switch {
+		case rs[i] == '\\' && quote != '`':
+			i += 2
+			continue
+		case rs[i] == '\n' && !triple && quote != '`':
+			return i
+		case rs[i] == quote:
+			if !triple {
+				return i + 1
+			}
+			if i+2 < len(rs) && rs[i+1] == quote && rs[i+2] == quote {
+				return i + 3
+			}
+		}
+		i++
+	}
+	return len(rs)
+}
+
+// Tokens splits code without comments into tokens: keywords and builtins as
+// they are, number and string literals whole and every other symbol by
+// itself. Other identifiers are numbered by their first occurrence (v1, v2,
+// ...), so consistently renamed variables still match but using one variable
+// where the solution uses another does not. Semicolons are dropped, they are
+// optional in several languages.
+func Tokens(code string) []string {
+	var tokens []string
+	idents := make(map[string]string)
+	rs := []rune(code)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r) || r == ';':
+			i++
+		case r == '"' || r == '\'' || r == '`':
+			end := stringEnd(rs, i)
+			tokens = append(tokens, string(rs[i:end]))
+			i = end
+		case unicode.IsDigit(r):
+			j := i + 1
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '.' || rs[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, string(rs[i:j]))
+			i = j
+		case unicode.IsLetter(r) || r == '_' || r == '$':
+			j := i + 1
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_' || rs[j] == '$') {
+				j++
+			}
+			word := string(rs[i:j])
+			if keywords[word] {
+				tokens = append(tokens, word)
+			} else {
+				ident, ok := idents[word]
+				if !ok {
+					ident = "v" + strconv.Itoa(len(idents)+1)
+					idents[word] = ident
+				}
+				tokens = append(tokens, ident)
+			}
+			i = j
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens
+}
+
+// displayLines splits code without comments into trimmed lines with runs of
+// spaces collapsed, dropping blank ones
+func displayLines(code string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(code, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// keywords are kept as they are when tokenizing: the keywords of the usual
+// LeetCode languages and the builtins and library names whose choice matters
+// to a solution
+var keywords = toSet(
+	// control flow and declarations
+	"if", "else", "elif", "for", "while", "do", "switch", "case", "default", "break", "continue",
+	"return", "yield", "func", "def", "function", "fn", "lambda", "class", "struct", "interface",
+	"type", "var", "let", "const", "mut", "static", "public", "private", "protected", "final",
+	"import", "package", "from", "as", "in", "is", "not", "and", "or", "try", "catch", "except",
+	"finally", "throw", "throws", "raise", "with", "new", "delete", "go", "defer", "select", "chan",
+	"map", "range", "goto", "pass", "global", "nonlocal", "match", "impl", "pub", "loop", "where",
+	"void", "auto", "extends", "implements", "instanceof", "typeof", "async", "await", "of",
+	// values
+	"true", "false", "True", "False", "null", "nil", "None", "undefined", "self", "this", "super",
+	// types
+	"int", "long", "short", "byte", "char", "float", "double", "bool", "boolean", "string", "str",
+	"String", "Integer", "Long", "Character", "Boolean", "Double", "rune", "int64", "int32",
+	"uint", "float64", "usize", "i32", "i64", "u32", "u64", "f64", "Vec", "Option", "Some",
+	"Ok", "Err", "Box", "List", "Optional", "Dict", "Set", "Map", "ListNode", "TreeNode",
+	// collections
+	"list", "dict", "set", "tuple", "deque", "defaultdict", "Counter", "OrderedDict", "heapq",
+	"heappush", "heappop", "heapify", "bisect", "bisect_left", "bisect_right", "ArrayList",
+	"LinkedList", "HashMap", "HashSet", "TreeMap", "TreeSet", "PriorityQueue", "ArrayDeque",
+	"Stack", "Queue", "Deque", "Arrays", "Collections", "vector", "unordered_map", "unordered_set",
+	"priority_queue", "stack", "queue", "pair", "VecDeque", "BinaryHeap",
+	// builtins and common methods
+	"len", "append", "make", "copy", "cap", "print", "min", "max", "abs", "sum", "sorted", "sort",
+	"reversed", "reverse", "enumerate", "zip", "any", "all", "ord", "chr", "math", "inf",
+	"size", "length", "push", "push_back", "pop", "pop_back", "popleft", "appendleft", "get",
+	"put", "add", "remove", "contains", "containsKey", "getOrDefault", "keys", "values", "items",
+	"insert", "erase", "find", "count", "index", "join", "split", "substring", "charAt",
+	"isEmpty", "empty", "offer", "poll", "peek", "front", "back", "top", "first", "last",
+	"next", "left", "right", "val", "Math", "sqrt", "pow", "floor", "ceil",
+)
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
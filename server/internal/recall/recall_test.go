@@ -0,0 +1,82 @@
+package recall
+
+import (
+	"strings"
+	"testing"
+)
+
+const reference = `class Solution:
+    def twoSum(self, nums: List[int], target: int) -> List[int]:
+        seen = {}  # value -> index
+        for i, num in enumerate(nums):
+            if target - num in seen:
+                return [seen[target - num], i]
+            seen[num] = i
+`
+
+func TestCompareIgnoresNamesCommentsAndLayout(t *testing.T) {
+	attempt := `class Solution:
+    def twoSum(self, a: List[int], t: int) -> List[int]:
+        """docstrings are strings, '#' inside them is kept"""
+        idx = {}
+        for j, x in enumerate(a):
+
+            if t - x in idx: return [idx[t - x], j]
+            idx[x] = j
+`
+	result := Compare("python3", reference, attempt)
+	if result.Score < 0.95 || result.Rating != RatingEasy {
+		t.Errorf("score %.2f, rating %d, want at least 0.95 and Easy", result.Score, result.Rating)
+	}
+	if !result.Diff.Changed() {
+		t.Error("diff shows no change for renamed lines")
+	}
+	for _, line := range result.Diff.Lines {
+		if strings.Contains(line.Text, "value -> index") {
+			t.Errorf("comment kept in diff: %q", line.Text)
+		}
+	}
+}
+
+func TestCompareScoresWrongSolutionsLow(t *testing.T) {
+	attempt := `class Solution:
+    def twoSum(self, nums, target):
+        for i in range(len(nums)):
+            for j in range(i + 1, len(nums)):
+                if nums[i] + nums[j] == target:
+                    return [i, j]
+`
+	result := Compare("python3", reference, attempt)
+	if result.Rating == RatingEasy || result.Rating == RatingGood {
+		t.Errorf("brute force scored %.2f, rating %d", result.Score, result.Rating)
+	}
+
+	if empty := Compare("python3", reference, ""); empty.Score != 0 || empty.Rating != RatingAgain {
+		t.Errorf("empty attempt scored %.2f, rating %d", empty.Score, empty.Rating)
+	}
+}
+
+func TestTokensNumberIdentifiers(t *testing.T) {
+	if renamed := Similarity(Tokens("a = b + c"), Tokens("x = y + z")); renamed != 1 {
+		t.Errorf("consistent rename scored %.2f, want 1", renamed)
+	}
+	if reused := Similarity(Tokens("a = b + c"), Tokens("x = x + x")); reused >= GoodScore {
+		t.Errorf("one variable used for three scored %.2f", reused)
+	}
+}
+
+func TestStripComments(t *testing.T) {
+	code := "int a = 1; // one\n/* two\nlines */ char *s = \"// not a comment\";"
+	want := "int a = 1; \n\n char *s = \"// not a comment\";"
+	if got := stripComments(commentStyleOf("cpp"), code); got != want {
+		t.Errorf("stripComments = %q, want %q", got, want)
+	}
+}
+
+func TestSuggestRating(t *testing.T) {
+	for score, want := range map[float64]int{1: RatingEasy, 0.9: RatingGood, 0.7: RatingHard, 0.2: RatingAgain} {
+		if got := SuggestRating(score); got != want {
+			t.Errorf("SuggestRating(%v) = %d, want %d", score, got, want)
+		}
+	}
+}
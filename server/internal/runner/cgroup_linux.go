@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// cgroup2Magic is the filesystem type of a cgroup v2 hierarchy
+const cgroup2Magic = 0x63677270
+
+// cgroupControllers limit a run's processes, memory and CPU as a whole
+const cgroupControllers = "+cpu +memory +pids"
+
+// cgroup is the cgroup v2 a run executes in. Unlike rlimits its limits cover
+// every process the solution starts, so a fork bomb is stopped at the process
+// limit and forked processes share the memory limit.
+type cgroup struct {
+	path string
+	dir  *os.File // passed to clone to start the sandbox inside
+}
+
+// setupCgroupParent creates the cgroup runs are created in, which the server's
+// user must be allowed to write, and enables the controllers for them
+func setupCgroupParent(parent string) error {
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return fmt.Errorf("error creating cgroup %s: %v", parent, err)
+	}
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(parent, &stat); err != nil {
+		return fmt.Errorf("error reading cgroup %s: %v", parent, err)
+	}
+	if stat.Type != cgroup2Magic {
+		return fmt.Errorf("%s is not in a cgroup v2 hierarchy", parent)
+	}
+	if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte(cgroupControllers), 0o644); err != nil {
+		return fmt.Errorf("error enabling the cpu, memory and pids controllers in %s: %v", parent, err)
+	}
+	return nil
+}
+
+// newCgroup creates a cgroup for one run below parent, limited to
+// limits.Processes processes and threads, limits.Memory bytes without swap and
+// one CPU
+func newCgroup(parent string, limits Limits) (*cgroup, error) {
+	path, err := os.MkdirTemp(parent, "run-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating cgroup: %v", err)
+	}
+
+	settings := []struct {
+		file, value string
+	}{
+		{"pids.max", strconv.Itoa(limits.Processes)},
+		{"memory.max", strconv.FormatInt(limits.Memory, 10)},
+		{"memory.swap.max", "0"},
+		{"cpu.max", "100000 100000"},
+	}
+	for _, setting := range settings {
+		err := os.WriteFile(filepath.Join(path, setting.file), []byte(setting.value), 0o644)
+		// kernels without swap accounting have no memory.swap.max
+		if err != nil && !(setting.file == "memory.swap.max" && errors.Is(err, fs.ErrNotExist)) {
+			os.Remove(path)
+			return nil, fmt.Errorf("error setting %s: %v", setting.file, err)
+		}
+	}
+
+	dir, err := os.Open(path)
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("error opening cgroup: %v", err)
+	}
+	return &cgroup{path: path, dir: dir}, nil
+}
+
+// kill kills every process in the cgroup
+func (c *cgroup) kill() {
+	// cgroup.kill needs Linux 5.14, before that kill what cgroup.procs lists
+	if err := os.WriteFile(filepath.Join(c.path, "cgroup.kill"), []byte("1"), 0o644); err == nil {
+		return
+	}
+	procs, _ := os.ReadFile(filepath.Join(c.path, "cgroup.procs"))
+	for _, line := range strings.Fields(string(procs)) {
+		if pid, err := strconv.Atoi(line); err == nil {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+}
+
+// Close kills whatever still runs in the cgroup and removes it
+func (c *cgroup) Close() error {
+	defer c.dir.Close()
+	c.kill()
+
+	// removing fails until the killed processes are gone
+	var err error
+	for i := 0; i < 100; i++ {
+		if err = os.Remove(c.path); err == nil {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Errorf("error removing cgroup %s: %v", c.path, err)
+}
@@ -0,0 +1,125 @@
+"""Runs a solution against one test case of internal/runner.
+
+Usage: harness.py SPEC SOLUTION CASE
+
+Writes the result of case CASE, its index in SPEC, as one JSON object to file
+descriptor 3, so nothing the solution prints can corrupt it, or a
+{"fatal": ...} object when the solution cannot be loaded. The sandbox runs a
+process per case and grades the results outside of it, so a solution writing
+to the descriptor can only speak for its own case.
+"""
+
+import contextlib
+import inspect
+import io
+import json
+import os
+import sys
+import time
+import traceback
+
+# names LeetCode makes available without imports
+PRELUDE = """
+from typing import *
+import bisect, collections, functools, heapq, itertools, math, operator, re, string
+from collections import *
+from functools import cache, lru_cache, reduce
+from heapq import *
+from math import inf
+"""
+
+SOLUTION_FILE = "solution.py"
+
+
+def load(path, function, arity):
+    namespace = {"__name__": "solution"}
+    exec(PRELUDE, namespace)
+    with open(path) as f:
+        source = f.read()
+    exec(compile(source, SOLUTION_FILE, "exec"), namespace)
+
+    solution = namespace.get("Solution")
+    if inspect.isclass(solution):
+        instance = solution()
+        candidates = {
+            name: getattr(instance, name)
+            for name in vars(solution)
+            if not name.startswith("_") and callable(getattr(instance, name))
+        }
+    else:
+        candidates = {
+            name: value
+            for name, value in namespace.items()
+            if inspect.isfunction(value) and value.__module__ == "solution" and not name.startswith("_")
+        }
+
+    if function:
+        if function not in candidates:
+            raise LookupError(f"function {function} is not defined")
+        return candidates[function]
+
+    matching = [fn for fn in candidates.values() if accepts(fn, arity)]
+    if len(matching) != 1:
+        raise LookupError(f"expected exactly one function taking {arity} arguments, found {len(matching)}")
+    return matching[0]
+
+
+def accepts(fn, arity):
+    try:
+        params = inspect.signature(fn).parameters.values()
+    except (TypeError, ValueError):
+        return False
+    positional = [p for p in params if p.kind in (p.POSITIONAL_ONLY, p.POSITIONAL_OR_KEYWORD)]
+    required = [p for p in positional if p.default is p.empty]
+    return len(required) <= arity <= len(positional)
+
+
+def describe_error():
+    """The exception and the last line of the solution it passed through."""
+    kind, error, tb = sys.exc_info()
+    message = "".join(traceback.format_exception_only(kind, error)).strip()
+    lines = [frame.lineno for frame in traceback.extract_tb(tb) if frame.filename == SOLUTION_FILE]
+    if lines:
+        return f"line {lines[-1]}: {message}"
+    return message
+
+
+def run(fn, args, output_limit):
+    stdout = io.StringIO()
+    result = {}
+    start = time.perf_counter()
+    try:
+        with contextlib.redirect_stdout(stdout):
+            output = fn(*args)
+        result["runtime_ms"] = (time.perf_counter() - start) * 1000
+        try:
+            result["output"] = json.loads(json.dumps(output))
+        except (TypeError, ValueError) as error:
+            result["error"] = f"cannot encode the return value as JSON: {error}"
+    except Exception:
+        result["runtime_ms"] = (time.perf_counter() - start) * 1000
+        result["error"] = describe_error()
+    result["stdout"] = stdout.getvalue()[:output_limit]
+    return result
+
+
+def main():
+    with open(sys.argv[1]) as f:
+        spec = json.load(f)
+    case = spec["cases"][int(sys.argv[3])]
+    results = os.fdopen(3, "w")
+    sys.setrecursionlimit(10000)
+
+    try:
+        fn = load(sys.argv[2], spec.get("function"), spec["arity"])
+    except Exception:
+        results.write(json.dumps({"fatal": describe_error()}) + "\n")
+        return 1
+
+    results.write(json.dumps(run(fn, case["args"], spec["output_limit"])) + "\n")
+    results.flush()
+    return 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
@@ -0,0 +1,233 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed harness/harness.py
+var pythonHarness []byte
+
+//go:embed harness/harness.go.tmpl
+var goHarnessSource string
+
+var goHarness = template.Must(template.New("harness").Parse(goHarnessSource))
+
+// goBuildTimeout bounds compiling a Go solution, which happens outside the
+// sandbox's namespaces
+const goBuildTimeout = 60 * time.Second
+
+// goBuildMemory bounds the memory of a Go build, in its own cgroup or per
+// compiler process without cgroups. Compiling needs more than running.
+const goBuildMemory = 1 << 30
+
+var packageClause = regexp.MustCompile(`(?m)^\s*package\s+(\w+)`)
+
+func (r *Runner) preparePython(dir string, program Program) ([]string, error) {
+	if r.python == "" {
+		return nil, fmt.Errorf("%w: python is not installed", ErrUnavailable)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "harness.py"), pythonHarness, 0o644); err != nil {
+		return nil, fmt.Errorf("error writing harness: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "solution.py"), []byte(program.Code), 0o644); err != nil {
+		return nil, fmt.Errorf("error writing solution: %v", err)
+	}
+
+	return []string{r.python, "-I", "-B", "harness.py", "spec.json", "solution.py"}, nil
+}
+
+// goSignature is the solution function the Go harness calls
+type goSignature struct {
+	Func    string
+	Params  []string // parameter types, as written in the solution
+	Args    string   // arguments of the call
+	Returns bool
+}
+
+// prepareGo compiles the solution together with a harness generated for its
+// signature. Solutions are written the LeetCode way, as functions without a
+// package clause.
+func (r *Runner) prepareGo(ctx context.Context, dir string, program Program, arity int) ([]string, error) {
+	if r.goTool == "" {
+		return nil, fmt.Errorf("%w: go is not installed", ErrUnavailable)
+	}
+
+	src := program.Code
+	if m := packageClause.FindStringSubmatch(src); m == nil {
+		src = "package main\n\n" + src
+	} else if m[1] != "main" {
+		return nil, &compileError{output: "solutions must be in package main"}
+	}
+
+	sig, err := goFunction(src, program.Function, arity)
+	if err != nil {
+		return nil, &compileError{output: err.Error()}
+	}
+
+	var harness bytes.Buffer
+	if err := goHarness.Execute(&harness, sig); err != nil {
+		return nil, fmt.Errorf("error generating harness: %v", err)
+	}
+
+	files := map[string][]byte{
+		"go.mod":      []byte("module solution\n\ngo 1.21\n"),
+		"solution.go": []byte(src),
+		"harness.go":  harness.Bytes(),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			return nil, fmt.Errorf("error writing %s: %v", name, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, goBuildTimeout)
+	defer cancel()
+
+	// the build never runs solution code: cgo, which could, is disabled and
+	// the toolchain may not download anything. It still gets none of the
+	// server's environment, which holds its secrets.
+	cmd := exec.CommandContext(ctx, r.goTool, "build", "-o", "solution", ".")
+	cmd.Dir = dir
+	cmd.Env = append([]string{
+		"PATH=" + filepath.Dir(r.goTool) + ":/usr/local/bin:/usr/bin:/bin",
+		"HOME=" + dir,
+		"GOMAXPROCS=2",
+		"CGO_ENABLED=0", "GOTOOLCHAIN=local", "GOPROXY=off", "GOFLAGS=-mod=mod", "GOWORK=off",
+	}, r.goEnv...)
+	output := &limitedBuffer{limit: r.config.Limits.Output}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	// a pathological source can make the compiler use any amount of memory
+	var cg *cgroup
+	if r.cgroups {
+		limits := r.config.Limits
+		limits.Memory = max(limits.Memory, goBuildMemory)
+		var err error
+		if cg, err = newCgroup(r.config.Cgroup, limits); err != nil {
+			return nil, fmt.Errorf("error creating build cgroup: %v", err)
+		}
+		defer cg.Close()
+	}
+	cmd.SysProcAttr = sandboxAttr(false, cg)
+	cmd.Cancel = func() error {
+		return killSandbox(cmd.Process)
+	}
+
+	err = cmd.Start()
+	if err == nil && cg == nil {
+		// the compiler processes go build starts inherit the limit
+		if err = limitData(cmd.Process.Pid, goBuildMemory); err != nil {
+			killSandbox(cmd.Process)
+			cmd.Wait()
+			return nil, fmt.Errorf("error limiting go build memory: %v", err)
+		}
+	}
+	if err == nil {
+		err = cmd.Wait()
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, &compileError{output: "compilation timed out"}
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, &compileError{output: strings.ReplaceAll(output.String(), dir+string(filepath.Separator), "")}
+		}
+		return nil, fmt.Errorf("error running go build: %v", err)
+	}
+
+	return []string{"./solution", "spec.json"}, nil
+}
+
+// goFunction finds the function named name, or the only top level function
+// taking arity parameters
+func goFunction(src, name string, arity int) (goSignature, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "solution.go", src, 0)
+	if err != nil {
+		return goSignature{}, err
+	}
+
+	var matches []*ast.FuncDecl
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Name.Name == "main" || fn.Name.Name == "init" {
+			continue
+		}
+		if name != "" {
+			if fn.Name.Name == name {
+				matches = append(matches, fn)
+			}
+		} else if fieldCount(fn.Type.Params) == arity {
+			matches = append(matches, fn)
+		}
+	}
+
+	if name != "" && len(matches) == 0 {
+		return goSignature{}, fmt.Errorf("function %s is not defined", name)
+	}
+	if len(matches) != 1 {
+		return goSignature{}, fmt.Errorf("expected exactly one function taking %d arguments, found %d", arity, len(matches))
+	}
+
+	fn := matches[0]
+	if n := fieldCount(fn.Type.Params); n != arity {
+		return goSignature{}, fmt.Errorf("function %s takes %d arguments, the test cases have %d", fn.Name.Name, n, arity)
+	}
+	if fn.Type.TypeParams != nil {
+		return goSignature{}, fmt.Errorf("function %s must not be generic", fn.Name.Name)
+	}
+	results := fieldCount(fn.Type.Results)
+	if results > 1 {
+		return goSignature{}, fmt.Errorf("function %s must return at most one value", fn.Name.Name)
+	}
+
+	sig := goSignature{Func: fn.Name.Name, Returns: results == 1}
+	var args []string
+	for _, field := range fn.Type.Params.List {
+		if _, variadic := field.Type.(*ast.Ellipsis); variadic {
+			return goSignature{}, fmt.Errorf("function %s must not be variadic", fn.Name.Name)
+		}
+
+		var typ bytes.Buffer
+		if err := printer.Fprint(&typ, fset, field.Type); err != nil {
+			return goSignature{}, err
+		}
+
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			args = append(args, fmt.Sprintf("arg%d", len(sig.Params)))
+			sig.Params = append(sig.Params, typ.String())
+		}
+	}
+	sig.Args = strings.Join(args, ", ")
+
+	return sig, nil
+}
+
+func fieldCount(fields *ast.FieldList) int {
+	if fields == nil {
+		return 0
+	}
+	return fields.NumFields()
+}
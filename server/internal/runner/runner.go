@@ -0,0 +1,624 @@
+// Package runner executes user solutions against test cases in a sandboxed
+// child process. Solutions run in their own user, PID, network, IPC, UTS and
+// mount namespaces, so they cannot reach the network, signal other processes
+// or see the host's files beyond the interpreter they need, in a cgroup
+// limiting the processes, memory and CPU of the whole run, with rlimits on CPU
+// time, memory and file size per process, and are killed when the run exceeds
+// its wall time.
+//
+// The child is this binary re-executed with SandboxCommand, which sets up the
+// filesystem and limits and then runs the interpreter or the compiled
+// solution once per case. Programs embedding the runner must call SandboxMain
+// for that command before doing anything else.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LanguagePython = "python"
+	LanguageGo     = "go"
+)
+
+const (
+	StatusAccepted          = "accepted"
+	StatusWrongAnswer       = "wrong_answer"
+	StatusRuntimeError      = "runtime_error"
+	StatusTimeLimitExceeded = "time_limit_exceeded"
+	StatusCompileError      = "compile_error"
+)
+
+var (
+	ErrUnavailable         = errors.New("code runner is unavailable")
+	ErrUnsupportedLanguage = errors.New("unsupported language")
+)
+
+func IsValidLanguage(language string) bool {
+	return language == LanguagePython || language == LanguageGo
+}
+
+// Limits bound a single run, all of its cases together
+type Limits struct {
+	WallTime  time.Duration
+	CPUTime   time.Duration
+	Memory    int64 // bytes of data and heap
+	Output    int   // bytes of stdout kept per case, and of other output
+	Processes int   // processes and threads at once
+}
+
+var DefaultLimits = Limits{
+	WallTime:  10 * time.Second,
+	CPUTime:   5 * time.Second,
+	Memory:    512 << 20,
+	Output:    64 << 10,
+	Processes: 128,
+}
+
+// DefaultCgroup is the cgroup v2 runs are created in unless configured
+const DefaultCgroup = "/sys/fs/cgroup/code-runner"
+
+// systemPaths are mounted read-only for interpreted languages, for the shared
+// libraries they load
+var systemPaths = []string{"/usr", "/lib", "/lib32", "/lib64", "/libx32", "/etc/ld.so.cache"}
+
+type Config struct {
+	Python        string // interpreter, python3 on the PATH when empty
+	Go            string // toolchain, go on the PATH when empty
+	MaxConcurrent int    // runs at once, further runs wait for a slot
+	// Cgroup is the cgroup v2 directory runs are created in, DefaultCgroup
+	// when empty. The server's user must be able to write it.
+	Cgroup string
+	// AllowUnisolated falls back to rlimits only where namespaces or cgroups
+	// cannot be created, e.g. in containers without unprivileged user
+	// namespaces. The solution can then reach the network and the server's
+	// files, and fork without limit.
+	AllowUnisolated bool
+	Limits          Limits
+}
+
+// ConfigFromEnv reads RUNNER_PYTHON, RUNNER_GO, RUNNER_MAX_CONCURRENT,
+// RUNNER_CGROUP and RUNNER_ALLOW_UNISOLATED, using the default limits
+func ConfigFromEnv() Config {
+	config := Config{
+		Python:          os.Getenv("RUNNER_PYTHON"),
+		Go:              os.Getenv("RUNNER_GO"),
+		Cgroup:          os.Getenv("RUNNER_CGROUP"),
+		AllowUnisolated: os.Getenv("RUNNER_ALLOW_UNISOLATED") == "true",
+		Limits:          DefaultLimits,
+	}
+	config.MaxConcurrent, _ = strconv.Atoi(os.Getenv("RUNNER_MAX_CONCURRENT"))
+	return config
+}
+
+// Program is a solution to run. Function names the solution function, or
+// method of class Solution in Python; when empty the only function taking as
+// many arguments as the cases have is used.
+type Program struct {
+	Language string
+	Code     string
+	Function string
+}
+
+// Case is one call of the solution. Cases without an expected value pass
+// unless the call fails.
+type Case struct {
+	Args       []json.RawMessage
+	Expected   json.RawMessage
+	TestCaseID int // set on a user's own test cases, copied to the result
+}
+
+type CaseResult struct {
+	Index      int             `json:"index"`
+	TestCaseID int             `json:"test_case_id,omitempty"`
+	Passed     bool            `json:"passed"`
+	Output     json.RawMessage `json:"output,omitempty"`
+	Expected   json.RawMessage `json:"expected,omitempty"`
+	Stdout     string          `json:"stdout"`
+	Error      string          `json:"error,omitempty"`
+	RuntimeMS  float64         `json:"runtime_ms"`
+}
+
+type Result struct {
+	Status    string       `json:"status"`
+	Passed    int          `json:"passed"`
+	Total     int          `json:"total"`
+	RuntimeMS float64      `json:"runtime_ms"` // sum over the cases that ran
+	Cases     []CaseResult `json:"cases"`
+	Output    string       `json:"output,omitempty"` // compiler errors and output outside the cases
+}
+
+type Runner struct {
+	config      Config
+	executable  string   // this binary, re-executed to enter the sandbox
+	python      string   // resolved interpreter, empty when not installed
+	pythonPaths []string // what the interpreter needs mounted in the sandbox
+	goTool      string
+	goEnv       []string // GOCACHE and GOPATH of the toolchain, shared by builds
+	namespaces  bool
+	cgroups     bool
+	slots       chan struct{}
+}
+
+// New checks the sandbox can be entered and finds the Python interpreter and
+// Go toolchain. A missing language is reported when it is run.
+func New(config Config) (*Runner, error) {
+	if !sandboxSupported() {
+		return nil, fmt.Errorf("%w: sandboxing requires Linux", ErrUnavailable)
+	}
+
+	if config.Limits == (Limits{}) {
+		config.Limits = DefaultLimits
+	}
+	if config.Limits.Processes <= 0 {
+		config.Limits.Processes = DefaultLimits.Processes
+	}
+	if config.Cgroup == "" {
+		config.Cgroup = DefaultCgroup
+	}
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = 2
+	}
+	if config.Python == "" {
+		config.Python = "python3"
+	}
+	if config.Go == "" {
+		config.Go = "go"
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	r := &Runner{
+		config:     config,
+		executable: executable,
+		slots:      make(chan struct{}, config.MaxConcurrent),
+	}
+
+	// rlimits are per process, a cgroup per run bounds all of its processes
+	if err := setupCgroupParent(config.Cgroup); err == nil {
+		r.cgroups = true
+	} else if !config.AllowUnisolated {
+		return nil, fmt.Errorf("%w: cannot create cgroups: %v", ErrUnavailable, err)
+	}
+
+	r.namespaces = true
+	if err := r.probe(); err != nil {
+		if !config.AllowUnisolated {
+			return nil, fmt.Errorf("%w: cannot create namespaces: %v", ErrUnavailable, err)
+		}
+		r.namespaces = false
+		if err := r.probe(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+	}
+
+	// pyenv style shims do not work with the sandbox environment, so run the
+	// interpreter they resolve to, and mount its installation
+	if out, err := exec.Command(config.Python, "-c", "import sys; print(sys.executable); print(sys.base_prefix); print(sys.prefix)").Output(); err == nil {
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		r.python = lines[0]
+		r.pythonPaths = existingPaths(append(append([]string{}, systemPaths...), lines[1:]...))
+	}
+	if path, err := exec.LookPath(config.Go); err == nil {
+		r.goTool = path
+		// builds get a minimal environment, so resolve the caches they keep
+		// sharing now
+		if out, err := exec.Command(path, "env", "GOCACHE", "GOPATH").Output(); err == nil {
+			if dirs := strings.Split(strings.TrimSpace(string(out)), "\n"); len(dirs) == 2 {
+				r.goEnv = []string{"GOCACHE=" + dirs[0], "GOPATH=" + dirs[1]}
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// Isolated reports whether solutions run in their own namespaces and cgroups
+func (r *Runner) Isolated() bool {
+	return r.namespaces && r.cgroups
+}
+
+// probe enters the sandbox without running anything
+func (r *Runner) probe() error {
+	dir, err := os.MkdirTemp("", "runner-probe-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	var cg *cgroup
+	if r.cgroups {
+		if cg, err = newCgroup(r.config.Cgroup, r.config.Limits); err != nil {
+			return err
+		}
+		defer cg.Close()
+	}
+
+	output := &limitedBuffer{limit: r.config.Limits.Output}
+	cmd := r.sandboxCommand(context.Background(), dir, cg, 0, nil)
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+// existingPaths drops the paths that do not exist and those inside another
+// of them
+func existingPaths(paths []string) []string {
+	var kept []string
+	for _, path := range paths {
+		if _, err := os.Lstat(path); err != nil || path == "" || !filepath.IsAbs(path) {
+			continue
+		}
+		inside := false
+		for _, other := range paths {
+			if other != path && strings.HasPrefix(path, strings.TrimSuffix(other, "/")+"/") {
+				inside = true
+			}
+		}
+		if !inside && !slices.Contains(kept, path) {
+			kept = append(kept, path)
+		}
+	}
+	return kept
+}
+
+// runSpec is what the harnesses read from spec.json
+type runSpec struct {
+	Function    string     `json:"function,omitempty"`
+	Arity       int        `json:"arity"`
+	OutputLimit int        `json:"output_limit"`
+	Cases       []specCase `json:"cases"`
+}
+
+type specCase struct {
+	Args []json.RawMessage `json:"args"`
+}
+
+// harnessResult is one line a harness writes per case, or a fatal error when
+// the solution cannot be loaded
+type harnessResult struct {
+	Output    json.RawMessage `json:"output,omitempty"`
+	Stdout    string          `json:"stdout"`
+	Error     string          `json:"error,omitempty"`
+	RuntimeMS float64         `json:"runtime_ms"`
+	Fatal     string          `json:"fatal,omitempty"`
+	// set by the supervisor on a case killed for using up its CPU time
+	TimeLimitExceeded bool `json:"time_limit_exceeded,omitempty"`
+}
+
+// compileError is a solution rejected before it ran
+type compileError struct {
+	output string
+}
+
+func (e *compileError) Error() string {
+	return e.output
+}
+
+// Run runs program once per case. Errors are reserved for the runner itself
+// failing; problems with the solution are reported in the result.
+func (r *Runner) Run(ctx context.Context, program Program, cases []Case) (Result, error) {
+	if !IsValidLanguage(program.Language) {
+		return Result{}, ErrUnsupportedLanguage
+	}
+	if len(cases) == 0 {
+		return Result{}, errors.New("no test cases to run")
+	}
+	arity := len(cases[0].Args)
+	for _, c := range cases {
+		if len(c.Args) != arity {
+			return Result{}, errors.New("test cases have different numbers of arguments")
+		}
+	}
+
+	select {
+	case r.slots <- struct{}{}:
+		defer func() { <-r.slots }()
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+
+	dir, err := os.MkdirTemp("", "runner-")
+	if err != nil {
+		return Result{}, fmt.Errorf("error creating run directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	spec := runSpec{Function: program.Function, Arity: arity, OutputLimit: r.config.Limits.Output}
+	for _, c := range cases {
+		spec.Cases = append(spec.Cases, specCase{Args: c.Args})
+	}
+	encoded, err := json.Marshal(spec)
+	if err != nil {
+		return Result{}, fmt.Errorf("error encoding test cases: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "spec.json"), encoded, 0o644); err != nil {
+		return Result{}, fmt.Errorf("error writing test cases: %v", err)
+	}
+
+	var argv, binds []string
+	switch program.Language {
+	case LanguagePython:
+		argv, err = r.preparePython(dir, program)
+		binds = r.pythonPaths
+	case LanguageGo:
+		argv, err = r.prepareGo(ctx, dir, program, arity)
+	}
+	var compileErr *compileError
+	if errors.As(err, &compileErr) {
+		return Result{Status: StatusCompileError, Total: len(cases), Cases: []CaseResult{}, Output: compileErr.output}, nil
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	return r.execute(ctx, dir, argv, binds, cases)
+}
+
+// sandboxCommand re-executes this binary to run argv once per case in the
+// sandbox, with binds mounted read-only, or just to enter it when argv is
+// empty
+func (r *Runner) sandboxCommand(ctx context.Context, dir string, cg *cgroup, cases int, binds []string, argv ...string) *exec.Cmd {
+	limits := r.config.Limits
+	args := []string{
+		SandboxCommand,
+		"-cpu", strconv.Itoa(int(math.Ceil(limits.CPUTime.Seconds()))),
+		"-memory", strconv.FormatInt(limits.Memory, 10),
+		"-fsize", strconv.Itoa(limits.Output),
+		"-dir", dir,
+		"-cases", strconv.Itoa(cases),
+	}
+	if r.namespaces {
+		args = append(args, "-isolated")
+	}
+	for _, path := range binds {
+		args = append(args, "-bind", path)
+	}
+	args = append(args, "--")
+
+	cmd := exec.CommandContext(ctx, r.executable, append(args, argv...)...)
+	cmd.Dir = dir
+	cmd.Env = []string{
+		"PATH=/usr/local/bin:/usr/bin:/bin",
+		"HOME=" + dir,
+		"TMPDIR=" + dir,
+		"LANG=C.UTF-8",
+		"PYTHONDONTWRITEBYTECODE=1",
+		"PYTHONUNBUFFERED=1",
+	}
+	cmd.SysProcAttr = sandboxAttr(r.namespaces, cg)
+	cmd.Cancel = func() error {
+		return killSandbox(cmd.Process)
+	}
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
+func (r *Runner) execute(ctx context.Context, dir string, argv, binds []string, cases []Case) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.Limits.WallTime)
+	defer cancel()
+
+	var cg *cgroup
+	if r.cgroups {
+		var err error
+		if cg, err = newCgroup(r.config.Cgroup, r.config.Limits); err != nil {
+			return Result{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		// kills whatever the solution left running
+		defer cg.Close()
+	}
+
+	// the supervisor reports the results on descriptor 3 so the solution's
+	// own output cannot corrupt them; each case has a process and descriptor
+	// of its own, so a solution cannot report on other cases
+	resultsReader, resultsWriter, err := os.Pipe()
+	if err != nil {
+		return Result{}, fmt.Errorf("error creating results pipe: %v", err)
+	}
+	defer resultsReader.Close()
+
+	output := &limitedBuffer{limit: r.config.Limits.Output}
+	cmd := r.sandboxCommand(ctx, dir, cg, len(cases), binds, argv...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.ExtraFiles = []*os.File{resultsWriter}
+
+	if err := cmd.Start(); err != nil {
+		resultsWriter.Close()
+		return Result{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	resultsWriter.Close()
+
+	type readResults struct {
+		results []harnessResult
+		err     error
+	}
+	read := make(chan readResults, 1)
+	go func() {
+		var res readResults
+		limit := int64(len(cases)) * int64(4*r.config.Limits.Output+1024)
+		decoder := json.NewDecoder(io.LimitReader(resultsReader, limit))
+		for {
+			var result harnessResult
+			if err := decoder.Decode(&result); err != nil {
+				if err != io.EOF {
+					res.err = err
+				}
+				break
+			}
+			res.results = append(res.results, result)
+		}
+		read <- res
+	}()
+
+	waitErr := cmd.Wait()
+	if !r.namespaces {
+		// end what the solution left running, which may hold the results
+		// pipe open; in a PID namespace it died with the supervisor
+		killSandbox(cmd.Process)
+		if cg != nil {
+			cg.kill()
+		}
+	}
+	res := <-read
+
+	timedOut := ctx.Err() == context.DeadlineExceeded
+	for _, reported := range res.results {
+		timedOut = timedOut || reported.TimeLimitExceeded
+	}
+	if ctx.Err() == context.Canceled {
+		return Result{}, ctx.Err()
+	}
+
+	if len(res.results) > 0 && res.results[0].Fatal != "" {
+		return Result{Status: StatusCompileError, Total: len(cases), Cases: []CaseResult{}, Output: res.results[0].Fatal}, nil
+	}
+
+	result := grade(cases, res.results)
+	result.Output = output.String()
+
+	switch {
+	case timedOut:
+		result.Status = StatusTimeLimitExceeded
+	case result.Status == StatusAccepted && (waitErr != nil || res.err != nil || len(res.results) < len(cases)):
+		// the solution ended the process, e.g. by exiting or running out of
+		// memory, before reporting every case
+		result.Status = StatusRuntimeError
+	}
+
+	for i := len(res.results); i < len(cases); i++ {
+		if timedOut {
+			result.Cases[i].Error = "time limit exceeded"
+		} else {
+			result.Cases[i].Error = "not run, the process ended early"
+		}
+	}
+
+	return result, nil
+}
+
+// grade compares the results reported by the harness with the expected
+// values. Cases without a result fail.
+func grade(cases []Case, results []harnessResult) Result {
+	result := Result{Status: StatusAccepted, Total: len(cases)}
+
+	for i, c := range cases {
+		cr := CaseResult{Index: i, TestCaseID: c.TestCaseID, Expected: c.Expected}
+		if i < len(results) {
+			reported := results[i]
+			cr.Stdout = reported.Stdout
+			cr.Error = reported.Error
+			cr.RuntimeMS = reported.RuntimeMS
+			if len(reported.Output) > 0 {
+				cr.Output = reported.Output
+			}
+			cr.Passed = cr.Error == "" && (c.Expected == nil || EqualJSON(cr.Output, c.Expected))
+			result.RuntimeMS += cr.RuntimeMS
+		}
+
+		if cr.Passed {
+			result.Passed++
+		} else if i < len(results) {
+			switch {
+			case cr.Error != "":
+				result.Status = StatusRuntimeError
+			case result.Status == StatusAccepted:
+				result.Status = StatusWrongAnswer
+			}
+		}
+
+		result.Cases = append(result.Cases, cr)
+	}
+
+	return result
+}
+
+// EqualJSON compares two JSON values structurally, allowing numbers to differ
+// by the 1e-5 LeetCode accepts for floating point answers. It is how outputs
+// are checked against expected values.
+func EqualJSON(a, b json.RawMessage) bool {
+	if a == nil {
+		a = json.RawMessage("null")
+	}
+	var left, right interface{}
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		return bytes.Equal(a, b)
+	}
+	return equalValues(left, right)
+}
+
+func equalValues(a, b interface{}) bool {
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		return ok && math.Abs(x-y) <= 1e-5*math.Max(1, math.Abs(y))
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equalValues(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			if other, ok := y[key]; !ok || !equalValues(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the
+// rest, so a solution printing in a loop cannot exhaust the server's memory
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "\n[output truncated]"
+	}
+	return b.buf.String()
+}
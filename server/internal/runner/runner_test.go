@@ -0,0 +1,360 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary serve as the sandbox, as the server does
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == SandboxCommand {
+		os.Exit(SandboxMain(os.Args[2:]))
+	}
+	os.Exit(m.Run())
+}
+
+func twoSumCases() []Case {
+	return []Case{
+		{Args: []json.RawMessage{json.RawMessage("[2,7,11,15]"), json.RawMessage("9")}, Expected: json.RawMessage("[0,1]")},
+		{Args: []json.RawMessage{json.RawMessage("[3,2,4]"), json.RawMessage("6")}, Expected: json.RawMessage("[1,2]")},
+	}
+}
+
+func newTestRunner(t *testing.T) *Runner {
+	t.Helper()
+	config := Config{Limits: Limits{WallTime: 5 * time.Second, CPUTime: 2 * time.Second, Memory: 512 << 20, Output: 1 << 10}}
+	r, err := New(config)
+	if errors.Is(err, ErrUnavailable) {
+		// hosts without a delegated cgroup v2 can still test the namespaces
+		config.AllowUnisolated = true
+		r, err = New(config)
+	}
+	if errors.Is(err, ErrUnavailable) {
+		t.Skipf("sandbox unavailable: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return r
+}
+
+func TestRunPython(t *testing.T) {
+	r := newTestRunner(t)
+	if r.python == "" {
+		t.Skip("python is not installed")
+	}
+
+	tests := []struct {
+		name   string
+		code   string
+		status string
+		passed int
+	}{
+		{
+			name: "accepted",
+			code: `class Solution:
+    def twoSum(self, nums: List[int], target: int) -> List[int]:
+        print("looking for", target)
+        seen = {}
+        for i, n in enumerate(nums):
+            if target - n in seen:
+                return [seen[target - n], i]
+            seen[n] = i
+`,
+			status: StatusAccepted,
+			passed: 2,
+		},
+		{
+			name: "wrong answer",
+			code: `class Solution:
+    def twoSum(self, nums, target):
+        return [0, 1]
+`,
+			status: StatusWrongAnswer,
+			passed: 1,
+		},
+		{
+			name: "runtime error",
+			code: `class Solution:
+    def twoSum(self, nums, target):
+        return nums[10]
+`,
+			status: StatusRuntimeError,
+		},
+		{
+			name:   "syntax error",
+			code:   "class Solution:\n    def twoSum(self, nums, target)\n",
+			status: StatusCompileError,
+		},
+		{
+			name: "time limit",
+			code: `class Solution:
+    def twoSum(self, nums, target):
+        while True:
+            pass
+`,
+			status: StatusTimeLimitExceeded,
+		},
+		{
+			name: "memory limit",
+			code: `class Solution:
+    def twoSum(self, nums, target):
+        return len(bytearray(1 << 30))
+`,
+			status: StatusRuntimeError,
+		},
+		{
+			name: "no network",
+			code: `import socket
+class Solution:
+    def twoSum(self, nums, target):
+        socket.create_connection(("1.1.1.1", 53), timeout=1)
+        return [0, 1]
+`,
+			status: StatusRuntimeError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.name == "no network" && !r.namespaces {
+				t.Skip("network isolation needs namespaces")
+			}
+
+			result, err := r.Run(context.Background(), Program{Language: LanguagePython, Code: tt.code}, twoSumCases())
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if result.Status != tt.status || result.Passed != tt.passed {
+				t.Errorf("expected %s with %d passed, got %s with %d passed: %+v", tt.status, tt.passed, result.Status, result.Passed, result)
+			}
+		})
+	}
+}
+
+func TestRunPythonStdout(t *testing.T) {
+	r := newTestRunner(t)
+	if r.python == "" {
+		t.Skip("python is not installed")
+	}
+
+	code := "def add(a, b):\n    print(a)\n    return a + b\n"
+	cases := []Case{
+		{Args: []json.RawMessage{json.RawMessage("1"), json.RawMessage("2")}, Expected: json.RawMessage("3")},
+		{Args: []json.RawMessage{json.RawMessage("0.1"), json.RawMessage("0.2")}, Expected: json.RawMessage("0.3")},
+	}
+
+	result, err := r.Run(context.Background(), Program{Language: LanguagePython, Code: code}, cases)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Status != StatusAccepted {
+		t.Fatalf("expected accepted, got %+v", result)
+	}
+	if result.Cases[0].Stdout != "1\n" || result.Cases[1].Stdout != "0.1\n" {
+		t.Errorf("stdout was not captured per case: %+v", result.Cases)
+	}
+}
+
+func TestRunPythonFilesystem(t *testing.T) {
+	r := newTestRunner(t)
+	if r.python == "" {
+		t.Skip("python is not installed")
+	}
+	if !r.namespaces {
+		t.Skip("filesystem isolation needs namespaces")
+	}
+
+	server, err := filepath.Abs("runner.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := `def can_open(path, mode):
+    try:
+        open(path, mode).close()
+        return True
+    except OSError:
+        return False
+`
+	args := func(path, mode string) []json.RawMessage {
+		encoded, _ := json.Marshal([]string{path, mode})
+		var list []json.RawMessage
+		json.Unmarshal(encoded, &list)
+		return list
+	}
+	cases := []Case{
+		{Args: args(server, "r"), Expected: json.RawMessage("false")},
+		{Args: args("/etc/passwd", "r"), Expected: json.RawMessage("false")},
+		{Args: args("/proc/1/environ", "r"), Expected: json.RawMessage("false")},
+		{Args: args(filepath.Join(os.TempDir(), "escaped"), "w"), Expected: json.RawMessage("false")},
+		{Args: args("spec.json", "r"), Expected: json.RawMessage("true")},
+		{Args: args("scratch.txt", "w"), Expected: json.RawMessage("true")},
+	}
+
+	result, err := r.Run(context.Background(), Program{Language: LanguagePython, Code: code}, cases)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Status != StatusAccepted {
+		t.Errorf("expected only the run directory to be reachable, got %+v", result)
+	}
+}
+
+func TestRunPythonForgedResults(t *testing.T) {
+	r := newTestRunner(t)
+	if r.python == "" {
+		t.Skip("python is not installed")
+	}
+
+	// reports the right answers for both cases, then exits before the harness
+	// runs anything
+	code := `import os
+os.write(3, b'{"output": 3}\n{"output": 4}\n')
+os._exit(0)
+def add(a, b):
+    return 0
+`
+	cases := []Case{
+		{Args: []json.RawMessage{json.RawMessage("1"), json.RawMessage("2")}, Expected: json.RawMessage("3")},
+		{Args: []json.RawMessage{json.RawMessage("2"), json.RawMessage("2")}, Expected: json.RawMessage("4")},
+	}
+
+	result, err := r.Run(context.Background(), Program{Language: LanguagePython, Code: code}, cases)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Status == StatusAccepted || result.Passed != 1 {
+		t.Errorf("expected the forged results to cover only their own case, got %+v", result)
+	}
+}
+
+func TestRunPythonProcessLimit(t *testing.T) {
+	r := newTestRunner(t)
+	if r.python == "" {
+		t.Skip("python is not installed")
+	}
+	if !r.cgroups {
+		t.Skip("the process limit needs cgroups")
+	}
+
+	code := `import os, time
+def fork():
+    forked = 0
+    try:
+        while True:
+            if os.fork() == 0:
+                time.sleep(60)
+                os._exit(0)
+            forked += 1
+    except OSError:
+        return forked
+`
+	result, err := r.Run(context.Background(), Program{Language: LanguagePython, Code: code}, []Case{{}})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	var forked int
+	if err := json.Unmarshal(result.Cases[0].Output, &forked); err != nil || forked >= r.config.Limits.Processes {
+		t.Errorf("expected forking to stop below %d processes, got %+v", r.config.Limits.Processes, result)
+	}
+}
+
+func TestRunGo(t *testing.T) {
+	r := newTestRunner(t)
+	if r.goTool == "" {
+		t.Skip("go is not installed")
+	}
+
+	code := `func twoSum(nums []int, target int) []int {
+	seen := map[int]int{}
+	for i, n := range nums {
+		if j, ok := seen[target-n]; ok {
+			return []int{j, i}
+		}
+		seen[n] = i
+	}
+	return nil
+}
+`
+	result, err := r.Run(context.Background(), Program{Language: LanguageGo, Code: code}, twoSumCases())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Status != StatusAccepted || result.Passed != 2 {
+		t.Errorf("expected accepted, got %+v", result)
+	}
+
+	result, err = r.Run(context.Background(), Program{Language: LanguageGo, Code: "func twoSum(nums []int, target int) []int {\n\treturn nums[:target]\n}\n"}, twoSumCases())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Status != StatusRuntimeError || !strings.Contains(result.Cases[0].Error, "panic") {
+		t.Errorf("expected a panic, got %+v", result)
+	}
+
+	result, err = r.Run(context.Background(), Program{Language: LanguageGo, Code: "func twoSum(nums []int, target int) []int {\n\treturn undefined\n}\n"}, twoSumCases())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Status != StatusCompileError || !strings.Contains(result.Output, "undefined") {
+		t.Errorf("expected a compile error, got %+v", result)
+	}
+}
+
+func TestGoFunction(t *testing.T) {
+	src := `package main
+
+func helper(x int) int { return x }
+
+func search(nums []int, target int) int { return helper(target) }
+
+func rotate(matrix [][]int) {}
+`
+	sig, err := goFunction(src, "", 2)
+	if err != nil {
+		t.Fatalf("goFunction failed: %v", err)
+	}
+	if sig.Func != "search" || strings.Join(sig.Params, ";") != "[]int;int" || sig.Args != "arg0, arg1" || !sig.Returns {
+		t.Errorf("unexpected signature: %+v", sig)
+	}
+
+	sig, err = goFunction(src, "rotate", 1)
+	if err != nil || sig.Returns || sig.Params[0] != "[][]int" {
+		t.Errorf("unexpected signature %+v, error %v", sig, err)
+	}
+
+	if _, err := goFunction(src, "", 1); err == nil {
+		t.Error("expected an error for two functions taking one argument")
+	}
+	if _, err := goFunction(src, "missing", 1); err == nil {
+		t.Error("expected an error for an undefined function")
+	}
+}
+
+func TestEqualJSON(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		equal bool
+	}{
+		{"[0,1]", "[0, 1]", true},
+		{"[0,1]", "[1,0]", false},
+		{"2.00000", "2", true},
+		{"0.30000000000000004", "0.3", true},
+		{"0.3001", "0.3", false},
+		{`{"a":[1]}`, `{"a": [1]}`, true},
+		{`"abc"`, `"abd"`, false},
+		{"null", "[]", false},
+	}
+
+	for _, tt := range tests {
+		if got := EqualJSON(json.RawMessage(tt.a), json.RawMessage(tt.b)); got != tt.equal {
+			t.Errorf("EqualJSON(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.equal)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// SandboxCommand is the hidden subcommand the runner re-executes this binary
+// with to enter the sandbox
+const SandboxCommand = "sandbox-exec"
+
+// nobody is the user solutions run as inside their own user namespace, mapped
+// to the supervisor's root and so to the server's user outside of it
+const nobody = 65534
+
+// sandboxDevices are the only devices solutions can open
+var sandboxDevices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+// inheritedMountFlags are the flags of a bind mount's source that a user
+// namespace may not clear when remounting it
+const inheritedMountFlags = syscall.MS_RDONLY | syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC |
+	syscall.MS_NOATIME | syscall.MS_NODIRATIME | syscall.MS_RELATIME
+
+func sandboxSupported() bool {
+	return true
+}
+
+func sandboxAttr(isolated bool, cg *cgroup) *syscall.SysProcAttr {
+	// the process group lets killSandbox reach everything the solution forked
+	// when it is not in its own PID namespace
+	attr := &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGKILL}
+	if cg != nil {
+		// started inside the run's cgroup, so no process escapes its limits
+		attr.UseCgroupFD = true
+		attr.CgroupFD = int(cg.dir.Fd())
+	}
+	if !isolated {
+		return attr
+	}
+
+	// root of the namespaces, to set up the filesystem; solutions run in a
+	// nested user namespace without its capabilities
+	attr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWPID | syscall.CLONE_NEWNET |
+		syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS | syscall.CLONE_NEWNS
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+	attr.GidMappingsEnableSetgroups = false
+	return attr
+}
+
+// cpuLimitExceeded reports a process that used up its CPU time, which
+// RLIMIT_CPU ends with SIGXCPU at the soft limit and SIGKILL at the hard one.
+// Go programs crash on SIGXCPU rather than dying of it, hence the usage.
+func cpuLimitExceeded(state *os.ProcessState, seconds uint64) bool {
+	if state == nil {
+		return false
+	}
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() && status.Signal() == syscall.SIGXCPU {
+		return true
+	}
+	used := state.UserTime() + state.SystemTime()
+	return seconds > 0 && used >= time.Duration(seconds)*time.Second*9/10
+}
+
+// killSandbox kills the sandboxed process and everything it started. In a
+// PID namespace killing its init is enough, the group covers the fallback.
+func killSandbox(process *os.Process) error {
+	if err := syscall.Kill(-process.Pid, syscall.SIGKILL); err != nil {
+		return process.Kill()
+	}
+	return nil
+}
+
+// limitData sets RLIMIT_DATA of a running process, which the processes it
+// starts afterwards inherit
+func limitData(pid int, bytes uint64) error {
+	limit := syscall.Rlimit{Cur: bytes, Max: bytes}
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), syscall.RLIMIT_DATA, uintptr(unsafe.Pointer(&limit)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// pathList is a flag that can be repeated
+type pathList []string
+
+func (l *pathList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *pathList) Set(path string) error {
+	*l = append(*l, path)
+	return nil
+}
+
+// SandboxMain implements SandboxCommand. It is the supervisor of a run: in
+// the namespaces of the sandbox it replaces the root filesystem with one
+// holding only the -bind paths, read-only, and the run directory, applies the
+// limits passed as flags and then runs the program after "--" once per case,
+// passing the case's index as the last argument. Each case reports its result
+// on descriptor 3 of its own process; the supervisor forwards them on its
+// descriptor 3, so a solution can only ever speak for the case it runs.
+// Without a program it only reports that the sandbox could be entered.
+//
+// Limits set here are per process. The cgroup the runner starts the sandbox
+// in bounds the run as a whole.
+func SandboxMain(args []string) int {
+	flags := flag.NewFlagSet(SandboxCommand, flag.ContinueOnError)
+	cpu := flags.Uint64("cpu", 0, "CPU seconds per case")
+	memory := flags.Uint64("memory", 0, "data segment and heap in bytes")
+	fsize := flags.Uint64("fsize", 0, "largest file written in bytes")
+	isolated := flags.Bool("isolated", false, "replace the root filesystem, in the sandbox's namespaces")
+	dir := flags.String("dir", "", "run directory, the only writable one")
+	cases := flags.Int("cases", 0, "number of cases to run the program for")
+	var binds pathList
+	flags.Var(&binds, "bind", "path to mount read-only, repeatable")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// the results descriptor stays with the supervisor
+	syscall.CloseOnExec(3)
+
+	if *isolated {
+		if err := enterRoot(*dir, binds); err != nil {
+			fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+			return 126
+		}
+	}
+
+	program := flags.Args()
+	if len(program) == 0 {
+		return 0
+	}
+
+	// solutions run as the same host user; without this they could reach the
+	// results descriptor through /proc/1/fd or ptrace
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_SET_DUMPABLE, 0, 0); errno != 0 {
+		fmt.Fprintf(os.Stderr, "sandbox: cannot protect the supervisor: %v\n", errno)
+		return 126
+	}
+
+	limits := []struct {
+		resource int
+		limit    syscall.Rlimit
+	}{
+		// SIGXCPU at the soft limit, SIGKILL a second later if it is ignored
+		{syscall.RLIMIT_CPU, syscall.Rlimit{Cur: *cpu, Max: *cpu + 1}},
+		// RLIMIT_DATA rather than RLIMIT_AS, which the Go runtime exceeds by
+		// reserving address space it never uses
+		{syscall.RLIMIT_DATA, syscall.Rlimit{Cur: *memory, Max: *memory}},
+		{syscall.RLIMIT_FSIZE, syscall.Rlimit{Cur: *fsize, Max: *fsize}},
+		{syscall.RLIMIT_CORE, syscall.Rlimit{}},
+		{syscall.RLIMIT_NOFILE, syscall.Rlimit{Cur: 64, Max: 64}},
+	}
+	for _, l := range limits {
+		if l.limit.Max == 0 && l.resource != syscall.RLIMIT_CORE {
+			continue
+		}
+		if err := syscall.Setrlimit(l.resource, &l.limit); err != nil {
+			fmt.Fprintf(os.Stderr, "sandbox: cannot set resource limit %d: %v\n", l.resource, err)
+			return 126
+		}
+	}
+
+	path, err := exec.LookPath(program[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		return 127
+	}
+
+	// room for a case's output, stdout and error, as the runner allows
+	resultLimit := 4*int64(*fsize) + 1024
+	results := json.NewEncoder(os.NewFile(3, "results"))
+	for i := 0; i < *cases; i++ {
+		result := runCase(path, program, i, *isolated, *cpu, resultLimit)
+		if err := results.Encode(result); err != nil {
+			fmt.Fprintf(os.Stderr, "sandbox: cannot report results: %v\n", err)
+			return 1
+		}
+		// a solution that cannot be loaded or runs out of time fails every
+		// other case the same way
+		if result.Fatal != "" || result.TimeLimitExceeded {
+			break
+		}
+	}
+	return 0
+}
+
+// runCase runs the program for one case and returns the result it reported,
+// or one describing how it ended without reporting
+func runCase(path string, program []string, index int, isolated bool, cpuSeconds uint64, resultLimit int64) harnessResult {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return harnessResult{Error: fmt.Sprintf("cannot create results pipe: %v", err)}
+	}
+	defer reader.Close()
+
+	cmd := &exec.Cmd{
+		Path:       path,
+		Args:       append(append([]string{}, program...), strconv.Itoa(index)),
+		Env:        os.Environ(),
+		Stdout:     os.Stdout,
+		Stderr:     os.Stderr,
+		ExtraFiles: []*os.File{writer},
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+	if isolated {
+		// a user namespace of its own as nobody, without the capabilities
+		// the supervisor set up the mounts with
+		cmd.SysProcAttr.Cloneflags = syscall.CLONE_NEWUSER
+		cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: nobody, HostID: 0, Size: 1}}
+		cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: nobody, HostID: 0, Size: 1}}
+		cmd.SysProcAttr.GidMappingsEnableSetgroups = false
+	}
+
+	if err := cmd.Start(); err != nil {
+		writer.Close()
+		return harnessResult{Error: fmt.Sprintf("cannot start the solution: %v", err)}
+	}
+	writer.Close()
+
+	type decoded struct {
+		result harnessResult
+		ok     bool
+	}
+	read := make(chan decoded, 1)
+	go func() {
+		var result harnessResult
+		err := json.NewDecoder(io.LimitReader(reader, resultLimit)).Decode(&result)
+		read <- decoded{result: result, ok: err == nil}
+	}()
+
+	waitErr := cmd.Wait()
+	if isolated {
+		killLeftovers()
+	}
+	// what the case reported is in the pipe by now; do not wait for anything
+	// it left holding the pipe open
+	reader.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if res := <-read; res.ok {
+		return res.result
+	}
+
+	if cpuLimitExceeded(cmd.ProcessState, cpuSeconds) {
+		return harnessResult{Error: "time limit exceeded", TimeLimitExceeded: true}
+	}
+	if waitErr == nil {
+		waitErr = errors.New("exit status 0")
+	}
+	return harnessResult{Error: fmt.Sprintf("the process ended without a result: %v", waitErr)}
+}
+
+// killLeftovers ends every process a case left behind and reaps them. The
+// supervisor is init of the sandbox's PID namespace, which spares it.
+func killLeftovers() {
+	if os.Getpid() != 1 {
+		return
+	}
+	syscall.Kill(-1, syscall.SIGKILL)
+	for {
+		var status syscall.WaitStatus
+		if _, err := syscall.Wait4(-1, &status, 0, nil); err != syscall.EINTR && err != nil {
+			return
+		}
+	}
+}
+
+// enterRoot replaces the root filesystem of the sandbox's mount namespace with
+// a read-only tmpfs holding the binds, a few devices, a fresh /proc of the
+// sandbox's PID namespace and the run directory, the only writable path. The
+// host's root is detached, so nothing else of it, such as the server's
+// configuration, can be opened afterwards.
+func enterRoot(dir string, binds []string) error {
+	if dir == "" {
+		return errors.New("no run directory")
+	}
+	// keep the mounts below from propagating to the host
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("error making mounts private: %v", err)
+	}
+
+	root, err := os.MkdirTemp(dir, ".root-")
+	if err != nil {
+		return fmt.Errorf("error creating root: %v", err)
+	}
+	if err := syscall.Mount("tmpfs", root, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=0755,size=1m"); err != nil {
+		return fmt.Errorf("error mounting root: %v", err)
+	}
+
+	// parents before the paths inside them
+	binds = append([]string{}, binds...)
+	sort.Slice(binds, func(i, j int) bool { return len(binds[i]) < len(binds[j]) })
+	for _, path := range binds {
+		if err := bindInto(root, path, true); err != nil {
+			return err
+		}
+	}
+	for _, device := range sandboxDevices {
+		if err := bindInto(root, device, false); err != nil {
+			return err
+		}
+	}
+	if err := bindInto(root, dir, false); err != nil {
+		return err
+	}
+
+	proc := filepath.Join(root, "proc")
+	if err := os.MkdirAll(proc, 0o555); err != nil {
+		return fmt.Errorf("error creating /proc: %v", err)
+	}
+	if err := syscall.Mount("proc", proc, "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("error mounting /proc: %v", err)
+	}
+
+	if err := syscall.Chdir(root); err != nil {
+		return fmt.Errorf("error entering root: %v", err)
+	}
+	// the old root ends up stacked on the new one, from where it is detached
+	if err := syscall.PivotRoot(".", "."); err != nil {
+		return fmt.Errorf("error pivoting root: %v", err)
+	}
+	if err := syscall.Unmount(".", syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("error detaching the host's root: %v", err)
+	}
+	if err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_BIND|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("error making root read-only: %v", err)
+	}
+	if err := syscall.Chdir(dir); err != nil {
+		return fmt.Errorf("error entering run directory: %v", err)
+	}
+	return nil
+}
+
+// bindInto mounts path at the same place below root, read-only when asked.
+// Symlinks, such as /lib on merged /usr systems, are copied instead.
+func bindInto(root, path string, readOnly bool) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("error binding %s: %v", path, err)
+	}
+
+	target := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("error binding %s: %v", path, err)
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(path)
+		if err == nil {
+			err = os.Symlink(link, target)
+		}
+		if err != nil {
+			return fmt.Errorf("error linking %s: %v", path, err)
+		}
+		return nil
+	case info.IsDir():
+		err = os.MkdirAll(target, 0o755)
+	default:
+		err = os.WriteFile(target, nil, 0o644)
+	}
+	if err != nil {
+		return fmt.Errorf("error binding %s: %v", path, err)
+	}
+
+	if err := syscall.Mount(path, target, "", syscall.MS_BIND, ""); err != nil {
+		return fmt.Errorf("error binding %s: %v", path, err)
+	}
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return fmt.Errorf("error binding %s: %v", path, err)
+	}
+	flags := uintptr(syscall.MS_BIND|syscall.MS_REMOUNT|syscall.MS_NOSUID) | uintptr(stat.Flags)&inheritedMountFlags
+	if readOnly {
+		flags |= syscall.MS_RDONLY
+	}
+	if err := syscall.Mount("", target, "", flags, ""); err != nil {
+		return fmt.Errorf("error remounting %s: %v", path, err)
+	}
+	return nil
+}
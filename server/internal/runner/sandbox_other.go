@@ -0,0 +1,54 @@
+//go:build !linux
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// SandboxCommand is the hidden subcommand the runner re-executes this binary
+// with to enter the sandbox
+const SandboxCommand = "sandbox-exec"
+
+// cgroup stands in for the cgroups of Linux
+type cgroup struct{}
+
+func sandboxSupported() bool {
+	return false
+}
+
+func sandboxAttr(isolated bool, cg *cgroup) *syscall.SysProcAttr {
+	return nil
+}
+
+func killSandbox(process *os.Process) error {
+	return process.Kill()
+}
+
+func limitData(pid int, bytes uint64) error {
+	return errors.New("rlimits require Linux")
+}
+
+func setupCgroupParent(parent string) error {
+	return errors.New("cgroups require Linux")
+}
+
+func newCgroup(parent string, limits Limits) (*cgroup, error) {
+	return nil, errors.New("cgroups require Linux")
+}
+
+func (c *cgroup) kill() {}
+
+func (c *cgroup) Close() error {
+	return nil
+}
+
+// SandboxMain implements SandboxCommand, which needs Linux namespaces and
+// rlimits
+func SandboxMain(args []string) int {
+	fmt.Fprintln(os.Stderr, "sandbox: the code runner requires Linux")
+	return 1
+}
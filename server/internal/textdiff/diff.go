@@ -0,0 +1,261 @@
+// Package textdiff computes line diffs between two texts, for showing what
+// changed between note revisions and how recalled code differs from a
+// reference solution.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Line operations
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// maxCells bounds the size of the LCS table a diff walks; larger inputs are
+// diffed as one deletion followed by one insertion of everything between their
+// common prefix and suffix. LCSLength needs no table and has no bound.
+const maxCells = 4 << 20
+
+// Line is one line of a diff. OldLine and NewLine are 1-based line numbers in
+// each text, 0 when the line is not in it.
+type Line struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// Diff is the line diff of two texts
+type Diff struct {
+	Lines   []Line `json:"lines"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
+// Changed reports whether the texts differ
+func (d Diff) Changed() bool {
+	return d.Added > 0 || d.Removed > 0
+}
+
+// Compute diffs old against new line by line, keeping the longest common
+// subsequence of lines as equal
+func Compute(old, new string) Diff {
+	return CompareLines(splitLines(old), splitLines(new))
+}
+
+// CompareLines diffs two texts already split into lines, or any other
+// sequences of tokens
+func CompareLines(a, b []string) Diff {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var d Diff
+	oldLine, newLine := 1, 1
+	equal := func(text string) {
+		d.Lines = append(d.Lines, Line{Op: OpEqual, Text: text, OldLine: oldLine, NewLine: newLine})
+		oldLine++
+		newLine++
+	}
+	remove := func(text string) {
+		d.Lines = append(d.Lines, Line{Op: OpDelete, Text: text, OldLine: oldLine})
+		d.Removed++
+		oldLine++
+	}
+	insert := func(text string) {
+		d.Lines = append(d.Lines, Line{Op: OpInsert, Text: text, NewLine: newLine})
+		d.Added++
+		newLine++
+	}
+
+	for _, text := range a[:prefix] {
+		equal(text)
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(midA)+1)*(len(midB)+1) > maxCells {
+		for _, text := range midA {
+			remove(text)
+		}
+		for _, text := range midB {
+			insert(text)
+		}
+	} else {
+		lcsWalk(midA, midB, equal, remove, insert)
+	}
+
+	for _, text := range a[len(a)-suffix:] {
+		equal(text)
+	}
+
+	if d.Lines == nil {
+		d.Lines = []Line{}
+	}
+	return d
+}
+
+// LCSLength is the length of the longest common subsequence of a and b. It
+// keeps one row of the LCS table, so unlike a Diff it is exact for inputs of
+// any size.
+func LCSLength(a, b []string) int {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(midB) > len(midA) {
+		midA, midB = midB, midA
+	}
+
+	// row[j] is the LCS length of the lines of midA seen so far and midB[:j]
+	row := make([]int32, len(midB)+1)
+	for _, line := range midA {
+		var diagonal int32
+		for j := range midB {
+			above := row[j+1]
+			if line == midB[j] {
+				row[j+1] = diagonal + 1
+			} else if row[j] > above {
+				row[j+1] = row[j]
+			}
+			diagonal = above
+		}
+	}
+
+	return prefix + suffix + int(row[len(midB)])
+}
+
+// lcsWalk emits the lines of a and b in order, deletions before insertions
+// within each changed run
+func lcsWalk(a, b []string, equal, remove, insert func(string)) {
+	// lengths[i][j] is the LCS length of a[i:] and b[j:]
+	width := len(b) + 1
+	lengths := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i*width+j] = lengths[(i+1)*width+j+1] + 1
+			} else if lengths[(i+1)*width+j] >= lengths[i*width+j+1] {
+				lengths[i*width+j] = lengths[(i+1)*width+j]
+			} else {
+				lengths[i*width+j] = lengths[i*width+j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			equal(a[i])
+			i++
+			j++
+		case lengths[(i+1)*width+j] >= lengths[i*width+j+1]:
+			remove(a[i])
+			i++
+		default:
+			insert(b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		remove(a[i])
+	}
+	for ; j < len(b); j++ {
+		insert(b[j])
+	}
+}
+
+// Unified formats the diff like diff -u, with context equal lines around each
+// change, without file headers
+func (d Diff) Unified(context int) string {
+	var sb strings.Builder
+
+	for start := 0; start < len(d.Lines); {
+		// find the next change
+		for start < len(d.Lines) && d.Lines[start].Op == OpEqual {
+			start++
+		}
+		if start == len(d.Lines) {
+			break
+		}
+
+		// grow the hunk until a run of equal lines longer than twice the
+		// context separates it from the next change
+		from := max(start-context, 0)
+		end := start
+		for end < len(d.Lines) {
+			if d.Lines[end].Op != OpEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(d.Lines) && d.Lines[run].Op == OpEqual {
+				run++
+			}
+			if run == len(d.Lines) || run-end > 2*context {
+				end = min(end+context, len(d.Lines))
+				break
+			}
+			end = run
+		}
+
+		hunk := d.Lines[from:end]
+		oldStart, oldCount, newStart, newCount := 0, 0, 0, 0
+		for _, line := range hunk {
+			if line.OldLine > 0 {
+				if oldStart == 0 {
+					oldStart = line.OldLine
+				}
+				oldCount++
+			}
+			if line.NewLine > 0 {
+				if newStart == 0 {
+					newStart = line.NewLine
+				}
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, line := range hunk {
+			switch line.Op {
+			case OpEqual:
+				sb.WriteString(" ")
+			case OpDelete:
+				sb.WriteString("-")
+			case OpInsert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(line.Text)
+			sb.WriteString("\n")
+		}
+
+		start = end
+	}
+
+	return sb.String()
+}
+
+// splitLines splits text into lines without their terminators; a final line
+// break does not start another line
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
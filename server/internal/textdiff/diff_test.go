@@ -0,0 +1,77 @@
+package textdiff
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+	old := "a\nb\nc\nd\n"
+	new := "a\nc\nd\ne\n"
+
+	d := Compute(old, new)
+	if d.Added != 1 || d.Removed != 1 {
+		t.Fatalf("added %d, removed %d, want 1 and 1", d.Added, d.Removed)
+	}
+
+	var ops []string
+	for _, line := range d.Lines {
+		ops = append(ops, line.Op[:1]+line.Text)
+	}
+	if got, want := strings.Join(ops, " "), "ea db ec ed ie"; got != want {
+		t.Errorf("lines = %s, want %s", got, want)
+	}
+
+	last := d.Lines[len(d.Lines)-1]
+	if last.OldLine != 0 || last.NewLine != 4 {
+		t.Errorf("inserted line numbers = %d/%d, want 0/4", last.OldLine, last.NewLine)
+	}
+}
+
+func TestComputeEqual(t *testing.T) {
+	d := Compute("x\ny", "x\ny\n")
+	if d.Changed() {
+		t.Errorf("a final line break counted as a change: %+v", d)
+	}
+	if empty := Compute("", ""); empty.Lines == nil || empty.Changed() {
+		t.Errorf("empty diff = %+v", empty)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, string(rune('a'+i)))
+	}
+	old := strings.Join(lines, "\n")
+	lines[2] = "C"
+	lines[17] = "R"
+	new := strings.Join(lines, "\n")
+
+	got := Compute(old, new).Unified(1)
+	want := "@@ -2,3 +2,3 @@\n b\n-c\n+C\n d\n@@ -17,3 +17,3 @@\n q\n-r\n+R\n s\n"
+	if got != want {
+		t.Errorf("Unified =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLCSLength(t *testing.T) {
+	if got := LCSLength(strings.Split("a b c d", " "), strings.Split("a c d e", " ")); got != 3 {
+		t.Errorf("LCSLength = %d, want 3", got)
+	}
+
+	// too large for a Diff to walk, which would keep nothing in common
+	var a, b []string
+	for i := 0; i < 3000; i++ {
+		a = append(a, "t"+strconv.Itoa(i))
+		if i%10 == 0 {
+			b = append(b, "changed")
+		} else {
+			b = append(b, a[i])
+		}
+	}
+	if got := LCSLength(a, b); got != 2700 {
+		t.Errorf("LCSLength of large inputs = %d, want 2700", got)
+	}
+}
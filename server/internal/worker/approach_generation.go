@@ -0,0 +1,160 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"go-leetcode/backend/internal/approachgen"
+	"go-leetcode/backend/models"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const approachGenerationBatch = 20
+
+// ApproachGenerationJob drafts approaches and hints for the problems lacking
+// explained approaches, queueing each draft for editors to review. Progress
+// is saved after every problem, so an interrupted run resumes where it
+// stopped.
+type ApproachGenerationJob struct {
+	draftStore *models.ApproachDraftStore
+	provider   approachgen.Provider
+	logger     *zap.SugaredLogger
+
+	mu      sync.Mutex
+	running map[int]bool
+}
+
+func NewApproachGenerationJob(draftStore *models.ApproachDraftStore, provider approachgen.Provider, logger *zap.Logger) *ApproachGenerationJob {
+	return &ApproachGenerationJob{
+		draftStore: draftStore,
+		provider:   provider,
+		logger:     logger.Sugar(),
+		running:    make(map[int]bool),
+	}
+}
+
+// StartRun creates a run over at most maxProblems problems, 0 for all of
+// them, and runs it
+func (j *ApproachGenerationJob) StartRun(ctx context.Context, maxProblems int) (models.GenerationRun, error) {
+	run := models.GenerationRun{
+		PromptVersion: approachgen.PromptVersion,
+		Provider:      j.provider.Name(),
+		Model:         j.provider.Model(),
+		MaxProblems:   maxProblems,
+	}
+	if err := j.draftStore.CreateRun(&run); err != nil {
+		return run, err
+	}
+
+	err := j.Run(ctx, run.ID)
+	if finished, getErr := j.draftStore.GetRun(run.ID); getErr == nil {
+		run = finished
+	}
+	return run, err
+}
+
+// ResumeUnfinished runs again the runs interrupted before finishing, one
+// after the other, continuing after the last problem each one drafted
+func (j *ApproachGenerationJob) ResumeUnfinished(ctx context.Context) error {
+	runs, err := j.draftStore.ListRuns(true, 100)
+	if err != nil {
+		return err
+	}
+
+	for i := len(runs) - 1; i >= 0; i-- {
+		j.logger.Infof("resuming approach generation run %d after problem %d", runs[i].ID, runs[i].LastProblemID)
+		if err := j.Run(ctx, runs[i].ID); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			j.logger.Warnf("approach generation run %d: %v", runs[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (j *ApproachGenerationJob) Run(ctx context.Context, runID int) error {
+	j.mu.Lock()
+	if j.running[runID] {
+		j.mu.Unlock()
+		return nil
+	}
+	j.running[runID] = true
+	j.mu.Unlock()
+
+	defer func() {
+		j.mu.Lock()
+		delete(j.running, runID)
+		j.mu.Unlock()
+	}()
+
+	run, err := j.draftStore.GetRun(runID)
+	if err != nil {
+		return err
+	}
+	if run.Status == models.GenerationCompleted {
+		return fmt.Errorf("approach generation run %d is already completed", run.ID)
+	}
+	// drafts keep the prompt version and model of their run
+	if run.PromptVersion != approachgen.PromptVersion || run.Model != j.provider.Model() {
+		return fmt.Errorf("approach generation run %d used prompt %s with %s, not prompt %s with %s",
+			run.ID, run.PromptVersion, run.Model, approachgen.PromptVersion, j.provider.Model())
+	}
+	if run.Status == models.GenerationFailed {
+		if err := j.draftStore.ReopenRun(&run); err != nil {
+			return err
+		}
+	}
+
+	if err := j.run(ctx, &run); err != nil {
+		if ctx.Err() != nil {
+			// leave the run running so it can be resumed
+			return err
+		}
+		if finishErr := j.draftStore.FinishRun(&run, models.GenerationFailed, err.Error()); finishErr != nil {
+			return fmt.Errorf("%v (and failed to record failure: %v)", err, finishErr)
+		}
+		return err
+	}
+
+	return j.draftStore.FinishRun(&run, models.GenerationCompleted, "")
+}
+
+func (j *ApproachGenerationJob) run(ctx context.Context, run *models.GenerationRun) error {
+	for {
+		limit := approachGenerationBatch
+		if run.MaxProblems > 0 {
+			limit = min(limit, run.MaxProblems-run.Processed())
+			if limit <= 0 {
+				return nil
+			}
+		}
+
+		inputs, err := j.draftStore.NextGenerationInputs(run.LastProblemID, limit)
+		if err != nil {
+			return err
+		}
+		if len(inputs) == 0 {
+			return nil
+		}
+
+		for _, input := range inputs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			draft, err := approachgen.Generate(ctx, j.provider, input)
+			if err != nil {
+				return fmt.Errorf("error generating approaches for %s: %v", input.TitleSlug, err)
+			}
+			if draft.Status == models.DraftFailed {
+				j.logger.Warnf("approach generation run %d: unusable answer for %s: %s", run.ID, input.TitleSlug, draft.Error)
+			}
+
+			if err := j.draftStore.RecordDraft(run, &draft); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"go-leetcode/backend/internal/leetcode"
+	"go-leetcode/backend/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+	"go.uber.org/zap"
+)
+
+const dailyChallengeDeckDescription = "LeetCode daily challenges, added automatically every day"
+
+// DailyChallengeClient is the part of leetcode.Client used by the daily challenge job
+type DailyChallengeClient interface {
+	GetDailyChallenge() (leetcode.DailyChallenge, error)
+}
+
+// DailyChallengeJob records the question of the day for every region, adds it
+// to the "Daily Challenges" deck of each opted in user and marks the
+// challenges users solved on the day itself
+type DailyChallengeJob struct {
+	challengeStore *models.DailyChallengeStore
+	problemStore   *models.ProblemStore
+	deckStore      *models.DeckStore
+	newClient      func(region leetcode.Region) DailyChallengeClient
+	logger         *zap.SugaredLogger
+}
+
+func NewDailyChallengeJob(
+	challengeStore *models.DailyChallengeStore,
+	problemStore *models.ProblemStore,
+	deckStore *models.DeckStore,
+	logger *zap.Logger,
+) *DailyChallengeJob {
+	return &DailyChallengeJob{
+		challengeStore: challengeStore,
+		problemStore:   problemStore,
+		deckStore:      deckStore,
+		newClient: func(region leetcode.Region) DailyChallengeClient {
+			return leetcode.NewClient(region)
+		},
+		logger: logger.Sugar(),
+	}
+}
+
+// Start runs the job immediately and then on every tick until ctx is cancelled.
+// Running it more often than daily is harmless, every step is idempotent.
+func (j *DailyChallengeJob) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(ctx); err != nil {
+			j.logger.Errorf("daily challenge job: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *DailyChallengeJob) RunOnce(ctx context.Context) error {
+	regions := []leetcode.Region{leetcode.RegionCOM, leetcode.RegionCN}
+
+	for _, region := range regions {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := j.recordChallenge(region); err != nil {
+			// a failure on one site should not block the other
+			j.logger.Warnf("daily challenge job: region %s: %v", region, err)
+		}
+	}
+
+	// problems the scraper added since make their earlier days enrollable
+	linked, err := j.challengeStore.LinkChallengeProblems()
+	if err != nil {
+		return err
+	}
+	if linked > 0 {
+		j.logger.Infof("daily challenge job: linked %d daily challenges to their problem", linked)
+	}
+
+	for _, region := range regions {
+		if err := j.enrollPending(ctx, region); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			j.logger.Warnf("daily challenge job: region %s: %v", region, err)
+		}
+	}
+
+	marked, err := j.challengeStore.MarkSolvedChallenges()
+	if err != nil {
+		return err
+	}
+	if marked > 0 {
+		j.logger.Infof("daily challenge job: marked %d daily challenges as solved", marked)
+	}
+
+	return nil
+}
+
+// recordChallenge stores the current question of the day of a region
+func (j *DailyChallengeJob) recordChallenge(region leetcode.Region) error {
+	lcChallenge, err := j.newClient(region).GetDailyChallenge()
+	if err != nil {
+		return fmt.Errorf("error fetching daily challenge: %v", err)
+	}
+
+	date, err := time.Parse("2006-01-02", lcChallenge.Date)
+	if err != nil {
+		return fmt.Errorf("invalid daily challenge date %q: %v", lcChallenge.Date, err)
+	}
+
+	challenge := models.DailyChallenge{
+		Date:       date,
+		Region:     string(region),
+		Title:      lcChallenge.Title,
+		TitleSlug:  lcChallenge.TitleSlug,
+		Difficulty: lcChallenge.Difficulty,
+		Link:       lcChallenge.Link,
+	}
+
+	// the challenge is still recorded when the problem is missing from our
+	// catalogue, users are enrolled once LinkChallengeProblems finds it
+	if problem, err := j.problemStore.GetProblemBySlug(lcChallenge.TitleSlug); err == nil {
+		challenge.ProblemID = &problem.ID
+	} else {
+		j.logger.Warnf("daily challenge job: problem %s not found: %v", lcChallenge.TitleSlug, err)
+	}
+
+	return j.challengeStore.UpsertChallenge(&challenge)
+}
+
+// enrollPending enrolls opted in users of a region in every challenge since
+// they opted in that they are not enrolled in yet, so days that failed or
+// waited for their problem are retried on every run
+func (j *DailyChallengeJob) enrollPending(ctx context.Context, region leetcode.Region) error {
+	pending, err := j.challengeStore.ListPendingEnrollments(string(region))
+	if err != nil {
+		return err
+	}
+
+	decks := make(map[uuid.UUID]models.Deck)
+	for _, p := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		deck, ok := decks[p.UserID]
+		if !ok {
+			deck, err = j.deckStore.GetOrCreateUserDeck(p.UserID, models.DailyChallengeDeckName, dailyChallengeDeckDescription)
+			if err != nil {
+				j.logger.Warnf("daily challenge job: failed to enroll user %s: %v", p.UserID, err)
+				continue
+			}
+			decks[p.UserID] = deck
+		}
+
+		if err := j.enrollUser(p.UserID, deck, p.Challenge); err != nil {
+			j.logger.Warnf("daily challenge job: failed to enroll user %s on %s: %v", p.UserID, p.Challenge.Date.Format("2006-01-02"), err)
+		}
+	}
+
+	return nil
+}
+
+// enrollUser adds the challenge to the user's daily challenge deck with a
+// fresh flashcard and records it, all or nothing
+func (j *DailyChallengeJob) enrollUser(userID uuid.UUID, deck models.Deck, challenge models.DailyChallenge) error {
+	now := time.Now().UTC()
+	review := &models.FlashcardReview{
+		ProblemID: *challenge.ProblemID,
+		UserID:    userID.String(),
+		DeckID:    deck.ID,
+		FsrsCard:  fsrs.NewCard(),
+	}
+	// due immediately, same as a manually added problem
+	review.FsrsCard.Due = now
+	review.FsrsCard.LastReview = now
+
+	_, err := j.challengeStore.EnrollUser(&models.UserDailyChallenge{
+		UserID: userID,
+		Date:   challenge.Date,
+		Region: challenge.Region,
+		DeckID: deck.ID,
+	}, review)
+	return err
+}
@@ -0,0 +1,283 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-leetcode/backend/internal/cryptoutils"
+	"go-leetcode/backend/internal/leetcode"
+	"go-leetcode/backend/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const historyPageSize = 20
+
+// HistoryClient is the part of leetcode.Client used by the history import
+type HistoryClient interface {
+	GetSubmissionList(session string, offset, limit int, lastKey string) (leetcode.SubmissionPage, error)
+}
+
+var (
+	errImportsStopped = errors.New("history imports stopped")
+	errSessionDeleted = errors.New("LeetCode session was deleted")
+)
+
+// HistoryImportJob backfills a user's full accepted submission history using
+// the LEETCODE_SESSION cookie they stored, then seeds review schedules for
+// every problem from its historical solve dates. One job serves the whole
+// process; imports are claimed in the database so no two instances run the
+// same one.
+type HistoryImportJob struct {
+	userStore       *models.UserStore
+	sessionStore    *models.LeetcodeSessionStore
+	importStore     *models.SubmissionImportStore
+	submissionStore *models.SubmissionStore
+	reviewStore     *models.ReviewScheduleStore
+	newClient       func(region leetcode.Region) HistoryClient
+	pageDelay       time.Duration
+	logger          *zap.SugaredLogger
+
+	ctx  context.Context
+	stop context.CancelCauseFunc
+	wg   sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[int]runningImport
+}
+
+type runningImport struct {
+	userID uuid.UUID
+	cancel context.CancelCauseFunc
+}
+
+func NewHistoryImportJob(
+	userStore *models.UserStore,
+	sessionStore *models.LeetcodeSessionStore,
+	importStore *models.SubmissionImportStore,
+	submissionStore *models.SubmissionStore,
+	reviewStore *models.ReviewScheduleStore,
+	logger *zap.Logger,
+) *HistoryImportJob {
+	ctx, stop := context.WithCancelCause(context.Background())
+	return &HistoryImportJob{
+		userStore:       userStore,
+		sessionStore:    sessionStore,
+		importStore:     importStore,
+		submissionStore: submissionStore,
+		reviewStore:     reviewStore,
+		newClient: func(region leetcode.Region) HistoryClient {
+			return leetcode.NewClient(region)
+		},
+		// leetcode rate limits aggressively, so page slowly
+		pageDelay: 2 * time.Second,
+		logger:    logger.Sugar(),
+		ctx:       ctx,
+		stop:      stop,
+		running:   make(map[int]runningImport),
+	}
+}
+
+// Start runs the import in the background until it finishes or Stop is
+// called. It is a no-op if the import is already claimed.
+func (j *HistoryImportJob) Start(importID int) {
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		if err := j.Run(j.ctx, importID); err != nil {
+			j.logger.Warnf("history import %d: %v", importID, err)
+		}
+	}()
+}
+
+// ResumeUnfinished restarts imports interrupted by a shutdown, continuing
+// from the last saved page. Imports still claimed by a live instance are left
+// to it.
+func (j *HistoryImportJob) ResumeUnfinished() error {
+	imports, err := j.importStore.ListUnfinishedImports()
+	if err != nil {
+		return err
+	}
+
+	for _, imp := range imports {
+		j.logger.Infof("resuming history import %d at offset %d", imp.ID, imp.NextOffset)
+		j.Start(imp.ID)
+	}
+
+	return nil
+}
+
+// Stop interrupts running imports and waits for them to hand their claim
+// back, so the next start resumes them
+func (j *HistoryImportJob) Stop() {
+	j.stop(errImportsStopped)
+	j.wg.Wait()
+}
+
+// CancelUser fails the user's running imports, e.g. once they delete the
+// session it runs with. Instances that did not start the import notice the
+// deleted session after the page they are fetching.
+func (j *HistoryImportJob) CancelUser(userID uuid.UUID) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, run := range j.running {
+		if run.userID == userID {
+			run.cancel(errSessionDeleted)
+		}
+	}
+}
+
+func (j *HistoryImportJob) Run(ctx context.Context, importID int) error {
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+
+	imp, claimed, err := j.importStore.ClaimImport(importID)
+	if err != nil || !claimed {
+		return err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	j.mu.Lock()
+	j.running[importID] = runningImport{userID: imp.UserID, cancel: cancel}
+	j.mu.Unlock()
+
+	defer func() {
+		j.mu.Lock()
+		delete(j.running, importID)
+		j.mu.Unlock()
+		cancel(nil)
+	}()
+
+	if err := j.run(ctx, &imp); err != nil {
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
+
+		if errors.Is(err, errImportsStopped) {
+			// hand the import back so it resumes on the next start
+			imp.Status = models.ImportStatusPending
+			if updateErr := j.importStore.UpdateImport(&imp); updateErr != nil {
+				return fmt.Errorf("%v (and failed to release import: %v)", err, updateErr)
+			}
+			return err
+		}
+
+		imp.Status = models.ImportStatusFailed
+		imp.Error = err.Error()
+		if errors.Is(err, leetcode.ErrSessionExpired) {
+			imp.Error = "LeetCode session is invalid or expired, please store a new LEETCODE_SESSION cookie"
+		}
+		now := time.Now().UTC()
+		imp.FinishedAt = &now
+
+		if updateErr := j.importStore.UpdateImport(&imp); updateErr != nil {
+			return fmt.Errorf("%v (and failed to record failure: %v)", err, updateErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (j *HistoryImportJob) run(ctx context.Context, imp *models.SubmissionImport) error {
+	user, err := j.userStore.GetUserByID(imp.UserID)
+	if err != nil {
+		return err
+	}
+
+	region, err := leetcode.ParseRegion(user.LeetcodeRegion)
+	if err != nil {
+		return err
+	}
+
+	stored, err := j.sessionStore.GetSession(imp.UserID)
+	if err != nil {
+		return err
+	}
+
+	session, err := cryptoutils.DecryptString(stored.EncryptedSession)
+	if err != nil {
+		return fmt.Errorf("error decrypting leetcode session: %v", err)
+	}
+
+	client := j.newClient(region)
+	for {
+		page, err := client.GetSubmissionList(session, imp.NextOffset, historyPageSize, imp.LastKey)
+		if err != nil {
+			return err
+		}
+
+		for _, lcSub := range page.Submissions {
+			if !lcSub.Accepted() {
+				continue
+			}
+
+			created, err := j.submissionStore.CreateSubmissionIfNotExists(models.Submission{
+				ID:          leetcode.SubmissionID(lcSub.ID),
+				UserID:      imp.UserID,
+				Title:       lcSub.Title,
+				TitleSlug:   lcSub.TitleSlug,
+				SubmittedAt: time.Unix(lcSub.Timestamp, 0).UTC(),
+				CreatedAt:   time.Now().UTC(),
+			})
+			if err != nil {
+				return err
+			}
+			if created {
+				imp.ImportedCount++
+			}
+		}
+
+		imp.FetchedCount += len(page.Submissions)
+		imp.NextOffset += historyPageSize
+		imp.LastKey = page.LastKey
+
+		// progress is saved after every page so an interrupted run can resume here
+		if err := j.importStore.UpdateImport(imp); err != nil {
+			return err
+		}
+
+		if !page.HasNext || len(page.Submissions) == 0 {
+			break
+		}
+
+		// the session may have been deleted through another instance
+		if _, err := j.sessionStore.GetSession(imp.UserID); errors.Is(err, models.ErrLeetcodeSessionNotFound) {
+			return errSessionDeleted
+		} else if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(j.pageDelay):
+		}
+	}
+
+	history, err := j.submissionStore.GetSubmissionHistoryBySlug(imp.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, submissions := range history {
+		_, seeded, err := j.reviewStore.SeedReviewFromHistory(submissions)
+		if err != nil {
+			return err
+		}
+		if seeded {
+			imp.SeededReviews++
+		}
+	}
+
+	now := time.Now().UTC()
+	imp.Status = models.ImportStatusCompleted
+	imp.FinishedAt = &now
+
+	return j.importStore.UpdateImport(imp)
+}
@@ -0,0 +1,179 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"go-leetcode/backend/internal/leetcode"
+	"go-leetcode/backend/models"
+	"time"
+
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+	"go.uber.org/zap"
+)
+
+// recentSubmissionLimit mirrors the cap of leetcode's recentAcSubmissionList
+const recentSubmissionLimit = 20
+
+// LeetcodeClient is the part of leetcode.Client the workers depend on
+type LeetcodeClient interface {
+	GetRecentSubmission(username string, limit int) ([]leetcode.Submission, error)
+	GetQuestion(titleSlug string) (leetcode.Question, error)
+}
+
+// SyncWorker periodically pulls each user's recent accepted submissions from
+// the LeetCode site matching their region and feeds them into the review schedule
+type SyncWorker struct {
+	userStore       *models.UserStore
+	submissionStore *models.SubmissionStore
+	reviewStore     *models.ReviewScheduleStore
+	problemStore    *models.ProblemStore
+	newClient       func(region leetcode.Region) LeetcodeClient
+	logger          *zap.SugaredLogger
+}
+
+func NewSyncWorker(
+	userStore *models.UserStore,
+	submissionStore *models.SubmissionStore,
+	reviewStore *models.ReviewScheduleStore,
+	problemStore *models.ProblemStore,
+	logger *zap.Logger,
+) *SyncWorker {
+	return &SyncWorker{
+		userStore:       userStore,
+		submissionStore: submissionStore,
+		reviewStore:     reviewStore,
+		problemStore:    problemStore,
+		newClient: func(region leetcode.Region) LeetcodeClient {
+			return leetcode.NewClient(region)
+		},
+		logger: logger.Sugar(),
+	}
+}
+
+// Start runs a sync immediately and then on every tick until ctx is cancelled
+func (w *SyncWorker) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.RunOnce(ctx); err != nil {
+			w.logger.Errorf("sync worker: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *SyncWorker) RunOnce(ctx context.Context) error {
+	users, err := w.userStore.ListUsersForSync()
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		synced, err := w.SyncUser(user)
+		if err != nil {
+			// one failing account should not stop the others from syncing
+			w.logger.Warnf("sync worker: failed to sync user %s: %v", user.ID, err)
+			continue
+		}
+
+		if synced > 0 {
+			w.logger.Infof("sync worker: synced %d submissions for user %s", synced, user.ID)
+		}
+	}
+
+	return nil
+}
+
+// SyncUser imports any recent accepted submissions we have not seen yet and
+// returns how many were added
+func (w *SyncWorker) SyncUser(user models.User) (int, error) {
+	region, err := leetcode.ParseRegion(user.LeetcodeRegion)
+	if err != nil {
+		return 0, err
+	}
+
+	client := w.newClient(region)
+	submissions, err := client.GetRecentSubmission(user.LeetcodeUsername, recentSubmissionLimit)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching recent submissions: %v", err)
+	}
+
+	synced := 0
+	for _, lcSub := range submissions {
+		sub := models.Submission{
+			ID:          leetcode.SubmissionID(lcSub.ID),
+			UserID:      user.ID,
+			Title:       lcSub.Title,
+			TitleSlug:   lcSub.TitleSlug,
+			SubmittedAt: time.Unix(lcSub.Timestamp, 0).UTC(),
+			CreatedAt:   time.Now().UTC(),
+		}
+
+		exists, err := w.submissionStore.CheckSubmissionExists(sub.ID)
+		if err != nil {
+			return synced, err
+		}
+		if exists {
+			continue
+		}
+
+		if err := w.submissionStore.CreateSubmission(sub); err != nil {
+			return synced, err
+		}
+
+		if _, err := w.reviewStore.UpdateOrCreateReviewForSubmission(&sub, fsrs.Good); err != nil {
+			return synced, err
+		}
+
+		synced++
+
+		if region == leetcode.RegionCN {
+			w.syncTranslations(client, sub.TitleSlug)
+		}
+	}
+
+	return synced, nil
+}
+
+// syncTranslations stores the translated tag names and similar question titles
+// that only leetcode.cn returns. Failures are logged, not fatal to the sync.
+func (w *SyncWorker) syncTranslations(client LeetcodeClient, titleSlug string) {
+	question, err := client.GetQuestion(titleSlug)
+	if err != nil {
+		w.logger.Warnf("sync worker: failed to fetch question %s: %v", titleSlug, err)
+		return
+	}
+
+	var tags []models.TopicTag
+	for _, tag := range question.TopicTags {
+		tags = append(tags, models.TopicTag{
+			Name:           tag.Name,
+			Slug:           tag.Slug,
+			TranslatedName: tag.TranslatedName,
+		})
+	}
+
+	var similar []models.SimilarQuestion
+	for _, q := range question.SimilarQuestions {
+		similar = append(similar, models.SimilarQuestion{
+			Title:           q.Title,
+			TitleSlug:       q.TitleSlug,
+			Difficulty:      q.Difficulty,
+			TranslatedTitle: q.TranslatedTitle,
+		})
+	}
+
+	if err := w.problemStore.UpdateProblemTranslations(titleSlug, tags, similar); err != nil {
+		w.logger.Warnf("sync worker: failed to store translations for %s: %v", titleSlug, err)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"go-leetcode/backend/api/routes"
 	"go-leetcode/backend/internal/authutils"
 	"go-leetcode/backend/internal/database"
+	"go-leetcode/backend/internal/runner"
+	"go-leetcode/backend/internal/worker"
+	"go-leetcode/backend/models"
 	"net/http"
 	"os"
 	"os/signal"
@@ -17,6 +20,12 @@ import (
 )
 
 func main() {
+	// the code runner re-executes the server to enter its sandbox, which has
+	// to happen before anything else runs
+	if len(os.Args) > 1 && os.Args[1] == runner.SandboxCommand {
+		os.Exit(runner.SandboxMain(os.Args[2:]))
+	}
+
 	// Get port from environment variable, default to 8080
 	port := getEnv("PORT", "8080")
 
@@ -25,6 +34,20 @@ func main() {
 		fmt.Printf("Warning, failed to load .env file: %v\n", err)
 	}
 
+	// subcommands run once against the database instead of starting the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import-solves":
+			os.Exit(runImportSolves(os.Args[2:]))
+		case "render-content":
+			os.Exit(runRenderContent(os.Args[2:]))
+		case "import-snippets":
+			os.Exit(runImportSnippets(os.Args[2:]))
+		case "generate-approaches":
+			os.Exit(runGenerateApproaches(os.Args[2:]))
+		}
+	}
+
 	logger, err := zap.NewProduction()
 	if err != nil {
 		fmt.Printf("Failed to create logger: %v\n", err)
@@ -36,15 +59,7 @@ func main() {
 	log := logger.Sugar()
 	log.Info("Starting SPACECODE")
 
-	dbConfig := &database.Config{
-		Host:	getEnv("DB_HOST", "localhost"),
-		Port: 	getEnv("DB_PORT", "5432"),
-		User: 	getEnv("DB_USER", ""),
-		Password: getEnv("DB_PASSWORD", ""),
-		DBName: getEnv("DB_NAME", "leetcode_practice"),
-	}
-
-	db, err := database.NewConnection(dbConfig)
+	db, err := database.NewConnection(dbConfigFromEnv())
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -56,7 +71,17 @@ func main() {
 	}
 
 	log.Info("Connected to database")
-	router := routes.SetupRoutes(db, logger)
+
+	// one job runs every history import, started from the API or resumed below
+	historyImportJob := worker.NewHistoryImportJob(
+		models.NewUserStore(db),
+		models.NewLeetcodeSessionStore(db),
+		models.NewSubmissionImportStore(db),
+		models.NewSubmissionStore(db),
+		models.NewReviewScheduleStore(db),
+		logger,
+	)
+	router := routes.SetupRoutes(db, logger, historyImportJob)
 
 	authutils.Initialize()
 
@@ -65,6 +90,52 @@ func main() {
 		Handler:	router,
 	}
 
+	// background sync of recent LeetCode submissions, enabled by setting
+	// SYNC_INTERVAL to a duration such as "30m"
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	if syncInterval := getEnv("SYNC_INTERVAL", ""); syncInterval != "" {
+		interval, err := time.ParseDuration(syncInterval)
+		if err != nil {
+			log.Fatalf("Invalid SYNC_INTERVAL: %v", err)
+		}
+
+		syncWorker := worker.NewSyncWorker(
+			models.NewUserStore(db),
+			models.NewSubmissionStore(db),
+			models.NewReviewScheduleStore(db),
+			models.NewProblemStore(db),
+			logger,
+		)
+		go syncWorker.Start(workerCtx, interval)
+		log.Infof("Sync worker running every %s", interval)
+	}
+
+	// daily challenge ingestion, enabled by setting DAILY_CHALLENGE_INTERVAL
+	// to a duration such as "1h"
+	if dailyInterval := getEnv("DAILY_CHALLENGE_INTERVAL", ""); dailyInterval != "" {
+		interval, err := time.ParseDuration(dailyInterval)
+		if err != nil {
+			log.Fatalf("Invalid DAILY_CHALLENGE_INTERVAL: %v", err)
+		}
+
+		deckStore := models.NewDeckStore(db, models.NewFlashcardReviewStore(db))
+		dailyChallengeJob := worker.NewDailyChallengeJob(
+			models.NewDailyChallengeStore(db, deckStore),
+			models.NewProblemStore(db),
+			deckStore,
+			logger,
+		)
+		go dailyChallengeJob.Start(workerCtx, interval)
+		log.Infof("Daily challenge job running every %s", interval)
+	}
+
+	// pick up history imports interrupted by the last shutdown
+	if err := historyImportJob.ResumeUnfinished(); err != nil {
+		log.Warnf("Failed to resume history imports: %v", err)
+	}
+
 	go func() {
 		log.Infof("Server starting on :%s", port)
 
@@ -77,6 +148,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Info("Shutting down server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -85,9 +157,22 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// after the server so no import starts once they are stopped
+	historyImportJob.Stop()
+
 	log.Info("Server exited gracefully")
 }
 
+func dbConfigFromEnv() *database.Config {
+	return &database.Config{
+		Host:	getEnv("DB_HOST", "localhost"),
+		Port: 	getEnv("DB_PORT", "5432"),
+		User: 	getEnv("DB_USER", ""),
+		Password: getEnv("DB_PASSWORD", ""),
+		DBName: getEnv("DB_NAME", "leetcode_practice"),
+	}
+}
+
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -0,0 +1,4 @@
+-- Which LeetCode site the user practices on ('com' for leetcode.com, 'cn' for leetcode.cn)
+ALTER TABLE users ADD COLUMN leetcode_region varchar NOT NULL DEFAULT 'com';
+
+ALTER TABLE users ADD CONSTRAINT users_leetcode_region_check CHECK ((leetcode_region = ANY (ARRAY['com'::varchar, 'cn'::varchar])));
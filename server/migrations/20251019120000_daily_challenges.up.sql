@@ -0,0 +1,33 @@
+-- The LeetCode question of the day, one row per site per day
+CREATE TABLE daily_challenges (
+	challenge_date date NOT NULL,
+	region varchar NOT NULL,
+	problem_id int4 NULL,
+	title varchar NOT NULL,
+	title_slug varchar NOT NULL,
+	difficulty varchar NOT NULL,
+	link varchar NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT daily_challenges_pkey PRIMARY KEY (challenge_date, region)
+);
+
+ALTER TABLE daily_challenges ADD CONSTRAINT daily_challenges_problem_id_fkey FOREIGN KEY (problem_id) REFERENCES problems(id);
+
+-- Users opt in to have every daily challenge added to their "Daily Challenges" deck
+ALTER TABLE users ADD COLUMN daily_challenge_enrolled boolean NOT NULL DEFAULT false;
+
+-- Which daily challenges were added for a user, and whether they solved it that day
+CREATE TABLE user_daily_challenges (
+	user_id uuid NOT NULL,
+	challenge_date date NOT NULL,
+	region varchar NOT NULL,
+	deck_id int4 NOT NULL,
+	solved boolean DEFAULT false NOT NULL,
+	solved_at timestamp NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT user_daily_challenges_pkey PRIMARY KEY (user_id, challenge_date, region)
+);
+
+ALTER TABLE user_daily_challenges ADD CONSTRAINT user_daily_challenges_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE user_daily_challenges ADD CONSTRAINT user_daily_challenges_challenge_fkey FOREIGN KEY (challenge_date, region) REFERENCES daily_challenges(challenge_date, region) ON DELETE CASCADE;
+ALTER TABLE user_daily_challenges ADD CONSTRAINT user_daily_challenges_deck_id_fkey FOREIGN KEY (deck_id) REFERENCES decks(id) ON DELETE CASCADE;
@@ -0,0 +1,9 @@
+-- Cached renders of problems.content made by internal/content. A render is
+-- stale once content_rendered_md5 no longer matches the content, e.g. after
+-- the scraper updated it, or when it was made by an older renderer version.
+ALTER TABLE problems
+	ADD COLUMN content_html text,
+	ADD COLUMN content_markdown text,
+	ADD COLUMN content_summary text,
+	ADD COLUMN content_rendered_md5 text,
+	ADD COLUMN content_render_version int4;
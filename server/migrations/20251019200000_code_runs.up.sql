@@ -0,0 +1,29 @@
+-- Solutions run against a problem's examples by internal/runner. A run stays
+-- unattached until the user submits it, which creates an internal submission
+-- and schedules the review with a rating derived from the runs.
+CREATE TABLE code_runs (
+	id serial4 NOT NULL,
+	user_id uuid NOT NULL,
+	problem_id int4 NOT NULL,
+	title_slug varchar NOT NULL,
+	submission_id varchar NULL,
+	"language" varchar NOT NULL,
+	code text NOT NULL,
+	status varchar NOT NULL,
+	passed int4 DEFAULT 0 NOT NULL,
+	total int4 DEFAULT 0 NOT NULL,
+	runtime_ms float8 DEFAULT 0 NOT NULL,
+	cases jsonb DEFAULT '[]'::jsonb NOT NULL,
+	"output" text DEFAULT '' NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT code_runs_pkey PRIMARY KEY (id),
+	CONSTRAINT code_runs_language_check CHECK (("language" = ANY (ARRAY['python'::varchar, 'go'::varchar]))),
+	CONSTRAINT code_runs_status_check CHECK ((status = ANY (ARRAY['accepted'::varchar, 'wrong_answer'::varchar, 'runtime_error'::varchar, 'time_limit_exceeded'::varchar, 'compile_error'::varchar])))
+);
+
+ALTER TABLE code_runs ADD CONSTRAINT code_runs_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE code_runs ADD CONSTRAINT code_runs_problem_id_fkey FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE;
+ALTER TABLE code_runs ADD CONSTRAINT code_runs_submission_id_fkey FOREIGN KEY (submission_id) REFERENCES submissions(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_code_runs_user_problem ON code_runs(user_id, title_slug, created_at);
+CREATE INDEX idx_code_runs_submission ON code_runs(submission_id);
@@ -0,0 +1,27 @@
+-- Roles for the admin API: editors maintain problem content, admins also
+-- create, hide and retire problems and grant roles.
+ALTER TABLE users ADD COLUMN "role" varchar DEFAULT 'user' NOT NULL;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (("role" = ANY (ARRAY['user'::varchar, 'editor'::varchar, 'admin'::varchar])));
+
+-- Hidden problems are not served at all, retired ones stay reachable by ID
+-- and slug but are no longer listed or recommended.
+ALTER TABLE problems ADD COLUMN lifecycle varchar DEFAULT 'active' NOT NULL;
+ALTER TABLE problems ADD CONSTRAINT problems_lifecycle_check CHECK ((lifecycle = ANY (ARRAY['active'::varchar, 'hidden'::varchar, 'retired'::varchar])));
+
+-- Who changed what through the admin API. changes maps each changed field to
+-- its old and new value.
+CREATE TABLE audit_log (
+	id serial4 NOT NULL,
+	actor_id uuid NOT NULL,
+	"action" varchar NOT NULL,
+	entity_type varchar NOT NULL,
+	entity_id varchar NOT NULL,
+	changes jsonb DEFAULT '{}'::jsonb NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT audit_log_pkey PRIMARY KEY (id)
+);
+
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES users(id);
+
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id, created_at);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_id, created_at);
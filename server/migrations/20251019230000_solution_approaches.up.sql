@@ -0,0 +1,38 @@
+-- Several approaches per problem (brute force, optimal, alternatives), each
+-- with its explanation, complexity and code in any number of languages. Code
+-- stays in problem_solutions, now one row per approach and language.
+CREATE TABLE solution_approaches (
+	id serial4 NOT NULL,
+	problem_id int4 NOT NULL,
+	kind varchar DEFAULT 'optimal' NOT NULL,
+	title varchar NOT NULL,
+	explanation text DEFAULT '' NOT NULL,
+	time_complexity varchar DEFAULT '' NOT NULL,
+	space_complexity varchar DEFAULT '' NOT NULL,
+	"position" int4 DEFAULT 0 NOT NULL,
+	author_id uuid NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp NULL,
+	CONSTRAINT solution_approaches_pkey PRIMARY KEY (id),
+	CONSTRAINT solution_approaches_kind_check CHECK ((kind = ANY (ARRAY['brute_force'::varchar, 'optimal'::varchar, 'alternative'::varchar])))
+);
+
+ALTER TABLE solution_approaches ADD CONSTRAINT solution_approaches_problem_id_fkey FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE;
+ALTER TABLE solution_approaches ADD CONSTRAINT solution_approaches_author_id_fkey FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_solution_approaches_problem ON solution_approaches(problem_id, "position", id);
+
+-- the existing solutions become each problem's first approach
+INSERT INTO solution_approaches (problem_id, kind, title)
+SELECT DISTINCT problem_id, 'optimal', 'Solution' FROM problem_solutions;
+
+ALTER TABLE problem_solutions ADD COLUMN approach_id int4 NULL;
+
+UPDATE problem_solutions s SET approach_id = a.id
+FROM solution_approaches a
+WHERE a.problem_id = s.problem_id;
+
+ALTER TABLE problem_solutions ALTER COLUMN approach_id SET NOT NULL;
+ALTER TABLE problem_solutions ADD CONSTRAINT problem_solutions_approach_id_fkey FOREIGN KEY (approach_id) REFERENCES solution_approaches(id) ON DELETE CASCADE;
+ALTER TABLE problem_solutions DROP CONSTRAINT unique_problem_language;
+ALTER TABLE problem_solutions ADD CONSTRAINT unique_approach_language UNIQUE (approach_id, language);
@@ -0,0 +1,9 @@
+-- Approaches as ordered hints (pattern, key insight, algorithm, complexity)
+-- revealed one at a time during flashcard reviews. Approaches without stored
+-- hints get them derived from their explanation and complexity.
+ALTER TABLE solution_approaches ADD COLUMN hints jsonb DEFAULT '[]'::jsonb NOT NULL;
+
+-- hints revealed since the card was last rated; the count moves to the review
+-- log when it is rated
+ALTER TABLE flashcard_reviews ADD COLUMN hints_revealed int4 DEFAULT 0 NOT NULL;
+ALTER TABLE flashcard_review_logs ADD COLUMN hints_revealed int4 DEFAULT 0 NOT NULL;
@@ -0,0 +1,46 @@
+-- Generated solution approaches. A run walks the problems lacking explained
+-- approaches in ID order, asking a language model for approaches and hints;
+-- each answer is queued as a draft for editors to approve or reject.
+CREATE TABLE approach_generation_runs (
+	id serial4 NOT NULL,
+	status varchar DEFAULT 'running' NOT NULL,
+	prompt_version varchar NOT NULL,
+	provider varchar NOT NULL,
+	model varchar NOT NULL,
+	max_problems int4 DEFAULT 0 NOT NULL,
+	last_problem_id int4 DEFAULT 0 NOT NULL,
+	drafted int4 DEFAULT 0 NOT NULL,
+	failed int4 DEFAULT 0 NOT NULL,
+	error text DEFAULT '' NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	finished_at timestamp NULL,
+	CONSTRAINT approach_generation_runs_pkey PRIMARY KEY (id),
+	CONSTRAINT approach_generation_runs_status_check CHECK ((status = ANY (ARRAY['running'::varchar, 'completed'::varchar, 'failed'::varchar])))
+);
+
+CREATE TABLE approach_drafts (
+	id serial4 NOT NULL,
+	run_id int4 NOT NULL,
+	problem_id int4 NOT NULL,
+	status varchar DEFAULT 'pending' NOT NULL,
+	prompt_version varchar NOT NULL,
+	model varchar NOT NULL,
+	summary text DEFAULT '' NOT NULL,
+	approaches jsonb DEFAULT '[]'::jsonb NOT NULL,
+	error text DEFAULT '' NOT NULL,
+	reviewer_id uuid NULL,
+	review_note text DEFAULT '' NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	reviewed_at timestamp NULL,
+	CONSTRAINT approach_drafts_pkey PRIMARY KEY (id),
+	CONSTRAINT unique_run_problem UNIQUE (run_id, problem_id),
+	CONSTRAINT approach_drafts_status_check CHECK ((status = ANY (ARRAY['pending'::varchar, 'approved'::varchar, 'rejected'::varchar, 'failed'::varchar])))
+);
+
+ALTER TABLE approach_drafts ADD CONSTRAINT approach_drafts_run_id_fkey FOREIGN KEY (run_id) REFERENCES approach_generation_runs(id) ON DELETE CASCADE;
+ALTER TABLE approach_drafts ADD CONSTRAINT approach_drafts_problem_id_fkey FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE;
+ALTER TABLE approach_drafts ADD CONSTRAINT approach_drafts_reviewer_id_fkey FOREIGN KEY (reviewer_id) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_approach_drafts_status ON approach_drafts(status, created_at);
+CREATE INDEX idx_approach_drafts_problem ON approach_drafts(problem_id);
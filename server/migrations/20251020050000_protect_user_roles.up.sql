@@ -0,0 +1,16 @@
+-- The RLS policy "Allow users to update their own data" lets signed-in users
+-- update their own row through Supabase, which would include "role". Only the
+-- server, connecting as its own database role, may change roles.
+CREATE OR REPLACE FUNCTION users_role_guard() RETURNS trigger AS $$
+BEGIN
+	IF NEW."role" IS DISTINCT FROM OLD."role" AND current_user IN ('authenticated', 'anon') THEN
+		RAISE EXCEPTION 'role can only be changed through the admin API'
+			USING ERRCODE = '42501';
+	END IF;
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_role_guard_trigger
+	BEFORE UPDATE OF "role" ON users
+	FOR EACH ROW EXECUTE FUNCTION users_role_guard();
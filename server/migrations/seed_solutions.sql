@@ -1,9 +1,19 @@
 -- Seed file for problem solutions
 -- Assuming the problems from seed_test_problems.sql are already loaded
 
+-- Code belongs to an approach, so give each seeded problem its primary
+-- approach first unless it already has one
+INSERT INTO solution_approaches (problem_id, kind, title)
+SELECT p.id, 'optimal', 'Solution' FROM problems p
+WHERE p.id IN (1, 9)
+  AND NOT EXISTS (SELECT 1 FROM solution_approaches a WHERE a.problem_id = p.id);
+
+INSERT INTO problem_solutions (problem_id, approach_id, language, solution_code, created_at)
+SELECT v.problem_id,
+       (SELECT a.id FROM solution_approaches a WHERE a.problem_id = v.problem_id ORDER BY a."position", a.id LIMIT 1),
+       v.language, v.solution_code, v.created_at
+FROM (VALUES
 -- Two Sum solutions
-INSERT INTO problem_solutions (problem_id, language, solution_code, created_at)
-VALUES 
 (1, 'go', 'func twoSum(nums []int, target int) []int {
     m := make(map[int]int)
     for i, num := range nums {
@@ -63,4 +73,4 @@ VALUES
         return False
     
     # Convert to string and check if it equals its reverse
-    return str(x) == str(x)[::-1]', NOW());
\ No newline at end of file
+    return str(x) == str(x)[::-1]', NOW())) AS v(problem_id, language, solution_code, created_at);
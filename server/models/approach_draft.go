@@ -0,0 +1,566 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrGenerationRunNotFound = errors.New("approach generation run not found")
+	ErrDraftNotFound         = errors.New("approach draft not found")
+	// ErrDraftReviewed is returned when reviewing a draft that is not pending
+	ErrDraftReviewed = errors.New("approach draft is not pending")
+)
+
+// Approach generation run statuses. A run left running by a crash or a
+// shutdown is resumed from its last problem.
+const (
+	GenerationRunning   = "running"
+	GenerationCompleted = "completed"
+	GenerationFailed    = "failed"
+)
+
+// Approach draft statuses. Failed drafts record problems the model gave no
+// usable answer for.
+const (
+	DraftPending  = "pending"
+	DraftApproved = "approved"
+	DraftRejected = "rejected"
+	DraftFailed   = "failed"
+)
+
+func IsValidDraftStatus(status string) bool {
+	return status == DraftPending || status == DraftApproved || status == DraftRejected || status == DraftFailed
+}
+
+// GenerationRun is one pass of the approach generator over the problems
+// lacking explained approaches, in problem ID order
+type GenerationRun struct {
+	ID            int        `json:"id"`
+	Status        string     `json:"status"`
+	PromptVersion string     `json:"prompt_version"`
+	Provider      string     `json:"provider"`
+	Model         string     `json:"model"`
+	MaxProblems   int        `json:"max_problems"` // 0 for no limit
+	LastProblemID int        `json:"last_problem_id"`
+	Drafted       int        `json:"drafted"`
+	Failed        int        `json:"failed"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+}
+
+// Processed is the number of problems the run has gone through
+func (r GenerationRun) Processed() int {
+	return r.Drafted + r.Failed
+}
+
+// DraftApproach is a generated approach awaiting review
+type DraftApproach struct {
+	Kind            string `json:"kind"`
+	Title           string `json:"title"`
+	Explanation     string `json:"explanation"`
+	TimeComplexity  string `json:"time_complexity"`
+	SpaceComplexity string `json:"space_complexity"`
+	Hints           []Hint `json:"hints"`
+}
+
+// ApproachDraft holds the approaches generated for a problem, with the
+// prompt version and model that produced them
+type ApproachDraft struct {
+	ID            int             `json:"id"`
+	RunID         int             `json:"run_id"`
+	ProblemID     int             `json:"problem_id"`
+	TitleSlug     string          `json:"title_slug"`
+	Status        string          `json:"status"`
+	PromptVersion string          `json:"prompt_version"`
+	Model         string          `json:"model"`
+	Summary       string          `json:"summary"` // one line, for problems.solution_approach
+	Approaches    DraftApproaches `json:"approaches"`
+	Error         string          `json:"error,omitempty"`
+	ReviewerID    *uuid.UUID      `json:"reviewer_id"`
+	ReviewNote    string          `json:"review_note,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ReviewedAt    *time.Time      `json:"reviewed_at"`
+}
+
+// DraftFilter selects drafts; zero fields match everything
+type DraftFilter struct {
+	RunID     int
+	ProblemID int
+	Status    string
+	Limit     int
+}
+
+// GenerationInput is what the generator is told about a problem
+type GenerationInput struct {
+	ProblemID  int
+	Title      string
+	TitleSlug  string
+	Difficulty string
+	Content    string // Markdown when rendered, HTML otherwise
+	Topics     []string
+	Code       map[string]string // reference code of the primary approach
+}
+
+type ApproachDraftStore struct {
+	db *sql.DB
+}
+
+func NewApproachDraftStore(db *sql.DB) *ApproachDraftStore {
+	return &ApproachDraftStore{db: db}
+}
+
+const generationRunColumns = `id, status, prompt_version, provider, model, max_problems, last_problem_id,
+	drafted, failed, error, created_at, updated_at, finished_at`
+
+func scanGenerationRun(row rowScanner) (GenerationRun, error) {
+	var run GenerationRun
+	var finishedAt sql.NullTime
+	err := row.Scan(&run.ID, &run.Status, &run.PromptVersion, &run.Provider, &run.Model, &run.MaxProblems,
+		&run.LastProblemID, &run.Drafted, &run.Failed, &run.Error, &run.CreatedAt, &run.UpdatedAt, &finishedAt)
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return run, err
+}
+
+func (s *ApproachDraftStore) CreateRun(run *GenerationRun) error {
+	query := `
+		INSERT INTO approach_generation_runs (status, prompt_version, provider, model, max_problems)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + generationRunColumns
+
+	created, err := scanGenerationRun(s.db.QueryRow(query, GenerationRunning, run.PromptVersion, run.Provider, run.Model, run.MaxProblems))
+	if err != nil {
+		return fmt.Errorf("error creating approach generation run: %v", err)
+	}
+	*run = created
+	return nil
+}
+
+func (s *ApproachDraftStore) GetRun(id int) (GenerationRun, error) {
+	run, err := scanGenerationRun(s.db.QueryRow(`SELECT `+generationRunColumns+` FROM approach_generation_runs WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return GenerationRun{}, ErrGenerationRunNotFound
+		}
+		return GenerationRun{}, fmt.Errorf("error fetching approach generation run: %v", err)
+	}
+	return run, nil
+}
+
+// ListRuns returns the latest runs, newest first, only unfinished ones when
+// running is set
+func (s *ApproachDraftStore) ListRuns(running bool, limit int) ([]GenerationRun, error) {
+	query := `
+		SELECT ` + generationRunColumns + `
+		FROM approach_generation_runs
+		WHERE NOT $1 OR status = 'running'
+		ORDER BY id DESC
+		LIMIT $2
+	`
+
+	rows, err := s.db.Query(query, running, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing approach generation runs: %v", err)
+	}
+	defer rows.Close()
+
+	runs := []GenerationRun{}
+	for rows.Next() {
+		run, err := scanGenerationRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning approach generation run: %v", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing approach generation runs: %v", err)
+	}
+	return runs, nil
+}
+
+// FinishRun records the final status of a run, with the error that stopped
+// it when it failed
+func (s *ApproachDraftStore) FinishRun(run *GenerationRun, status, runErr string) error {
+	query := `
+		UPDATE approach_generation_runs
+		SET status = $1, error = $2, updated_at = CURRENT_TIMESTAMP, finished_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+		RETURNING ` + generationRunColumns
+
+	finished, err := scanGenerationRun(s.db.QueryRow(query, status, runErr, run.ID))
+	if err != nil {
+		return fmt.Errorf("error finishing approach generation run: %v", err)
+	}
+	*run = finished
+	return nil
+}
+
+// ReopenRun sets a failed run running again so it can be resumed
+func (s *ApproachDraftStore) ReopenRun(run *GenerationRun) error {
+	query := `
+		UPDATE approach_generation_runs
+		SET status = 'running', error = '', updated_at = CURRENT_TIMESTAMP, finished_at = NULL
+		WHERE id = $1 AND status = 'failed'
+		RETURNING ` + generationRunColumns
+
+	reopened, err := scanGenerationRun(s.db.QueryRow(query, run.ID))
+	if err != nil {
+		return fmt.Errorf("error reopening approach generation run: %v", err)
+	}
+	*run = reopened
+	return nil
+}
+
+// NextGenerationInputs returns up to limit visible problems after
+// afterProblemID, in ID order, that have no approach with an explanation and
+// no draft awaiting review
+func (s *ApproachDraftStore) NextGenerationInputs(afterProblemID, limit int) ([]GenerationInput, error) {
+	query := `
+		SELECT p.id, p.title, p.title_slug, p.difficulty,
+		       COALESCE(NULLIF(p.content_markdown, ''), p.content, ''),
+		       COALESCE((SELECT array_agg(t->>'name') FROM jsonb_array_elements(p.topic_tags) t), '{}')
+		FROM problems p
+		WHERE p.id > $1 AND ` + visibleProblem + `
+		  AND NOT EXISTS (
+			SELECT 1 FROM solution_approaches a WHERE a.problem_id = p.id AND a.explanation <> ''
+		  )
+		  AND NOT EXISTS (
+			SELECT 1 FROM approach_drafts d WHERE d.problem_id = p.id AND d.status = 'pending'
+		  )
+		ORDER BY p.id
+		LIMIT $2
+	`
+
+	rows, err := s.db.Query(query, afterProblemID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing problems to generate approaches for: %v", err)
+	}
+	defer rows.Close()
+
+	inputs := []GenerationInput{}
+	for rows.Next() {
+		input := GenerationInput{Code: map[string]string{}}
+		err := rows.Scan(&input.ProblemID, &input.Title, &input.TitleSlug, &input.Difficulty, &input.Content,
+			pq.Array(&input.Topics))
+		if err != nil {
+			return nil, fmt.Errorf("error scanning problem: %v", err)
+		}
+		inputs = append(inputs, input)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing problems to generate approaches for: %v", err)
+	}
+
+	for i := range inputs {
+		codeRows, err := s.db.Query(`
+			SELECT language, solution_code FROM problem_solutions
+			WHERE approach_id = (`+primaryApproachQuery+`)
+		`, inputs[i].ProblemID)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching reference code: %v", err)
+		}
+		for codeRows.Next() {
+			var language, code string
+			if err := codeRows.Scan(&language, &code); err != nil {
+				codeRows.Close()
+				return nil, fmt.Errorf("error scanning reference code: %v", err)
+			}
+			inputs[i].Code[language] = code
+		}
+		err = codeRows.Err()
+		codeRows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error fetching reference code: %v", err)
+		}
+	}
+
+	return inputs, nil
+}
+
+// RecordDraft stores the draft generated for a problem and moves the run
+// past it in one transaction, so a resumed run neither repeats nor skips the
+// problem. Drafts with status DraftFailed count as failures.
+func (s *ApproachDraftStore) RecordDraft(run *GenerationRun, draft *ApproachDraft) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO approach_drafts (run_id, problem_id, status, prompt_version, model, summary, approaches, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (run_id, problem_id) DO NOTHING
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(query, run.ID, draft.ProblemID, draft.Status, run.PromptVersion, run.Model, draft.Summary,
+		draft.Approaches, draft.Error).Scan(&draft.ID, &draft.CreatedAt)
+	inserted := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error creating approach draft: %v", err)
+	}
+	draft.RunID, draft.PromptVersion, draft.Model = run.ID, run.PromptVersion, run.Model
+
+	drafted, failed := 0, 0
+	if inserted && draft.Status == DraftFailed {
+		failed = 1
+	} else if inserted {
+		drafted = 1
+	}
+	query = `
+		UPDATE approach_generation_runs
+		SET last_problem_id = GREATEST(last_problem_id, $1), drafted = drafted + $2, failed = failed + $3,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+		RETURNING ` + generationRunColumns
+	updated, err := scanGenerationRun(tx.QueryRow(query, draft.ProblemID, drafted, failed, run.ID))
+	if err != nil {
+		return fmt.Errorf("error updating approach generation run: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing approach draft: %v", err)
+	}
+	*run = updated
+	return nil
+}
+
+const draftColumns = `d.id, d.run_id, d.problem_id, p.title_slug, d.status, d.prompt_version, d.model, d.summary,
+	d.approaches, d.error, d.reviewer_id, d.review_note, d.created_at, d.reviewed_at`
+
+func scanDraft(row rowScanner) (ApproachDraft, error) {
+	var draft ApproachDraft
+	var reviewerID uuid.NullUUID
+	var reviewedAt sql.NullTime
+	err := row.Scan(&draft.ID, &draft.RunID, &draft.ProblemID, &draft.TitleSlug, &draft.Status, &draft.PromptVersion,
+		&draft.Model, &draft.Summary, &draft.Approaches, &draft.Error, &reviewerID, &draft.ReviewNote,
+		&draft.CreatedAt, &reviewedAt)
+	if reviewerID.Valid {
+		draft.ReviewerID = &reviewerID.UUID
+	}
+	if reviewedAt.Valid {
+		draft.ReviewedAt = &reviewedAt.Time
+	}
+	return draft, err
+}
+
+func (s *ApproachDraftStore) GetDraft(id int) (ApproachDraft, error) {
+	return getDraft(s.db, id, false)
+}
+
+func getDraft(q rowQuerier, id int, forUpdate bool) (ApproachDraft, error) {
+	query := `SELECT ` + draftColumns + ` FROM approach_drafts d JOIN problems p ON p.id = d.problem_id WHERE d.id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE OF d`
+	}
+
+	draft, err := scanDraft(q.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ApproachDraft{}, ErrDraftNotFound
+		}
+		return ApproachDraft{}, fmt.Errorf("error fetching approach draft: %v", err)
+	}
+	return draft, nil
+}
+
+// ListDrafts returns the drafts matching filter, oldest first
+func (s *ApproachDraftStore) ListDrafts(filter DraftFilter) ([]ApproachDraft, error) {
+	query := `
+		SELECT ` + draftColumns + `
+		FROM approach_drafts d
+		JOIN problems p ON p.id = d.problem_id
+		WHERE ($1 = 0 OR d.run_id = $1)
+		  AND ($2 = 0 OR d.problem_id = $2)
+		  AND ($3 = '' OR d.status = $3)
+		ORDER BY d.created_at, d.id
+		LIMIT $4
+	`
+
+	rows, err := s.db.Query(query, filter.RunID, filter.ProblemID, filter.Status, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing approach drafts: %v", err)
+	}
+	defer rows.Close()
+
+	drafts := []ApproachDraft{}
+	for rows.Next() {
+		draft, err := scanDraft(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning approach draft: %v", err)
+		}
+		drafts = append(drafts, draft)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing approach drafts: %v", err)
+	}
+	return drafts, nil
+}
+
+// ApproveDraft adds the approaches of a pending draft to its problem on
+// behalf of reviewerID, with approaches replacing the generated ones when
+// given. The first approach fills in the problem's primary approach when
+// that has neither explanation nor hints, keeping its code; the others are
+// added after the existing approaches. The summary becomes the problem's
+// solution approach unless it has one.
+func (s *ApproachDraftStore) ApproveDraft(id int, reviewerID uuid.UUID, note string, approaches []DraftApproach) (ApproachDraft, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return ApproachDraft{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	draft, err := lockPendingDraft(tx, id)
+	if err != nil {
+		return ApproachDraft{}, err
+	}
+	if approaches != nil {
+		draft.Approaches = approaches
+	}
+
+	var primary Approach
+	primary, err = scanApproach(tx.QueryRow(`SELECT `+approachColumns+` FROM solution_approaches WHERE id = (`+primaryApproachQuery+`) FOR UPDATE`, draft.ProblemID))
+	if err != nil && err != sql.ErrNoRows {
+		return ApproachDraft{}, fmt.Errorf("error fetching primary approach: %v", err)
+	}
+	fillPrimary := err == nil && primary.Explanation == "" && len(primary.Hints) == 0
+
+	var position int
+	err = tx.QueryRow(`SELECT COALESCE(MAX("position") + 1, 0) FROM solution_approaches WHERE problem_id = $1`, draft.ProblemID).Scan(&position)
+	if err != nil {
+		return ApproachDraft{}, fmt.Errorf("error fetching approach positions: %v", err)
+	}
+
+	for i, generated := range draft.Approaches {
+		if i == 0 && fillPrimary {
+			_, err := updateApproach(tx, reviewerID, primary.ID, ApproachUpdate{
+				Kind:            &generated.Kind,
+				Title:           &generated.Title,
+				Explanation:     &generated.Explanation,
+				TimeComplexity:  &generated.TimeComplexity,
+				SpaceComplexity: &generated.SpaceComplexity,
+				Hints:           &generated.Hints,
+			})
+			if err != nil {
+				return ApproachDraft{}, err
+			}
+			continue
+		}
+
+		approach := Approach{
+			ProblemID:       draft.ProblemID,
+			Kind:            generated.Kind,
+			Title:           generated.Title,
+			Explanation:     generated.Explanation,
+			TimeComplexity:  generated.TimeComplexity,
+			SpaceComplexity: generated.SpaceComplexity,
+			Position:        position,
+			Hints:           generated.Hints,
+		}
+		if err := createApproach(tx, reviewerID, &approach); err != nil {
+			return ApproachDraft{}, err
+		}
+		position++
+	}
+
+	if draft.Summary != "" {
+		var problemID int
+		err := tx.QueryRow(`
+			UPDATE problems SET solution_approach = $1
+			WHERE id = $2 AND COALESCE(solution_approach, '') = ''
+			RETURNING id
+		`, draft.Summary, draft.ProblemID).Scan(&problemID)
+		switch {
+		case err == nil:
+			changes := map[string]AuditChange{}
+			auditField(changes, "solution_approach", nil, draft.Summary)
+			if err := recordAudit(tx, reviewerID, AuditProblemUpdate, "problem", strconv.Itoa(problemID), changes); err != nil {
+				return ApproachDraft{}, err
+			}
+		case err != sql.ErrNoRows:
+			return ApproachDraft{}, fmt.Errorf("error updating solution approach: %v", err)
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE approach_drafts SET approaches = $1 WHERE id = $2`, draft.Approaches, id)
+	if err != nil {
+		return ApproachDraft{}, fmt.Errorf("error updating approach draft: %v", err)
+	}
+	draft, err = reviewDraft(tx, id, DraftApproved, reviewerID, note)
+	if err != nil {
+		return ApproachDraft{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ApproachDraft{}, fmt.Errorf("error committing approach draft: %v", err)
+	}
+	return draft, nil
+}
+
+// RejectDraft closes a pending draft on behalf of reviewerID
+func (s *ApproachDraftStore) RejectDraft(id int, reviewerID uuid.UUID, note string) (ApproachDraft, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return ApproachDraft{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPendingDraft(tx, id); err != nil {
+		return ApproachDraft{}, err
+	}
+
+	draft, err := reviewDraft(tx, id, DraftRejected, reviewerID, note)
+	if err != nil {
+		return ApproachDraft{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ApproachDraft{}, fmt.Errorf("error committing approach draft: %v", err)
+	}
+	return draft, nil
+}
+
+func lockPendingDraft(tx *sql.Tx, id int) (ApproachDraft, error) {
+	draft, err := getDraft(tx, id, true)
+	if err != nil {
+		return ApproachDraft{}, err
+	}
+	if draft.Status != DraftPending {
+		return ApproachDraft{}, ErrDraftReviewed
+	}
+	return draft, nil
+}
+
+// reviewDraft records the review of a draft and audits it
+func reviewDraft(tx *sql.Tx, id int, status string, reviewerID uuid.UUID, note string) (ApproachDraft, error) {
+	_, err := tx.Exec(`
+		UPDATE approach_drafts
+		SET status = $1, reviewer_id = $2, review_note = $3, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`, status, reviewerID, note, id)
+	if err != nil {
+		return ApproachDraft{}, fmt.Errorf("error reviewing approach draft: %v", err)
+	}
+
+	action := AuditDraftApprove
+	if status == DraftRejected {
+		action = AuditDraftReject
+	}
+	changes := map[string]AuditChange{"status": {Old: DraftPending, New: status}}
+	auditField(changes, "review_note", nil, nullIfEmpty(note))
+	if err := recordAudit(tx, reviewerID, action, "approach_draft", strconv.Itoa(id), changes); err != nil {
+		return ApproachDraft{}, err
+	}
+
+	return getDraft(tx, id, false)
+}
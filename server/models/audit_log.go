@@ -0,0 +1,126 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audited actions, see AuditEntry
+const (
+	AuditProblemCreate    = "problem.create"
+	AuditProblemUpdate    = "problem.update"
+	AuditProblemLifecycle = "problem.lifecycle"
+	AuditProblemTopics    = "problem.topics"
+	AuditUserRole         = "user.role"
+	AuditSolutionCreate   = "solution.create"
+	AuditSolutionUpdate   = "solution.update"
+	AuditSolutionDelete   = "solution.delete"
+	AuditSolutionApprove  = "solution.approve"
+	AuditSolutionReject   = "solution.reject"
+	AuditApproachCreate   = "approach.create"
+	AuditApproachUpdate   = "approach.update"
+	AuditApproachDelete   = "approach.delete"
+	AuditDraftApprove     = "approach_draft.approve"
+	AuditDraftReject      = "approach_draft.reject"
+)
+
+// AuditChange is the value of one field before and after a change; Old is
+// null for created entities
+type AuditChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AuditEntry records who changed what through the admin API
+type AuditEntry struct {
+	ID         int                    `json:"id"`
+	ActorID    uuid.UUID              `json:"actor_id"`
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   string                 `json:"entity_id"`
+	Changes    map[string]AuditChange `json:"changes"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	ActorID    uuid.UUID // any actor when nil
+	Limit      int
+}
+
+type AuditStore struct {
+	db *sql.DB
+}
+
+func NewAuditStore(db *sql.DB) *AuditStore {
+	return &AuditStore{db: db}
+}
+
+// recordAudit writes an audit entry in the transaction making the change, so
+// a change is never stored without its entry
+func recordAudit(tx *sql.Tx, actorID uuid.UUID, action, entityType, entityID string, changes map[string]AuditChange) error {
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("error encoding audit changes: %v", err)
+	}
+
+	query := `
+		INSERT INTO audit_log (actor_id, action, entity_type, entity_id, changes)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := tx.Exec(query, actorID, action, entityType, entityID, string(encoded)); err != nil {
+		return fmt.Errorf("error recording audit entry: %v", err)
+	}
+	return nil
+}
+
+// auditField adds a change to changes when the value differs
+func auditField(changes map[string]AuditChange, field string, old, new interface{}) {
+	oldJSON, _ := json.Marshal(old)
+	newJSON, _ := json.Marshal(new)
+	if string(oldJSON) != string(newJSON) {
+		changes[field] = AuditChange{Old: old, New: new}
+	}
+}
+
+// ListAuditEntries returns the latest entries matching the filter, newest first
+func (s *AuditStore) ListAuditEntries(filter AuditFilter) ([]AuditEntry, error) {
+	query := `
+		SELECT id, actor_id, action, entity_type, entity_id, changes, created_at
+		FROM audit_log
+		WHERE ($1 = '' OR entity_type = $1)
+		  AND ($2 = '' OR entity_id = $2)
+		  AND ($3::uuid IS NULL OR actor_id = $3)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`
+
+	rows, err := s.db.Query(query, filter.EntityType, filter.EntityID, nullableUUID(filter.ActorID), filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing audit entries: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var entry AuditEntry
+		var changes []byte
+		if err := rows.Scan(&entry.ID, &entry.ActorID, &entry.Action, &entry.EntityType, &entry.EntityID, &changes, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning audit entry: %v", err)
+		}
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, fmt.Errorf("error decoding audit changes: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing audit entries: %v", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-leetcode/backend/internal/runner"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+)
+
+var (
+	ErrCodeRunNotFound  = errors.New("code run not found")
+	ErrCodeRunSubmitted = errors.New("code run was already submitted")
+)
+
+// The cases a solution is run against: the problem's examples, the user's own
+// test cases of it or both
+const (
+	RunCasesExamples = "examples"
+	RunCasesCustom   = "custom"
+	RunCasesAll      = "all"
+)
+
+// CodeRun is a solution run against a problem's examples or the user's test
+// cases of it, see internal/runner
+type CodeRun struct {
+	ID           int          `json:"id"`
+	UserID       uuid.UUID    `json:"user_id"`
+	ProblemID    int          `json:"problem_id"`
+	TitleSlug    string       `json:"title_slug"`
+	SubmissionID *string      `json:"submission_id"`
+	Language     string       `json:"language"`
+	Code         string       `json:"code"`
+	CaseSet      string       `json:"case_set"`
+	Status       string       `json:"status"`
+	Passed       int          `json:"passed"`
+	Total        int          `json:"total"`
+	RuntimeMS    float64      `json:"runtime_ms"`
+	Cases        CodeRunCases `json:"cases"`
+	Output       string       `json:"output,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// NewCodeRun records the result of running code for a problem
+func NewCodeRun(userID uuid.UUID, problem Problem, language, code, caseSet string, result runner.Result) CodeRun {
+	return CodeRun{
+		UserID:    userID,
+		ProblemID: problem.ID,
+		TitleSlug: problem.TitleSlug,
+		Language:  language,
+		Code:      code,
+		CaseSet:   caseSet,
+		Status:    result.Status,
+		Passed:    result.Passed,
+		Total:     result.Total,
+		RuntimeMS: result.RuntimeMS,
+		Cases:     CodeRunCases(result.Cases),
+		Output:    result.Output,
+	}
+}
+
+// DeriveRating turns a submitted run into a review rating: Again when it
+// fails, Hard when it passes after failed runs since the problem was last
+// submitted, Good otherwise. Easy is never derived, only chosen. Runs without
+// the problem's examples or any case with an expected output prove nothing,
+// as cases without one pass whenever the code runs, so the bool result is
+// false for them and a rating must be chosen.
+func DeriveRating(run CodeRun, failedAttempts int) (fsrs.Rating, bool) {
+	checked := 0
+	for _, c := range run.Cases {
+		if len(c.Expected) > 0 {
+			checked++
+		}
+	}
+	if run.CaseSet == RunCasesCustom || checked == 0 {
+		return 0, false
+	}
+
+	switch {
+	case run.Status != runner.StatusAccepted:
+		return fsrs.Again, true
+	case failedAttempts > 0:
+		return fsrs.Hard, true
+	default:
+		return fsrs.Good, true
+	}
+}
+
+type CodeRunStore struct {
+	db *sql.DB
+}
+
+func NewCodeRunStore(db *sql.DB) *CodeRunStore {
+	return &CodeRunStore{db: db}
+}
+
+const codeRunColumns = `id, user_id, problem_id, title_slug, submission_id, "language", code, case_set,
+	status, passed, total, runtime_ms, cases, "output", created_at`
+
+func scanCodeRun(row rowScanner) (CodeRun, error) {
+	var run CodeRun
+	var submissionID sql.NullString
+	err := row.Scan(&run.ID, &run.UserID, &run.ProblemID, &run.TitleSlug, &submissionID, &run.Language, &run.Code, &run.CaseSet,
+		&run.Status, &run.Passed, &run.Total, &run.RuntimeMS, &run.Cases, &run.Output, &run.CreatedAt)
+	if submissionID.Valid {
+		run.SubmissionID = &submissionID.String
+	}
+	return run, err
+}
+
+func (s *CodeRunStore) CreateCodeRun(run *CodeRun) error {
+	query := `
+		INSERT INTO code_runs
+		(user_id, problem_id, title_slug, "language", code, case_set, status, passed, total, runtime_ms, cases, "output")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at
+	`
+
+	err := s.db.QueryRow(query, run.UserID, run.ProblemID, run.TitleSlug, run.Language, run.Code, run.CaseSet,
+		run.Status, run.Passed, run.Total, run.RuntimeMS, run.Cases, run.Output).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating code run: %v", err)
+	}
+	return nil
+}
+
+// GetCodeRun loads one of the user's runs
+func (s *CodeRunStore) GetCodeRun(id int, userID uuid.UUID) (CodeRun, error) {
+	query := `SELECT ` + codeRunColumns + ` FROM code_runs WHERE id = $1 AND user_id = $2`
+
+	run, err := scanCodeRun(s.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return CodeRun{}, ErrCodeRunNotFound
+		}
+		return CodeRun{}, fmt.Errorf("error fetching code run: %v", err)
+	}
+	return run, nil
+}
+
+// ListCodeRuns returns the user's latest runs of a problem, newest first
+func (s *CodeRunStore) ListCodeRuns(userID uuid.UUID, titleSlug string, limit int) ([]CodeRun, error) {
+	query := `
+		SELECT ` + codeRunColumns + `
+		FROM code_runs
+		WHERE user_id = $1 AND title_slug = $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`
+
+	rows, err := s.db.Query(query, userID, titleSlug, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing code runs: %v", err)
+	}
+	defer rows.Close()
+
+	runs := []CodeRun{}
+	for rows.Next() {
+		run, err := scanCodeRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning code run: %v", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing code runs: %v", err)
+	}
+	return runs, nil
+}
+
+// CountFailedAttempts counts the user's failed runs of the problem made
+// before the given run and after the problem was last submitted
+func (s *CodeRunStore) CountFailedAttempts(run CodeRun) (int, error) {
+	query := `
+		SELECT count(*)
+		FROM code_runs
+		WHERE user_id = $1 AND title_slug = $2 AND status <> $3 AND created_at < $4
+		  AND created_at > coalesce((
+			SELECT max(submitted_at) FROM submissions WHERE user_id = $1 AND title_slug = $2
+		  ), '-infinity')
+	`
+
+	var count int
+	if err := s.db.QueryRow(query, run.UserID, run.TitleSlug, runner.StatusAccepted, run.CreatedAt).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting failed runs: %v", err)
+	}
+	return count, nil
+}
+
+// SubmitCodeRun creates sub, attaches the run to it and reviews its problem
+// with rating in one transaction, failing with ErrCodeRunSubmitted when the
+// run already has a submission
+func (s *CodeRunStore) SubmitCodeRun(run *CodeRun, sub Submission, rating fsrs.Rating) (ReviewSchedule, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return ReviewSchedule{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO submissions
+		(id, user_id, title, title_slug, submitted_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, sub.ID, sub.UserID, sub.Title, sub.TitleSlug, sub.SubmittedAt, sub.CreatedAt)
+	if err != nil {
+		return ReviewSchedule{}, fmt.Errorf("error creating submission: %v", err)
+	}
+
+	result, err := tx.Exec(`
+		UPDATE code_runs SET submission_id = $1
+		WHERE id = $2 AND user_id = $3 AND submission_id IS NULL
+	`, sub.ID, run.ID, run.UserID)
+	if err != nil {
+		return ReviewSchedule{}, fmt.Errorf("error attaching code run: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return ReviewSchedule{}, fmt.Errorf("error checking update result: %v", err)
+	} else if n == 0 {
+		return ReviewSchedule{}, ErrCodeRunSubmitted
+	}
+
+	review, err := updateOrCreateReviewForSubmission(tx, &sub, rating)
+	if err != nil {
+		return ReviewSchedule{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ReviewSchedule{}, fmt.Errorf("error committing submission: %v", err)
+	}
+	run.SubmissionID = &sub.ID
+	return review, nil
+}
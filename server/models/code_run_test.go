@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"go-leetcode/backend/internal/runner"
+	"testing"
+
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+)
+
+func TestDeriveRating(t *testing.T) {
+	checked := CodeRunCases{{Passed: true, Expected: json.RawMessage(`[0,1]`)}}
+	unchecked := CodeRunCases{{Passed: true, TestCaseID: 7}}
+
+	tests := []struct {
+		caseSet        string
+		cases          CodeRunCases
+		status         string
+		failedAttempts int
+		want           fsrs.Rating
+		derived        bool
+	}{
+		{RunCasesExamples, checked, runner.StatusAccepted, 0, fsrs.Good, true},
+		{RunCasesExamples, checked, runner.StatusAccepted, 2, fsrs.Hard, true},
+		{RunCasesExamples, checked, runner.StatusWrongAnswer, 0, fsrs.Again, true},
+		{RunCasesAll, checked, runner.StatusTimeLimitExceeded, 3, fsrs.Again, true},
+		// the user's own cases may have no expected output to check
+		{RunCasesCustom, checked, runner.StatusAccepted, 0, 0, false},
+		{RunCasesAll, unchecked, runner.StatusAccepted, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		run := CodeRun{CaseSet: tt.caseSet, Cases: tt.cases, Status: tt.status}
+		got, derived := DeriveRating(run, tt.failedAttempts)
+		if got != tt.want || derived != tt.derived {
+			t.Errorf("DeriveRating(%s %s, %d) = %v, %v, want %v, %v", tt.caseSet, tt.status, tt.failedAttempts, got, derived, tt.want, tt.derived)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidCursor     = errors.New("invalid cursor")
+	ErrCursorUnsupported = errors.New("cursor pagination is not supported for this ordering")
+)
+
+// Cursor points just past the last row of a keyset page: the ordering the
+// page was read with, that row's ordering key and its ID to break ties.
+// Clients only ever see it encoded, so the fields can change freely.
+type Cursor struct {
+	Order string `json:"o"`
+	Key   string `json:"k"`
+	ID    int    `json:"i"`
+}
+
+// Page selects a page by offset, or by keyset when Cursor is set. A nil
+// cursor with Keyset set requests the first keyset page. Keyset pages tell
+// whether more follow without counting every matching row, unless CountTotal
+// is set.
+type Page struct {
+	Limit      int
+	Offset     int
+	Keyset     bool
+	Cursor     *Cursor
+	CountTotal bool
+}
+
+// countTotal reports whether the page needs the total number of rows
+func (p Page) countTotal() bool {
+	return !p.Keyset || p.CountTotal
+}
+
+// PageInfo describes the page a list method returned
+type PageInfo struct {
+	Total      int // 0 when not counted, see Page.CountTotal
+	NextCursor string
+	HasMore    bool
+}
+
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.Order == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// cursorTimeLayout matches the precision of postgres timestamps
+const cursorTimeLayout = "2006-01-02 15:04:05.999999"
+
+func timeCursorKey(t time.Time) string {
+	return t.Format(cursorTimeLayout)
+}
+
+// keysetCondition compares the ordering key and tie breaking ID against the
+// cursor placeholders at pos and pos+1, in the direction the list is read
+func keysetCondition(keyExpr, idExpr, keyType string, desc bool, pos int) string {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	return fmt.Sprintf(" AND (%s, %s) %s ($%d::%s, $%d)", keyExpr, idExpr, op, pos, keyType, pos+1)
+}
+
+// checkCursor rejects cursors issued for a different ordering, which would
+// otherwise silently compare unrelated keys
+func checkCursor(cursor *Cursor, order string) error {
+	if cursor != nil && cursor.Order != order {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// trimKeysetPage drops the extra row fetched to detect a following page and
+// reports whether there was one
+func trimKeysetPage(n, limit int) (int, bool) {
+	if limit > 0 && n > limit {
+		return limit, true
+	}
+	return n, false
+}
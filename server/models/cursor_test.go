@@ -0,0 +1,97 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	due := time.Date(2025, 3, 11, 12, 30, 0, 123456000, time.UTC)
+	cursor := Cursor{Order: "reviews:due", Key: timeCursorKey(due), ID: 42}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if *decoded != cursor {
+		t.Errorf("DecodeCursor() = %+v, want %+v", *decoded, cursor)
+	}
+	if decoded.Key != "2025-03-11 12:30:00.123456" {
+		t.Errorf("time key = %q", decoded.Key)
+	}
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	for _, encoded := range []string{"not base64!", "bm90IGpzb24", Cursor{Key: "1", ID: 1}.Encode()} {
+		if _, err := DecodeCursor(encoded); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", encoded, err)
+		}
+	}
+}
+
+func TestCheckCursorOrder(t *testing.T) {
+	cursor := &Cursor{Order: "problems:title", Key: "Two Sum", ID: 1}
+	if err := checkCursor(cursor, "problems:title"); err != nil {
+		t.Errorf("checkCursor() same order error = %v", err)
+	}
+	if err := checkCursor(cursor, "problems:title:desc"); err != ErrInvalidCursor {
+		t.Errorf("checkCursor() other order error = %v, want ErrInvalidCursor", err)
+	}
+	if err := checkCursor(nil, "problems:title"); err != nil {
+		t.Errorf("checkCursor(nil) error = %v", err)
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	got := keysetCondition("r.next_review_at", "r.id", "timestamp", false, 2)
+	if want := " AND (r.next_review_at, r.id) > ($2::timestamp, $3)"; got != want {
+		t.Errorf("keysetCondition() = %q, want %q", got, want)
+	}
+
+	got = keysetCondition("p.title", "p.id", "text", true, 5)
+	if want := " AND (p.title, p.id) < ($5::text, $6)"; got != want {
+		t.Errorf("keysetCondition() desc = %q, want %q", got, want)
+	}
+}
+
+func TestTrimKeysetPage(t *testing.T) {
+	tests := []struct {
+		n, limit int
+		wantN    int
+		wantMore bool
+	}{
+		{21, 20, 20, true},
+		{20, 20, 20, false},
+		{3, 20, 3, false},
+		{0, 20, 0, false},
+	}
+
+	for _, tt := range tests {
+		n, more := trimKeysetPage(tt.n, tt.limit)
+		if n != tt.wantN || more != tt.wantMore {
+			t.Errorf("trimKeysetPage(%d, %d) = %d, %v, want %d, %v", tt.n, tt.limit, n, more, tt.wantN, tt.wantMore)
+		}
+	}
+}
+
+func TestProblemListOrder(t *testing.T) {
+	if order := problemListOrder(ListProblemOptions{}, false, true); order != nil {
+		t.Errorf("search without order_by = %+v, want relevance ordering", order)
+	}
+
+	order := problemListOrder(ListProblemOptions{OrderBy: "next_review", OrderDir: "desc"}, true, false)
+	if order.name != "problems:next_review:desc" || order.key(Problem{}, ProblemWithStatus{}) != "-infinity" {
+		t.Errorf("next_review desc = %q, key %q", order.name, order.key(Problem{}, ProblemWithStatus{}))
+	}
+
+	// user orderings need a user, otherwise the default applies
+	order = problemListOrder(ListProblemOptions{OrderBy: "last_solved"}, false, false)
+	if order.name != "problems:frontend_id" {
+		t.Errorf("last_solved without user = %q, want problems:frontend_id", order.name)
+	}
+
+	order = problemListOrder(ListProblemOptions{OrderBy: "difficulty"}, false, false)
+	if key := order.key(Problem{Difficulty: "Medium"}, ProblemWithStatus{}); key != "2" {
+		t.Errorf("difficulty key = %q, want 2", key)
+	}
+}
@@ -0,0 +1,291 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DailyChallengeDeckName is the personal deck daily challenges are added to
+const DailyChallengeDeckName = "Daily Challenges"
+
+type DailyChallenge struct {
+	Date       time.Time `json:"date"`
+	Region     string    `json:"region"`
+	ProblemID  *int      `json:"problem_id"`
+	Title      string    `json:"title"`
+	TitleSlug  string    `json:"title_slug"`
+	Difficulty string    `json:"difficulty"`
+	Link       string    `json:"link"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type UserDailyChallenge struct {
+	UserID   uuid.UUID  `json:"user_id"`
+	Date     time.Time  `json:"date"`
+	Region   string     `json:"region"`
+	DeckID   int        `json:"deck_id"`
+	Solved   bool       `json:"solved"`
+	SolvedAt *time.Time `json:"solved_at,omitempty"`
+}
+
+type DailyChallengeStore struct {
+	db        *sql.DB
+	deckStore *DeckStore
+}
+
+func NewDailyChallengeStore(db *sql.DB, deckStore *DeckStore) *DailyChallengeStore {
+	return &DailyChallengeStore{db: db, deckStore: deckStore}
+}
+
+// dayStartOffset is the SQL interval between UTC midnight and the start of
+// the challenge day: leetcode.cn days run on China Standard Time (UTC+8)
+const dayStartOffset = `CASE WHEN dc.region = 'cn' THEN INTERVAL '8 hours' ELSE INTERVAL '0 hours' END`
+
+func (s *DailyChallengeStore) UpsertChallenge(challenge *DailyChallenge) error {
+	query := `
+		INSERT INTO daily_challenges
+		(challenge_date, region, problem_id, title, title_slug, difficulty, link)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (challenge_date, region) DO UPDATE SET
+			problem_id = EXCLUDED.problem_id,
+			title = EXCLUDED.title,
+			title_slug = EXCLUDED.title_slug,
+			difficulty = EXCLUDED.difficulty,
+			link = EXCLUDED.link
+		RETURNING created_at
+	`
+
+	err := s.db.QueryRow(
+		query,
+		challenge.Date,
+		challenge.Region,
+		challenge.ProblemID,
+		challenge.Title,
+		challenge.TitleSlug,
+		challenge.Difficulty,
+		challenge.Link,
+	).Scan(&challenge.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error saving daily challenge: %v", err)
+	}
+
+	return nil
+}
+
+// GetLatestChallenge returns the most recent recorded challenge for a region
+func (s *DailyChallengeStore) GetLatestChallenge(region string) (DailyChallenge, error) {
+	query := `
+		SELECT challenge_date, region, problem_id, title, title_slug, difficulty, COALESCE(link, ''), created_at
+		FROM daily_challenges
+		WHERE region = $1
+		ORDER BY challenge_date DESC
+		LIMIT 1
+	`
+
+	var challenge DailyChallenge
+	var problemID sql.NullInt64
+
+	err := s.db.QueryRow(query, region).Scan(
+		&challenge.Date,
+		&challenge.Region,
+		&problemID,
+		&challenge.Title,
+		&challenge.TitleSlug,
+		&challenge.Difficulty,
+		&challenge.Link,
+		&challenge.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return DailyChallenge{}, fmt.Errorf("no daily challenge recorded for region %s", region)
+		}
+		return DailyChallenge{}, fmt.Errorf("error fetching daily challenge: %v", err)
+	}
+
+	if problemID.Valid {
+		id := int(problemID.Int64)
+		challenge.ProblemID = &id
+	}
+
+	return challenge, nil
+}
+
+// LinkChallengeProblems fills in the problem of challenges recorded before
+// their problem was in the catalogue, returning how many were linked
+func (s *DailyChallengeStore) LinkChallengeProblems() (int64, error) {
+	query := `
+		UPDATE daily_challenges dc SET problem_id = p.id
+		FROM problems p
+		WHERE dc.problem_id IS NULL AND p.title_slug = dc.title_slug
+	`
+
+	result, err := s.db.Exec(query)
+	if err != nil {
+		return 0, fmt.Errorf("error linking daily challenge problems: %v", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// PendingEnrollment is a challenge to add to an opted in user's deck
+type PendingEnrollment struct {
+	UserID    uuid.UUID
+	Challenge DailyChallenge
+}
+
+// ListPendingEnrollments returns, oldest first, the challenges of a region
+// since each opted in user's enrollment that are not in their deck yet.
+// Challenges whose problem is missing from the catalogue wait until it is
+// added.
+func (s *DailyChallengeStore) ListPendingEnrollments(region string) ([]PendingEnrollment, error) {
+	query := `
+		SELECT u.id, dc.challenge_date, dc.region, dc.problem_id, dc.title, dc.title_slug,
+			dc.difficulty, COALESCE(dc.link, ''), dc.created_at
+		FROM users u
+		JOIN daily_challenges dc ON dc.region = u.leetcode_region
+		WHERE u.daily_challenge_enrolled = true AND u.leetcode_region = $1
+		  AND dc.problem_id IS NOT NULL
+		  AND dc.challenge_date >= (u.daily_challenge_enrolled_at + ` + dayStartOffset + `)::date
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_daily_challenges udc
+			WHERE udc.user_id = u.id AND udc.challenge_date = dc.challenge_date AND udc.region = dc.region
+		  )
+		ORDER BY dc.challenge_date, u.created_at
+	`
+
+	rows, err := s.db.Query(query, region)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pending daily challenges: %v", err)
+	}
+	defer rows.Close()
+
+	var pending []PendingEnrollment
+	for rows.Next() {
+		var p PendingEnrollment
+		var problemID int
+		err := rows.Scan(
+			&p.UserID,
+			&p.Challenge.Date,
+			&p.Challenge.Region,
+			&problemID,
+			&p.Challenge.Title,
+			&p.Challenge.TitleSlug,
+			&p.Challenge.Difficulty,
+			&p.Challenge.Link,
+			&p.Challenge.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning pending daily challenge: %v", err)
+		}
+		p.Challenge.ProblemID = &problemID
+		pending = append(pending, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending daily challenges: %v", err)
+	}
+
+	return pending, nil
+}
+
+// EnrollUser records the challenge for the user and adds its problem to their
+// deck with the given flashcard, in one transaction. Daily challenges repeat,
+// so a problem already in the deck keeps its flashcard. It returns false if
+// the challenge had already been recorded for the user.
+func (s *DailyChallengeStore) EnrollUser(udc *UserDailyChallenge, review *FlashcardReview) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// a concurrent run enrolling the same day waits here, then skips it
+	result, err := tx.Exec(`
+		INSERT INTO user_daily_challenges (user_id, challenge_date, region, deck_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, challenge_date, region) DO NOTHING
+	`, udc.UserID, udc.Date, udc.Region, udc.DeckID)
+	if err != nil {
+		return false, fmt.Errorf("error recording user daily challenge: %v", err)
+	}
+
+	recorded, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if recorded == 0 {
+		return false, nil
+	}
+
+	added, err := s.deckStore.AddProblemToDeck(tx, review.DeckID, review.ProblemID)
+	if err != nil {
+		return false, err
+	}
+	if added {
+		if err := createFlashcardReview(tx.QueryRow, review); err != nil {
+			return false, fmt.Errorf("error creating flashcard review: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing user daily challenge: %v", err)
+	}
+	return true, nil
+}
+
+// MarkSolvedChallenges flags enrolled challenges the user solved on the
+// challenge day itself, returning how many were newly marked
+func (s *DailyChallengeStore) MarkSolvedChallenges() (int64, error) {
+	query := `
+		UPDATE user_daily_challenges udc
+		SET solved = true, solved_at = solves.first_solved_at
+		FROM (
+			SELECT u.user_id, u.challenge_date, u.region, MIN(s.submitted_at) AS first_solved_at
+			FROM user_daily_challenges u
+			JOIN daily_challenges dc ON dc.challenge_date = u.challenge_date AND dc.region = u.region
+			JOIN submissions s ON s.user_id = u.user_id AND s.title_slug = dc.title_slug
+			WHERE u.solved = false
+			  AND s.submitted_at >= dc.challenge_date::timestamp - ` + dayStartOffset + `
+			  AND s.submitted_at < dc.challenge_date::timestamp + INTERVAL '1 day' - ` + dayStartOffset + `
+			GROUP BY u.user_id, u.challenge_date, u.region
+		) solves
+		WHERE udc.user_id = solves.user_id
+		  AND udc.challenge_date = solves.challenge_date
+		  AND udc.region = solves.region
+	`
+
+	result, err := s.db.Exec(query)
+	if err != nil {
+		return 0, fmt.Errorf("error marking solved daily challenges: %v", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// GetSolvedAt returns when the user first solved the challenge on its day, or nil
+func (s *DailyChallengeStore) GetSolvedAt(userID uuid.UUID, challenge DailyChallenge) (*time.Time, error) {
+	query := `
+		SELECT MIN(s.submitted_at)
+		FROM daily_challenges dc
+		JOIN submissions s ON s.title_slug = dc.title_slug
+		WHERE dc.challenge_date = $1 AND dc.region = $2 AND s.user_id = $3
+		  AND s.submitted_at >= dc.challenge_date::timestamp - ` + dayStartOffset + `
+		  AND s.submitted_at < dc.challenge_date::timestamp + INTERVAL '1 day' - ` + dayStartOffset + `
+	`
+
+	var solvedAt sql.NullTime
+	if err := s.db.QueryRow(query, challenge.Date, challenge.Region, userID).Scan(&solvedAt); err != nil {
+		return nil, fmt.Errorf("error checking daily challenge solve: %v", err)
+	}
+
+	if !solvedAt.Valid {
+		return nil, nil
+	}
+
+	return &solvedAt.Time, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context" // Added
 	"database/sql"
 	"fmt" // Added
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -106,6 +107,38 @@ func (s *DeckStore) CreateDeck(deck *Deck) error {
 	return s.db.QueryRow(query, deck.Name, deck.Description, deck.IsPublic, deck.UserID).Scan(&deck.ID, &deck.CreatedAt)
 }
 
+// GetOrCreateUserDeck returns the user's private deck with the given name,
+// creating it if it does not exist yet
+func (s *DeckStore) GetOrCreateUserDeck(userID uuid.UUID, name, description string) (Deck, error) {
+	query := `SELECT id, name, description, created_at, is_public, user_id FROM decks WHERE user_id = $1 AND name = $2 ORDER BY id LIMIT 1`
+	var deck Deck
+	err := s.db.QueryRow(query, userID, name).Scan(
+		&deck.ID,
+		&deck.Name,
+		&deck.Description,
+		&deck.CreatedAt,
+		&deck.IsPublic,
+		&deck.UserID,
+	)
+	if err == nil {
+		return deck, nil
+	}
+	if err != sql.ErrNoRows {
+		return Deck{}, fmt.Errorf("error fetching deck %q: %v", name, err)
+	}
+
+	deck = Deck{
+		Name:        name,
+		Description: description,
+		IsPublic:    false,
+		UserID:      userID.String(),
+	}
+	if err := s.CreateDeck(&deck); err != nil {
+		return Deck{}, fmt.Errorf("error creating deck %q: %v", name, err)
+	}
+	return deck, nil
+}
+
 func (s *DeckStore) UpdateDeck(deck *Deck) error {
 	query := `UPDATE decks SET name = $1, description = $2, is_public = $3 WHERE id = $4`
 	_, err := s.db.Exec(query, deck.Name, deck.Description, deck.IsPublic, deck.ID)
@@ -118,10 +151,28 @@ func (s *DeckStore) DeleteDeck(deckID int, userID uuid.UUID) error {
 	return err
 }
 
-func (s *DeckStore) AddProblemToDeck(deckID int, problemID int) error {
-	query := `INSERT INTO deck_problems (deck_id, problem_id) VALUES ($1, $2)`
-	_, err := s.db.Exec(query, deckID, problemID)
-	return err
+// AddProblemToDeck adds a problem to a deck within tx, or on its own when tx
+// is nil. It returns false when the problem was already in the deck.
+func (s *DeckStore) AddProblemToDeck(tx *sql.Tx, deckID int, problemID int) (bool, error) {
+	var q execer = s.db
+	if tx != nil {
+		q = tx
+	}
+
+	query := `
+		INSERT INTO deck_problems (deck_id, problem_id) VALUES ($1, $2)
+		ON CONFLICT (problem_id, deck_id) DO NOTHING
+	`
+	result, err := q.Exec(query, deckID, problemID)
+	if err != nil {
+		return false, fmt.Errorf("error adding problem to deck: %v", err)
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+	return added > 0, nil
 }
 
 // RemoveProblemFromDeck removes a problem from a deck and also deletes the corresponding flashcard review for the user.
@@ -163,21 +214,49 @@ func (s *DeckStore) RemoveProblemFromDeck(ctx context.Context, deckID, problemID
 }
 
 
-// GetProblemsInDeck retrieves problems associated with a specific deck with pagination
-func (s *DeckStore) GetProblemsInDeck(deckID int, limit int, offset int) ([]Problem, error) {
+// GetProblemsInDeck retrieves problems associated with a specific deck, ordered
+// by frontend ID and paged by offset or by keyset cursor
+func (s *DeckStore) GetProblemsInDeck(deckID int, page Page) ([]Problem, PageInfo, error) {
+	var info PageInfo
+
+	order := "deck_problems:frontend_id"
+	if err := checkCursor(page.Cursor, order); err != nil {
+		return nil, info, err
+	}
+
+	if page.countTotal() {
+		err := s.db.QueryRow(`SELECT COUNT(*) FROM deck_problems WHERE deck_id = $1`, deckID).Scan(&info.Total)
+		if err != nil {
+			return nil, info, err
+		}
+	}
+
 	query := `
 		SELECT p.id, p.frontend_id, p.title, p.title_slug, p.difficulty, p.is_paid_only
 		FROM problems p
 		JOIN deck_problems dp ON p.id = dp.problem_id
 		WHERE dp.deck_id = $1
-		ORDER BY p.frontend_id -- Or another meaningful order
-		LIMIT $2 OFFSET $3
 	`
-	// DEBUG: Log the query and parameters before execution
-	// fmt.Printf("DEBUG: Executing GetProblemsInDeck query:\nQuery: %s\nParams: deckID=%d, limit=%d, offset=%d\n", query, deckID, limit, offset)
-	rows, err := s.db.Query(query, deckID, limit, offset)
+	params := []interface{}{deckID}
+
+	if page.Cursor != nil {
+		query += keysetCondition("p.frontend_id", "p.id", "int", false, 2)
+		params = append(params, page.Cursor.Key, page.Cursor.ID)
+	}
+
+	query += " ORDER BY p.frontend_id, p.id"
+	if page.Keyset {
+		// one extra row tells whether another page follows
+		query += fmt.Sprintf(" LIMIT $%d", len(params)+1)
+		params = append(params, page.Limit+1)
+	} else {
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(params)+1, len(params)+2)
+		params = append(params, page.Limit, page.Offset)
+	}
+
+	rows, err := s.db.Query(query, params...)
 	if err != nil {
-		return nil, err
+		return nil, info, err
 	}
 	defer rows.Close()
 
@@ -193,9 +272,22 @@ func (s *DeckStore) GetProblemsInDeck(deckID int, limit int, offset int) ([]Prob
 			&problem.IsPaidOnly,
 		)
 		if err != nil {
-			return nil, err
+			return nil, info, err
 		}
 		problems = append(problems, problem)
 	}
-	return problems, nil
+
+	if page.Keyset {
+		var n int
+		n, info.HasMore = trimKeysetPage(len(problems), page.Limit)
+		problems = problems[:n]
+		if info.HasMore {
+			last := problems[n-1]
+			info.NextCursor = Cursor{Order: order, Key: strconv.Itoa(last.FrontendID), ID: last.ID}.Encode()
+		}
+	} else {
+		info.HasMore = page.Offset+len(problems) < info.Total
+	}
+
+	return problems, info, nil
 }
@@ -3,6 +3,7 @@ package models
 import (
 	"context" // Added import
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +11,10 @@ import (
 	"github.com/open-spaced-repetition/go-fsrs/v3"
 )
 
+// ErrHintsRevealedChanged is returned when a hint was revealed for a review
+// between loading it and rating it
+var ErrHintsRevealedChanged = errors.New("hints were revealed while rating the review")
+
 func NewFlashcardReviewStore(db *sql.DB) *FlashcardReviewStore {
 	return &FlashcardReviewStore{db: db}
 }
@@ -20,6 +25,8 @@ type FlashcardReview struct {
 	UserID    string    `json:"user_id"`
 	DeckID    int       `json:"deck_id"`
 	FsrsCard  fsrs.Card `json:"fsrs_card"`
+	// HintsRevealed counts the hints revealed since the card was last rated
+	HintsRevealed int `json:"hints_revealed"`
 }
 
 type FlashcardReviewLog struct {
@@ -30,24 +37,40 @@ type FlashcardReviewLog struct {
 	ElapsedDays       int       `json:"elapsed_days"`
 	ScheduledDays     int       `json:"scheduled_days"`
 	State             int       `json:"state"`
+	HintsRevealed     int       `json:"hints_revealed"`
 }
 
 type FlashcardReviewWithProblem struct {
 	FlashcardReview
 	Problem Problem `json:"problem"`
+	// Note is the user's own note of the problem, when they wrote one
+	Note *Note `json:"note,omitempty"`
+	// TestCase asks for the output of one of the user's test cases of the
+	// problem, when they wrote one with an expected value
+	TestCase *TestCasePrompt `json:"test_case,omitempty"`
 }
 
 type FlashcardReviewStore struct {
 	db *sql.DB
 }
 
-func (s *FlashcardReviewStore) GetDueFlashcardReviews(userID uuid.UUID, deckID int, limit, offset int) ([]FlashcardReviewWithProblem, int, error) {
+// GetDueFlashcardReviews pages through due flashcards ordered by due date and
+// then ID, by offset or by keyset cursor. The problem's solution approach is
+// left out, reviews only reach it through hints.
+func (s *FlashcardReviewStore) GetDueFlashcardReviews(userID uuid.UUID, deckID int, page Page) ([]FlashcardReviewWithProblem, PageInfo, error) {
+	var info PageInfo
+
+	order := "flashcards:due"
+	if err := checkCursor(page.Cursor, order); err != nil {
+		return nil, info, err
+	}
+
 	baseQuery := `
 		SELECT
 			fr.id, fr.problem_id, fr.user_id, fr.deck_id,
 			fr.stability, fr.difficulty, fr.elapsed_days, fr.scheduled_days,
-			fr.reps, fr.lapses, fr.state, fr.last_review, fr.next_review_at,
-			p.id, p.frontend_id, p.title, p.title_slug, p.difficulty, p.is_paid_only, p.content, COALESCE(p.solution_approach, '') AS solution_approach
+			fr.reps, fr.lapses, fr.state, fr.last_review, fr.next_review_at, fr.hints_revealed,
+			p.id, p.frontend_id, p.title, p.title_slug, p.difficulty, p.is_paid_only, p.content
 		FROM flashcard_reviews fr
 		JOIN problems p ON fr.problem_id = p.id
 		WHERE fr.user_id = $1 AND fr.next_review_at <= NOW()::timestamp
@@ -65,18 +88,32 @@ func (s *FlashcardReviewStore) GetDueFlashcardReviews(userID uuid.UUID, deckID i
 		paramPos++
 	}
 
-	baseQuery += fmt.Sprintf(" ORDER BY fr.next_review_at LIMIT $%d OFFSET $%d", paramPos, paramPos+1)
-	params = append(params, limit, offset)
+	countParams := params
 
-	var total int
-	err := s.db.QueryRow(countQuery, params[:paramPos-1]...).Scan(&total)
-	if err != nil {
-		return nil, 0, err
+	if page.Cursor != nil {
+		baseQuery += keysetCondition("fr.next_review_at", "fr.id", "timestamp", false, paramPos)
+		params = append(params, page.Cursor.Key, page.Cursor.ID)
+		paramPos += 2
+	}
+
+	if page.Keyset {
+		// one extra row tells whether another page follows
+		baseQuery += fmt.Sprintf(" ORDER BY fr.next_review_at, fr.id LIMIT $%d", paramPos)
+		params = append(params, page.Limit+1)
+	} else {
+		baseQuery += fmt.Sprintf(" ORDER BY fr.next_review_at, fr.id LIMIT $%d OFFSET $%d", paramPos, paramPos+1)
+		params = append(params, page.Limit, page.Offset)
+	}
+
+	if page.countTotal() {
+		if err := s.db.QueryRow(countQuery, countParams...).Scan(&info.Total); err != nil {
+			return nil, info, err
+		}
 	}
 
 	rows, err := s.db.Query(baseQuery, params...)
 	if err != nil {
-		return nil, 0, err
+		return nil, info, err
 	}
 	defer rows.Close()
 
@@ -97,6 +134,7 @@ func (s *FlashcardReviewStore) GetDueFlashcardReviews(userID uuid.UUID, deckID i
 			&review.FsrsCard.State,
 			&review.FsrsCard.LastReview,
 			&review.FsrsCard.Due,
+			&review.HintsRevealed,
 			&review.Problem.ID,
 			&review.Problem.FrontendID,
 			&review.Problem.Title,
@@ -104,20 +142,49 @@ func (s *FlashcardReviewStore) GetDueFlashcardReviews(userID uuid.UUID, deckID i
 			&review.Problem.Difficulty,
 			&review.Problem.IsPaidOnly,
 			&review.Problem.Content,
-			&review.Problem.SolutionApproach,
 		)
 		if err != nil {
-			return nil, 0, err
+			return nil, info, err
 		}
 
 		reviews = append(reviews, review)
 	}
 
-	return reviews, total, nil
+	if page.Keyset {
+		var n int
+		n, info.HasMore = trimKeysetPage(len(reviews), page.Limit)
+		reviews = reviews[:n]
+		if info.HasMore {
+			last := reviews[n-1]
+			info.NextCursor = Cursor{Order: order, Key: timeCursorKey(last.FsrsCard.Due), ID: last.ID}.Encode()
+		}
+	} else {
+		info.HasMore = page.Offset+len(reviews) < info.Total
+	}
+
+	problemIDs := make([]int, len(reviews))
+	for i, review := range reviews {
+		problemIDs[i] = review.ProblemID
+	}
+	notes, err := notesByProblem(s.db, userID, problemIDs)
+	if err != nil {
+		return nil, info, err
+	}
+	testCases, err := testCasesByProblem(s.db, userID, problemIDs)
+	if err != nil {
+		return nil, info, err
+	}
+	for i := range reviews {
+		if note, ok := notes[reviews[i].ProblemID]; ok {
+			reviews[i].Note = &note
+		}
+		reviews[i].TestCase = PromptTestCase(testCases[reviews[i].ProblemID], reviews[i].FsrsCard.Reps)
+	}
+
+	return reviews, info, nil
 }
 
-func (s *FlashcardReviewStore) CreateFlashcardReview(review *FlashcardReview) error {
-	query := `
+const createFlashcardReviewQuery = `
 		INSERT INTO flashcard_reviews (
 			problem_id, user_id, deck_id,
 			stability, difficulty, elapsed_days, scheduled_days,
@@ -129,7 +196,15 @@ func (s *FlashcardReviewStore) CreateFlashcardReview(review *FlashcardReview) er
 		)
 		RETURNING id
 	`
-	return s.db.QueryRow(query,
+
+func (s *FlashcardReviewStore) CreateFlashcardReview(review *FlashcardReview) error {
+	return createFlashcardReview(s.db.QueryRow, review)
+}
+
+// createFlashcardReview inserts review through queryRow, a *sql.DB or *sql.Tx
+// QueryRow
+func createFlashcardReview(queryRow func(query string, args ...any) *sql.Row, review *FlashcardReview) error {
+	return queryRow(createFlashcardReviewQuery,
 		review.ProblemID,
 		review.UserID,
 		review.DeckID,
@@ -157,8 +232,9 @@ func (s *FlashcardReviewStore) UpdateFlashcardReview(review *FlashcardReview) er
 			lapses = $6,
 			state = $7,
 			last_review = $8,
-			next_review_at = $9
-		WHERE id = $10
+			next_review_at = $9,
+			hints_revealed = $10
+		WHERE id = $11
 	`
 	_, err := s.db.Exec(query,
 		review.FsrsCard.Stability,
@@ -170,16 +246,81 @@ func (s *FlashcardReviewStore) UpdateFlashcardReview(review *FlashcardReview) er
 		review.FsrsCard.State,
 		review.FsrsCard.LastReview,
 		review.FsrsCard.Due,
+		review.HintsRevealed,
 		review.ID,
 	)
 	return err
 }
 
+// RateFlashcardReview saves the rated review's next schedule, resets its
+// revealed hints and stores log in one transaction. The rating was capped by
+// log.HintsRevealed, so it fails with ErrHintsRevealedChanged when another hint
+// was revealed since the review was loaded.
+func (s *FlashcardReviewStore) RateFlashcardReview(review *FlashcardReview, log *FlashcardReviewLog) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE flashcard_reviews
+		SET
+			stability = $1,
+			difficulty = $2,
+			elapsed_days = $3,
+			scheduled_days = $4,
+			reps = $5,
+			lapses = $6,
+			state = $7,
+			last_review = $8,
+			next_review_at = $9,
+			hints_revealed = 0
+		WHERE id = $10 AND hints_revealed = $11
+	`,
+		review.FsrsCard.Stability,
+		review.FsrsCard.Difficulty,
+		review.FsrsCard.ElapsedDays,
+		review.FsrsCard.ScheduledDays,
+		review.FsrsCard.Reps,
+		review.FsrsCard.Lapses,
+		review.FsrsCard.State,
+		review.FsrsCard.LastReview,
+		review.FsrsCard.Due,
+		review.ID,
+		log.HintsRevealed,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating flashcard review: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error checking update result: %v", err)
+	} else if n == 0 {
+		return ErrHintsRevealedChanged
+	}
+	review.HintsRevealed = 0
+
+	err = tx.QueryRow(`
+		INSERT INTO flashcard_review_logs
+		(flashcard_review_id, rating, review_date, elapsed_days, scheduled_days, state, hints_revealed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, log.FlashcardReviewID, log.Rating, log.ReviewDate, log.ElapsedDays, log.ScheduledDays, log.State, log.HintsRevealed).Scan(&log.ID)
+	if err != nil {
+		return fmt.Errorf("error creating flashcard review log: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing flashcard review: %v", err)
+	}
+	return nil
+}
+
 func (s *FlashcardReviewStore) CreateFlashcardReviewLog(log *FlashcardReviewLog) error {
 	query := `
 		INSERT INTO flashcard_review_logs 
-		(flashcard_review_id, rating, review_date, elapsed_days, scheduled_days, state)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		(flashcard_review_id, rating, review_date, elapsed_days, scheduled_days, state, hints_revealed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id
 	`
 	return s.db.QueryRow(query,
@@ -189,15 +330,31 @@ func (s *FlashcardReviewStore) CreateFlashcardReviewLog(log *FlashcardReviewLog)
 		log.ElapsedDays,
 		log.ScheduledDays,
 		log.State,
+		log.HintsRevealed,
 	).Scan(&log.ID)
 }
 
+// RevealHint counts one more hint revealed for a review, up to total, and
+// returns how many are revealed
+func (s *FlashcardReviewStore) RevealHint(reviewID, total int) (int, error) {
+	var revealed int
+	err := s.db.QueryRow(`
+		UPDATE flashcard_reviews SET hints_revealed = LEAST(hints_revealed + 1, $2)
+		WHERE id = $1
+		RETURNING hints_revealed
+	`, reviewID, total).Scan(&revealed)
+	if err != nil {
+		return 0, fmt.Errorf("error revealing hint: %v", err)
+	}
+	return revealed, nil
+}
+
 func (s *FlashcardReviewStore) GetReviewByID(reviewID int) (FlashcardReview, error) {
 	query := `
 		SELECT
 			id, problem_id, user_id, deck_id,
 			stability, difficulty, elapsed_days, scheduled_days,
-			reps, lapses, state, last_review, next_review_at
+			reps, lapses, state, last_review, next_review_at, hints_revealed
 		FROM flashcard_reviews
 		WHERE id = $1
 	`
@@ -216,6 +373,7 @@ func (s *FlashcardReviewStore) GetReviewByID(reviewID int) (FlashcardReview, err
 		&review.FsrsCard.State,
 		&review.FsrsCard.LastReview,
 		&review.FsrsCard.Due,
+		&review.HintsRevealed,
 	)
 	if err != nil {
 		return FlashcardReview{}, err
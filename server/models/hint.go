@@ -0,0 +1,126 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Hint kinds, in the order hints are revealed: each gives away more of the
+// solution than the one before
+const (
+	HintPattern    = "pattern"
+	HintInsight    = "insight"
+	HintAlgorithm  = "algorithm"
+	HintComplexity = "complexity"
+)
+
+// MaxHints bounds the hints of an approach
+const MaxHints = 8
+
+// hintOrder ranks hint kinds in reveal order
+var hintOrder = map[string]int{HintPattern: 0, HintInsight: 1, HintAlgorithm: 2, HintComplexity: 3}
+
+// hintRatingCaps is the highest rating a review may get once a hint of the
+// kind was revealed
+var hintRatingCaps = map[string]int{HintPattern: 3, HintInsight: 2, HintAlgorithm: 1, HintComplexity: 3}
+
+// Hint is one step of an approach revealed during a review
+type Hint struct {
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+}
+
+func IsValidHintKind(kind string) bool {
+	_, ok := hintOrder[kind]
+	return ok
+}
+
+// HintsInOrder reports whether hints follow the reveal order of their kinds
+func HintsInOrder(hints []Hint) bool {
+	for i := 1; i < len(hints); i++ {
+		if hintOrder[hints[i].Kind] < hintOrder[hints[i-1].Kind] {
+			return false
+		}
+	}
+	return true
+}
+
+// RatingCap is the highest rating allowed after revealing hints: Good after
+// the pattern or the complexity, Hard after the key insight and Again after
+// the algorithm
+func RatingCap(revealed []Hint) int {
+	limit := 4
+	for _, hint := range revealed {
+		if c, ok := hintRatingCaps[hint.Kind]; ok && c < limit {
+			limit = c
+		}
+	}
+	return limit
+}
+
+var listNumbering = regexp.MustCompile(`^\s*(\d+[.)]|[-*])\s+`)
+
+// DeriveHints builds hints for an approach that has none stored: the pattern
+// from the problem's one-line solution approach, the first paragraph of the
+// explanation as the key insight, the rest of it (or all of it when it is a
+// single paragraph) as the algorithm, and the complexity
+func DeriveHints(solutionApproach string, approach Approach) []Hint {
+	hints := []Hint{}
+
+	if line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(solutionApproach), "\n", 2)[0]); line != "" {
+		hints = append(hints, Hint{Kind: HintPattern, Text: listNumbering.ReplaceAllString(line, "")})
+	}
+
+	var paragraphs []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(approach.Explanation, "\r\n", "\n"), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	switch {
+	case len(paragraphs) == 1:
+		hints = append(hints, Hint{Kind: HintAlgorithm, Text: paragraphs[0]})
+	case len(paragraphs) > 1:
+		hints = append(hints,
+			Hint{Kind: HintInsight, Text: paragraphs[0]},
+			Hint{Kind: HintAlgorithm, Text: strings.Join(paragraphs[1:], "\n\n")})
+	}
+
+	var complexity []string
+	if approach.TimeComplexity != "" {
+		complexity = append(complexity, "Time: "+approach.TimeComplexity)
+	}
+	if approach.SpaceComplexity != "" {
+		complexity = append(complexity, "Space: "+approach.SpaceComplexity)
+	}
+	if len(complexity) > 0 {
+		hints = append(hints, Hint{Kind: HintComplexity, Text: strings.Join(complexity, ", ")})
+	}
+
+	return hints
+}
+
+// GetProblemHints returns the hints of a problem's primary approach, derived
+// when the approach has none stored or the problem has no approach
+func (s *SolutionStore) GetProblemHints(problemID int) ([]Hint, error) {
+	var solutionApproach string
+	err := s.db.QueryRow(`SELECT COALESCE(solution_approach, '') FROM problems WHERE id = $1`, problemID).Scan(&solutionApproach)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: no problem with ID %d", ErrProblemNotFound, problemID)
+		}
+		return nil, fmt.Errorf("error fetching problem: %v", err)
+	}
+
+	approach, err := scanApproach(s.db.QueryRow(`SELECT `+approachColumns+` FROM solution_approaches WHERE id = (`+primaryApproachQuery+`)`, problemID))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error fetching primary approach: %v", err)
+	}
+
+	if len(approach.Hints) > 0 {
+		return approach.Hints, nil
+	}
+	return DeriveHints(solutionApproach, approach), nil
+}
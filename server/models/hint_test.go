@@ -0,0 +1,56 @@
+package models
+
+import "testing"
+
+func TestDeriveHints(t *testing.T) {
+	approach := Approach{
+		Explanation:     "Store each value's index.\n\nScan once, looking up target - num before storing num.",
+		TimeComplexity:  "O(n)",
+		SpaceComplexity: "O(n)",
+	}
+
+	hints := DeriveHints("1. Hash map lookup\n2. Two pointers after sorting", approach)
+	want := []Hint{
+		{Kind: HintPattern, Text: "Hash map lookup"},
+		{Kind: HintInsight, Text: "Store each value's index."},
+		{Kind: HintAlgorithm, Text: "Scan once, looking up target - num before storing num."},
+		{Kind: HintComplexity, Text: "Time: O(n), Space: O(n)"},
+	}
+	if len(hints) != len(want) {
+		t.Fatalf("hints = %+v, want %+v", hints, want)
+	}
+	for i := range want {
+		if hints[i] != want[i] {
+			t.Errorf("hints[%d] = %+v, want %+v", i, hints[i], want[i])
+		}
+	}
+	if !HintsInOrder(hints) {
+		t.Error("derived hints are out of order")
+	}
+
+	if hints := DeriveHints("", Approach{Explanation: "Sort, then sweep."}); len(hints) != 1 || hints[0].Kind != HintAlgorithm {
+		t.Errorf("single paragraph hints = %+v, want one algorithm hint", hints)
+	}
+}
+
+func TestRatingCap(t *testing.T) {
+	tests := []struct {
+		kinds []string
+		want  int
+	}{
+		{nil, 4},
+		{[]string{HintPattern}, 3},
+		{[]string{HintPattern, HintInsight}, 2},
+		{[]string{HintPattern, HintInsight, HintAlgorithm, HintComplexity}, 1},
+		{[]string{HintComplexity}, 3},
+	}
+	for _, test := range tests {
+		var hints []Hint
+		for _, kind := range test.kinds {
+			hints = append(hints, Hint{Kind: kind})
+		}
+		if got := RatingCap(hints); got != test.want {
+			t.Errorf("RatingCap(%v) = %d, want %d", test.kinds, got, test.want)
+		}
+	}
+}
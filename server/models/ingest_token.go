@@ -0,0 +1,162 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IngestToken authenticates pushes to the submission ingest endpoint. The
+// token and its signing secret are only shown once on creation; we keep the
+// token's hash and a short prefix so users can tell their tokens apart, and
+// the signing secret encrypted so requests can be verified.
+type IngestToken struct {
+	ID                     int        `json:"id"`
+	UserID                 uuid.UUID  `json:"user_id"`
+	Name                   string     `json:"name"`
+	TokenPrefix            string     `json:"token_prefix"`
+	TokenHash              string     `json:"-"`
+	EncryptedSigningSecret string     `json:"-"`
+	CreatedAt              time.Time  `json:"created_at"`
+	LastUsedAt             *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt              *time.Time `json:"revoked_at,omitempty"`
+}
+
+type IngestTokenStore struct {
+	db *sql.DB
+}
+
+func NewIngestTokenStore(db *sql.DB) *IngestTokenStore {
+	return &IngestTokenStore{db: db}
+}
+
+func (s *IngestTokenStore) CreateToken(token *IngestToken) error {
+	query := `
+		INSERT INTO ingest_tokens (user_id, name, token_prefix, token_hash, encrypted_signing_secret)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := s.db.QueryRow(query, token.UserID, token.Name, token.TokenPrefix, token.TokenHash, token.EncryptedSigningSecret).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating ingest token: %v", err)
+	}
+
+	return nil
+}
+
+func (s *IngestTokenStore) ListTokens(userID uuid.UUID) ([]IngestToken, error) {
+	query := `
+		SELECT id, user_id, name, token_prefix, token_hash, COALESCE(encrypted_signing_secret, ''), created_at, last_used_at, revoked_at
+		FROM ingest_tokens WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing ingest tokens: %v", err)
+	}
+	defer rows.Close()
+
+	tokens := []IngestToken{}
+	for rows.Next() {
+		token, err := scanIngestToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ingest tokens: %v", err)
+	}
+
+	return tokens, nil
+}
+
+// GetActiveTokenByHash looks up a token that has not been revoked
+func (s *IngestTokenStore) GetActiveTokenByHash(tokenHash string) (IngestToken, error) {
+	query := `
+		SELECT id, user_id, name, token_prefix, token_hash, COALESCE(encrypted_signing_secret, ''), created_at, last_used_at, revoked_at
+		FROM ingest_tokens
+		WHERE token_hash = $1 AND revoked_at IS NULL AND encrypted_signing_secret IS NOT NULL
+	`
+
+	token, err := scanIngestToken(s.db.QueryRow(query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return IngestToken{}, fmt.Errorf("ingest token not found")
+		}
+		return IngestToken{}, err
+	}
+
+	return token, nil
+}
+
+// RevokeToken revokes one of the user's tokens. Revoked tokens are kept so
+// their last use stays visible.
+func (s *IngestTokenStore) RevokeToken(id int, userID uuid.UUID) error {
+	query := `
+		UPDATE ingest_tokens SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := s.db.Exec(query, id, userID)
+	if err != nil {
+		return fmt.Errorf("error revoking ingest token: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking revoke result: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("ingest token with ID %d not found", id)
+	}
+
+	return nil
+}
+
+func (s *IngestTokenStore) TouchToken(id int) error {
+	query := `UPDATE ingest_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1`
+
+	if _, err := s.db.Exec(query, id); err != nil {
+		return fmt.Errorf("error updating ingest token: %v", err)
+	}
+
+	return nil
+}
+
+func scanIngestToken(row interface{ Scan(dest ...any) error }) (IngestToken, error) {
+	var token IngestToken
+	var lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenPrefix,
+		&token.TokenHash,
+		&token.EncryptedSigningSecret,
+		&token.CreatedAt,
+		&lastUsedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return IngestToken{}, err
+		}
+		return IngestToken{}, fmt.Errorf("error scanning ingest token: %v", err)
+	}
+
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+
+	return token, nil
+}
@@ -0,0 +1,194 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"go-leetcode/backend/internal/examples"
+	"go-leetcode/backend/internal/runner"
+)
+
+// TopicTags is the problems.topic_tags JSONB column
+type TopicTags []TopicTag
+
+// SimilarQuestions is the problems.similar_questions JSONB column
+type SimilarQuestions []SimilarQuestion
+
+func (t *TopicTags) Scan(src interface{}) error {
+	return scanJSONB(src, (*[]TopicTag)(t), "topic tags")
+}
+
+func (t TopicTags) Value() (driver.Value, error) {
+	return jsonbValue([]TopicTag(t), "topic tags")
+}
+
+func (q *SimilarQuestions) Scan(src interface{}) error {
+	return scanJSONB(src, (*[]SimilarQuestion)(q), "similar questions")
+}
+
+func (q SimilarQuestions) Value() (driver.Value, error) {
+	return jsonbValue([]SimilarQuestion(q), "similar questions")
+}
+
+// scanJSONB decodes a JSONB array column into dest. NULL decodes to an empty
+// list so the API never returns null for these fields.
+func scanJSONB[T any](src interface{}, dest *[]T, what string) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*dest = []T{}
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("error scanning %s: unsupported type %T", what, src)
+	}
+
+	var decoded []T
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return fmt.Errorf("error scanning %s: %v", what, err)
+	}
+	if decoded == nil {
+		decoded = []T{}
+	}
+	*dest = decoded
+	return nil
+}
+
+// jsonbValue encodes a list for a JSONB column, storing nil as an empty array
+func jsonbValue[T any](list []T, what string) (driver.Value, error) {
+	if list == nil {
+		list = []T{}
+	}
+	encoded, err := json.Marshal(list)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %s: %v", what, err)
+	}
+	return string(encoded), nil
+}
+
+// ProblemExamples is the problems.examples JSONB column
+type ProblemExamples []examples.Example
+
+func (e *ProblemExamples) Scan(src interface{}) error {
+	return scanJSONB(src, (*[]examples.Example)(e), "examples")
+}
+
+func (e ProblemExamples) Value() (driver.Value, error) {
+	return jsonbValue([]examples.Example(e), "examples")
+}
+
+// CodeRunCases is the code_runs.cases JSONB column
+type CodeRunCases []runner.CaseResult
+
+func (c *CodeRunCases) Scan(src interface{}) error {
+	return scanJSONB(src, (*[]runner.CaseResult)(c), "code run cases")
+}
+
+func (c CodeRunCases) Value() (driver.Value, error) {
+	return jsonbValue([]runner.CaseResult(c), "code run cases")
+}
+
+// NoteCode is the JSONB language → code map of problem_notes.code and its
+// revisions. NULL scans to an empty map.
+type NoteCode map[string]string
+
+func (c *NoteCode) Scan(src interface{}) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*c = NoteCode{}
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("error scanning note code: unsupported type %T", src)
+	}
+
+	decoded := NoteCode{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return fmt.Errorf("error scanning note code: %v", err)
+	}
+	if decoded == nil {
+		decoded = NoteCode{}
+	}
+	*c = decoded
+	return nil
+}
+
+func (c NoteCode) Value() (driver.Value, error) {
+	if c == nil {
+		c = NoteCode{}
+	}
+	encoded, err := json.Marshal(map[string]string(c))
+	if err != nil {
+		return nil, fmt.Errorf("error encoding note code: %v", err)
+	}
+	return string(encoded), nil
+}
+
+// ApproachHints is the solution_approaches.hints JSONB column
+type ApproachHints []Hint
+
+func (h *ApproachHints) Scan(src interface{}) error {
+	return scanJSONB(src, (*[]Hint)(h), "hints")
+}
+
+func (h ApproachHints) Value() (driver.Value, error) {
+	return jsonbValue([]Hint(h), "hints")
+}
+
+// DraftApproaches is the approach_drafts.approaches JSONB column
+type DraftApproaches []DraftApproach
+
+func (a *DraftApproaches) Scan(src interface{}) error {
+	return scanJSONB(src, (*[]DraftApproach)(a), "draft approaches")
+}
+
+func (a DraftApproaches) Value() (driver.Value, error) {
+	return jsonbValue([]DraftApproach(a), "draft approaches")
+}
+
+// TestCaseInputs is the JSONB parameter name → value map of
+// problem_test_cases.inputs. NULL scans to an empty map.
+type TestCaseInputs map[string]json.RawMessage
+
+func (i *TestCaseInputs) Scan(src interface{}) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*i = TestCaseInputs{}
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("error scanning test case inputs: unsupported type %T", src)
+	}
+
+	decoded := TestCaseInputs{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return fmt.Errorf("error scanning test case inputs: %v", err)
+	}
+	if decoded == nil {
+		decoded = TestCaseInputs{}
+	}
+	*i = decoded
+	return nil
+}
+
+func (i TestCaseInputs) Value() (driver.Value, error) {
+	if i == nil {
+		i = TestCaseInputs{}
+	}
+	encoded, err := json.Marshal(map[string]json.RawMessage(i))
+	if err != nil {
+		return nil, fmt.Errorf("error encoding test case inputs: %v", err)
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestTopicTagsScan(t *testing.T) {
+	var tags TopicTags
+	if err := tags.Scan([]byte(`[{"name":"Array","slug":"array"}]`)); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(tags) != 1 || tags[0].Slug != "array" || tags[0].Name != "Array" {
+		t.Errorf("Scan() = %+v", tags)
+	}
+
+	if err := tags.Scan(nil); err != nil || tags == nil || len(tags) != 0 {
+		t.Errorf("Scan(nil) = %+v, %v, want an empty list", tags, err)
+	}
+
+	if err := tags.Scan([]byte(`{"slug":"array"}`)); err == nil {
+		t.Error("Scan() of a JSON object succeeded, want an error")
+	}
+}
+
+func TestSimilarQuestionsValue(t *testing.T) {
+	value, err := SimilarQuestions(nil).Value()
+	if err != nil || value != "[]" {
+		t.Errorf("Value() of nil = %v, %v, want []", value, err)
+	}
+
+	value, err = SimilarQuestions{{Title: "3Sum", TitleSlug: "3sum", Difficulty: "Medium"}}.Value()
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+
+	var questions SimilarQuestions
+	if err := questions.Scan(value); err != nil || len(questions) != 1 || questions[0].TitleSlug != "3sum" {
+		t.Errorf("round trip = %+v, %v", questions, err)
+	}
+}
+
+func TestTopicTagsContaining(t *testing.T) {
+	if got := topicTagsContaining("graph", "dynamic-programming"); got != `[{"slug":"graph"},{"slug":"dynamic-programming"}]` {
+		t.Errorf("topicTagsContaining() = %s", got)
+	}
+
+	got := eachTopicTagContaining([]string{"graph", "math"})
+	if len(got) != 2 || got[1] != `[{"slug":"math"}]` {
+		t.Errorf("eachTopicTagContaining() = %v", got)
+	}
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LeetcodeSession holds a user's LEETCODE_SESSION cookie. The value is
+// encrypted before it reaches the store and is never returned by the API.
+type LeetcodeSession struct {
+	UserID           uuid.UUID `json:"user_id"`
+	EncryptedSession string    `json:"-"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+var ErrLeetcodeSessionNotFound = errors.New("no leetcode session stored")
+
+type LeetcodeSessionStore struct {
+	db *sql.DB
+}
+
+func NewLeetcodeSessionStore(db *sql.DB) *LeetcodeSessionStore {
+	return &LeetcodeSessionStore{db: db}
+}
+
+// SaveSession inserts the user's session or replaces the stored one
+func (s *LeetcodeSessionStore) SaveSession(session *LeetcodeSession) error {
+	query := `
+		INSERT INTO leetcode_sessions (user_id, encrypted_session)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			encrypted_session = EXCLUDED.encrypted_session,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at
+	`
+
+	err := s.db.QueryRow(query, session.UserID, session.EncryptedSession).Scan(&session.CreatedAt, &session.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error saving leetcode session: %v", err)
+	}
+
+	return nil
+}
+
+func (s *LeetcodeSessionStore) GetSession(userID uuid.UUID) (LeetcodeSession, error) {
+	query := `
+		SELECT user_id, encrypted_session, created_at, updated_at
+		FROM leetcode_sessions WHERE user_id = $1
+	`
+
+	var session LeetcodeSession
+	err := s.db.QueryRow(query, userID).Scan(
+		&session.UserID,
+		&session.EncryptedSession,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return LeetcodeSession{}, fmt.Errorf("%w for user %s", ErrLeetcodeSessionNotFound, userID)
+		}
+		return LeetcodeSession{}, fmt.Errorf("error fetching leetcode session: %v", err)
+	}
+
+	return session, nil
+}
+
+func (s *LeetcodeSessionStore) DeleteSession(userID uuid.UUID) error {
+	query := `DELETE FROM leetcode_sessions WHERE user_id = $1`
+
+	result, err := s.db.Exec(query, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting leetcode session: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no leetcode session stored for user %s", userID)
+	}
+
+	return nil
+}
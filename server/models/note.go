@@ -0,0 +1,424 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-leetcode/backend/internal/textdiff"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNoteNotFound         = errors.New("note not found")
+	ErrNoteRevisionNotFound = errors.New("note revision not found")
+	// ErrNoteConflict is returned when saving a note over a revision other
+	// than the one the edit started from
+	ErrNoteConflict = errors.New("note was changed since the given revision")
+)
+
+// Limits on the tags of a note
+const (
+	MaxNoteTags      = 20
+	MaxNoteTagLength = 40
+)
+
+// Note is a user's own write-up of a problem: Markdown text, their code per
+// language and their tags. Every save adds a revision, see NoteRevision.
+type Note struct {
+	ID        int       `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	ProblemID int       `json:"problem_id"`
+	TitleSlug string    `json:"title_slug"`
+	Body      string    `json:"body"`
+	Code      NoteCode  `json:"code"`
+	Tags      []string  `json:"tags"`
+	Revision  int       `json:"revision"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NoteRevision is the content of a note as one save left it. RestoredFrom is
+// set on revisions made by restoring an older one.
+type NoteRevision struct {
+	Revision     int       `json:"revision"`
+	Body         string    `json:"body"`
+	Code         NoteCode  `json:"code"`
+	Tags         []string  `json:"tags"`
+	RestoredFrom *int      `json:"restored_from"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// NoteContent is what a save changes
+type NoteContent struct {
+	Body string
+	Code NoteCode
+	Tags []string
+}
+
+// NoteDiff compares two revisions of a note: the body, each language of code
+// present in either, and the tags added and removed
+type NoteDiff struct {
+	From        int                      `json:"from"`
+	To          int                      `json:"to"`
+	Body        textdiff.Diff            `json:"body"`
+	Code        map[string]textdiff.Diff `json:"code"`
+	TagsAdded   []string                 `json:"tags_added"`
+	TagsRemoved []string                 `json:"tags_removed"`
+}
+
+// NormalizeNoteTags lowercases and trims tags, dropping empty and repeated
+// ones, and reports whether they fit the tag limits
+func NormalizeNoteTags(tags []string) ([]string, bool) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > MaxNoteTagLength {
+			return nil, false
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized, len(normalized) <= MaxNoteTags
+}
+
+// DiffNoteRevisions compares revision from against revision to
+func DiffNoteRevisions(from, to NoteRevision) NoteDiff {
+	diff := NoteDiff{
+		From:        from.Revision,
+		To:          to.Revision,
+		Body:        textdiff.Compute(from.Body, to.Body),
+		Code:        map[string]textdiff.Diff{},
+		TagsAdded:   []string{},
+		TagsRemoved: []string{},
+	}
+
+	for language, code := range from.Code {
+		diff.Code[language] = textdiff.Compute(code, to.Code[language])
+	}
+	for language, code := range to.Code {
+		if _, ok := from.Code[language]; !ok {
+			diff.Code[language] = textdiff.Compute("", code)
+		}
+	}
+
+	inFrom := map[string]bool{}
+	for _, tag := range from.Tags {
+		inFrom[tag] = true
+	}
+	inTo := map[string]bool{}
+	for _, tag := range to.Tags {
+		inTo[tag] = true
+		if !inFrom[tag] {
+			diff.TagsAdded = append(diff.TagsAdded, tag)
+		}
+	}
+	for _, tag := range from.Tags {
+		if !inTo[tag] {
+			diff.TagsRemoved = append(diff.TagsRemoved, tag)
+		}
+	}
+
+	return diff
+}
+
+type NoteStore struct {
+	db *sql.DB
+}
+
+func NewNoteStore(db *sql.DB) *NoteStore {
+	return &NoteStore{db: db}
+}
+
+const noteColumns = `n.id, n.user_id, n.problem_id, p.title_slug, n.body, n.code, n.tags, n.revision, n.created_at, n.updated_at`
+
+func scanNote(row rowScanner) (Note, error) {
+	var note Note
+	err := row.Scan(&note.ID, &note.UserID, &note.ProblemID, &note.TitleSlug, &note.Body, &note.Code,
+		pq.Array(&note.Tags), &note.Revision, &note.CreatedAt, &note.UpdatedAt)
+	if note.Tags == nil {
+		note.Tags = []string{}
+	}
+	return note, err
+}
+
+func (s *NoteStore) GetNote(userID uuid.UUID, problemID int) (Note, error) {
+	query := `
+		SELECT ` + noteColumns + `
+		FROM problem_notes n
+		JOIN problems p ON p.id = n.problem_id
+		WHERE n.user_id = $1 AND n.problem_id = $2
+	`
+
+	note, err := scanNote(s.db.QueryRow(query, userID, problemID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Note{}, ErrNoteNotFound
+		}
+		return Note{}, fmt.Errorf("error fetching note: %v", err)
+	}
+	return note, nil
+}
+
+// ListNotes returns the user's notes, most recently updated first, only
+// those with the tag when it is given
+func (s *NoteStore) ListNotes(userID uuid.UUID, tag string, limit, offset int) ([]Note, error) {
+	query := `
+		SELECT ` + noteColumns + `
+		FROM problem_notes n
+		JOIN problems p ON p.id = n.problem_id
+		WHERE n.user_id = $1 AND ($2 = '' OR $2 = ANY(n.tags))
+		ORDER BY n.updated_at DESC, n.id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := s.db.Query(query, userID, tag, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error listing notes: %v", err)
+	}
+	defer rows.Close()
+
+	notes := []Note{}
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning note: %v", err)
+		}
+		notes = append(notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing notes: %v", err)
+	}
+	return notes, nil
+}
+
+// SaveNote creates or changes the user's note of a problem and records the
+// new content as a revision. With a baseRevision other than 0 it fails with
+// ErrNoteConflict unless the note is still at that revision. Saving unchanged
+// content adds no revision.
+func (s *NoteStore) SaveNote(userID uuid.UUID, problemID int, content NoteContent, baseRevision int) (Note, error) {
+	return s.save(userID, problemID, content, baseRevision, nil)
+}
+
+// RestoreNoteRevision saves the content of an older revision as a new one
+func (s *NoteStore) RestoreNoteRevision(userID uuid.UUID, problemID, revision int) (Note, error) {
+	old, err := s.GetNoteRevision(userID, problemID, revision)
+	if err != nil {
+		return Note{}, err
+	}
+	return s.save(userID, problemID, NoteContent{Body: old.Body, Code: old.Code, Tags: old.Tags}, 0, &revision)
+}
+
+func (s *NoteStore) save(userID uuid.UUID, problemID int, content NoteContent, baseRevision int, restoredFrom *int) (Note, error) {
+	if content.Code == nil {
+		content.Code = NoteCode{}
+	}
+	if content.Tags == nil {
+		content.Tags = []string{}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Note{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM problems p WHERE p.id = $1 AND `+visibleProblem+`)
+	`, problemID).Scan(&exists)
+	if err != nil {
+		return Note{}, fmt.Errorf("error checking problem: %v", err)
+	}
+	if !exists {
+		return Note{}, fmt.Errorf("%w: no problem with ID %d", ErrProblemNotFound, problemID)
+	}
+
+	// creating the note row first, when missing, gives concurrent saves a
+	// row to lock
+	_, err = tx.Exec(`
+		INSERT INTO problem_notes (user_id, problem_id) VALUES ($1, $2)
+		ON CONFLICT (user_id, problem_id) DO NOTHING
+	`, userID, problemID)
+	if err != nil {
+		return Note{}, fmt.Errorf("error creating note: %v", err)
+	}
+
+	var noteID, revision int
+	var current NoteRevision
+	err = tx.QueryRow(`
+		SELECT id, revision, body, code, tags FROM problem_notes
+		WHERE user_id = $1 AND problem_id = $2
+		FOR UPDATE
+	`, userID, problemID).Scan(&noteID, &revision, &current.Body, &current.Code, pq.Array(&current.Tags))
+	if err != nil {
+		return Note{}, fmt.Errorf("error fetching note: %v", err)
+	}
+
+	if baseRevision != 0 && baseRevision != revision {
+		return Note{}, ErrNoteConflict
+	}
+
+	if revision == 0 || !sameNoteContent(current, content) {
+		revision++
+		_, err = tx.Exec(`
+			UPDATE problem_notes SET body = $1, code = $2, tags = $3, revision = $4, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $5
+		`, content.Body, content.Code, pq.Array(content.Tags), revision, noteID)
+		if err != nil {
+			return Note{}, fmt.Errorf("error updating note: %v", err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO problem_note_revisions (note_id, revision, body, code, tags, restored_from)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, noteID, revision, content.Body, content.Code, pq.Array(content.Tags), restoredFrom)
+		if err != nil {
+			return Note{}, fmt.Errorf("error recording note revision: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Note{}, fmt.Errorf("error committing note: %v", err)
+	}
+
+	return s.GetNote(userID, problemID)
+}
+
+func sameNoteContent(current NoteRevision, content NoteContent) bool {
+	if current.Body != content.Body || len(current.Code) != len(content.Code) || len(current.Tags) != len(content.Tags) {
+		return false
+	}
+	for language, code := range content.Code {
+		if old, ok := current.Code[language]; !ok || old != code {
+			return false
+		}
+	}
+	for i, tag := range content.Tags {
+		if current.Tags[i] != tag {
+			return false
+		}
+	}
+	return true
+}
+
+// DeleteNote removes the user's note of a problem with its revisions
+func (s *NoteStore) DeleteNote(userID uuid.UUID, problemID int) error {
+	result, err := s.db.Exec(`DELETE FROM problem_notes WHERE user_id = $1 AND problem_id = $2`, userID, problemID)
+	if err != nil {
+		return fmt.Errorf("error deleting note: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	} else if n == 0 {
+		return ErrNoteNotFound
+	}
+	return nil
+}
+
+const noteRevisionColumns = `r.revision, r.body, r.code, r.tags, r.restored_from, r.created_at`
+
+func scanNoteRevision(row rowScanner) (NoteRevision, error) {
+	var revision NoteRevision
+	var restoredFrom sql.NullInt64
+	err := row.Scan(&revision.Revision, &revision.Body, &revision.Code, pq.Array(&revision.Tags), &restoredFrom, &revision.CreatedAt)
+	if restoredFrom.Valid {
+		from := int(restoredFrom.Int64)
+		revision.RestoredFrom = &from
+	}
+	if revision.Tags == nil {
+		revision.Tags = []string{}
+	}
+	return revision, err
+}
+
+// ListNoteRevisions returns the revisions of the user's note, newest first
+func (s *NoteStore) ListNoteRevisions(userID uuid.UUID, problemID int) ([]NoteRevision, error) {
+	query := `
+		SELECT ` + noteRevisionColumns + `
+		FROM problem_note_revisions r
+		JOIN problem_notes n ON n.id = r.note_id
+		WHERE n.user_id = $1 AND n.problem_id = $2
+		ORDER BY r.revision DESC
+	`
+
+	rows, err := s.db.Query(query, userID, problemID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing note revisions: %v", err)
+	}
+	defer rows.Close()
+
+	revisions := []NoteRevision{}
+	for rows.Next() {
+		revision, err := scanNoteRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning note revision: %v", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing note revisions: %v", err)
+	}
+	if len(revisions) == 0 {
+		return nil, ErrNoteNotFound
+	}
+	return revisions, nil
+}
+
+func (s *NoteStore) GetNoteRevision(userID uuid.UUID, problemID, revision int) (NoteRevision, error) {
+	query := `
+		SELECT ` + noteRevisionColumns + `
+		FROM problem_note_revisions r
+		JOIN problem_notes n ON n.id = r.note_id
+		WHERE n.user_id = $1 AND n.problem_id = $2 AND r.revision = $3
+	`
+
+	rev, err := scanNoteRevision(s.db.QueryRow(query, userID, problemID, revision))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return NoteRevision{}, ErrNoteRevisionNotFound
+		}
+		return NoteRevision{}, fmt.Errorf("error fetching note revision: %v", err)
+	}
+	return rev, nil
+}
+
+// notesByProblem loads the user's notes of the given problems
+func notesByProblem(db *sql.DB, userID uuid.UUID, problemIDs []int) (map[int]Note, error) {
+	notes := map[int]Note{}
+	if len(problemIDs) == 0 {
+		return notes, nil
+	}
+
+	query := `
+		SELECT ` + noteColumns + `
+		FROM problem_notes n
+		JOIN problems p ON p.id = n.problem_id
+		WHERE n.user_id = $1 AND n.problem_id = ANY($2) AND n.revision > 0
+	`
+
+	rows, err := db.Query(query, userID, pq.Array(problemIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching notes: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning note: %v", err)
+		}
+		notes[note.ProblemID] = note
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching notes: %v", err)
+	}
+	return notes, nil
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go-leetcode/backend/internal/database"
+	"go-leetcode/backend/internal/testutils"
+)
+
+func TestNormalizeNoteTags(t *testing.T) {
+	tags, ok := NormalizeNoteTags([]string{" Two Pointers", "dp", "DP", "", "two pointers"})
+	if !ok || strings.Join(tags, ",") != "two pointers,dp" {
+		t.Errorf("tags = %q, %v, want [two pointers dp], true", tags, ok)
+	}
+
+	if _, ok := NormalizeNoteTags([]string{strings.Repeat("x", MaxNoteTagLength+1)}); ok {
+		t.Error("accepted a tag over the length limit")
+	}
+
+	many := make([]string, MaxNoteTags+1)
+	for i := range many {
+		many[i] = strings.Repeat("t", i+1)
+	}
+	if _, ok := NormalizeNoteTags(many); ok {
+		t.Error("accepted more tags than the limit")
+	}
+}
+
+func TestDiffNoteRevisions(t *testing.T) {
+	from := NoteRevision{
+		Revision: 1,
+		Body:     "use a stack",
+		Code:     NoteCode{"python": "a\nb", "go": "x"},
+		Tags:     []string{"stack", "easy"},
+	}
+	to := NoteRevision{
+		Revision: 3,
+		Body:     "use a stack",
+		Code:     NoteCode{"python": "a\nc", "java": "y"},
+		Tags:     []string{"stack", "monotonic"},
+	}
+
+	diff := DiffNoteRevisions(from, to)
+	if diff.From != 1 || diff.To != 3 || diff.Body.Changed() {
+		t.Errorf("diff = %+v", diff)
+	}
+	for language, want := range map[string][2]int{"python": {1, 1}, "go": {0, 1}, "java": {1, 0}} {
+		d := diff.Code[language]
+		if d.Added != want[0] || d.Removed != want[1] {
+			t.Errorf("code[%s] added %d, removed %d, want %d and %d", language, d.Added, d.Removed, want[0], want[1])
+		}
+	}
+	if strings.Join(diff.TagsAdded, ",") != "monotonic" || strings.Join(diff.TagsRemoved, ",") != "easy" {
+		t.Errorf("tags added %v, removed %v", diff.TagsAdded, diff.TagsRemoved)
+	}
+}
+
+func TestSaveNote(t *testing.T) {
+	testDB := database.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	user := User{Username: "testuser", LeetcodeUsername: "leetcode_testuser"}
+	testutils.CheckErr(t, NewUserStore(testDB.DB).CreateUser(&user), "Failed to create test user")
+
+	store := NewNoteStore(testDB.DB)
+	first := NoteContent{Body: "use a hash map", Code: NoteCode{"python": "pass"}, Tags: []string{"hash table"}}
+
+	note, err := store.SaveNote(user.ID, 1, first, 0)
+	testutils.CheckErr(t, err, "Failed to create note")
+	if note.Revision != 1 || note.Body != first.Body || note.TitleSlug != "two-sum" {
+		t.Fatalf("expected revision 1 of the two-sum note, got %+v", note)
+	}
+
+	// saving the same content again adds no revision
+	note, err = store.SaveNote(user.ID, 1, first, 1)
+	testutils.CheckErr(t, err, "Failed to save unchanged note")
+	if note.Revision != 1 {
+		t.Errorf("expected an unchanged save to stay at revision 1, got %d", note.Revision)
+	}
+
+	second := NoteContent{Body: "one pass with a hash map", Code: NoteCode{"python": "pass"}, Tags: []string{"hash table"}}
+	note, err = store.SaveNote(user.ID, 1, second, 1)
+	testutils.CheckErr(t, err, "Failed to update note")
+	if note.Revision != 2 || note.Body != second.Body {
+		t.Fatalf("expected revision 2 with the new body, got %+v", note)
+	}
+
+	// an edit started from revision 1 conflicts with revision 2
+	if _, err := store.SaveNote(user.ID, 1, NoteContent{Body: "stale"}, 1); !errors.Is(err, ErrNoteConflict) {
+		t.Errorf("expected ErrNoteConflict saving over revision 1, got %v", err)
+	}
+	current, err := store.GetNote(user.ID, 1)
+	testutils.CheckErr(t, err, "Failed to get note")
+	if current.Revision != 2 || current.Body != second.Body {
+		t.Errorf("expected the conflicting save to leave revision 2, got %+v", current)
+	}
+
+	revisions, err := store.ListNoteRevisions(user.ID, 1)
+	testutils.CheckErr(t, err, "Failed to list note revisions")
+	if len(revisions) != 2 || revisions[0].Revision != 2 || revisions[1].Revision != 1 {
+		t.Errorf("expected revisions 2 and 1, got %+v", revisions)
+	}
+}
+
+func TestRestoreNoteRevision(t *testing.T) {
+	testDB := database.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	user := User{Username: "testuser", LeetcodeUsername: "leetcode_testuser"}
+	testutils.CheckErr(t, NewUserStore(testDB.DB).CreateUser(&user), "Failed to create test user")
+
+	store := NewNoteStore(testDB.DB)
+	first := NoteContent{Body: "brute force", Code: NoteCode{"go": "func twoSum() {}"}, Tags: []string{"array"}}
+	_, err := store.SaveNote(user.ID, 1, first, 0)
+	testutils.CheckErr(t, err, "Failed to create note")
+	_, err = store.SaveNote(user.ID, 1, NoteContent{Body: "hash map", Tags: []string{"hash table"}}, 1)
+	testutils.CheckErr(t, err, "Failed to update note")
+
+	note, err := store.RestoreNoteRevision(user.ID, 1, 1)
+	testutils.CheckErr(t, err, "Failed to restore revision 1")
+	if note.Revision != 3 || note.Body != first.Body || note.Code["go"] != first.Code["go"] || strings.Join(note.Tags, ",") != "array" {
+		t.Fatalf("expected revision 3 with the content of revision 1, got %+v", note)
+	}
+
+	restored, err := store.GetNoteRevision(user.ID, 1, 3)
+	testutils.CheckErr(t, err, "Failed to get revision 3")
+	if restored.RestoredFrom == nil || *restored.RestoredFrom != 1 {
+		t.Errorf("expected revision 3 to be restored from 1, got %v", restored.RestoredFrom)
+	}
+
+	if _, err := store.RestoreNoteRevision(user.ID, 1, 7); !errors.Is(err, ErrNoteRevisionNotFound) {
+		t.Errorf("expected ErrNoteRevisionNotFound restoring revision 7, got %v", err)
+	}
+}
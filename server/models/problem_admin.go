@@ -0,0 +1,350 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// ErrProblemExists is returned when creating a problem whose ID, frontend ID
+// or slug is taken
+var ErrProblemExists = errors.New("problem already exists")
+
+// ErrProblemSlugChange is returned when an update renames a problem's slug,
+// which submissions, reviews and code runs refer to the problem by
+var ErrProblemSlugChange = errors.New("problem slug cannot change")
+
+// ProblemUpdate holds the fields an admin changes on a problem; nil fields are
+// left as they are
+type ProblemUpdate struct {
+	FrontendID       *int              `json:"frontend_id"`
+	Title            *string           `json:"title"`
+	TitleSlug        *string           `json:"title_slug"`
+	Difficulty       *string           `json:"difficulty"`
+	IsPaidOnly       *bool             `json:"is_paid_only"`
+	Content          *string           `json:"content"`
+	ExampleTestcases *string           `json:"example_testcases"`
+	SimilarQuestions *SimilarQuestions `json:"similar_questions"`
+	SolutionApproach *string           `json:"solution_approach"`
+}
+
+// apply sets the fields of the update on problem, recording each changed
+// field in changes
+func (u ProblemUpdate) apply(problem *Problem, changes map[string]AuditChange) {
+	if u.FrontendID != nil {
+		auditField(changes, "frontend_id", problem.FrontendID, *u.FrontendID)
+		problem.FrontendID = *u.FrontendID
+	}
+	if u.Title != nil {
+		auditField(changes, "title", problem.Title, *u.Title)
+		problem.Title = *u.Title
+	}
+	if u.TitleSlug != nil {
+		auditField(changes, "title_slug", problem.TitleSlug, *u.TitleSlug)
+		problem.TitleSlug = *u.TitleSlug
+	}
+	if u.Difficulty != nil {
+		auditField(changes, "difficulty", problem.Difficulty, *u.Difficulty)
+		problem.Difficulty = *u.Difficulty
+	}
+	if u.IsPaidOnly != nil {
+		auditField(changes, "is_paid_only", problem.IsPaidOnly, *u.IsPaidOnly)
+		problem.IsPaidOnly = *u.IsPaidOnly
+	}
+	if u.Content != nil {
+		auditField(changes, "content", problem.Content, *u.Content)
+		problem.Content = *u.Content
+	}
+	if u.ExampleTestcases != nil {
+		auditField(changes, "example_testcases", problem.ExampleTestcases, *u.ExampleTestcases)
+		problem.ExampleTestcases = *u.ExampleTestcases
+	}
+	if u.SimilarQuestions != nil {
+		auditField(changes, "similar_questions", problem.SimilarQuestions, *u.SimilarQuestions)
+		problem.SimilarQuestions = *u.SimilarQuestions
+	}
+	if u.SolutionApproach != nil {
+		auditField(changes, "solution_approach", problem.SolutionApproach, *u.SolutionApproach)
+		problem.SolutionApproach = *u.SolutionApproach
+	}
+}
+
+// adminProblemColumns follow problemColumns in admin queries, which also read
+// the solution approach
+const adminProblemColumns = problemColumns + `, COALESCE(p.solution_approach, '')`
+
+// rowQuerier is implemented by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// getProblemForAdmin loads a problem whatever its lifecycle, locking its row
+// for the rest of the transaction when forUpdate is set
+func getProblemForAdmin(q rowQuerier, id int, forUpdate bool) (Problem, error) {
+	query := `SELECT ` + adminProblemColumns + ` FROM problems p WHERE p.id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+
+	var approach string
+	problem, err := scanProblem(q.QueryRow(query, id), &approach)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Problem{}, fmt.Errorf("%w: no problem with ID %d", ErrProblemNotFound, id)
+		}
+		return Problem{}, fmt.Errorf("error fetching problem: %v", err)
+	}
+	problem.SolutionApproach = approach
+	return problem, nil
+}
+
+// GetProblemForAdmin loads a problem with its solution approach, including
+// hidden and retired problems
+func (s *ProblemStore) GetProblemForAdmin(id int) (Problem, error) {
+	problem, err := getProblemForAdmin(s.db, id, false)
+	if err != nil {
+		return Problem{}, err
+	}
+
+	problems := []Problem{problem}
+	if err := s.AttachExamples(problems); err != nil {
+		return Problem{}, err
+	}
+	return problems[0], nil
+}
+
+// problemTaken reports whether another problem than id has the frontend ID or
+// slug
+func problemTaken(tx *sql.Tx, id, frontendID int, titleSlug string) (bool, error) {
+	var taken bool
+	err := tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM problems WHERE id <> $1 AND (frontend_id = $2 OR title_slug = $3)
+		)
+	`, id, frontendID, titleSlug).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("error checking problem uniqueness: %v", err)
+	}
+	return taken, nil
+}
+
+// CreateProblem inserts an active problem and its topic mappings on behalf of
+// actorID, failing with ErrProblemExists when its ID, frontend ID or slug is
+// taken
+func (s *ProblemStore) CreateProblem(actorID uuid.UUID, problem *Problem) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM problems WHERE id = $1)`, problem.ID).Scan(&exists); err != nil {
+		return fmt.Errorf("error checking problem uniqueness: %v", err)
+	}
+	taken, err := problemTaken(tx, problem.ID, problem.FrontendID, problem.TitleSlug)
+	if err != nil {
+		return err
+	}
+	if exists || taken {
+		return ErrProblemExists
+	}
+
+	if problem.TopicTags == nil {
+		problem.TopicTags = TopicTags{}
+	}
+	if problem.SimilarQuestions == nil {
+		problem.SimilarQuestions = SimilarQuestions{}
+	}
+	problem.Lifecycle = ProblemActive
+
+	err = tx.QueryRow(`
+		INSERT INTO problems
+		(id, frontend_id, title, title_slug, difficulty, is_paid_only, content, topic_tags,
+		 example_testcases, similar_questions, solution_approach, lifecycle)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12)
+		RETURNING created_at
+	`, problem.ID, problem.FrontendID, problem.Title, problem.TitleSlug, problem.Difficulty, problem.IsPaidOnly,
+		problem.Content, problem.TopicTags, problem.ExampleTestcases, problem.SimilarQuestions,
+		problem.SolutionApproach, problem.Lifecycle).Scan(&problem.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating problem: %v", err)
+	}
+
+	if err := replaceProblemTopics(tx, problem.ID, problem.TopicTags); err != nil {
+		return err
+	}
+
+	if err := saveRenderedContent(tx.Exec, problem.ID, problem.Content); err != nil {
+		return err
+	}
+
+	changes := map[string]AuditChange{}
+	auditField(changes, "frontend_id", nil, problem.FrontendID)
+	auditField(changes, "title", nil, problem.Title)
+	auditField(changes, "title_slug", nil, problem.TitleSlug)
+	auditField(changes, "difficulty", nil, problem.Difficulty)
+	auditField(changes, "is_paid_only", nil, problem.IsPaidOnly)
+	auditField(changes, "topic_tags", nil, problem.TopicTags)
+	if err := recordAudit(tx, actorID, AuditProblemCreate, "problem", strconv.Itoa(problem.ID), changes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing problem: %v", err)
+	}
+	return nil
+}
+
+// UpdateProblem applies update to a problem on behalf of actorID and returns
+// the updated problem. Nothing is written or audited when no field changes.
+func (s *ProblemStore) UpdateProblem(actorID uuid.UUID, id int, update ProblemUpdate) (Problem, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Problem{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	problem, err := getProblemForAdmin(tx, id, true)
+	if err != nil {
+		return Problem{}, err
+	}
+
+	changes := map[string]AuditChange{}
+	update.apply(&problem, changes)
+	if len(changes) == 0 {
+		return problem, nil
+	}
+
+	if _, slugChanged := changes["title_slug"]; slugChanged {
+		return Problem{}, ErrProblemSlugChange
+	}
+
+	if _, frontendIDChanged := changes["frontend_id"]; frontendIDChanged {
+		taken, err := problemTaken(tx, id, problem.FrontendID, problem.TitleSlug)
+		if err != nil {
+			return Problem{}, err
+		}
+		if taken {
+			return Problem{}, ErrProblemExists
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE problems
+		SET frontend_id = $1, title = $2, title_slug = $3, difficulty = $4, is_paid_only = $5,
+		    content = $6, example_testcases = $7, similar_questions = $8, solution_approach = NULLIF($9, '')
+		WHERE id = $10
+	`, problem.FrontendID, problem.Title, problem.TitleSlug, problem.Difficulty, problem.IsPaidOnly,
+		problem.Content, problem.ExampleTestcases, problem.SimilarQuestions, problem.SolutionApproach, id)
+	if err != nil {
+		return Problem{}, fmt.Errorf("error updating problem: %v", err)
+	}
+
+	if _, contentChanged := changes["content"]; contentChanged {
+		if err := saveRenderedContent(tx.Exec, id, problem.Content); err != nil {
+			return Problem{}, err
+		}
+	}
+
+	if err := recordAudit(tx, actorID, AuditProblemUpdate, "problem", strconv.Itoa(id), changes); err != nil {
+		return Problem{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Problem{}, fmt.Errorf("error committing problem: %v", err)
+	}
+	return problem, nil
+}
+
+// SetProblemLifecycle hides, retires or reactivates a problem on behalf of
+// actorID
+func (s *ProblemStore) SetProblemLifecycle(actorID uuid.UUID, id int, lifecycle string) (Problem, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Problem{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	problem, err := getProblemForAdmin(tx, id, true)
+	if err != nil {
+		return Problem{}, err
+	}
+	if problem.Lifecycle == lifecycle {
+		return problem, nil
+	}
+
+	if _, err := tx.Exec(`UPDATE problems SET lifecycle = $1 WHERE id = $2`, lifecycle, id); err != nil {
+		return Problem{}, fmt.Errorf("error updating problem lifecycle: %v", err)
+	}
+
+	changes := map[string]AuditChange{"lifecycle": {Old: problem.Lifecycle, New: lifecycle}}
+	if err := recordAudit(tx, actorID, AuditProblemLifecycle, "problem", strconv.Itoa(id), changes); err != nil {
+		return Problem{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Problem{}, fmt.Errorf("error committing problem lifecycle: %v", err)
+	}
+	problem.Lifecycle = lifecycle
+	return problem, nil
+}
+
+// SetProblemTopics replaces the topic tags of a problem on behalf of actorID,
+// keeping the topic_tags column and problems_topic rows in step
+func (s *ProblemStore) SetProblemTopics(actorID uuid.UUID, id int, tags TopicTags) (Problem, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Problem{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	problem, err := getProblemForAdmin(tx, id, true)
+	if err != nil {
+		return Problem{}, err
+	}
+
+	changes := map[string]AuditChange{}
+	auditField(changes, "topic_tags", problem.TopicTags, tags)
+	if len(changes) == 0 {
+		return problem, nil
+	}
+
+	if _, err := tx.Exec(`UPDATE problems SET topic_tags = $1 WHERE id = $2`, tags, id); err != nil {
+		return Problem{}, fmt.Errorf("error updating problem topics: %v", err)
+	}
+	if err := replaceProblemTopics(tx, id, tags); err != nil {
+		return Problem{}, err
+	}
+
+	if err := recordAudit(tx, actorID, AuditProblemTopics, "problem", strconv.Itoa(id), changes); err != nil {
+		return Problem{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Problem{}, fmt.Errorf("error committing problem topics: %v", err)
+	}
+	problem.TopicTags = tags
+	return problem, nil
+}
+
+// replaceProblemTopics makes the problems_topic rows of a problem match tags
+func replaceProblemTopics(tx *sql.Tx, problemID int, tags TopicTags) error {
+	if _, err := tx.Exec(`DELETE FROM problems_topic WHERE problem_id = $1`, problemID); err != nil {
+		return fmt.Errorf("error clearing problem topics: %v", err)
+	}
+
+	for _, tag := range tags {
+		_, err := tx.Exec(`
+			INSERT INTO problems_topic (problem_id, topic_slug) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, problemID, tag.Slug)
+		if err != nil {
+			return fmt.Errorf("error adding problem topic: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"go-leetcode/backend/internal/database"
+	"go-leetcode/backend/internal/testutils"
+
+	"github.com/google/uuid"
+)
+
+func TestRoleAtLeast(t *testing.T) {
+	tests := []struct {
+		role, required string
+		want           bool
+	}{
+		{RoleAdmin, RoleEditor, true},
+		{RoleEditor, RoleEditor, true},
+		{RoleEditor, RoleAdmin, false},
+		{RoleUser, RoleEditor, false},
+		{"", RoleUser, false},
+		{"owner", RoleUser, false},
+	}
+
+	for _, tt := range tests {
+		if got := RoleAtLeast(tt.role, tt.required); got != tt.want {
+			t.Errorf("RoleAtLeast(%q, %q) = %v, want %v", tt.role, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestProblemUpdateApply(t *testing.T) {
+	problem := Problem{
+		Title:            "Two Sum",
+		Difficulty:       "Easy",
+		SolutionApproach: "hash map",
+		SimilarQuestions: SimilarQuestions{{Title: "3Sum", TitleSlug: "3sum", Difficulty: "Medium"}},
+	}
+	title := "Two Sum"
+	difficulty := "Medium"
+	approach := ""
+	similar := SimilarQuestions{{Title: "3Sum", TitleSlug: "3sum", Difficulty: "Medium"}}
+
+	changes := map[string]AuditChange{}
+	ProblemUpdate{
+		Title:            &title,
+		Difficulty:       &difficulty,
+		SolutionApproach: &approach,
+		SimilarQuestions: &similar,
+	}.apply(&problem, changes)
+
+	if problem.Difficulty != "Medium" || problem.SolutionApproach != "" {
+		t.Errorf("apply left problem at %q / %q", problem.Difficulty, problem.SolutionApproach)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("changes = %v, want difficulty and solution_approach only", changes)
+	}
+	if c := changes["difficulty"]; c.Old != "Easy" || c.New != "Medium" {
+		t.Errorf("difficulty change = %v", c)
+	}
+	if c := changes["solution_approach"]; c.Old != "hash map" || c.New != "" {
+		t.Errorf("solution_approach change = %v", c)
+	}
+}
+
+// createAdminTestProblem creates a problem of its own for a test to change,
+// which the caller removes with deleteAdminTestProblem
+func createAdminTestProblem(t *testing.T, testDB *database.TestDB, actorID uuid.UUID) Problem {
+	t.Helper()
+	problem := Problem{
+		ID:         990001,
+		FrontendID: 990001,
+		Title:      "Admin Test Problem",
+		TitleSlug:  "admin-test-problem",
+		Difficulty: "Easy",
+		Content:    "<p>Return the answer.</p>",
+	}
+	err := NewProblemStore(testDB.DB).CreateProblem(actorID, &problem)
+	testutils.CheckErr(t, err, "Failed to create test problem")
+	return problem
+}
+
+func deleteAdminTestProblem(t *testing.T, testDB *database.TestDB, id int) {
+	if _, err := testDB.DB.Exec(`DELETE FROM problems WHERE id = $1`, id); err != nil {
+		t.Errorf("Could not delete test problem %d: %v", id, err)
+	}
+}
+
+// auditEntries returns the audit entries of an entity, newest first
+func auditEntries(t *testing.T, testDB *database.TestDB, entityType, entityID string) []AuditEntry {
+	t.Helper()
+	entries, err := NewAuditStore(testDB.DB).ListAuditEntries(AuditFilter{EntityType: entityType, EntityID: entityID, Limit: 10})
+	testutils.CheckErr(t, err, "Failed to list audit entries")
+	return entries
+}
+
+func TestUpdateProblem(t *testing.T) {
+	testDB := database.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	admin := User{Username: "admin", LeetcodeUsername: "leetcode_admin"}
+	testutils.CheckErr(t, NewUserStore(testDB.DB).CreateUser(&admin), "Failed to create admin")
+	problem := createAdminTestProblem(t, testDB, admin.ID)
+	defer deleteAdminTestProblem(t, testDB, problem.ID)
+	entityID := strconv.Itoa(problem.ID)
+
+	store := NewProblemStore(testDB.DB)
+	difficulty := "Hard"
+	updated, err := store.UpdateProblem(admin.ID, problem.ID, ProblemUpdate{Title: &problem.Title, Difficulty: &difficulty})
+	testutils.CheckErr(t, err, "Failed to update problem")
+	if updated.Difficulty != "Hard" {
+		t.Errorf("expected difficulty Hard, got %q", updated.Difficulty)
+	}
+
+	entries := auditEntries(t, testDB, "problem", entityID)
+	if len(entries) != 2 || entries[0].Action != AuditProblemUpdate || entries[1].Action != AuditProblemCreate {
+		t.Fatalf("expected the create and update entries, got %+v", entries)
+	}
+	if len(entries[0].Changes) != 1 {
+		t.Errorf("expected only the difficulty in the update entry, got %v", entries[0].Changes)
+	}
+	if c := entries[0].Changes["difficulty"]; c.Old != "Easy" || c.New != "Hard" {
+		t.Errorf("expected difficulty to change from Easy to Hard, got %v", c)
+	}
+
+	// an update changing nothing writes no audit entry
+	_, err = store.UpdateProblem(admin.ID, problem.ID, ProblemUpdate{Difficulty: &difficulty})
+	testutils.CheckErr(t, err, "Failed to apply unchanged update")
+	if entries := auditEntries(t, testDB, "problem", entityID); len(entries) != 2 {
+		t.Errorf("expected no entry for an unchanged update, got %d entries", len(entries))
+	}
+
+	slug := "admin-test-problem-renamed"
+	title := "Renamed"
+	if _, err := store.UpdateProblem(admin.ID, problem.ID, ProblemUpdate{Title: &title, TitleSlug: &slug}); !errors.Is(err, ErrProblemSlugChange) {
+		t.Fatalf("expected ErrProblemSlugChange renaming the slug, got %v", err)
+	}
+	stored, err := store.GetProblemForAdmin(problem.ID)
+	testutils.CheckErr(t, err, "Failed to get problem")
+	if stored.TitleSlug != problem.TitleSlug || stored.Title != problem.Title {
+		t.Errorf("expected the rejected rename to change nothing, got %q / %q", stored.Title, stored.TitleSlug)
+	}
+
+	taken := 1
+	if _, err := store.UpdateProblem(admin.ID, problem.ID, ProblemUpdate{FrontendID: &taken}); !errors.Is(err, ErrProblemExists) {
+		t.Errorf("expected ErrProblemExists taking frontend ID 1, got %v", err)
+	}
+}
+
+func TestSetProblemLifecycle(t *testing.T) {
+	testDB := database.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	admin := User{Username: "admin", LeetcodeUsername: "leetcode_admin"}
+	testutils.CheckErr(t, NewUserStore(testDB.DB).CreateUser(&admin), "Failed to create admin")
+	problem := createAdminTestProblem(t, testDB, admin.ID)
+	defer deleteAdminTestProblem(t, testDB, problem.ID)
+	entityID := strconv.Itoa(problem.ID)
+
+	store := NewProblemStore(testDB.DB)
+	hidden, err := store.SetProblemLifecycle(admin.ID, problem.ID, ProblemHidden)
+	testutils.CheckErr(t, err, "Failed to hide problem")
+	if hidden.Lifecycle != ProblemHidden {
+		t.Errorf("expected the problem hidden, got %q", hidden.Lifecycle)
+	}
+	if _, err := store.GetProblemByID(problem.ID); !errors.Is(err, ErrProblemNotFound) {
+		t.Errorf("expected a hidden problem not to be served, got %v", err)
+	}
+
+	entries := auditEntries(t, testDB, "problem", entityID)
+	if len(entries) != 2 || entries[0].Action != AuditProblemLifecycle {
+		t.Fatalf("expected a lifecycle entry after the create entry, got %+v", entries)
+	}
+	if c := entries[0].Changes["lifecycle"]; c.Old != ProblemActive || c.New != ProblemHidden {
+		t.Errorf("expected lifecycle to change from active to hidden, got %v", c)
+	}
+
+	// setting the lifecycle the problem has is not audited
+	_, err = store.SetProblemLifecycle(admin.ID, problem.ID, ProblemHidden)
+	testutils.CheckErr(t, err, "Failed to hide hidden problem")
+	if entries := auditEntries(t, testDB, "problem", entityID); len(entries) != 2 {
+		t.Errorf("expected no entry for an unchanged lifecycle, got %d entries", len(entries))
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	testDB := database.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	userStore := NewUserStore(testDB.DB)
+	admin := User{Username: "admin", LeetcodeUsername: "leetcode_admin"}
+	testutils.CheckErr(t, userStore.CreateUser(&admin), "Failed to create admin")
+	user := User{Username: "testuser", LeetcodeUsername: "leetcode_testuser"}
+	testutils.CheckErr(t, userStore.CreateUser(&user), "Failed to create test user")
+
+	testutils.CheckErr(t, userStore.SetUserRole(admin.ID, user.ID, RoleEditor), "Failed to make user an editor")
+	role, err := userStore.GetUserRole(user.ID)
+	testutils.CheckErr(t, err, "Failed to get user role")
+	if role != RoleEditor {
+		t.Errorf("expected role %q, got %q", RoleEditor, role)
+	}
+
+	entries := auditEntries(t, testDB, "user", user.ID.String())
+	if len(entries) != 1 || entries[0].Action != AuditUserRole || entries[0].ActorID != admin.ID {
+		t.Fatalf("expected one role entry by the admin, got %+v", entries)
+	}
+	if c := entries[0].Changes["role"]; c.Old != RoleUser || c.New != RoleEditor {
+		t.Errorf("expected role to change from user to editor, got %v", c)
+	}
+
+	testutils.CheckErr(t, userStore.SetUserRole(admin.ID, user.ID, RoleEditor), "Failed to set unchanged role")
+	if entries := auditEntries(t, testDB, "user", user.ID.String()); len(entries) != 1 {
+		t.Errorf("expected no entry for an unchanged role, got %d entries", len(entries))
+	}
+
+	if err := userStore.SetUserRole(admin.ID, uuid.New(), RoleAdmin); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound for an unknown user, got %v", err)
+	}
+}
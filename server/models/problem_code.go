@@ -0,0 +1,165 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"go-leetcode/backend/internal/examples"
+)
+
+// CodeSnippet is LeetCode's starter code for a problem in one language
+type CodeSnippet struct {
+	Lang     string `json:"lang"`      // display name, e.g. "Python3"
+	LangSlug string `json:"lang_slug"` // e.g. "python3"
+	Code     string `json:"code"`
+}
+
+// CodeSnippets is the problems.code_snippets JSONB column
+type CodeSnippets []CodeSnippet
+
+func (c *CodeSnippets) Scan(src interface{}) error {
+	return scanJSONB(src, (*[]CodeSnippet)(c), "code snippets")
+}
+
+func (c CodeSnippets) Value() (driver.Value, error) {
+	return jsonbValue([]CodeSnippet(c), "code snippets")
+}
+
+// MetadataType is a parameter or return type in problem metadata, e.g.
+// "integer[]" or "list<TreeNode>"
+type MetadataType struct {
+	Type string `json:"type"`
+}
+
+// MetadataMethod is a method of the class of a design problem
+type MetadataMethod struct {
+	Name   string           `json:"name,omitempty"`
+	Params []examples.Param `json:"params"`
+	Return *MetadataType    `json:"return,omitempty"`
+}
+
+// ProblemMetadata is LeetCode's description of the solution function, as
+// stored in problems.metadata. Design problems describe a class instead.
+type ProblemMetadata struct {
+	Name         string           `json:"name,omitempty"`
+	Params       []examples.Param `json:"params,omitempty"`
+	Return       *MetadataType    `json:"return,omitempty"`
+	ClassName    string           `json:"classname,omitempty"`
+	Constructor  *MetadataMethod  `json:"constructor,omitempty"`
+	Methods      []MetadataMethod `json:"methods,omitempty"`
+	SystemDesign bool             `json:"systemdesign,omitempty"`
+}
+
+// ParseProblemMetadata decodes a metadata document, nil for an empty one
+func ParseProblemMetadata(raw []byte) (*ProblemMetadata, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var metadata ProblemMetadata
+	if err := json.Unmarshal(raw, &metadata); err != nil {
+		return nil, fmt.Errorf("error parsing problem metadata: %v", err)
+	}
+	return &metadata, nil
+}
+
+// Signature is the solution function the metadata describes, nil for design
+// problems and metadata without parameters
+func (m *ProblemMetadata) Signature() *examples.Signature {
+	if m == nil || m.SystemDesign || m.ClassName != "" || len(m.Params) == 0 {
+		return nil
+	}
+	sig := &examples.Signature{Params: m.Params}
+	if m.Return != nil {
+		sig.ReturnType = m.Return.Type
+	}
+	return sig
+}
+
+// FunctionName is the name of the solution function, empty for design
+// problems
+func (m *ProblemMetadata) FunctionName() string {
+	if m == nil || m.SystemDesign || m.ClassName != "" {
+		return ""
+	}
+	return m.Name
+}
+
+// Params are the parameters of the problem's solution function, from its
+// metadata when known and otherwise from its parsed examples
+func (p Problem) Params() []examples.Param {
+	if sig := p.Metadata.Signature(); sig != nil {
+		return sig.Params
+	}
+	for _, example := range p.Examples {
+		if len(example.Params) > 0 {
+			return example.Params
+		}
+	}
+	return nil
+}
+
+// ProblemCodeTarget is a problem whose snippets and metadata are imported
+type ProblemCodeTarget struct {
+	ID        int
+	TitleSlug string
+}
+
+// ListProblemsForCodeImport returns up to limit problems after afterID, in ID
+// order, that have no code snippets yet, or all of them with all set.
+// LeetCode only returns the starter code of paid problems to subscribers, so
+// those are skipped.
+func (s *ProblemStore) ListProblemsForCodeImport(afterID, limit int, all bool) ([]ProblemCodeTarget, error) {
+	query := `
+		SELECT id, title_slug
+		FROM problems
+		WHERE id > $1 AND NOT is_paid_only AND ($2 OR code_snippets = '[]'::jsonb)
+		ORDER BY id
+		LIMIT $3
+	`
+
+	rows, err := s.db.Query(query, afterID, all, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing problems for code import: %v", err)
+	}
+	defer rows.Close()
+
+	targets := []ProblemCodeTarget{}
+	for rows.Next() {
+		var target ProblemCodeTarget
+		if err := rows.Scan(&target.ID, &target.TitleSlug); err != nil {
+			return nil, fmt.Errorf("error scanning problem: %v", err)
+		}
+		targets = append(targets, target)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing problems for code import: %v", err)
+	}
+	return targets, nil
+}
+
+// SetProblemCode stores the starter code and metadata of a problem. When the
+// metadata changes, the problem's examples are parsed again with it.
+func (s *ProblemStore) SetProblemCode(problemID int, snippets CodeSnippets, metadata json.RawMessage) error {
+	if _, err := ParseProblemMetadata(metadata); err != nil {
+		return err
+	}
+	var metadataValue interface{}
+	if len(metadata) > 0 {
+		metadataValue = string(metadata)
+	}
+
+	query := `
+		UPDATE problems
+		SET code_snippets = $1, metadata = $2::jsonb,
+		    examples_source_md5 = CASE WHEN metadata IS DISTINCT FROM $2::jsonb THEN NULL ELSE examples_source_md5 END
+		WHERE id = $3
+	`
+	result, err := s.db.Exec(query, snippets, metadataValue, problemID)
+	if err != nil {
+		return fmt.Errorf("error saving problem code: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: no problem with ID %d", ErrProblemNotFound, problemID)
+	}
+	return nil
+}
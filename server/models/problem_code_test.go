@@ -0,0 +1,55 @@
+package models
+
+import "testing"
+
+func TestProblemMetadataSignature(t *testing.T) {
+	meta, err := ParseProblemMetadata([]byte(`{
+		"name": "twoSum",
+		"params": [{"name": "nums", "type": "integer[]"}, {"name": "target", "type": "integer"}],
+		"return": {"type": "integer[]", "size": 2}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseProblemMetadata: %v", err)
+	}
+
+	sig := meta.Signature()
+	if sig == nil || len(sig.Params) != 2 || sig.Params[1].Name != "target" || sig.ReturnType != "integer[]" {
+		t.Errorf("signature = %+v", sig)
+	}
+	if meta.FunctionName() != "twoSum" {
+		t.Errorf("function name = %q, want twoSum", meta.FunctionName())
+	}
+
+	examples := parseExamples("[2,7,11,15]\n9", "<pre>Input: a = [2,7,11,15], b = 9\nOutput: [0,1]</pre>", []byte(`{
+		"name": "twoSum", "params": [{"name": "nums", "type": "integer[]"}, {"name": "target", "type": "integer"}]
+	}`))
+	if len(examples) != 1 || examples[0].Params[0].Name != "nums" {
+		t.Errorf("examples = %+v, want parameters named from the metadata", examples)
+	}
+}
+
+func TestProblemMetadataDesign(t *testing.T) {
+	meta, err := ParseProblemMetadata([]byte(`{
+		"classname": "LRUCache",
+		"constructor": {"params": [{"name": "capacity", "type": "integer"}]},
+		"methods": [{"name": "get", "params": [{"name": "key", "type": "integer"}], "return": {"type": "integer"}}],
+		"systemdesign": true
+	}`))
+	if err != nil {
+		t.Fatalf("ParseProblemMetadata: %v", err)
+	}
+	if meta.Signature() != nil || meta.FunctionName() != "" {
+		t.Error("design problems have no solution function")
+	}
+	if len(meta.Methods) != 1 || meta.Methods[0].Name != "get" {
+		t.Errorf("methods = %+v", meta.Methods)
+	}
+
+	if meta, err := ParseProblemMetadata(nil); meta != nil || err != nil {
+		t.Errorf("empty metadata = %+v, %v, want nil", meta, err)
+	}
+	var missing *ProblemMetadata
+	if missing.Signature() != nil || missing.FunctionName() != "" {
+		t.Error("missing metadata has no signature")
+	}
+}
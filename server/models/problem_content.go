@@ -0,0 +1,129 @@
+package models
+
+import (
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"go-leetcode/backend/internal/content"
+
+	"github.com/lib/pq"
+)
+
+// freshRender matches problems whose cached renders belong to their current
+// content and the current renderer; $1 is content.Version
+const freshRender = `content_rendered_md5 = md5("content") AND content_render_version = $1`
+
+// ApplyContentFormat replaces the content of each problem with the requested
+// rendering of it, using the renders cached in the database. Renders are
+// cached when problems are written, so any that are missing or stale, such as
+// those of freshly scraped problems, are rendered here without being stored.
+// The raw format leaves problems as is.
+func (s *ProblemStore) ApplyContentFormat(problems []Problem, format string) error {
+	if format == "" || format == content.FormatRaw || len(problems) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(problems))
+	for _, problem := range problems {
+		ids = append(ids, problem.ID)
+	}
+
+	query := `
+		SELECT id, content_html, content_markdown, content_summary
+		FROM problems
+		WHERE id = ANY($2) AND ` + freshRender
+
+	rows, err := s.db.Query(query, content.Version, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("error fetching rendered content: %v", err)
+	}
+	defer rows.Close()
+
+	cached := make(map[int]content.Rendered)
+	for rows.Next() {
+		var id int
+		var rendered content.Rendered
+		if err := rows.Scan(&id, &rendered.HTML, &rendered.Markdown, &rendered.Summary); err != nil {
+			return fmt.Errorf("error scanning rendered content: %v", err)
+		}
+		cached[id] = rendered
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error fetching rendered content: %v", err)
+	}
+
+	for i := range problems {
+		rendered, ok := cached[problems[i].ID]
+		if !ok {
+			rendered = content.Render(problems[i].Content)
+		}
+
+		switch format {
+		case content.FormatHTML:
+			problems[i].Content = rendered.HTML
+		case content.FormatMarkdown:
+			problems[i].Content = rendered.Markdown
+		case content.FormatSummary:
+			problems[i].Content = rendered.Summary
+		}
+		problems[i].ContentFormat = format
+	}
+
+	return nil
+}
+
+// RenderStaleContent renders and caches the content of every problem without
+// a fresh render, returning how many were rendered
+func (s *ProblemStore) RenderStaleContent() (int, error) {
+	rows, err := s.db.Query(`SELECT id, "content" FROM problems WHERE NOT (`+freshRender+`) OR content_rendered_md5 IS NULL`, content.Version)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching stale content: %v", err)
+	}
+
+	type staleProblem struct {
+		id  int
+		src string
+	}
+	var stale []staleProblem
+	for rows.Next() {
+		var p staleProblem
+		if err := rows.Scan(&p.id, &p.src); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error scanning stale content: %v", err)
+		}
+		stale = append(stale, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error fetching stale content: %v", err)
+	}
+
+	for _, p := range stale {
+		if err := saveRenderedContent(s.db.Exec, p.id, p.src); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(stale), nil
+}
+
+// saveRenderedContent renders src and caches the renders through exec, so
+// writes can cache them in their own transaction, unless the problem's content
+// changed since src was read
+func saveRenderedContent(exec func(string, ...any) (sql.Result, error), problemID int, src string) error {
+	rendered := content.Render(src)
+	sum := md5.Sum([]byte(src))
+	checksum := hex.EncodeToString(sum[:])
+
+	query := `
+		UPDATE problems
+		SET content_html = $1, content_markdown = $2, content_summary = $3,
+		    content_rendered_md5 = $4, content_render_version = $5
+		WHERE id = $6 AND md5("content") = $4
+	`
+	if _, err := exec(query, rendered.HTML, rendered.Markdown, rendered.Summary, checksum, content.Version, problemID); err != nil {
+		return fmt.Errorf("error saving rendered content: %v", err)
+	}
+	return nil
+}
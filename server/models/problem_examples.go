@@ -0,0 +1,143 @@
+package models
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"go-leetcode/backend/internal/examples"
+
+	"github.com/lib/pq"
+)
+
+// freshExamples matches problems whose stored examples were parsed from their
+// current example_testcases and content by the current parser; $1 is
+// examples.Version
+const freshExamples = `examples_source_md5 = md5(coalesce(example_testcases, '') || "content") AND examples_version = $1`
+
+// examplesChecksum matches the md5 freshExamples computes in the database
+func examplesChecksum(testcases, src string) string {
+	sum := md5.Sum([]byte(testcases + src))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseExamples parses the examples of a problem, naming and typing the
+// arguments from its metadata when known. Problems whose examples cannot be
+// parsed, such as design problems, get none rather than an error so they are
+// not parsed again on every read.
+func parseExamples(testcases, src string, metadata []byte) ProblemExamples {
+	meta, _ := ParseProblemMetadata(metadata)
+	parsed, err := examples.Parse(testcases, src, meta.Signature())
+	if err != nil {
+		return ProblemExamples{}
+	}
+	return ProblemExamples(parsed)
+}
+
+// AttachExamples sets the structured examples of each problem, using those
+// stored in the database and parsing and storing any that are missing or
+// stale. It reads the raw content, so it must run before ApplyContentFormat.
+func (s *ProblemStore) AttachExamples(problems []Problem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(problems))
+	for _, problem := range problems {
+		ids = append(ids, problem.ID)
+	}
+
+	query := `
+		SELECT id, COALESCE(` + freshExamples + `, false), examples, metadata
+		FROM problems
+		WHERE id = ANY($2)`
+
+	rows, err := s.db.Query(query, examples.Version, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("error fetching examples: %v", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[int]ProblemExamples)
+	metadata := make(map[int][]byte)
+	for rows.Next() {
+		var id int
+		var fresh bool
+		var parsed ProblemExamples
+		var meta []byte
+		if err := rows.Scan(&id, &fresh, &parsed, &meta); err != nil {
+			return fmt.Errorf("error scanning examples: %v", err)
+		}
+		if fresh {
+			stored[id] = parsed
+		} else {
+			metadata[id] = meta
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error fetching examples: %v", err)
+	}
+
+	for i := range problems {
+		parsed, ok := stored[problems[i].ID]
+		if !ok {
+			parsed = parseExamples(problems[i].ExampleTestcases, problems[i].Content, metadata[problems[i].ID])
+			if err := s.saveExamples(problems[i].ID, problems[i].ExampleTestcases, problems[i].Content, parsed); err != nil {
+				return err
+			}
+		}
+		problems[i].Examples = parsed
+	}
+
+	return nil
+}
+
+// ParseStaleExamples parses and stores the examples of every problem without
+// fresh ones, returning how many were parsed
+func (s *ProblemStore) ParseStaleExamples() (int, error) {
+	rows, err := s.db.Query(`SELECT id, coalesce(example_testcases, ''), "content", metadata FROM problems WHERE NOT (`+freshExamples+`) OR examples_source_md5 IS NULL`, examples.Version)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching stale examples: %v", err)
+	}
+
+	type staleProblem struct {
+		id        int
+		testcases string
+		src       string
+		metadata  []byte
+	}
+	var stale []staleProblem
+	for rows.Next() {
+		var p staleProblem
+		if err := rows.Scan(&p.id, &p.testcases, &p.src, &p.metadata); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error scanning stale examples: %v", err)
+		}
+		stale = append(stale, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error fetching stale examples: %v", err)
+	}
+
+	for _, p := range stale {
+		if err := s.saveExamples(p.id, p.testcases, p.src, parseExamples(p.testcases, p.src, p.metadata)); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(stale), nil
+}
+
+// saveExamples stores examples parsed from testcases and src, unless the
+// problem changed since they were read
+func (s *ProblemStore) saveExamples(problemID int, testcases, src string, parsed ProblemExamples) error {
+	query := `
+		UPDATE problems
+		SET examples = $1, examples_source_md5 = $2, examples_version = $3
+		WHERE id = $4 AND md5(coalesce(example_testcases, '') || "content") = $2
+	`
+	if _, err := s.db.Exec(query, parsed, examplesChecksum(testcases, src), examples.Version, problemID); err != nil {
+		return fmt.Errorf("error saving examples: %v", err)
+	}
+	return nil
+}
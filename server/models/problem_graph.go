@@ -0,0 +1,181 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrProblemNotFound = errors.New("problem not found")
+
+// MaxRelatedDepth bounds how many similarity hops GetRelatedProblems follows
+const MaxRelatedDepth = 3
+
+type RelatedProblem struct {
+	Problem Problem `json:"problem"`
+	Depth   int     `json:"depth"`
+}
+
+// ProblemRecommendation is an unsolved problem suggested to a user, with the
+// solved problems it is similar to and the weak topics it practices
+type ProblemRecommendation struct {
+	Problem    Problem  `json:"problem"`
+	Score      float64  `json:"score"`
+	SimilarTo  []string `json:"similar_to"`
+	WeakTopics []string `json:"weak_topics"`
+}
+
+// similarityEdges reads problem_similarities in both directions
+const similarityEdges = `
+	SELECT problem_id AS from_id, similar_problem_id AS to_id FROM problem_similarities
+	UNION
+	SELECT similar_problem_id, problem_id FROM problem_similarities`
+
+// GetRelatedProblems walks the similarity graph from the problem up to depth
+// hops away, returning each reachable problem once at its shortest distance,
+// nearest first. difficulties filters the results, not the walk.
+func (s *ProblemStore) GetRelatedProblems(titleSlug string, depth int, difficulties []string, limit int) ([]RelatedProblem, error) {
+	var problemID int
+	err := s.db.QueryRow(`SELECT id FROM problems p WHERE title_slug = $1 AND `+visibleProblem, titleSlug).Scan(&problemID)
+	if err == sql.ErrNoRows {
+		return nil, ErrProblemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching problem: %v", err)
+	}
+
+	query := `
+		WITH RECURSIVE edges AS (` + similarityEdges + `
+		), walk (problem_id, depth) AS (
+			SELECT $1::int4, 0
+			UNION
+			SELECT e.to_id, w.depth + 1
+			FROM walk w
+			JOIN edges e ON e.from_id = w.problem_id
+			WHERE w.depth < $2
+		)
+		SELECT p.id, p.frontend_id, p.title, p.title_slug, p.difficulty, p.is_paid_only, MIN(w.depth)
+		FROM walk w
+		JOIN problems p ON p.id = w.problem_id
+		WHERE w.problem_id <> $1 AND ` + listedProblem + `
+		  AND (cardinality($3::varchar[]) = 0 OR p.difficulty = ANY($3))
+		GROUP BY p.id
+		ORDER BY MIN(w.depth), p.frontend_id
+		LIMIT $4
+	`
+
+	rows, err := s.db.Query(query, problemID, depth, pq.Array(difficulties), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching related problems: %v", err)
+	}
+	defer rows.Close()
+
+	related := []RelatedProblem{}
+	for rows.Next() {
+		var r RelatedProblem
+		if err := rows.Scan(
+			&r.Problem.ID,
+			&r.Problem.FrontendID,
+			&r.Problem.Title,
+			&r.Problem.TitleSlug,
+			&r.Problem.Difficulty,
+			&r.Problem.IsPaidOnly,
+			&r.Depth,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning related problem: %v", err)
+		}
+		related = append(related, r)
+	}
+
+	return related, rows.Err()
+}
+
+// RecommendProblems suggests problems the user has not submitted yet. Each
+// candidate scores one point per solved problem it is similar to, plus
+// ln(1 + lapses) summed over the review lapses of its topics, so topics the
+// user keeps forgetting pull their problems up. Ties, and users with no
+// history at all, fall back to easier problems first.
+func (s *ProblemStore) RecommendProblems(userID uuid.UUID, difficulties []string, includePaid bool, limit int) ([]ProblemRecommendation, error) {
+	query := `
+		WITH solved AS (
+			SELECT DISTINCT p.id, p.title_slug
+			FROM submissions s
+			JOIN problems p ON p.title_slug = s.title_slug
+			WHERE s.user_id = $1
+		), weak_topics AS (
+			SELECT pt.topic_slug, SUM(r.lapses) AS lapses
+			FROM review_schedules r
+			JOIN submissions s ON r.submission_id = s.id
+			JOIN problems p ON p.title_slug = s.title_slug
+			JOIN problems_topic pt ON pt.problem_id = p.id
+			WHERE s.user_id = $1 AND r.lapses > 0
+			GROUP BY pt.topic_slug
+		), edges AS (` + similarityEdges + `
+		), neighbours AS (
+			SELECT e.to_id AS problem_id, array_agg(DISTINCT so.title_slug) AS similar_to
+			FROM edges e
+			JOIN solved so ON so.id = e.from_id
+			GROUP BY e.to_id
+		), candidates AS (
+			SELECT p.id,
+			       COALESCE(n.similar_to, '{}') AS similar_to,
+			       ARRAY(
+			           SELECT wt.topic_slug FROM problems_topic pt
+			           JOIN weak_topics wt ON wt.topic_slug = pt.topic_slug
+			           WHERE pt.problem_id = p.id
+			           ORDER BY wt.lapses DESC, wt.topic_slug
+			       ) AS weak_topics,
+			       COALESCE(cardinality(n.similar_to), 0) + COALESCE((
+			           SELECT SUM(LN(1 + wt.lapses)) FROM problems_topic pt
+			           JOIN weak_topics wt ON wt.topic_slug = pt.topic_slug
+			           WHERE pt.problem_id = p.id
+			       ), 0) AS score
+			FROM problems p
+			LEFT JOIN neighbours n ON n.problem_id = p.id
+			WHERE p.id NOT IN (SELECT id FROM solved) AND ` + listedProblem + `
+			  AND ($2::boolean OR NOT p.is_paid_only)
+			  AND (cardinality($3::varchar[]) = 0 OR p.difficulty = ANY($3))
+		)
+		SELECT p.id, p.frontend_id, p.title, p.title_slug, p.difficulty, p.is_paid_only,
+		       c.score, c.similar_to, c.weak_topics
+		FROM candidates c
+		JOIN problems p ON p.id = c.id
+		ORDER BY c.score DESC,
+		         CASE LOWER(p.difficulty) WHEN 'easy' THEN 1 WHEN 'medium' THEN 2 WHEN 'hard' THEN 3 ELSE 4 END,
+		         p.frontend_id
+		LIMIT $4
+	`
+
+	rows, err := s.db.Query(query, userID, includePaid, pq.Array(difficulties), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error recommending problems: %v", err)
+	}
+	defer rows.Close()
+
+	recommendations := []ProblemRecommendation{}
+	for rows.Next() {
+		var r ProblemRecommendation
+		var similarTo, weakTopics []string
+		if err := rows.Scan(
+			&r.Problem.ID,
+			&r.Problem.FrontendID,
+			&r.Problem.Title,
+			&r.Problem.TitleSlug,
+			&r.Problem.Difficulty,
+			&r.Problem.IsPaidOnly,
+			&r.Score,
+			pq.Array(&similarTo),
+			pq.Array(&weakTopics),
+		); err != nil {
+			return nil, fmt.Errorf("error scanning recommendation: %v", err)
+		}
+		r.SimilarTo = append([]string{}, similarTo...)
+		r.WeakTopics = append([]string{}, weakTopics...)
+		recommendations = append(recommendations, r)
+	}
+
+	return recommendations, rows.Err()
+}
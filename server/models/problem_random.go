@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/google/uuid"
+)
+
+// MaxRandomProblems bounds how many problems one random pick returns
+const MaxRandomProblems = 20
+
+// RandomProblems picks up to count distinct problems uniformly at random from
+// those matching the filter, in random order. Only the IDs of the matching
+// problems are read to sample from, instead of sorting whole rows with
+// ORDER BY random(), and just the picked problems are loaded.
+func (s *ProblemStore) RandomProblems(filter ProblemFilter, userID uuid.UUID, count int) ([]Problem, error) {
+	filterQuery, err := problemFilterSQL(filter, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT p.id FROM problems p` + filterQuery.fromJoins + ` WHERE 1 = 1` + filterQuery.whereClause
+	rows, err := s.db.Query(query, filterQuery.filterParams...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching problem ids: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning problem id: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching problem ids: %v", err)
+	}
+
+	picked := sampleIDs(ids, count)
+	if len(picked) == 0 {
+		return []Problem{}, nil
+	}
+
+	list, err := s.ListProblems(ListProblemOptions{Filter: ProblemFilter{IDs: picked}, Limit: len(picked)})
+	if err != nil {
+		return nil, err
+	}
+
+	// the list comes back ordered by frontend ID, restore the random order
+	byID := make(map[int]Problem, len(list.Problems))
+	for _, problem := range list.Problems {
+		byID[problem.ID] = problem
+	}
+
+	problems := make([]Problem, 0, len(picked))
+	for _, id := range picked {
+		if problem, ok := byID[id]; ok {
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems, nil
+}
+
+// sampleIDs shuffles a uniform random sample of count IDs to the front of ids
+// with a partial Fisher-Yates shuffle and returns it
+func sampleIDs(ids []int, count int) []int {
+	if count > len(ids) {
+		count = len(ids)
+	}
+	for i := 0; i < count; i++ {
+		j := i + rand.IntN(len(ids)-i)
+		ids[i], ids[j] = ids[j], ids[i]
+	}
+	return ids[:count]
+}
@@ -0,0 +1,28 @@
+package models
+
+import "testing"
+
+func TestSampleIDs(t *testing.T) {
+	ids := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	picked := sampleIDs(append([]int{}, ids...), 4)
+	if len(picked) != 4 {
+		t.Fatalf("sampleIDs() picked %d ids, want 4", len(picked))
+	}
+
+	seen := make(map[int]bool)
+	for _, id := range picked {
+		if id < 1 || id > 10 || seen[id] {
+			t.Errorf("sampleIDs() = %v, want distinct ids from the input", picked)
+		}
+		seen[id] = true
+	}
+
+	if picked := sampleIDs(append([]int{}, ids...), 50); len(picked) != len(ids) {
+		t.Errorf("sampleIDs() with count above the pool picked %d ids, want %d", len(picked), len(ids))
+	}
+
+	if picked := sampleIDs(nil, 1); len(picked) != 0 {
+		t.Errorf("sampleIDs(nil) = %v, want empty", picked)
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestBuildPrefixTSQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"sliding window", "sliding:* & window:*"},
+		{"  Monotonic   STACK ", "monotonic:* & stack:*"},
+		{"two-sum", "two:* & sum:*"},
+		{"a & b | !c:*", "a:* & b:* & c:*"},
+		{"O(n) 2d dp", "o:* & n:* & 2d:* & dp:*"},
+		{"':*&|!()", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := BuildPrefixTSQuery(tt.input); got != tt.want {
+			t.Errorf("BuildPrefixTSQuery(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -4,8 +4,13 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type TopicTag struct {
@@ -22,18 +27,33 @@ type SimilarQuestion struct {
 }
 
 type Problem struct {
-	ID               int               `json:"id"`
-	FrontendID       int               `json:"frontend_id"`
-	Title            string            `json:"title"`
-	TitleSlug        string            `json:"title_slug"`
-	Difficulty       string            `json:"difficulty"`
-	IsPaidOnly       bool              `json:"is_paid_only"`
-	Content          string            `json:"content"`
-	TopicTags        []TopicTag        `json:"topic_tags"`
-	ExampleTestcases string            `json:"example_testcases"`
-	SimilarQuestions []SimilarQuestion `json:"similar_questions"`
-	CreatedAt        time.Time         `json:"created_at"`
-	SolutionApproach string            `json:"solution_approach"`
+	ID               int              `json:"id"`
+	FrontendID       int              `json:"frontend_id"`
+	Title            string           `json:"title"`
+	TitleSlug        string           `json:"title_slug"`
+	Difficulty       string           `json:"difficulty"`
+	IsPaidOnly       bool             `json:"is_paid_only"`
+	Content          string           `json:"content"`
+	TopicTags        TopicTags        `json:"topic_tags"`
+	ExampleTestcases string           `json:"example_testcases"`
+	SimilarQuestions SimilarQuestions `json:"similar_questions"`
+	CreatedAt        time.Time        `json:"created_at"`
+	SolutionApproach string           `json:"solution_approach"`
+	Search           *SearchMatch     `json:"search,omitempty"`
+	ContentFormat    string           `json:"content_format,omitempty"`
+	Lifecycle        string           `json:"lifecycle"`
+	Examples         ProblemExamples  `json:"examples,omitempty"`
+	CodeSnippets     CodeSnippets     `json:"code_snippets,omitempty"` // single problem reads only
+	Metadata         *ProblemMetadata `json:"metadata,omitempty"`
+	TestCases        []TestCase       `json:"test_cases,omitempty"` // the signed in user's, single problem reads only
+}
+
+// SearchMatch describes why a problem matched a full text query. Matched terms
+// are wrapped in <mark> tags.
+type SearchMatch struct {
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
 }
 
 type ProblemStore struct {
@@ -44,16 +64,21 @@ func NewProblemStore(db *sql.DB) *ProblemStore {
 	return &ProblemStore{db: db}
 }
 
-func (s *ProblemStore) GetProblemBySlug(titleSlug string) (Problem, error) {
-	var problem Problem
-	var similarQuestionsString, topicTagsString string
+// problemColumns are the columns scanProblem reads, in order, from problems p
+const problemColumns = `p.id, p.frontend_id, p.title, p.title_slug, p.difficulty, p.is_paid_only, p.content,
+		p.topic_tags, COALESCE(p.example_testcases, ''), p.similar_questions, p.created_at, p.lifecycle`
 
-	query := `
-		SELECT id, frontend_id, title, title_slug, difficulty, is_paid_only, content, topic_tags, 
-				example_testcases, similar_questions, created_at FROM problems WHERE title_slug = $1
-	`
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProblem scans problemColumns into a problem, followed by any extra
+// columns the query selected after them
+func scanProblem(row rowScanner, extra ...interface{}) (Problem, error) {
+	var problem Problem
 
-	err := s.db.QueryRow(query, titleSlug).Scan(
+	dest := append([]interface{}{
 		&problem.ID,
 		&problem.FrontendID,
 		&problem.Title,
@@ -61,312 +86,778 @@ func (s *ProblemStore) GetProblemBySlug(titleSlug string) (Problem, error) {
 		&problem.Difficulty,
 		&problem.IsPaidOnly,
 		&problem.Content,
-		&topicTagsString,
+		&problem.TopicTags,
 		&problem.ExampleTestcases,
-		&similarQuestionsString,
+		&problem.SimilarQuestions,
 		&problem.CreatedAt,
-	)
+		&problem.Lifecycle,
+	}, extra...)
+
+	err := row.Scan(dest...)
+	return problem, err
+}
+
+// getProblem loads the single problem matching condition, describing it as
+// what in the not found error
+func (s *ProblemStore) getProblem(condition, what string, arg interface{}) (Problem, error) {
+	query := `SELECT ` + problemColumns + `, p.code_snippets, p.metadata FROM problems p WHERE ` + condition
 
+	var snippets CodeSnippets
+	var metadata []byte
+	problem, err := scanProblem(s.db.QueryRow(query, arg), &snippets, &metadata)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return Problem{}, fmt.Errorf("Problem with slug %s not found", titleSlug)
+			return Problem{}, fmt.Errorf("%w: no problem with %s", ErrProblemNotFound, what)
 		}
 
 		return Problem{}, fmt.Errorf("error fetching problem: %v", err)
 	}
-
-	if err = json.Unmarshal([]byte(topicTagsString), &problem.TopicTags); err != nil {
-		return Problem{}, fmt.Errorf("error in parsing the topic tags")
+	problem.CodeSnippets = snippets
+	if problem.Metadata, err = ParseProblemMetadata(metadata); err != nil {
+		return Problem{}, err
 	}
 
-	if err = json.Unmarshal([]byte(similarQuestionsString), &problem.SimilarQuestions); err != nil {
-		return Problem{}, fmt.Errorf("")
+	problems := []Problem{problem}
+	if err := s.AttachExamples(problems); err != nil {
+		return Problem{}, err
 	}
 
-	return problem, nil
+	return problems[0], nil
 }
 
-func (s *ProblemStore) GetProblemByID(ID int) (Problem, error) {
-	var problem Problem
-	var similarQuestionsString, topicTagsString string
-
-	query := `
-		SELECT id, frontend_id, title, title_slug, difficulty, is_paid_only, content, topic_tags, 
-				example_testcases, similar_questions, created_at FROM problems WHERE id = $1
-	`
+func (s *ProblemStore) GetProblemBySlug(titleSlug string) (Problem, error) {
+	return s.getProblem("p.title_slug = $1 AND "+visibleProblem, "slug "+titleSlug, titleSlug)
+}
 
-	err := s.db.QueryRow(query, ID).Scan(
-		&problem.ID,
-		&problem.FrontendID,
-		&problem.Title,
-		&problem.TitleSlug,
-		&problem.Difficulty,
-		&problem.IsPaidOnly,
-		&problem.Content,
-		&topicTagsString,
-		&problem.ExampleTestcases,
-		&similarQuestionsString,
-		&problem.CreatedAt,
-	)
+func (s *ProblemStore) GetProblemByID(ID int) (Problem, error) {
+	return s.getProblem("p.id = $1 AND "+visibleProblem, fmt.Sprintf("ID %d", ID), ID)
+}
 
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return Problem{}, fmt.Errorf("problem with ID %d not found", ID)
-		}
+func (s *ProblemStore) GetProblemByFrontendID(FrontendID int) (Problem, error) {
+	return s.getProblem("p.frontend_id = $1 AND "+visibleProblem, fmt.Sprintf("frontend ID %d", FrontendID), FrontendID)
+}
 
-		return Problem{}, fmt.Errorf("error fetching problem: %v", err)
-	}
+const (
+	TagsModeAny = "any"
+	TagsModeAll = "all"
+)
 
-	if err = json.Unmarshal([]byte(topicTagsString), &problem.TopicTags); err != nil {
-		return Problem{}, fmt.Errorf("error in parsing the topic tags")
-	}
+type ProblemFilter struct {
+	Difficulty    string
+	Difficulties  []string // matches any of them, combined with Difficulty
+	Tags          []string
+	TagsMode      string // TagsModeAny (default) or TagsModeAll
+	ExcludeTags   []string
+	SearchKeyword string
+	Query         string // full text search, see BuildPrefixTSQuery
+	PaidOnly      *bool
+	Status        string   // one of the ProblemStatus values, needs a user
+	Exclude       []string // ProblemStatusSolved, ProblemStatusInReview (need a user) or ProblemExcludePaid
+	IDs           []int
+	Lifecycles    []string // ProblemActive only when empty
+}
 
-	if err = json.Unmarshal([]byte(similarQuestionsString), &problem.SimilarQuestions); err != nil {
-		return Problem{}, fmt.Errorf("error in parsing the similar questions tag")
-	}
+// Problem lifecycles, changed by admins. Retired problems stay reachable by
+// ID and slug, so reviews and decks holding them keep working, but are no
+// longer listed or recommended; hidden problems are not served at all.
+const (
+	ProblemActive  = "active"
+	ProblemHidden  = "hidden"
+	ProblemRetired = "retired"
+)
 
-	return problem, nil
+func IsValidProblemLifecycle(lifecycle string) bool {
+	return lifecycle == ProblemActive || lifecycle == ProblemHidden || lifecycle == ProblemRetired
 }
 
-func (s *ProblemStore) GetProblemByFrontendID(FrontendID int) (Problem, error) {
-	var problem Problem
-	var similarQuestionsString, topicTagsString string
-
-	query := `
-		SELECT id, frontend_id, title, title_slug, difficulty, is_paid_only, content, topic_tags, 
-				example_testcases, similar_questions, created_at FROM problems WHERE frontend_id = $1
-	`
+const (
+	// visibleProblem matches the problems served by ID and slug
+	visibleProblem = "p.lifecycle <> 'hidden'"
+	// listedProblem matches the problems offered in lists and recommendations
+	listedProblem = "p.lifecycle = 'active'"
+)
 
-	err := s.db.QueryRow(query, FrontendID).Scan(
-		&problem.ID,
-		&problem.FrontendID,
-		&problem.Title,
-		&problem.TitleSlug,
-		&problem.Difficulty,
-		&problem.IsPaidOnly,
-		&problem.Content,
-		&topicTagsString,
-		&problem.ExampleTestcases,
-		&similarQuestionsString,
-		&problem.CreatedAt,
-	)
+// ProblemExcludePaid leaves paid only problems out, see ProblemFilter.Exclude
+const ProblemExcludePaid = "paid"
 
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return Problem{}, fmt.Errorf("problem with ID %d not found", FrontendID)
-		}
+// Per-user problem states accepted by ProblemFilter.Status
+const (
+	ProblemStatusSolved    = "solved"     // at least one submission
+	ProblemStatusUnsolved  = "unsolved"   // no submission
+	ProblemStatusDue       = "due"        // review schedule is due now
+	ProblemStatusInReview  = "in_review"  // review schedule due in the future
+	ProblemStatusNeverSeen = "never_seen" // no submission, review or flashcard
+)
 
-		return Problem{}, fmt.Errorf("error fetching problem: %v", err)
+func IsValidProblemStatus(status string) bool {
+	switch status {
+	case ProblemStatusSolved, ProblemStatusUnsolved, ProblemStatusDue, ProblemStatusInReview, ProblemStatusNeverSeen:
+		return true
 	}
+	return false
+}
 
-	if err = json.Unmarshal([]byte(topicTagsString), &problem.TopicTags); err != nil {
-		return Problem{}, fmt.Errorf("error in parsing the topic tags")
+// BuildPrefixTSQuery turns free text into a to_tsquery expression that
+// requires every word, each matched as a prefix ("sliding win" finds
+// "sliding window"). Anything but letters and digits is dropped so user input
+// can never produce a tsquery syntax error.
+func BuildPrefixTSQuery(q string) string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		terms = append(terms, word+":*")
 	}
+	return strings.Join(terms, " & ")
+}
 
-	if err = json.Unmarshal([]byte(similarQuestionsString), &problem.SimilarQuestions); err != nil {
-		return Problem{}, fmt.Errorf("")
-	}
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
 
-	return problem, nil
+type ListProblemOptions struct {
+	Filter        ProblemFilter
+	Limit         int
+	Offset        int
+	OrderBy       string // field to order by (e.g. difficulty)
+	OrderDir      string // asc or desc
+	IncludeFacets bool
+	Keyset        bool    // page by cursor instead of offset
+	Cursor        *Cursor // position to continue after, nil for the first keyset page
+	CountTotal    bool    // count every match on keyset pages too
+	ContentFormat string  // see ApplyContentFormat, raw when empty
 }
 
-type ProblemFilter struct {
-	Difficulty    string
-	Tags          []string
-	SearchKeyword string
-	PaidOnly      *bool
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
 }
 
-type ListProblemOptions struct {
-	Filter   ProblemFilter
-	Limit    int
-	Offset   int
-	OrderBy  string // field to order by (e.g. difficulty)
-	OrderDir string // asc or desc
+// ProblemFacets counts the problems matching the current filter per topic tag
+// and per difficulty, for rendering filter sidebars
+type ProblemFacets struct {
+	Tags         []FacetCount `json:"tags"`
+	Difficulties []FacetCount `json:"difficulties"`
 }
 
 type ProblemList struct {
-	Problems []Problem `json:"problems"`
-	Total    int       `json:"total"`
+	Problems   []Problem      `json:"problems"`
+	Total      int            `json:"total"`
+	Facets     *ProblemFacets `json:"facets,omitempty"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	HasMore    bool           `json:"has_more"`
 }
 
 func (s *ProblemStore) ListProblems(options ListProblemOptions) (ProblemList, error) {
-	baseQuery := `
-		SELECT p.id, p.frontend_id, p.title, p.title_slug, p.difficulty, p.is_paid_only, p.content, p.topic_tags,
-		p.example_testcases, p.similar_questions, p.created_at
-		FROM problems p
-		LEFT JOIN problems_topic pt ON p.id = pt.problem_id
-		WHERE 1 = 1
-	`
+	result, _, err := s.listProblems(options, uuid.Nil)
+	return result, err
+}
 
-	countQuery := `SELECT COUNT(DISTINCT p.id) FROM problems p LEFT JOIN problems_topic pt ON p.id = pt.problem_id WHERE 1 = 1`
+// ListProblemsWithStatus lists problems like ListProblems, joined with the
+// user's submissions and review schedules so status filters, status ordering
+// and pagination all happen in the same query
+func (s *ProblemStore) ListProblemsWithStatus(options ListProblemOptions, userID uuid.UUID) (ProblemListWithStatus, error) {
+	list, statuses, err := s.listProblems(options, userID)
+	if err != nil {
+		return ProblemListWithStatus{}, err
+	}
+
+	problems := make([]ProblemWithStatus, 0, len(list.Problems))
+	for i, problem := range list.Problems {
+		status := statuses[i]
+		status.Problem = problem
+		status.Completed = status.SubmissionCount > 0
+		problems = append(problems, status)
+	}
+
+	return ProblemListWithStatus{
+		Problems:   problems,
+		Total:      list.Total,
+		Facets:     list.Facets,
+		NextCursor: list.NextCursor,
+		HasMore:    list.HasMore,
+	}, nil
+}
+
+// userStatusJoins attaches the user's submission stats (us) and latest review
+// schedule (ur) to every problem; $1 is the user ID
+const userStatusJoins = `
+		LEFT JOIN LATERAL (
+			SELECT MAX(s.submitted_at) AS last_submitted_at, COUNT(*) AS submission_count
+			FROM submissions s
+			WHERE s.user_id = $1 AND s.title_slug = p.title_slug
+		) us ON true
+		LEFT JOIN LATERAL (
+			SELECT r.next_review_at
+			FROM review_schedules r
+			JOIN submissions rs ON r.submission_id = rs.id
+			WHERE rs.user_id = $1 AND rs.title_slug = p.title_slug
+			ORDER BY rs.submitted_at DESC
+			LIMIT 1
+		) ur ON true`
+
+var problemStatusConditions = map[string]string{
+	ProblemStatusSolved:   "us.submission_count > 0",
+	ProblemStatusUnsolved: "us.submission_count = 0",
+	ProblemStatusDue:      "ur.next_review_at <= NOW()",
+	ProblemStatusInReview: "ur.next_review_at > NOW()",
+	ProblemStatusNeverSeen: `us.submission_count = 0 AND ur.next_review_at IS NULL AND NOT EXISTS (
+			SELECT 1 FROM flashcard_reviews fr WHERE fr.user_id = $1 AND fr.problem_id = p.id
+		)`,
+}
+
+// problemFilterQuery is the FROM joins and WHERE clause selecting the problems
+// matching a filter, shared by the list, count, facet and random queries
+type problemFilterQuery struct {
+	withUser      bool
+	fromJoins     string
+	whereClause   string
+	filterParams  []interface{}
+	searchColumns string // search rank and highlights, empty unless searching
+}
 
+// problemFilterSQL builds the filter conditions. With a user ID the user
+// status joins are added and $1 is the user ID.
+func problemFilterSQL(filter ProblemFilter, userID uuid.UUID) (problemFilterQuery, error) {
 	// and then we add the query based on the filter
 	var whereClause string
+	var fromJoins string
 	var filterParams []interface{} // Parameters for WHERE clause (used in count)
-	var params []interface{}       // All parameters including pagination (used in main query)
 	paramPos := 1
 
-	if options.Filter.Difficulty != "" {
-		whereClause += fmt.Sprintf(" AND difficulty = $%d", paramPos)
-		filterParams = append(filterParams, options.Filter.Difficulty) // Add to filterParams
+	withUser := userID != uuid.Nil
+	if withUser {
+		fromJoins = userStatusJoins
+		filterParams = append(filterParams, userID)
+		paramPos++
+	}
+
+	if len(filter.Lifecycles) == 0 {
+		whereClause += " AND " + listedProblem
+	} else {
+		whereClause += fmt.Sprintf(" AND p.lifecycle = ANY($%d)", paramPos)
+		filterParams = append(filterParams, pq.Array(uniqueStrings(filter.Lifecycles)))
 		paramPos++
 	}
 
-	if options.Filter.PaidOnly != nil {
+	if filter.Status != "" {
+		condition, ok := problemStatusConditions[filter.Status]
+		if !ok || !withUser {
+			return problemFilterQuery{}, fmt.Errorf("invalid status filter %q", filter.Status)
+		}
+		whereClause += " AND " + condition
+	}
+
+	var difficulties []string
+	if filter.Difficulty != "" {
+		difficulties = append(difficulties, filter.Difficulty)
+	}
+	difficulties = append(difficulties, filter.Difficulties...)
+
+	if len(difficulties) > 0 {
+		whereClause += fmt.Sprintf(" AND p.difficulty = ANY($%d)", paramPos)
+		filterParams = append(filterParams, pq.Array(difficulties)) // Add to filterParams
+		paramPos++
+	}
+
+	if filter.PaidOnly != nil {
 		whereClause += fmt.Sprintf(" AND is_paid_only = $%d", paramPos)
-		filterParams = append(filterParams, *options.Filter.PaidOnly) // Add to filterParams
+		filterParams = append(filterParams, *filter.PaidOnly) // Add to filterParams
 		paramPos++
 	}
 
-	if options.Filter.SearchKeyword != "" {
+	if filter.SearchKeyword != "" {
 		whereClause += fmt.Sprintf(" AND title ILIKE $%d", paramPos)
-		filterParams = append(filterParams, "%"+options.Filter.SearchKeyword+"%") // Add to filterParams
+		filterParams = append(filterParams, "%"+filter.SearchKeyword+"%") // Add to filterParams
+		paramPos++
+	}
+
+	// full text search, ranked and highlighted in the select list below
+	var searchColumns string
+	tsQuery := BuildPrefixTSQuery(filter.Query)
+	if tsQuery != "" {
+		whereClause += fmt.Sprintf(" AND p.search_vector @@ to_tsquery('english', $%d)", paramPos)
+		filterParams = append(filterParams, tsQuery)
+		searchColumns = fmt.Sprintf(`,
+			ts_rank_cd(p.search_vector, to_tsquery('english', $%[1]d)) AS search_rank,
+			ts_headline('english', p.title, to_tsquery('english', $%[1]d), 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>'),
+			ts_headline('english', regexp_replace(p.content, '<[^>]+>', ' ', 'g'), to_tsquery('english', $%[1]d), '%[2]s')`,
+			paramPos, searchHeadlineOptions)
 		paramPos++
 	}
 
-	if len(options.Filter.Tags) > 0 {
-		tagConditions := []string{}
-		for _, tag := range options.Filter.Tags {
-			tagClause := fmt.Sprintf("pt.topic_slug = $%d", paramPos)
-			tagConditions = append(tagConditions, tagClause)
-			filterParams = append(filterParams, tag) // Add to filterParams
-			paramPos++
+	// tag filters use JSONB containment on topic_tags, which the GIN index on
+	// the column serves, so matching several tags never multiplies the rows
+	if tags := uniqueStrings(filter.Tags); len(tags) > 0 {
+		if filter.TagsMode == TagsModeAll {
+			whereClause += fmt.Sprintf(" AND p.topic_tags @> $%d::jsonb", paramPos)
+			filterParams = append(filterParams, topicTagsContaining(tags...))
+		} else {
+			whereClause += fmt.Sprintf(" AND p.topic_tags @> ANY($%d::jsonb[])", paramPos)
+			filterParams = append(filterParams, pq.Array(eachTopicTagContaining(tags)))
 		}
-		whereClause += " AND (" + strings.Join(tagConditions, " OR ") + ")"
+		paramPos++
 	}
 
-	var orderClause string
+	if excluded := uniqueStrings(filter.ExcludeTags); len(excluded) > 0 {
+		whereClause += fmt.Sprintf(" AND NOT p.topic_tags @> ANY($%d::jsonb[])", paramPos)
+		filterParams = append(filterParams, pq.Array(eachTopicTagContaining(excluded)))
+		paramPos++
+	}
 
-	if options.OrderBy != "" {
-		direction := "ASC"
+	if ids := filter.IDs; len(ids) > 0 {
+		whereClause += fmt.Sprintf(" AND p.id = ANY($%d)", paramPos)
+		filterParams = append(filterParams, pq.Array(ids))
+		paramPos++
+	}
 
-		if options.OrderDir == "desc" {
-			direction = "DESC"
+	for _, exclude := range uniqueStrings(filter.Exclude) {
+		switch exclude {
+		case ProblemExcludePaid:
+			whereClause += " AND NOT p.is_paid_only"
+		case ProblemStatusSolved, ProblemStatusInReview:
+			if !withUser {
+				return problemFilterQuery{}, fmt.Errorf("excluding %q needs a user", exclude)
+			}
+			whereClause += fmt.Sprintf(" AND NOT COALESCE(%s, false)", problemStatusConditions[exclude])
+		default:
+			return problemFilterQuery{}, fmt.Errorf("invalid exclude filter %q", exclude)
 		}
+	}
 
-		if options.OrderBy == "difficulty" {
-			// this is for difficulty
-			// we want when we order by difficulty, the easy / hard one comes first
-			// (depends on the desc or asc)
-
-			orderClause = fmt.Sprintf(`
-				ORDER BY 
-                CASE 
-                    WHEN LOWER(difficulty) = 'hard' THEN 3
-                    WHEN LOWER(difficulty) = 'medium' THEN 2
-                    WHEN LOWER(difficulty) = 'easy' THEN 1
-                    ELSE 0
-                END %s
-			`, direction)
-		} else {
-			validColumns := map[string]string{
-				"difficulty":  "difficulty",
-				"title":       "title",
-				"frontend_id": "frontend_id",
-				"created_at":  "created_at",
-			}
+	return problemFilterQuery{
+		withUser:      withUser,
+		fromJoins:     fromJoins,
+		whereClause:   whereClause,
+		filterParams:  filterParams,
+		searchColumns: searchColumns,
+	}, nil
+}
 
-			if column, exists := validColumns[options.OrderBy]; exists {
-				orderClause = fmt.Sprintf(" ORDER BY %s %s", column, direction)
-			} else {
-				orderClause = " ORDER BY frontend_id ASC"
-			}
+// listProblems builds and runs the problem list query. With a user ID the
+// per-user status columns are selected too and returned alongside each problem.
+func (s *ProblemStore) listProblems(options ListProblemOptions, userID uuid.UUID) (ProblemList, []ProblemWithStatus, error) {
+	withUser := userID != uuid.Nil
+
+	// the first %s holds the search and status columns, the second the user joins
+	baseQuery := `
+		SELECT ` + problemColumns + `%s
+		FROM problems p%s
+		WHERE 1 = 1
+	`
+
+	filterQuery, err := problemFilterSQL(options.Filter, userID)
+	if err != nil {
+		return ProblemList{}, nil, err
+	}
+	fromJoins, whereClause, filterParams := filterQuery.fromJoins, filterQuery.whereClause, filterQuery.filterParams
+	searchColumns := filterQuery.searchColumns
+
+	var params []interface{} // All parameters including pagination (used in main query)
+
+	order := problemListOrder(options, withUser, searchColumns != "")
+
+	var orderClause string
+	var keysetClause string
+	if order == nil {
+		// relevance ranks are floats recomputed per query, so they make poor
+		// keyset keys; ranked searches page by offset only
+		if options.Keyset {
+			return ProblemList{}, nil, ErrCursorUnsupported
 		}
+		orderClause = " ORDER BY search_rank DESC, p.frontend_id ASC"
 	} else {
-		orderClause = " ORDER BY frontend_id ASC"
+		if err := checkCursor(options.Cursor, order.name); err != nil {
+			return ProblemList{}, nil, err
+		}
+
+		direction := "ASC"
+		if order.desc {
+			direction = "DESC"
+		}
+		// the problem ID breaks ties so every row has a unique position
+		orderClause = fmt.Sprintf(" ORDER BY %s %s, p.id %s", order.expr, direction, direction)
+
+		if options.Cursor != nil {
+			keysetClause = keysetCondition(order.expr, "p.id", order.keyType, order.desc, len(filterParams)+1)
+		}
 	}
 
 	// apply pagination
 
 	// Prepare the full parameter list *after* filter params are finalized
-	params = append(filterParams, options.Limit, options.Offset)
+	params = append(params, filterParams...)
+	if keysetClause != "" {
+		params = append(params, options.Cursor.Key, options.Cursor.ID)
+	}
 
-	// Use the correct parameter positions for LIMIT and OFFSET in the clause string
-	limitParamPos := len(filterParams) + 1
-	offsetParamPos := len(filterParams) + 2
-	limitOffsetClause := fmt.Sprintf(" LIMIT $%d OFFSET $%d", limitParamPos, offsetParamPos)
-	groupByClause := ` GROUP BY p.id, p.frontend_id, p.title, p.title_slug, p.difficulty, p.is_paid_only, p.content, p.topic_tags, p.example_testcases, p.similar_questions, p.created_at `
+	var limitOffsetClause string
+	if options.Keyset {
+		// one extra row tells whether another page follows
+		limitOffsetClause = fmt.Sprintf(" LIMIT $%d", len(params)+1)
+		params = append(params, options.Limit+1)
+	} else {
+		limitOffsetClause = fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(params)+1, len(params)+2)
+		params = append(params, options.Limit, options.Offset)
+	}
+	selectColumns := searchColumns
+	if withUser {
+		selectColumns += ", us.last_submitted_at, us.submission_count, ur.next_review_at"
+	}
 
-	query := baseQuery + whereClause + groupByClause + orderClause + limitOffsetClause
-	countQuery = countQuery + whereClause
+	query := fmt.Sprintf(baseQuery, selectColumns, fromJoins) + whereClause + keysetClause + orderClause + limitOffsetClause
+	countQuery := `SELECT COUNT(*) FROM problems p` + fromJoins + ` WHERE 1 = 1` + whereClause
 
 	var total int
-	err := s.db.QueryRow(countQuery, filterParams...).Scan(&total) // Use filterParams
+	if !options.Keyset || options.CountTotal {
+		err = s.db.QueryRow(countQuery, filterParams...).Scan(&total) // Use filterParams
 
-	if err != nil {
-		// Also log the parameters used when the error occurs
-		fmt.Printf("ERROR executing countQuery. Query: %s, Params: %v\n", countQuery, filterParams)
-		return ProblemList{}, fmt.Errorf("error counting problems: %v", err)
+		if err != nil {
+			// Also log the parameters used when the error occurs
+			fmt.Printf("ERROR executing countQuery. Query: %s, Params: %v\n", countQuery, filterParams)
+			return ProblemList{}, nil, fmt.Errorf("error counting problems: %v", err)
+		}
 	}
 
 	var problems []Problem
+	var statuses []ProblemWithStatus
 	rows, err := s.db.Query(query, params...)
 
 	if err != nil {
 		// Also log the parameters used when the error occurs
 		fmt.Printf("ERROR executing main query. Query: %s, Params: %v\n", query, params)
-		return ProblemList{}, fmt.Errorf("error querying problems: %v", err)
+		return ProblemList{}, nil, fmt.Errorf("error querying problems: %v", err)
 	}
 
 	defer rows.Close()
 
 	for rows.Next() {
-		var problem Problem
-		var topicTagsString, similarQuestionsString string
-
-		err := rows.Scan(
-			&problem.ID,
-			&problem.FrontendID,
-			&problem.Title,
-			&problem.TitleSlug,
-			&problem.Difficulty,
-			&problem.IsPaidOnly,
-			&problem.Content,
-			&topicTagsString,
-			&problem.ExampleTestcases,
-			&similarQuestionsString,
-			&problem.CreatedAt,
-		)
+		var extra []interface{}
 
-		if err != nil {
-			// Log the scan error
-			fmt.Printf("ERROR scanning problems row: %v\n", err)
-			return ProblemList{}, fmt.Errorf("error scanning problems row: %v", err)
+		var search SearchMatch
+		if searchColumns != "" {
+			extra = append(extra, &search.Rank, &search.TitleHighlight, &search.Snippet)
 		}
 
-		// unmarshal the topic tags string
-		err = json.Unmarshal([]byte(topicTagsString), &problem.TopicTags)
+		var status ProblemWithStatus
+		var lastSubmittedAt, nextReviewAt sql.NullTime
+		if withUser {
+			extra = append(extra, &lastSubmittedAt, &status.SubmissionCount, &nextReviewAt)
+		}
 
+		problem, err := scanProblem(rows, extra...)
 		if err != nil {
-			// Log the unmarshal error
-			fmt.Printf("ERROR unmarshaling topic tags for problem ID %d: %v\n", problem.ID, err)
-			return ProblemList{}, fmt.Errorf("error unmarshaling topic tags: %v", err)
+			// Log the scan error
+			fmt.Printf("ERROR scanning problems row: %v\n", err)
+			return ProblemList{}, nil, fmt.Errorf("error scanning problems row: %v", err)
 		}
 
-		err = json.Unmarshal([]byte(similarQuestionsString), &problem.SimilarQuestions)
-		if err != nil {
-			// Log the unmarshal error
-			fmt.Printf("ERROR unmarshaling similar questions for problem ID %d: %v\n", problem.ID, err)
-			return ProblemList{}, fmt.Errorf("error unmarshaling similar questions: %v", err)
+		if searchColumns != "" {
+			problem.Search = &search
 		}
 
 		problems = append(problems, problem)
+
+		if withUser {
+			if lastSubmittedAt.Valid {
+				status.LastSubmittedAt = &lastSubmittedAt.Time
+			}
+			if nextReviewAt.Valid {
+				status.NextReviewAt = &nextReviewAt.Time
+			}
+			statuses = append(statuses, status)
+		}
 	}
 
-	// now we already have the problems
-	return ProblemList{
+	result := ProblemList{
 		Problems: problems,
 		Total:    total,
-	}, nil
+	}
+
+	if options.Keyset {
+		var n int
+		n, result.HasMore = trimKeysetPage(len(problems), options.Limit)
+		result.Problems = problems[:n]
+		if withUser {
+			statuses = statuses[:n]
+		}
+		if result.HasMore {
+			var status ProblemWithStatus
+			if withUser {
+				status = statuses[n-1]
+			}
+			last := problems[n-1]
+			result.NextCursor = Cursor{Order: order.name, Key: order.key(last, status), ID: last.ID}.Encode()
+		}
+	} else {
+		result.HasMore = options.Offset+len(problems) < total
+	}
+
+	// examples are parsed from the raw content, so before formatting it
+	if err := s.AttachExamples(result.Problems); err != nil {
+		return ProblemList{}, nil, err
+	}
+
+	if err := s.ApplyContentFormat(result.Problems, options.ContentFormat); err != nil {
+		return ProblemList{}, nil, err
+	}
+
+	if options.IncludeFacets {
+		facets, err := s.countFacets(fromJoins, whereClause, filterParams)
+		if err != nil {
+			return ProblemList{}, nil, err
+		}
+		result.Facets = &facets
+	}
+
+	// now we already have the problems
+	return result, statuses, nil
+}
+
+// problemOrder is the ordering key of a problem list. name identifies the
+// ordering inside cursors and key renders a row's key for the next cursor.
+type problemOrder struct {
+	name    string
+	expr    string
+	keyType string
+	desc    bool
+	key     func(Problem, ProblemWithStatus) string
+}
+
+// problemListOrder resolves order_by and order_dir; nil means the list is
+// ordered by search relevance
+func problemListOrder(options ListProblemOptions, withUser, searching bool) *problemOrder {
+	desc := options.OrderDir == "desc"
+	order := &problemOrder{desc: desc}
+
+	switch options.OrderBy {
+	case "last_solved", "next_review":
+		if !withUser {
+			return defaultProblemOrder()
+		}
+		// problems the user never touched sort last in either direction
+		missing := "'infinity'"
+		if desc {
+			missing = "'-infinity'"
+		}
+		if options.OrderBy == "last_solved" {
+			order.expr = fmt.Sprintf("COALESCE(us.last_submitted_at, %s::timestamp)", missing)
+			order.key = func(_ Problem, status ProblemWithStatus) string {
+				return nullableTimeCursorKey(status.LastSubmittedAt, desc)
+			}
+		} else {
+			order.expr = fmt.Sprintf("COALESCE(ur.next_review_at, %s::timestamp)", missing)
+			order.key = func(_ Problem, status ProblemWithStatus) string {
+				return nullableTimeCursorKey(status.NextReviewAt, desc)
+			}
+		}
+		order.keyType = "timestamp"
+	case "difficulty":
+		// we want when we order by difficulty, the easy / hard one comes first
+		// (depends on the desc or asc)
+		order.expr = `CASE
+                    WHEN LOWER(p.difficulty) = 'hard' THEN 3
+                    WHEN LOWER(p.difficulty) = 'medium' THEN 2
+                    WHEN LOWER(p.difficulty) = 'easy' THEN 1
+                    ELSE 0
+                END`
+		order.keyType = "int"
+		order.key = func(problem Problem, _ ProblemWithStatus) string {
+			return strconv.Itoa(difficultyRank(problem.Difficulty))
+		}
+	case "title":
+		order.expr = "p.title"
+		order.keyType = "text"
+		order.key = func(problem Problem, _ ProblemWithStatus) string { return problem.Title }
+	case "created_at":
+		order.expr = "p.created_at"
+		order.keyType = "timestamp"
+		order.key = func(problem Problem, _ ProblemWithStatus) string { return timeCursorKey(problem.CreatedAt) }
+	case "frontend_id":
+		order = defaultProblemOrder()
+		order.desc = desc
+	case "":
+		if searching {
+			return nil
+		}
+		return defaultProblemOrder()
+	default:
+		return defaultProblemOrder()
+	}
+
+	order.name = "problems:" + options.OrderBy
+	if order.desc {
+		order.name += ":desc"
+	}
+	return order
+}
+
+func defaultProblemOrder() *problemOrder {
+	return &problemOrder{
+		name:    "problems:frontend_id",
+		expr:    "p.frontend_id",
+		keyType: "int",
+		key:     func(problem Problem, _ ProblemWithStatus) string { return strconv.Itoa(problem.FrontendID) },
+	}
+}
+
+func nullableTimeCursorKey(t *time.Time, desc bool) string {
+	if t != nil {
+		return timeCursorKey(*t)
+	}
+	if desc {
+		return "-infinity"
+	}
+	return "infinity"
+}
+
+func difficultyRank(difficulty string) int {
+	switch strings.ToLower(difficulty) {
+	case "hard":
+		return 3
+	case "medium":
+		return 2
+	case "easy":
+		return 1
+	}
+	return 0
+}
+
+// topicTagsContaining renders a topic_tags value that a problem's tags contain
+// (@>) exactly when it has every one of the slugs
+func topicTagsContaining(slugs ...string) string {
+	tags := make([]map[string]string, 0, len(slugs))
+	for _, slug := range slugs {
+		tags = append(tags, map[string]string{"slug": slug})
+	}
+	encoded, _ := json.Marshal(tags)
+	return string(encoded)
+}
+
+// eachTopicTagContaining renders one containment value per slug, for
+// matching problems with any of them through @> ANY
+func eachTopicTagContaining(slugs []string) []string {
+	values := make([]string, 0, len(slugs))
+	for _, slug := range slugs {
+		values = append(values, topicTagsContaining(slug))
+	}
+	return values
+}
+
+// countFacets runs the facet counts for the WHERE clause built by ListProblems
+func (s *ProblemStore) countFacets(fromJoins, whereClause string, filterParams []interface{}) (ProblemFacets, error) {
+	facets := ProblemFacets{Tags: []FacetCount{}, Difficulties: []FacetCount{}}
+
+	tagQuery := `
+		SELECT ft.tag->>'slug', COUNT(*)
+		FROM problems p` + fromJoins + `
+		CROSS JOIN LATERAL jsonb_array_elements(p.topic_tags) AS ft(tag)
+		WHERE 1 = 1` + whereClause + `
+		GROUP BY ft.tag->>'slug'
+		ORDER BY COUNT(*) DESC, ft.tag->>'slug'
+	`
+	if err := s.scanFacetCounts(tagQuery, filterParams, &facets.Tags); err != nil {
+		return ProblemFacets{}, fmt.Errorf("error counting tag facets: %v", err)
+	}
+
+	difficultyQuery := `
+		SELECT p.difficulty, COUNT(*)
+		FROM problems p` + fromJoins + `
+		WHERE 1 = 1` + whereClause + `
+		GROUP BY p.difficulty
+		ORDER BY CASE LOWER(p.difficulty) WHEN 'easy' THEN 1 WHEN 'medium' THEN 2 WHEN 'hard' THEN 3 ELSE 4 END
+	`
+	if err := s.scanFacetCounts(difficultyQuery, filterParams, &facets.Difficulties); err != nil {
+		return ProblemFacets{}, fmt.Errorf("error counting difficulty facets: %v", err)
+	}
+
+	return facets, nil
+}
+
+func (s *ProblemStore) scanFacetCounts(query string, params []interface{}, out *[]FacetCount) error {
+	rows, err := s.db.Query(query, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var facet FacetCount
+		if err := rows.Scan(&facet.Value, &facet.Count); err != nil {
+			return err
+		}
+		*out = append(*out, facet)
+	}
+
+	return rows.Err()
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		unique = append(unique, v)
+	}
+	return unique
 }
 
 type ProblemWithStatus struct {
-	Problem   Problem `json:"problem"`
-	Completed bool    `json:"completed"`
+	Problem         Problem    `json:"problem"`
+	Completed       bool       `json:"completed"`
+	LastSubmittedAt *time.Time `json:"last_submitted_at"`
+	SubmissionCount int        `json:"submission_count"`
+	NextReviewAt    *time.Time `json:"next_review_at"`
 }
 
 type ProblemListWithStatus struct {
-	Problems []ProblemWithStatus `json:"problems"`
-	Total    int                 `json:"total"`
+	Problems   []ProblemWithStatus `json:"problems"`
+	Total      int                 `json:"total"`
+	Facets     *ProblemFacets      `json:"facets,omitempty"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+	HasMore    bool                `json:"has_more"`
+}
+
+// UpdateProblemTranslations merges translated topic tag names and similar question
+// titles (as returned by leetcode.cn) into the stored problem, matching on slug
+func (s *ProblemStore) UpdateProblemTranslations(titleSlug string, tags []TopicTag, similar []SimilarQuestion) error {
+	problem, err := s.GetProblemBySlug(titleSlug)
+	if err != nil {
+		return err
+	}
+
+	translatedTags := make(map[string]*string)
+	for _, tag := range tags {
+		if tag.TranslatedName != nil {
+			translatedTags[tag.Slug] = tag.TranslatedName
+		}
+	}
+
+	for i, tag := range problem.TopicTags {
+		if name, ok := translatedTags[tag.Slug]; ok {
+			problem.TopicTags[i].TranslatedName = name
+		}
+	}
+
+	translatedTitles := make(map[string]*string)
+	for _, question := range similar {
+		if question.TranslatedTitle != nil {
+			translatedTitles[question.TitleSlug] = question.TranslatedTitle
+		}
+	}
+
+	for i, question := range problem.SimilarQuestions {
+		if title, ok := translatedTitles[question.TitleSlug]; ok {
+			problem.SimilarQuestions[i].TranslatedTitle = title
+		}
+	}
+
+	query := `UPDATE problems SET topic_tags = $1, similar_questions = $2 WHERE title_slug = $3`
+	if _, err := s.db.Exec(query, problem.TopicTags, problem.SimilarQuestions, titleSlug); err != nil {
+		return fmt.Errorf("error updating problem translations: %v", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+)
+
+func TestInferCardFromHistory(t *testing.T) {
+	first := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	single := InferCardFromHistory([]time.Time{first})
+	if single.Reps != 1 {
+		t.Errorf("Expected 1 rep for a single solve, got %d", single.Reps)
+	}
+
+	if !single.LastReview.Equal(first) {
+		t.Errorf("Expected last review %v, got %v", first, single.LastReview)
+	}
+
+	repeated := InferCardFromHistory([]time.Time{
+		first,
+		first.Add(2 * time.Hour), // same day, should count once
+		first.Add(10 * 24 * time.Hour),
+		first.Add(40 * 24 * time.Hour),
+	})
+
+	if repeated.Reps != 3 {
+		t.Errorf("Expected 3 reps after collapsing same-day solves, got %d", repeated.Reps)
+	}
+
+	if repeated.State != fsrs.Review {
+		t.Errorf("Expected card to be in review state, got %v", repeated.State)
+	}
+
+	if repeated.Stability <= single.Stability {
+		t.Errorf("Expected repeated solves to increase stability, got %f <= %f", repeated.Stability, single.Stability)
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -28,6 +29,8 @@ type ReviewSchedule struct {
 	LastReview    time.Time `json:"last_review"`
 }
 
+var ErrReviewNotFound = errors.New("no review found")
+
 type ReviewScheduleStore struct {
 	db *sql.DB
 }
@@ -36,7 +39,17 @@ func NewReviewScheduleStore(db *sql.DB) *ReviewScheduleStore {
 	return &ReviewScheduleStore{db: db}
 }
 
+// execer runs statements on the database or inside a transaction
+type execer interface {
+	rowQuerier
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 func (s *ReviewScheduleStore) CreateReviewSchedule(review *ReviewSchedule) error {
+	return createReviewSchedule(s.db, review)
+}
+
+func createReviewSchedule(q execer, review *ReviewSchedule) error {
 	query := `
         INSERT INTO review_schedules
         (submission_id, next_review_at, created_at, stability, difficulty, 
@@ -45,7 +58,7 @@ func (s *ReviewScheduleStore) CreateReviewSchedule(review *ReviewSchedule) error
         RETURNING id
     `
 
-	err := s.db.QueryRow(
+	err := q.QueryRow(
 		query,
 		review.SubmissionID,
 		review.NextReviewAt,
@@ -68,6 +81,10 @@ func (s *ReviewScheduleStore) CreateReviewSchedule(review *ReviewSchedule) error
 }
 
 func (s *ReviewScheduleStore) UpdateReviewSchedule(review *ReviewSchedule) error {
+	return updateReviewSchedule(s.db, review)
+}
+
+func updateReviewSchedule(q execer, review *ReviewSchedule) error {
 	query := `
         UPDATE review_schedules
         SET submission_id = $1, next_review_at = $2, stability = $3, difficulty = $4,
@@ -76,7 +93,7 @@ func (s *ReviewScheduleStore) UpdateReviewSchedule(review *ReviewSchedule) error
         WHERE id = $11
     `
 
-	result, err := s.db.Exec(
+	result, err := q.Exec(
 		query,
 		review.SubmissionID,
 		review.NextReviewAt,
@@ -152,84 +169,43 @@ func (s *ReviewScheduleStore) GetReviewsBySubmissionID(submissionID string) ([]R
 
 	return reviews, nil
 }
-func (s *ReviewScheduleStore) GetUpcomingReviews(userID uuid.UUID, limit, offset int) ([]ReviewSchedule, int, error) {
-	// First, count total records for pagination
-	countQuery := `
-        SELECT COUNT(*)
-        FROM review_schedules r
-        JOIN submissions s ON r.submission_id = s.id
-        WHERE s.user_id = $1 AND r.next_review_at >= NOW()
-    `
-	var total int
-	err := s.db.QueryRow(countQuery, userID).Scan(&total)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error counting upcoming reviews: %v", err)
-	}
-
-	// Then get paginated results
-	query := `
-        SELECT r.id, r.submission_id, r.next_review_at, r.created_at,
-               r.stability, r.difficulty, r.elapsed_days, r.scheduled_days,
-               r.reps, r.lapses, r.state, r.last_review, s.title, s.title_slug
-        FROM review_schedules r
-        JOIN submissions s ON r.submission_id = s.id
-        WHERE s.user_id = $1 AND r.next_review_at >= NOW()
-        ORDER BY r.next_review_at
-        LIMIT $2 OFFSET $3
-    `
-
-	rows, err := s.db.Query(query, userID, limit, offset)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error fetching upcoming reviews: %v", err)
-	}
-	defer rows.Close()
+func (s *ReviewScheduleStore) GetUpcomingReviews(userID uuid.UUID, page Page) ([]ReviewSchedule, PageInfo, error) {
+	return s.listReviews(userID, "upcoming", " AND r.next_review_at >= NOW()", page)
+}
 
-	var reviews []ReviewSchedule
-	for rows.Next() {
-		var review ReviewSchedule
-		var lastReview sql.NullTime
+func (s *ReviewScheduleStore) GetDueReviews(userID uuid.UUID, page Page) ([]ReviewSchedule, PageInfo, error) {
+	return s.listReviews(userID, "due", " AND r.next_review_at <= NOW()", page)
+}
 
-		if err := rows.Scan(
-			&review.ID,
-			&review.SubmissionID,
-			&review.NextReviewAt,
-			&review.CreatedAt,
-			&review.Stability,
-			&review.Difficulty,
-			&review.ElapsedDays,
-			&review.ScheduledDays,
-			&review.Reps,
-			&review.Lapses,
-			&review.State,
-			&lastReview,
-			&review.Title,
-			&review.TitleSlug,
-		); err != nil {
-			return nil, 0, fmt.Errorf("error scanning review: %v", err)
-		}
+// GetScheduledReviews lists due and upcoming reviews together; ordering by
+// next review date puts every due review first
+func (s *ReviewScheduleStore) GetScheduledReviews(userID uuid.UUID, page Page) ([]ReviewSchedule, PageInfo, error) {
+	return s.listReviews(userID, "scheduled", "", page)
+}
 
-		if lastReview.Valid {
-			review.LastReview = lastReview.Time
-		}
+// listReviews pages through the user's reviews matching condition, ordered by
+// next review date and then ID so keyset pages stay stable while reviews are
+// rated and move out of the list
+func (s *ReviewScheduleStore) listReviews(userID uuid.UUID, list, condition string, page Page) ([]ReviewSchedule, PageInfo, error) {
+	var info PageInfo
 
-		reviews = append(reviews, review)
+	order := "reviews:" + list
+	if err := checkCursor(page.Cursor, order); err != nil {
+		return nil, info, err
 	}
 
-	return reviews, total, nil
-}
-
-func (s *ReviewScheduleStore) GetDueReviews(userID uuid.UUID, limit, offset int) ([]ReviewSchedule, int, error) {
 	// First, count total records for pagination
 	countQuery := `
         SELECT COUNT(*)
         FROM review_schedules r
         JOIN submissions s ON r.submission_id = s.id
-        WHERE s.user_id = $1 AND r.next_review_at <= NOW()
-    `
-	var total int
-	err := s.db.QueryRow(countQuery, userID).Scan(&total)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error counting due reviews: %v", err)
+        WHERE s.user_id = $1` + condition
+
+	if page.countTotal() {
+		err := s.db.QueryRow(countQuery, userID).Scan(&info.Total)
+		if err != nil {
+			return nil, info, fmt.Errorf("error counting %s reviews: %v", list, err)
+		}
 	}
 
 	// Then get paginated results
@@ -239,14 +215,27 @@ func (s *ReviewScheduleStore) GetDueReviews(userID uuid.UUID, limit, offset int)
                r.reps, r.lapses, r.state, r.last_review, s.title, s.title_slug
         FROM review_schedules r
         JOIN submissions s ON r.submission_id = s.id
-        WHERE s.user_id = $1 AND r.next_review_at <= NOW()
-        ORDER BY r.next_review_at
-        LIMIT $2 OFFSET $3
-    `
+        WHERE s.user_id = $1` + condition
 
-	rows, err := s.db.Query(query, userID, limit, offset)
+	params := []interface{}{userID}
+	if page.Cursor != nil {
+		query += keysetCondition("r.next_review_at", "r.id", "timestamp", false, 2)
+		params = append(params, page.Cursor.Key, page.Cursor.ID)
+	}
+
+	query += " ORDER BY r.next_review_at, r.id"
+	if page.Keyset {
+		// one extra row tells whether another page follows
+		query += fmt.Sprintf(" LIMIT $%d", len(params)+1)
+		params = append(params, page.Limit+1)
+	} else {
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(params)+1, len(params)+2)
+		params = append(params, page.Limit, page.Offset)
+	}
+
+	rows, err := s.db.Query(query, params...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error fetching due reviews: %v", err)
+		return nil, info, fmt.Errorf("error fetching %s reviews: %v", list, err)
 	}
 	defer rows.Close()
 
@@ -271,7 +260,7 @@ func (s *ReviewScheduleStore) GetDueReviews(userID uuid.UUID, limit, offset int)
 			&review.Title,
 			&review.TitleSlug,
 		); err != nil {
-			return nil, 0, fmt.Errorf("error scanning review: %v", err)
+			return nil, info, fmt.Errorf("error scanning review: %v", err)
 		}
 
 		if lastReview.Valid {
@@ -281,7 +270,19 @@ func (s *ReviewScheduleStore) GetDueReviews(userID uuid.UUID, limit, offset int)
 		reviews = append(reviews, review)
 	}
 
-	return reviews, total, nil
+	if page.Keyset {
+		var n int
+		n, info.HasMore = trimKeysetPage(len(reviews), page.Limit)
+		reviews = reviews[:n]
+		if info.HasMore {
+			last := reviews[n-1]
+			info.NextCursor = Cursor{Order: order, Key: timeCursorKey(last.NextReviewAt), ID: last.ID}.Encode()
+		}
+	} else {
+		info.HasMore = page.Offset+len(reviews) < info.Total
+	}
+
+	return reviews, info, nil
 }
 
 func (s *ReviewScheduleStore) GetReviewsByUserID(userID uuid.UUID) ([]ReviewSchedule, error) {
@@ -380,6 +381,12 @@ func (s *ReviewScheduleStore) GetReviewByID(reviewID int) (ReviewSchedule, error
 }
 
 func (s *ReviewScheduleStore) GetReviewByTitleSlug(userID uuid.UUID, titleSlug string) (ReviewSchedule, error) {
+	return getReviewByTitleSlug(s.db, userID, titleSlug, false)
+}
+
+// getReviewByTitleSlug loads the user's review of a problem, locking its row
+// for the rest of the transaction when forUpdate is set
+func getReviewByTitleSlug(q rowQuerier, userID uuid.UUID, titleSlug string, forUpdate bool) (ReviewSchedule, error) {
 	query := `
         SELECT r.id, r.submission_id, r.next_review_at, r.created_at, 
                r.stability, r.difficulty, r.elapsed_days, r.scheduled_days,
@@ -390,11 +397,14 @@ func (s *ReviewScheduleStore) GetReviewByTitleSlug(userID uuid.UUID, titleSlug s
         ORDER BY s.submitted_at DESC
         LIMIT 1
     `
+	if forUpdate {
+		query += ` FOR UPDATE OF r`
+	}
 
 	var review ReviewSchedule
 	var lastReview sql.NullTime
 
-	err := s.db.QueryRow(query, userID, titleSlug).Scan(
+	err := q.QueryRow(query, userID, titleSlug).Scan(
 		&review.ID,
 		&review.SubmissionID,
 		&review.NextReviewAt,
@@ -413,7 +423,7 @@ func (s *ReviewScheduleStore) GetReviewByTitleSlug(userID uuid.UUID, titleSlug s
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return ReviewSchedule{}, fmt.Errorf("no review found for title slug %s and user ID %s", titleSlug, userID)
+			return ReviewSchedule{}, fmt.Errorf("%w for title slug %s and user ID %s", ErrReviewNotFound, titleSlug, userID)
 		}
 		return ReviewSchedule{}, fmt.Errorf("error fetching review by title slug: %v", err)
 	}
@@ -454,8 +464,18 @@ func ConvertFSRSToReviewSchedule(card fsrs.Card, review *ReviewSchedule) {
 }
 
 func (s *ReviewScheduleStore) UpdateOrCreateReviewForSubmission(submission *Submission, rating fsrs.Rating) (ReviewSchedule, error) {
+	return updateOrCreateReviewForSubmission(s.db, submission, rating)
+}
+
+// updateOrCreateReviewForSubmission reviews the problem of submission with
+// rating through q, so callers can schedule it in the transaction that stores
+// the submission
+func updateOrCreateReviewForSubmission(q execer, submission *Submission, rating fsrs.Rating) (ReviewSchedule, error) {
 	// Check if we already have a review for this problem
-	existingReview, err := s.GetReviewByTitleSlug(submission.UserID, submission.TitleSlug)
+	existingReview, err := getReviewByTitleSlug(q, submission.UserID, submission.TitleSlug, true)
+	if err != nil && !errors.Is(err, ErrReviewNotFound) {
+		return ReviewSchedule{}, err
+	}
 
 	if err == nil {
 		fsrsCard := ConvertReviewScheduleToFSRS(&existingReview)
@@ -470,7 +490,7 @@ func (s *ReviewScheduleStore) UpdateOrCreateReviewForSubmission(submission *Subm
 		ConvertFSRSToReviewSchedule(result.Card, &existingReview)
 		existingReview.LastReview = now
 
-		if err := s.UpdateReviewSchedule(&existingReview); err != nil {
+		if err := updateReviewSchedule(q, &existingReview); err != nil {
 			return ReviewSchedule{}, fmt.Errorf("error updating existing review: %v", err)
 		}
 		return existingReview, nil
@@ -493,8 +513,65 @@ func (s *ReviewScheduleStore) UpdateOrCreateReviewForSubmission(submission *Subm
 	// Set FSRS fields
 	ConvertFSRSToReviewSchedule(result.Card, &newReview)
 
-	if err := s.CreateReviewSchedule(&newReview); err != nil {
+	if err := createReviewSchedule(q, &newReview); err != nil {
 		return ReviewSchedule{}, fmt.Errorf("error creating new review: %v", err)
 	}
 	return newReview, nil
 }
+
+// InferCardFromHistory approximates the FSRS memory state of a problem from
+// the dates it was solved, replaying each solve as a "Good" review. Solves on
+// the same day count once.
+func InferCardFromHistory(solvedAt []time.Time) fsrs.Card {
+	fsrsScheduler := fsrs.NewFSRS(fsrs.DefaultParam())
+	card := fsrs.NewCard()
+
+	var lastDay time.Time
+	for _, t := range solvedAt {
+		day := t.UTC().Truncate(24 * time.Hour)
+		if !lastDay.IsZero() && !day.After(lastDay) {
+			continue
+		}
+		lastDay = day
+
+		card = fsrsScheduler.Next(card, t.UTC(), fsrs.Good).Card
+	}
+
+	return card
+}
+
+// SeedReviewFromHistory creates the review schedule for a problem the user
+// solved before joining, using the full list of its submissions (oldest first).
+// Problems that already have a schedule are left untouched; the bool result
+// reports whether a schedule was created.
+func (s *ReviewScheduleStore) SeedReviewFromHistory(submissions []Submission) (ReviewSchedule, bool, error) {
+	if len(submissions) == 0 {
+		return ReviewSchedule{}, false, nil
+	}
+
+	latest := submissions[len(submissions)-1]
+	_, err := s.GetReviewByTitleSlug(latest.UserID, latest.TitleSlug)
+	if err == nil {
+		return ReviewSchedule{}, false, nil
+	}
+	if !errors.Is(err, ErrReviewNotFound) {
+		return ReviewSchedule{}, false, err
+	}
+
+	solvedAt := make([]time.Time, 0, len(submissions))
+	for _, sub := range submissions {
+		solvedAt = append(solvedAt, sub.SubmittedAt)
+	}
+
+	review := ReviewSchedule{
+		SubmissionID: latest.ID,
+		CreatedAt:    time.Now().UTC(),
+	}
+	ConvertFSRSToReviewSchedule(InferCardFromHistory(solvedAt), &review)
+
+	if err := s.CreateReviewSchedule(&review); err != nil {
+		return ReviewSchedule{}, false, fmt.Errorf("error seeding review from history: %v", err)
+	}
+
+	return review, true, nil
+}
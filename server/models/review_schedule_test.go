@@ -6,6 +6,7 @@ import (
    "go-leetcode/backend/internal/database"
    "go-leetcode/backend/internal/testutils"
 
+   "github.com/open-spaced-repetition/go-fsrs/v3"
 )
 
 func setupTestReview(t *testing.T) (*ReviewScheduleStore, *database.TestDB, ReviewSchedule) {
@@ -155,7 +156,7 @@ func TestUpdateOrCreateReviewForSubmission(t *testing.T) {
    testutils.CheckErr(t, err, "Failed to create test submission for update test")
    
    // Test CREATE case - first time solving
-   reviewResult, err := store.UpdateOrCreateReviewForSubmission(&testSubmission)
+   reviewResult, err := store.UpdateOrCreateReviewForSubmission(&testSubmission, fsrs.Good)
    testutils.CheckErr(t, err, "Failed to create review for new submission")
    
    // Verify the review was created properly
@@ -182,7 +183,7 @@ func TestUpdateOrCreateReviewForSubmission(t *testing.T) {
    err = subStore.CreateSubmission(secondSubmission)
    testutils.CheckErr(t, err, "Failed to create second test submission")
    
-   updatedReview, err := store.UpdateOrCreateReviewForSubmission(&secondSubmission)
+   updatedReview, err := store.UpdateOrCreateReviewForSubmission(&secondSubmission, fsrs.Good)
    testutils.CheckErr(t, err, "Failed to update review for existing problem")
    
    // Verify the review was updated
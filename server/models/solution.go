@@ -2,16 +2,30 @@ package models
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSolutionNotFound = errors.New("solution not found")
+	// ErrSolutionExists is returned when the problem already has a reference
+	// solution in the language
+	ErrSolutionExists = errors.New("solution already exists")
 )
 
 type Solution struct {
-	ID           int       `json:"id"`
-	ProblemID    int       `json:"problem_id"`
-	Language     string    `json:"language"`
-	SolutionCode string    `json:"solution_code"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           int        `json:"id"`
+	ProblemID    int        `json:"problem_id"`
+	ApproachID   int        `json:"approach_id"`
+	Language     string     `json:"language"`
+	SolutionCode string     `json:"solution_code"`
+	AuthorID     *uuid.UUID `json:"author_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
 }
 
 type SolutionStore struct {
@@ -22,48 +36,95 @@ func NewSolutionStore(db *sql.DB) *SolutionStore {
 	return &SolutionStore{db: db}
 }
 
-// Create a new solution
-func (s *SolutionStore) CreateSolution(solution Solution) (Solution, error) {
+const solutionColumns = `id, problem_id, approach_id, language, solution_code, author_id, created_at, updated_at`
+
+func scanSolution(row rowScanner) (Solution, error) {
+	var solution Solution
+	var authorID uuid.NullUUID
+	var updatedAt sql.NullTime
+	err := row.Scan(
+		&solution.ID,
+		&solution.ProblemID,
+		&solution.ApproachID,
+		&solution.Language,
+		&solution.SolutionCode,
+		&authorID,
+		&solution.CreatedAt,
+		&updatedAt,
+	)
+	if authorID.Valid {
+		solution.AuthorID = &authorID.UUID
+	}
+	if updatedAt.Valid {
+		solution.UpdatedAt = &updatedAt.Time
+	}
+	return solution, err
+}
+
+// Create a new reference solution in the problem's primary approach on behalf
+// of actorID, failing with ErrSolutionExists when the problem has one in the
+// language and ErrProblemNotFound when there is no such problem
+func (s *SolutionStore) CreateSolution(actorID uuid.UUID, solution Solution) (Solution, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Solution{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := getSolutionByLanguage(tx, solution.ProblemID, solution.Language); err == nil {
+		return Solution{}, ErrSolutionExists
+	} else if !errors.Is(err, ErrSolutionNotFound) {
+		return Solution{}, err
+	}
+
+	solution.ApproachID, err = primaryApproachID(tx, solution.ProblemID, actorID)
+	if err != nil {
+		return Solution{}, err
+	}
+
 	query := `
-		INSERT INTO problem_solutions (problem_id, language, solution_code)
-		VALUES ($1, $2, $3)
+		INSERT INTO problem_solutions (problem_id, approach_id, language, solution_code, author_id)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at
 	`
 
-	err := s.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		solution.ProblemID,
+		solution.ApproachID,
 		solution.Language,
 		solution.SolutionCode,
+		actorID,
 	).Scan(&solution.ID, &solution.CreatedAt)
 
 	if err != nil {
 		return Solution{}, fmt.Errorf("error creating solution: %v", err)
 	}
+	solution.AuthorID = &actorID
 
+	changes := map[string]AuditChange{}
+	auditField(changes, "problem_id", nil, solution.ProblemID)
+	auditField(changes, "language", nil, solution.Language)
+	auditField(changes, "solution_code", nil, solution.SolutionCode)
+	if err := recordAudit(tx, actorID, AuditSolutionCreate, "solution", strconv.Itoa(solution.ID), changes); err != nil {
+		return Solution{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Solution{}, fmt.Errorf("error committing solution: %v", err)
+	}
 	return solution, nil
 }
 
 // Get solution by ID
 func (s *SolutionStore) GetSolutionByID(id int) (Solution, error) {
-	query := `
-		SELECT id, problem_id, language, solution_code, created_at
-		FROM problem_solutions
-		WHERE id = $1
-	`
+	query := `SELECT ` + solutionColumns + ` FROM problem_solutions WHERE id = $1`
 
-	var solution Solution
-	err := s.db.QueryRow(query, id).Scan(
-		&solution.ID,
-		&solution.ProblemID,
-		&solution.Language,
-		&solution.SolutionCode,
-		&solution.CreatedAt,
-	)
+	solution, err := scanSolution(s.db.QueryRow(query, id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return Solution{}, fmt.Errorf("solution with ID %d not found", id)
+			return Solution{}, fmt.Errorf("%w: no solution with ID %d", ErrSolutionNotFound, id)
 		}
 		return Solution{}, fmt.Errorf("error fetching solution: %v", err)
 	}
@@ -71,16 +132,20 @@ func (s *SolutionStore) GetSolutionByID(id int) (Solution, error) {
 	return solution, nil
 }
 
-// Get solutions by problem ID
+// Get the solutions of the problem's primary approach, see Approach
 func (s *SolutionStore) GetSolutionsByProblemID(problemID int) ([]Solution, error) {
 	query := `
-		SELECT id, problem_id, language, solution_code, created_at
+		SELECT ` + solutionColumns + `
 		FROM problem_solutions
-		WHERE problem_id = $1
+		WHERE approach_id = (` + primaryApproachQuery + `)
 		ORDER BY language
 	`
 
-	rows, err := s.db.Query(query, problemID)
+	return s.querySolutions(query, problemID)
+}
+
+func (s *SolutionStore) querySolutions(query string, args ...interface{}) ([]Solution, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error querying solutions: %v", err)
 	}
@@ -88,14 +153,7 @@ func (s *SolutionStore) GetSolutionsByProblemID(problemID int) ([]Solution, erro
 
 	var solutions []Solution
 	for rows.Next() {
-		var solution Solution
-		err := rows.Scan(
-			&solution.ID,
-			&solution.ProblemID,
-			&solution.Language,
-			&solution.SolutionCode,
-			&solution.CreatedAt,
-		)
+		solution, err := scanSolution(rows)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning solution row: %v", err)
 		}
@@ -107,24 +165,24 @@ func (s *SolutionStore) GetSolutionsByProblemID(problemID int) ([]Solution, erro
 
 // Get solution by problem ID and language
 func (s *SolutionStore) GetSolutionByProblemAndLanguage(problemID int, language string) (Solution, error) {
+	return getSolutionByLanguage(s.db, problemID, language)
+}
+
+// getSolutionByLanguage looks in the problem's primary approach, matching the
+// language case insensitively as stored solutions use both "Python" and
+// "python"
+func getSolutionByLanguage(q rowQuerier, problemID int, language string) (Solution, error) {
 	query := `
-		SELECT id, problem_id, language, solution_code, created_at
+		SELECT ` + solutionColumns + `
 		FROM problem_solutions
-		WHERE problem_id = $1 AND lower(language) = $2
+		WHERE approach_id = (` + primaryApproachQuery + `) AND lower(language) = lower($2)
 	`
 
-	var solution Solution
-	err := s.db.QueryRow(query, problemID, language).Scan(
-		&solution.ID,
-		&solution.ProblemID,
-		&solution.Language,
-		&solution.SolutionCode,
-		&solution.CreatedAt,
-	)
+	solution, err := scanSolution(q.QueryRow(query, problemID, language))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return Solution{}, fmt.Errorf("solution for problem ID %d and language %s not found", problemID, language)
+			return Solution{}, fmt.Errorf("%w: no solution for problem ID %d and language %s", ErrSolutionNotFound, problemID, language)
 		}
 		return Solution{}, fmt.Errorf("error fetching solution: %v", err)
 	}
@@ -132,48 +190,78 @@ func (s *SolutionStore) GetSolutionByProblemAndLanguage(problemID int, language
 	return solution, nil
 }
 
-// Update a solution
-func (s *SolutionStore) UpdateSolution(solution Solution) error {
+// Update the code of a solution on behalf of actorID, returning the updated
+// solution
+func (s *SolutionStore) UpdateSolution(actorID uuid.UUID, solution Solution) (Solution, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Solution{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	old, err := scanSolution(tx.QueryRow(`SELECT `+solutionColumns+` FROM problem_solutions WHERE id = $1 FOR UPDATE`, solution.ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Solution{}, fmt.Errorf("%w: no solution with ID %d", ErrSolutionNotFound, solution.ID)
+		}
+		return Solution{}, fmt.Errorf("error fetching solution: %v", err)
+	}
+
+	changes := map[string]AuditChange{}
+	auditField(changes, "solution_code", old.SolutionCode, solution.SolutionCode)
+	if len(changes) == 0 {
+		return old, nil
+	}
+
 	query := `
 		UPDATE problem_solutions
-		SET solution_code = $1
+		SET solution_code = $1, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $2
-	`
+		RETURNING ` + solutionColumns
 
-	result, err := s.db.Exec(query, solution.SolutionCode, solution.ID)
+	updated, err := scanSolution(tx.QueryRow(query, solution.SolutionCode, solution.ID))
 	if err != nil {
-		return fmt.Errorf("error updating solution: %v", err)
+		return Solution{}, fmt.Errorf("error updating solution: %v", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error getting rows affected: %v", err)
+	if err := recordAudit(tx, actorID, AuditSolutionUpdate, "solution", strconv.Itoa(solution.ID), changes); err != nil {
+		return Solution{}, err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("solution with ID %d not found", solution.ID)
+	if err := tx.Commit(); err != nil {
+		return Solution{}, fmt.Errorf("error committing solution: %v", err)
 	}
-
-	return nil
+	return updated, nil
 }
 
-// Delete a solution
-func (s *SolutionStore) DeleteSolution(id int) error {
-	query := "DELETE FROM problem_solutions WHERE id = $1"
-
-	result, err := s.db.Exec(query, id)
+// Delete a solution on behalf of actorID
+func (s *SolutionStore) DeleteSolution(actorID uuid.UUID, id int) error {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("error deleting solution: %v", err)
+		return fmt.Errorf("error starting transaction: %v", err)
 	}
+	defer tx.Rollback()
+
+	query := "DELETE FROM problem_solutions WHERE id = $1 RETURNING " + solutionColumns
 
-	rowsAffected, err := result.RowsAffected()
+	old, err := scanSolution(tx.QueryRow(query, id))
 	if err != nil {
-		return fmt.Errorf("error getting rows affected: %v", err)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: no solution with ID %d", ErrSolutionNotFound, id)
+		}
+		return fmt.Errorf("error deleting solution: %v", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("solution with ID %d not found", id)
+	changes := map[string]AuditChange{}
+	auditField(changes, "problem_id", old.ProblemID, nil)
+	auditField(changes, "language", old.Language, nil)
+	auditField(changes, "solution_code", old.SolutionCode, nil)
+	if err := recordAudit(tx, actorID, AuditSolutionDelete, "solution", strconv.Itoa(id), changes); err != nil {
+		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing solution: %v", err)
+	}
 	return nil
 }
@@ -0,0 +1,450 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrApproachNotFound = errors.New("solution approach not found")
+
+// Approach kinds, see Approach
+const (
+	ApproachBruteForce  = "brute_force"
+	ApproachOptimal     = "optimal"
+	ApproachAlternative = "alternative"
+)
+
+func IsValidApproachKind(kind string) bool {
+	return kind == ApproachBruteForce || kind == ApproachOptimal || kind == ApproachAlternative
+}
+
+// Approach is one way of solving a problem, with its code per language. The
+// first approach by position is the problem's primary approach, whose code is
+// what the language → code solution map returns.
+type Approach struct {
+	ID              int               `json:"id"`
+	ProblemID       int               `json:"problem_id"`
+	Kind            string            `json:"kind"`
+	Title           string            `json:"title"`
+	Explanation     string            `json:"explanation"` // Markdown
+	TimeComplexity  string            `json:"time_complexity"`
+	SpaceComplexity string            `json:"space_complexity"`
+	Position        int               `json:"position"`
+	Code            map[string]string `json:"code"` // language → code
+	Hints           ApproachHints     `json:"hints"`
+	AuthorID        *uuid.UUID        `json:"author_id"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       *time.Time        `json:"updated_at"`
+}
+
+// ApproachUpdate holds the fields an editor changes on an approach; nil
+// fields are left as they are. Code entries replace the code in their
+// language, an empty code removes the language.
+type ApproachUpdate struct {
+	Kind            *string           `json:"kind"`
+	Title           *string           `json:"title"`
+	Explanation     *string           `json:"explanation"`
+	TimeComplexity  *string           `json:"time_complexity"`
+	SpaceComplexity *string           `json:"space_complexity"`
+	Position        *int              `json:"position"`
+	Code            map[string]string `json:"code"`
+	Hints           *[]Hint           `json:"hints"`
+}
+
+// apply sets the fields of the update on approach, recording each changed
+// field in changes
+func (u ApproachUpdate) apply(approach *Approach, changes map[string]AuditChange) {
+	if u.Kind != nil {
+		auditField(changes, "kind", approach.Kind, *u.Kind)
+		approach.Kind = *u.Kind
+	}
+	if u.Title != nil {
+		auditField(changes, "title", approach.Title, *u.Title)
+		approach.Title = *u.Title
+	}
+	if u.Explanation != nil {
+		auditField(changes, "explanation", approach.Explanation, *u.Explanation)
+		approach.Explanation = *u.Explanation
+	}
+	if u.TimeComplexity != nil {
+		auditField(changes, "time_complexity", approach.TimeComplexity, *u.TimeComplexity)
+		approach.TimeComplexity = *u.TimeComplexity
+	}
+	if u.SpaceComplexity != nil {
+		auditField(changes, "space_complexity", approach.SpaceComplexity, *u.SpaceComplexity)
+		approach.SpaceComplexity = *u.SpaceComplexity
+	}
+	if u.Position != nil {
+		auditField(changes, "position", approach.Position, *u.Position)
+		approach.Position = *u.Position
+	}
+	if u.Hints != nil {
+		auditField(changes, "hints", approach.Hints, ApproachHints(*u.Hints))
+		approach.Hints = *u.Hints
+	}
+
+	for language, code := range u.Code {
+		// match the stored spelling of the language, e.g. "Python"
+		stored, old := language, ""
+		for l, c := range approach.Code {
+			if strings.EqualFold(l, language) {
+				stored, old = l, c
+				break
+			}
+		}
+		if code == old {
+			continue
+		}
+		auditField(changes, "code."+stored, nullIfEmpty(old), nullIfEmpty(code))
+		if code == "" {
+			delete(approach.Code, stored)
+		} else {
+			approach.Code[stored] = code
+		}
+	}
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+const approachColumns = `id, problem_id, kind, title, explanation, time_complexity, space_complexity, "position",
+	hints, author_id, created_at, updated_at`
+
+// primaryApproachQuery selects the ID of the primary approach of problem $1
+const primaryApproachQuery = `SELECT id FROM solution_approaches WHERE problem_id = $1 ORDER BY "position", id LIMIT 1`
+
+func scanApproach(row rowScanner) (Approach, error) {
+	approach := Approach{Code: map[string]string{}}
+	var authorID uuid.NullUUID
+	var updatedAt sql.NullTime
+	err := row.Scan(&approach.ID, &approach.ProblemID, &approach.Kind, &approach.Title, &approach.Explanation,
+		&approach.TimeComplexity, &approach.SpaceComplexity, &approach.Position, &approach.Hints, &authorID, &approach.CreatedAt, &updatedAt)
+	if authorID.Valid {
+		approach.AuthorID = &authorID.UUID
+	}
+	if updatedAt.Valid {
+		approach.UpdatedAt = &updatedAt.Time
+	}
+	return approach, err
+}
+
+// ListApproaches returns the approaches of a problem with their code, primary
+// approach first
+func (s *SolutionStore) ListApproaches(problemID int) ([]Approach, error) {
+	query := `
+		SELECT ` + approachColumns + `
+		FROM solution_approaches
+		WHERE problem_id = $1
+		ORDER BY "position", id
+	`
+
+	rows, err := s.db.Query(query, problemID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing solution approaches: %v", err)
+	}
+	defer rows.Close()
+
+	approaches := []Approach{}
+	byID := map[int]int{}
+	for rows.Next() {
+		approach, err := scanApproach(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning solution approach: %v", err)
+		}
+		byID[approach.ID] = len(approaches)
+		approaches = append(approaches, approach)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing solution approaches: %v", err)
+	}
+
+	solutions, err := s.querySolutions(`SELECT `+solutionColumns+` FROM problem_solutions WHERE problem_id = $1`, problemID)
+	if err != nil {
+		return nil, err
+	}
+	for _, solution := range solutions {
+		if i, ok := byID[solution.ApproachID]; ok {
+			approaches[i].Code[solution.Language] = solution.SolutionCode
+		}
+	}
+
+	return approaches, nil
+}
+
+// getApproachForUpdate loads an approach with its code, locking it for the
+// rest of the transaction
+func getApproachForUpdate(tx *sql.Tx, id int) (Approach, error) {
+	approach, err := scanApproach(tx.QueryRow(`SELECT `+approachColumns+` FROM solution_approaches WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Approach{}, ErrApproachNotFound
+		}
+		return Approach{}, fmt.Errorf("error fetching solution approach: %v", err)
+	}
+
+	rows, err := tx.Query(`SELECT language, solution_code FROM problem_solutions WHERE approach_id = $1`, id)
+	if err != nil {
+		return Approach{}, fmt.Errorf("error fetching approach code: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var language, code string
+		if err := rows.Scan(&language, &code); err != nil {
+			return Approach{}, fmt.Errorf("error scanning approach code: %v", err)
+		}
+		approach.Code[language] = code
+	}
+	if err := rows.Err(); err != nil {
+		return Approach{}, fmt.Errorf("error fetching approach code: %v", err)
+	}
+	return approach, nil
+}
+
+// CreateApproach adds an approach and its code to a problem on behalf of
+// actorID, failing with ErrProblemNotFound when there is no such problem
+func (s *SolutionStore) CreateApproach(actorID uuid.UUID, approach *Approach) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := createApproach(tx, actorID, approach); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing solution approach: %v", err)
+	}
+	return nil
+}
+
+func createApproach(tx *sql.Tx, actorID uuid.UUID, approach *Approach) error {
+	query := `
+		INSERT INTO solution_approaches
+		(problem_id, kind, title, explanation, time_complexity, space_complexity, "position", hints, author_id)
+		SELECT p.id, $2, $3, $4, $5, $6, $7, $8, $9 FROM problems p WHERE p.id = $1
+		RETURNING id, created_at
+	`
+
+	err := tx.QueryRow(query, approach.ProblemID, approach.Kind, approach.Title, approach.Explanation,
+		approach.TimeComplexity, approach.SpaceComplexity, approach.Position, approach.Hints, actorID).Scan(&approach.ID, &approach.CreatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: no problem with ID %d", ErrProblemNotFound, approach.ProblemID)
+	}
+	if err != nil {
+		return fmt.Errorf("error creating solution approach: %v", err)
+	}
+	approach.AuthorID = &actorID
+
+	if approach.Code == nil {
+		approach.Code = map[string]string{}
+	}
+	for _, language := range sortedKeys(approach.Code) {
+		if err := insertApproachCode(tx, *approach, language, approach.Code[language], actorID); err != nil {
+			return err
+		}
+	}
+
+	changes := map[string]AuditChange{}
+	auditField(changes, "problem_id", nil, approach.ProblemID)
+	auditField(changes, "kind", nil, approach.Kind)
+	auditField(changes, "title", nil, approach.Title)
+	auditField(changes, "time_complexity", nil, approach.TimeComplexity)
+	auditField(changes, "space_complexity", nil, approach.SpaceComplexity)
+	if len(approach.Hints) > 0 {
+		auditField(changes, "hints", nil, approach.Hints)
+	}
+	for language, code := range approach.Code {
+		auditField(changes, "code."+language, nil, code)
+	}
+	return recordAudit(tx, actorID, AuditApproachCreate, "approach", strconv.Itoa(approach.ID), changes)
+}
+
+// UpdateApproach applies update to an approach on behalf of actorID and
+// returns the updated approach. Nothing is written or audited when no field
+// changes.
+func (s *SolutionStore) UpdateApproach(actorID uuid.UUID, id int, update ApproachUpdate) (Approach, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Approach{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	approach, err := updateApproach(tx, actorID, id, update)
+	if err != nil {
+		return Approach{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Approach{}, fmt.Errorf("error committing solution approach: %v", err)
+	}
+	return approach, nil
+}
+
+func updateApproach(tx *sql.Tx, actorID uuid.UUID, id int, update ApproachUpdate) (Approach, error) {
+	approach, err := getApproachForUpdate(tx, id)
+	if err != nil {
+		return Approach{}, err
+	}
+	old := make(map[string]string, len(approach.Code))
+	for language, code := range approach.Code {
+		old[language] = code
+	}
+
+	changes := map[string]AuditChange{}
+	update.apply(&approach, changes)
+	if len(changes) == 0 {
+		return approach, nil
+	}
+
+	query := `
+		UPDATE solution_approaches
+		SET kind = $1, title = $2, explanation = $3, time_complexity = $4, space_complexity = $5,
+		    "position" = $6, hints = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8
+		RETURNING updated_at
+	`
+	var updatedAt time.Time
+	err = tx.QueryRow(query, approach.Kind, approach.Title, approach.Explanation, approach.TimeComplexity,
+		approach.SpaceComplexity, approach.Position, approach.Hints, id).Scan(&updatedAt)
+	if err != nil {
+		return Approach{}, fmt.Errorf("error updating solution approach: %v", err)
+	}
+	approach.UpdatedAt = &updatedAt
+
+	if err := syncApproachCode(tx, approach, old, actorID); err != nil {
+		return Approach{}, err
+	}
+
+	if err := recordAudit(tx, actorID, AuditApproachUpdate, "approach", strconv.Itoa(id), changes); err != nil {
+		return Approach{}, err
+	}
+	return approach, nil
+}
+
+// DeleteApproach removes an approach and its code on behalf of actorID
+func (s *SolutionStore) DeleteApproach(actorID uuid.UUID, id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	approach, err := getApproachForUpdate(tx, id)
+	if err != nil {
+		return err
+	}
+
+	// problem_solutions rows go with it, ON DELETE CASCADE
+	if _, err := tx.Exec(`DELETE FROM solution_approaches WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("error deleting solution approach: %v", err)
+	}
+
+	changes := map[string]AuditChange{}
+	auditField(changes, "problem_id", approach.ProblemID, nil)
+	auditField(changes, "kind", approach.Kind, nil)
+	auditField(changes, "title", approach.Title, nil)
+	for language, code := range approach.Code {
+		auditField(changes, "code."+language, code, nil)
+	}
+	if err := recordAudit(tx, actorID, AuditApproachDelete, "approach", strconv.Itoa(id), changes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing solution approach: %v", err)
+	}
+	return nil
+}
+
+// syncApproachCode writes the difference between the old and the new code of
+// an approach to problem_solutions
+func syncApproachCode(tx *sql.Tx, approach Approach, old map[string]string, actorID uuid.UUID) error {
+	for language := range old {
+		if _, ok := approach.Code[language]; ok {
+			continue
+		}
+		if _, err := tx.Exec(`DELETE FROM problem_solutions WHERE approach_id = $1 AND language = $2`, approach.ID, language); err != nil {
+			return fmt.Errorf("error deleting approach code: %v", err)
+		}
+	}
+
+	for _, language := range sortedKeys(approach.Code) {
+		code := approach.Code[language]
+		previous, ok := old[language]
+		switch {
+		case !ok:
+			if err := insertApproachCode(tx, approach, language, code, actorID); err != nil {
+				return err
+			}
+		case previous != code:
+			_, err := tx.Exec(`
+				UPDATE problem_solutions SET solution_code = $1, author_id = $2, updated_at = CURRENT_TIMESTAMP
+				WHERE approach_id = $3 AND language = $4
+			`, code, actorID, approach.ID, language)
+			if err != nil {
+				return fmt.Errorf("error updating approach code: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func insertApproachCode(tx *sql.Tx, approach Approach, language, code string, authorID uuid.UUID) error {
+	_, err := tx.Exec(`
+		INSERT INTO problem_solutions (problem_id, approach_id, language, solution_code, author_id)
+		VALUES ($1, $2, $3, $4, $5)
+	`, approach.ProblemID, approach.ID, language, code, authorID)
+	if err != nil {
+		return fmt.Errorf("error adding approach code: %v", err)
+	}
+	return nil
+}
+
+// primaryApproachID returns the ID of the problem's primary approach,
+// creating a default one when the problem has none
+func primaryApproachID(tx *sql.Tx, problemID int, actorID uuid.UUID) (int, error) {
+	var id int
+	err := tx.QueryRow(primaryApproachQuery, problemID).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("error fetching primary approach: %v", err)
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO solution_approaches (problem_id, kind, title, author_id)
+		SELECT p.id, $2, 'Solution', $3 FROM problems p WHERE p.id = $1
+		RETURNING id
+	`, problemID, ApproachOptimal, actorID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w: no problem with ID %d", ErrProblemNotFound, problemID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error creating primary approach: %v", err)
+	}
+	return id, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,43 @@
+package models
+
+import "testing"
+
+func TestApproachUpdateApply(t *testing.T) {
+	approach := Approach{
+		Kind:           ApproachBruteForce,
+		Title:          "Nested loops",
+		TimeComplexity: "O(n^2)",
+		Code:           map[string]string{"Python": "a", "go": "b"},
+	}
+	kind := ApproachBruteForce
+	timeComplexity := "O(n)"
+
+	changes := map[string]AuditChange{}
+	ApproachUpdate{
+		Kind:           &kind,
+		TimeComplexity: &timeComplexity,
+		Code:           map[string]string{"python": "c", "go": "", "java": "d"},
+	}.apply(&approach, changes)
+
+	want := map[string]string{"Python": "c", "java": "d"}
+	if len(approach.Code) != len(want) {
+		t.Fatalf("code = %v, want %v", approach.Code, want)
+	}
+	for language, code := range want {
+		if approach.Code[language] != code {
+			t.Errorf("code[%s] = %q, want %q", language, approach.Code[language], code)
+		}
+	}
+
+	for _, field := range []string{"time_complexity", "code.Python", "code.go", "code.java"} {
+		if _, ok := changes[field]; !ok {
+			t.Errorf("changes lack %s: %v", field, changes)
+		}
+	}
+	if _, ok := changes["kind"]; ok {
+		t.Errorf("unchanged kind was recorded: %v", changes["kind"])
+	}
+	if c := changes["code.go"]; c.Old != "b" || c.New != nil {
+		t.Errorf("code.go change = %v, want b -> null", c)
+	}
+}
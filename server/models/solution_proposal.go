@@ -0,0 +1,320 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrProposalNotFound = errors.New("solution proposal not found")
+	// ErrProposalReviewed is returned when changing a proposal that was
+	// already approved or rejected
+	ErrProposalReviewed = errors.New("solution proposal was already reviewed")
+)
+
+// Solution proposal statuses
+const (
+	ProposalPending  = "pending"
+	ProposalApproved = "approved"
+	ProposalRejected = "rejected"
+)
+
+func IsValidProposalStatus(status string) bool {
+	return status == ProposalPending || status == ProposalApproved || status == ProposalRejected
+}
+
+// SolutionProposal is a reference solution suggested by a user. Editors
+// approve it into problem_solutions, replacing the solution in its language,
+// or reject it with a note.
+type SolutionProposal struct {
+	ID           int        `json:"id"`
+	ProblemID    int        `json:"problem_id"`
+	Language     string     `json:"language"`
+	SolutionCode string     `json:"solution_code"`
+	AuthorID     uuid.UUID  `json:"author_id"`
+	Status       string     `json:"status"`
+	SolutionID   *int       `json:"solution_id"`
+	ReviewerID   *uuid.UUID `json:"reviewer_id"`
+	ReviewNote   string     `json:"review_note,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+}
+
+// ProposalFilter selects proposals; zero fields match everything
+type ProposalFilter struct {
+	AuthorID  uuid.UUID
+	ProblemID int
+	Status    string
+	Limit     int
+}
+
+const proposalColumns = `id, problem_id, language, solution_code, author_id, status, solution_id,
+	reviewer_id, review_note, created_at, reviewed_at`
+
+func scanProposal(row rowScanner) (SolutionProposal, error) {
+	var proposal SolutionProposal
+	var solutionID sql.NullInt64
+	var reviewerID uuid.NullUUID
+	var reviewedAt sql.NullTime
+	err := row.Scan(&proposal.ID, &proposal.ProblemID, &proposal.Language, &proposal.SolutionCode, &proposal.AuthorID,
+		&proposal.Status, &solutionID, &reviewerID, &proposal.ReviewNote, &proposal.CreatedAt, &reviewedAt)
+	if solutionID.Valid {
+		id := int(solutionID.Int64)
+		proposal.SolutionID = &id
+	}
+	if reviewerID.Valid {
+		proposal.ReviewerID = &reviewerID.UUID
+	}
+	if reviewedAt.Valid {
+		proposal.ReviewedAt = &reviewedAt.Time
+	}
+	return proposal, err
+}
+
+// CreateProposal queues a solution proposed by proposal.AuthorID, failing
+// with ErrProblemNotFound unless the problem is visible
+func (s *SolutionStore) CreateProposal(proposal *SolutionProposal) error {
+	query := `
+		INSERT INTO solution_proposals (problem_id, language, solution_code, author_id)
+		SELECT p.id, $2, $3, $4 FROM problems p WHERE p.id = $1 AND ` + visibleProblem + `
+		RETURNING id, status, created_at
+	`
+
+	err := s.db.QueryRow(query, proposal.ProblemID, proposal.Language, proposal.SolutionCode, proposal.AuthorID).
+		Scan(&proposal.ID, &proposal.Status, &proposal.CreatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: no problem with ID %d", ErrProblemNotFound, proposal.ProblemID)
+	}
+	if err != nil {
+		return fmt.Errorf("error creating solution proposal: %v", err)
+	}
+	return nil
+}
+
+// GetProposal loads a proposal, only when authorID wrote it unless authorID
+// is nil
+func (s *SolutionStore) GetProposal(id int, authorID uuid.UUID) (SolutionProposal, error) {
+	query := `SELECT ` + proposalColumns + ` FROM solution_proposals WHERE id = $1 AND ($2::uuid IS NULL OR author_id = $2)`
+
+	proposal, err := scanProposal(s.db.QueryRow(query, id, nullableUUID(authorID)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return SolutionProposal{}, ErrProposalNotFound
+		}
+		return SolutionProposal{}, fmt.Errorf("error fetching solution proposal: %v", err)
+	}
+	return proposal, nil
+}
+
+// ListProposals returns the proposals matching the filter, oldest first so
+// the moderation queue is worked in order
+func (s *SolutionStore) ListProposals(filter ProposalFilter) ([]SolutionProposal, error) {
+	query := `
+		SELECT ` + proposalColumns + `
+		FROM solution_proposals
+		WHERE ($1::uuid IS NULL OR author_id = $1)
+		  AND ($2 = 0 OR problem_id = $2)
+		  AND ($3 = '' OR status = $3)
+		ORDER BY created_at, id
+		LIMIT $4
+	`
+
+	rows, err := s.db.Query(query, nullableUUID(filter.AuthorID), filter.ProblemID, filter.Status, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing solution proposals: %v", err)
+	}
+	defer rows.Close()
+
+	proposals := []SolutionProposal{}
+	for rows.Next() {
+		proposal, err := scanProposal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning solution proposal: %v", err)
+		}
+		proposals = append(proposals, proposal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing solution proposals: %v", err)
+	}
+	return proposals, nil
+}
+
+// UpdateProposal changes the code of one of the author's pending proposals
+func (s *SolutionStore) UpdateProposal(id int, authorID uuid.UUID, code string) (SolutionProposal, error) {
+	query := `
+		UPDATE solution_proposals SET solution_code = $1
+		WHERE id = $2 AND author_id = $3 AND status = 'pending'
+		RETURNING ` + proposalColumns
+
+	proposal, err := scanProposal(s.db.QueryRow(query, code, id, authorID))
+	if err == sql.ErrNoRows {
+		return SolutionProposal{}, s.proposalConflict(id, authorID)
+	}
+	if err != nil {
+		return SolutionProposal{}, fmt.Errorf("error updating solution proposal: %v", err)
+	}
+	return proposal, nil
+}
+
+// DeleteProposal withdraws one of the author's pending proposals
+func (s *SolutionStore) DeleteProposal(id int, authorID uuid.UUID) error {
+	result, err := s.db.Exec(`DELETE FROM solution_proposals WHERE id = $1 AND author_id = $2 AND status = 'pending'`, id, authorID)
+	if err != nil {
+		return fmt.Errorf("error deleting solution proposal: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	} else if n == 0 {
+		return s.proposalConflict(id, authorID)
+	}
+	return nil
+}
+
+// proposalConflict explains why a pending proposal of the author could not be
+// changed: it does not exist or was already reviewed
+func (s *SolutionStore) proposalConflict(id int, authorID uuid.UUID) error {
+	if _, err := s.GetProposal(id, authorID); err != nil {
+		return err
+	}
+	return ErrProposalReviewed
+}
+
+// ApproveProposal makes a pending proposal the problem's reference solution in
+// its language on behalf of reviewerID, replacing the existing one in the
+// primary approach
+func (s *SolutionStore) ApproveProposal(id int, reviewerID uuid.UUID, note string) (SolutionProposal, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return SolutionProposal{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	proposal, err := lockPendingProposal(tx, id)
+	if err != nil {
+		return SolutionProposal{}, err
+	}
+
+	changes := map[string]AuditChange{}
+	auditField(changes, "proposal_id", nil, proposal.ID)
+
+	var solutionID int
+	existing, err := getSolutionByLanguage(tx, proposal.ProblemID, proposal.Language)
+	switch {
+	case err == nil:
+		solutionID = existing.ID
+		auditField(changes, "solution_code", existing.SolutionCode, proposal.SolutionCode)
+		auditField(changes, "author_id", existing.AuthorID, proposal.AuthorID)
+		_, err = tx.Exec(`
+			UPDATE problem_solutions
+			SET solution_code = $1, author_id = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $3
+		`, proposal.SolutionCode, proposal.AuthorID, solutionID)
+		if err != nil {
+			return SolutionProposal{}, fmt.Errorf("error updating solution: %v", err)
+		}
+	case errors.Is(err, ErrSolutionNotFound):
+		auditField(changes, "problem_id", nil, proposal.ProblemID)
+		auditField(changes, "language", nil, proposal.Language)
+		auditField(changes, "solution_code", nil, proposal.SolutionCode)
+		auditField(changes, "author_id", nil, proposal.AuthorID)
+		approachID, err := primaryApproachID(tx, proposal.ProblemID, reviewerID)
+		if err != nil {
+			return SolutionProposal{}, err
+		}
+		err = tx.QueryRow(`
+			INSERT INTO problem_solutions (problem_id, approach_id, language, solution_code, author_id)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, proposal.ProblemID, approachID, proposal.Language, proposal.SolutionCode, proposal.AuthorID).Scan(&solutionID)
+		if err != nil {
+			return SolutionProposal{}, fmt.Errorf("error creating solution: %v", err)
+		}
+	default:
+		return SolutionProposal{}, err
+	}
+
+	proposal, err = reviewProposal(tx, id, ProposalApproved, &solutionID, reviewerID, note)
+	if err != nil {
+		return SolutionProposal{}, err
+	}
+
+	if err := recordAudit(tx, reviewerID, AuditSolutionApprove, "solution", strconv.Itoa(solutionID), changes); err != nil {
+		return SolutionProposal{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return SolutionProposal{}, fmt.Errorf("error committing solution proposal: %v", err)
+	}
+	return proposal, nil
+}
+
+// RejectProposal closes a pending proposal on behalf of reviewerID, with a
+// note for its author
+func (s *SolutionStore) RejectProposal(id int, reviewerID uuid.UUID, note string) (SolutionProposal, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return SolutionProposal{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPendingProposal(tx, id); err != nil {
+		return SolutionProposal{}, err
+	}
+
+	proposal, err := reviewProposal(tx, id, ProposalRejected, nil, reviewerID, note)
+	if err != nil {
+		return SolutionProposal{}, err
+	}
+
+	changes := map[string]AuditChange{"status": {Old: ProposalPending, New: ProposalRejected}}
+	auditField(changes, "review_note", nil, note)
+	if err := recordAudit(tx, reviewerID, AuditSolutionReject, "solution_proposal", strconv.Itoa(id), changes); err != nil {
+		return SolutionProposal{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return SolutionProposal{}, fmt.Errorf("error committing solution proposal: %v", err)
+	}
+	return proposal, nil
+}
+
+func lockPendingProposal(tx *sql.Tx, id int) (SolutionProposal, error) {
+	proposal, err := scanProposal(tx.QueryRow(`SELECT `+proposalColumns+` FROM solution_proposals WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return SolutionProposal{}, ErrProposalNotFound
+		}
+		return SolutionProposal{}, fmt.Errorf("error fetching solution proposal: %v", err)
+	}
+	if proposal.Status != ProposalPending {
+		return SolutionProposal{}, ErrProposalReviewed
+	}
+	return proposal, nil
+}
+
+func reviewProposal(tx *sql.Tx, id int, status string, solutionID *int, reviewerID uuid.UUID, note string) (SolutionProposal, error) {
+	query := `
+		UPDATE solution_proposals
+		SET status = $1, solution_id = $2, reviewer_id = $3, review_note = $4, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING ` + proposalColumns
+
+	proposal, err := scanProposal(tx.QueryRow(query, status, solutionID, reviewerID, note, id))
+	if err != nil {
+		return SolutionProposal{}, fmt.Errorf("error reviewing solution proposal: %v", err)
+	}
+	return proposal, nil
+}
+
+// nullableUUID turns uuid.Nil into NULL for optional query filters
+func nullableUUID(id uuid.UUID) interface{} {
+	if id == uuid.Nil {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"go-leetcode/backend/internal/database"
+	"go-leetcode/backend/internal/testutils"
+)
+
+func TestApproveProposal(t *testing.T) {
+	testDB := database.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	userStore := NewUserStore(testDB.DB)
+	author := User{Username: "author", LeetcodeUsername: "leetcode_author"}
+	testutils.CheckErr(t, userStore.CreateUser(&author), "Failed to create author")
+	editor := User{Username: "editor", LeetcodeUsername: "leetcode_editor"}
+	testutils.CheckErr(t, userStore.CreateUser(&editor), "Failed to create editor")
+
+	store := NewSolutionStore(testDB.DB)
+	propose := func(code string) SolutionProposal {
+		proposal := SolutionProposal{ProblemID: 1, Language: "python", SolutionCode: code, AuthorID: author.ID}
+		testutils.CheckErr(t, store.CreateProposal(&proposal), "Failed to create proposal")
+		return proposal
+	}
+
+	first, err := store.ApproveProposal(propose("first").ID, editor.ID, "")
+	testutils.CheckErr(t, err, "Failed to approve first proposal")
+	if first.Status != ProposalApproved || first.SolutionID == nil || first.ReviewerID == nil || *first.ReviewerID != editor.ID {
+		t.Fatalf("expected the first proposal approved into a solution by the editor, got %+v", first)
+	}
+
+	// approving over the solution now in the language replaces it in place
+	second, err := store.ApproveProposal(propose("second").ID, editor.ID, "faster")
+	testutils.CheckErr(t, err, "Failed to approve second proposal")
+	if second.SolutionID == nil || *second.SolutionID != *first.SolutionID {
+		t.Fatalf("expected the second proposal to replace solution %d, got %v", *first.SolutionID, second.SolutionID)
+	}
+
+	solution, err := store.GetSolutionByID(*second.SolutionID)
+	testutils.CheckErr(t, err, "Failed to get solution")
+	if solution.SolutionCode != "second" || solution.AuthorID == nil || *solution.AuthorID != author.ID || solution.UpdatedAt == nil {
+		t.Errorf("expected the solution to hold the second proposal, got %+v", solution)
+	}
+
+	entries, err := NewAuditStore(testDB.DB).ListAuditEntries(AuditFilter{
+		EntityType: "solution",
+		EntityID:   strconv.Itoa(solution.ID),
+		Limit:      10,
+	})
+	testutils.CheckErr(t, err, "Failed to list audit entries")
+	if len(entries) == 0 || entries[0].Action != AuditSolutionApprove || entries[0].ActorID != editor.ID {
+		t.Fatalf("expected an approval entry by the editor, got %+v", entries)
+	}
+	if c := entries[0].Changes["solution_code"]; c.Old != "first" || c.New != "second" {
+		t.Errorf("expected the solution code change from first to second, got %v", c)
+	}
+
+	// a proposal is reviewed once
+	if _, err := store.ApproveProposal(second.ID, editor.ID, ""); !errors.Is(err, ErrProposalReviewed) {
+		t.Errorf("expected ErrProposalReviewed approving twice, got %v", err)
+	}
+	if _, err := store.RejectProposal(second.ID, editor.ID, ""); !errors.Is(err, ErrProposalReviewed) {
+		t.Errorf("expected ErrProposalReviewed rejecting an approved proposal, got %v", err)
+	}
+}
+
+func TestRejectProposal(t *testing.T) {
+	testDB := database.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	userStore := NewUserStore(testDB.DB)
+	author := User{Username: "author", LeetcodeUsername: "leetcode_author"}
+	testutils.CheckErr(t, userStore.CreateUser(&author), "Failed to create author")
+	editor := User{Username: "editor", LeetcodeUsername: "leetcode_editor"}
+	testutils.CheckErr(t, userStore.CreateUser(&editor), "Failed to create editor")
+
+	store := NewSolutionStore(testDB.DB)
+	proposal := SolutionProposal{ProblemID: 1, Language: "python", SolutionCode: "pass", AuthorID: author.ID}
+	testutils.CheckErr(t, store.CreateProposal(&proposal), "Failed to create proposal")
+
+	rejected, err := store.RejectProposal(proposal.ID, editor.ID, "does not pass the examples")
+	testutils.CheckErr(t, err, "Failed to reject proposal")
+	if rejected.Status != ProposalRejected || rejected.SolutionID != nil || rejected.ReviewNote != "does not pass the examples" {
+		t.Fatalf("expected a rejected proposal with the note, got %+v", rejected)
+	}
+
+	entries, err := NewAuditStore(testDB.DB).ListAuditEntries(AuditFilter{
+		EntityType: "solution_proposal",
+		EntityID:   strconv.Itoa(proposal.ID),
+		Limit:      10,
+	})
+	testutils.CheckErr(t, err, "Failed to list audit entries")
+	if len(entries) != 1 || entries[0].Action != AuditSolutionReject {
+		t.Fatalf("expected one rejection entry, got %+v", entries)
+	}
+
+	if _, err := store.RejectProposal(proposal.ID, editor.ID, ""); !errors.Is(err, ErrProposalReviewed) {
+		t.Errorf("expected ErrProposalReviewed rejecting twice, got %v", err)
+	}
+	if _, err := store.ApproveProposal(proposal.ID, editor.ID, ""); !errors.Is(err, ErrProposalReviewed) {
+		t.Errorf("expected ErrProposalReviewed approving a rejected proposal, got %v", err)
+	}
+	if _, err := store.UpdateProposal(proposal.ID, author.ID, "print()"); !errors.Is(err, ErrProposalReviewed) {
+		t.Errorf("expected ErrProposalReviewed editing a rejected proposal, got %v", err)
+	}
+}
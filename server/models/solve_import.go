@@ -0,0 +1,331 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+)
+
+const (
+	SolveRowImported  = "imported"
+	SolveRowDuplicate = "duplicate"
+	SolveRowInvalid   = "invalid"
+)
+
+// SolveRecord is one past solve read from an uploaded export file
+type SolveRecord struct {
+	Line        int
+	TitleSlug   string
+	SubmittedAt time.Time
+	Language    string
+	Runtime     string
+}
+
+type SolveRowResult struct {
+	Line         int    `json:"line"`
+	TitleSlug    string `json:"title_slug,omitempty"`
+	Status       string `json:"status"`
+	SubmissionID string `json:"submission_id,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+type SolveImportSummary struct {
+	Imported       int              `json:"imported"`
+	Duplicates     int              `json:"duplicates"`
+	Invalid        int              `json:"invalid"`
+	ReviewsCreated int              `json:"reviews_created"`
+	ReviewsUpdated int              `json:"reviews_updated"`
+	Rows           []SolveRowResult `json:"rows"`
+}
+
+// AddInvalidRows merges rows rejected before reaching the store, such as
+// parse errors, keeping the rows in file order
+func (s *SolveImportSummary) AddInvalidRows(rows []SolveRowResult) {
+	s.Invalid += len(rows)
+	s.Rows = append(s.Rows, rows...)
+	sort.SliceStable(s.Rows, func(i, j int) bool { return s.Rows[i].Line < s.Rows[j].Line })
+}
+
+// SolveImportStore imports past solves from an export file in one transaction,
+// creating or updating the review schedule of every touched problem at the end
+// instead of once per row
+type SolveImportStore struct {
+	db *sql.DB
+}
+
+func NewSolveImportStore(db *sql.DB) *SolveImportStore {
+	return &SolveImportStore{db: db}
+}
+
+// ImportedSubmissionID derives a stable submission ID for a solve without a
+// LeetCode submission ID, so importing the same file twice is a no-op
+func ImportedSubmissionID(userID uuid.UUID, titleSlug string, submittedAt time.Time) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d", userID, titleSlug, submittedAt.Unix())))
+	return "import-" + hex.EncodeToString(sum[:])[:16]
+}
+
+type slugSolve struct {
+	submissionID string
+	submittedAt  time.Time
+}
+
+// ImportSolves validates, dedupes and stores the records. Rows that fail
+// validation are reported, not fatal; any database error rolls back the whole import.
+func (s *SolveImportStore) ImportSolves(userID uuid.UUID, records []SolveRecord) (SolveImportSummary, error) {
+	summary := SolveImportSummary{Rows: make([]SolveRowResult, 0, len(records))}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return summary, fmt.Errorf("error starting import transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	slugSet := make(map[string]bool)
+	for _, record := range records {
+		slugSet[record.TitleSlug] = true
+	}
+	slugs := make([]string, 0, len(slugSet))
+	for slug := range slugSet {
+		slugs = append(slugs, slug)
+	}
+
+	titles, err := problemTitlesBySlug(tx, slugs)
+	if err != nil {
+		return summary, err
+	}
+
+	existing, err := solvesBySlug(tx, userID, slugs)
+	if err != nil {
+		return summary, err
+	}
+
+	seen := make(map[string]bool)
+	for slug, solves := range existing {
+		for _, solve := range solves {
+			seen[fmt.Sprintf("%s|%d", slug, solve.submittedAt.Unix())] = true
+		}
+	}
+
+	insertQuery := `
+		INSERT INTO submissions
+		(id, user_id, title, title_slug, submitted_at, created_at, language, runtime)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''))
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	imported := make(map[string][]slugSolve)
+	for _, record := range records {
+		result := SolveRowResult{Line: record.Line, TitleSlug: record.TitleSlug}
+
+		title, ok := titles[record.TitleSlug]
+		if !ok {
+			result.Status = SolveRowInvalid
+			result.Error = fmt.Sprintf("unknown problem slug %q", record.TitleSlug)
+			summary.Invalid++
+			summary.Rows = append(summary.Rows, result)
+			continue
+		}
+
+		submittedAt := record.SubmittedAt.UTC().Truncate(time.Second)
+		key := fmt.Sprintf("%s|%d", record.TitleSlug, submittedAt.Unix())
+		result.SubmissionID = ImportedSubmissionID(userID, record.TitleSlug, submittedAt)
+
+		if seen[key] {
+			result.Status = SolveRowDuplicate
+			summary.Duplicates++
+			summary.Rows = append(summary.Rows, result)
+			continue
+		}
+		seen[key] = true
+
+		res, err := tx.Exec(insertQuery, result.SubmissionID, userID, title, record.TitleSlug, submittedAt, time.Now().UTC(), record.Language, record.Runtime)
+		if err != nil {
+			return summary, fmt.Errorf("error importing line %d: %v", record.Line, err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return summary, fmt.Errorf("error getting rows affected: %v", err)
+		}
+		if rowsAffected == 0 {
+			result.Status = SolveRowDuplicate
+			summary.Duplicates++
+			summary.Rows = append(summary.Rows, result)
+			continue
+		}
+
+		result.Status = SolveRowImported
+		summary.Imported++
+		summary.Rows = append(summary.Rows, result)
+		imported[record.TitleSlug] = append(imported[record.TitleSlug], slugSolve{result.SubmissionID, submittedAt})
+	}
+
+	for slug, newSolves := range imported {
+		created, err := upsertReviewFromSolves(tx, userID, slug, existing[slug], newSolves)
+		if err != nil {
+			return summary, err
+		}
+		if created {
+			summary.ReviewsCreated++
+		} else {
+			summary.ReviewsUpdated++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return summary, fmt.Errorf("error committing import: %v", err)
+	}
+
+	return summary, nil
+}
+
+func problemTitlesBySlug(tx *sql.Tx, slugs []string) (map[string]string, error) {
+	rows, err := tx.Query(`SELECT title_slug, title FROM problems WHERE title_slug = ANY($1)`, pq.Array(slugs))
+	if err != nil {
+		return nil, fmt.Errorf("error validating problem slugs: %v", err)
+	}
+	defer rows.Close()
+
+	titles := make(map[string]string)
+	for rows.Next() {
+		var slug, title string
+		if err := rows.Scan(&slug, &title); err != nil {
+			return nil, fmt.Errorf("error scanning problem: %v", err)
+		}
+		titles[slug] = title
+	}
+
+	return titles, rows.Err()
+}
+
+func solvesBySlug(tx *sql.Tx, userID uuid.UUID, slugs []string) (map[string][]slugSolve, error) {
+	query := `
+		SELECT id, title_slug, submitted_at FROM submissions
+		WHERE user_id = $1 AND title_slug = ANY($2)
+	`
+
+	rows, err := tx.Query(query, userID, pq.Array(slugs))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching existing submissions: %v", err)
+	}
+	defer rows.Close()
+
+	solves := make(map[string][]slugSolve)
+	for rows.Next() {
+		var slug string
+		var solve slugSolve
+		if err := rows.Scan(&solve.submissionID, &slug, &solve.submittedAt); err != nil {
+			return nil, fmt.Errorf("error scanning submission: %v", err)
+		}
+		solves[slug] = append(solves[slug], solve)
+	}
+
+	return solves, rows.Err()
+}
+
+// upsertReviewFromSolves brings the problem's review schedule up to date with
+// newly imported solves. Without a schedule one is inferred from the full
+// history; otherwise solves newer than the last review are replayed as "Good".
+// It reports whether a schedule was created.
+func upsertReviewFromSolves(tx *sql.Tx, userID uuid.UUID, slug string, existing, newSolves []slugSolve) (bool, error) {
+	all := append(append([]slugSolve{}, existing...), newSolves...)
+	sort.Slice(all, func(i, j int) bool { return all[i].submittedAt.Before(all[j].submittedAt) })
+	latest := all[len(all)-1]
+
+	query := `
+		SELECT r.id, r.next_review_at, r.stability, r.difficulty, r.elapsed_days,
+		       r.scheduled_days, r.reps, r.lapses, r.state, r.last_review
+		FROM review_schedules r
+		JOIN submissions s ON r.submission_id = s.id
+		WHERE s.user_id = $1 AND s.title_slug = $2
+		ORDER BY s.submitted_at DESC
+		LIMIT 1
+	`
+
+	var review ReviewSchedule
+	var lastReview sql.NullTime
+	err := tx.QueryRow(query, userID, slug).Scan(
+		&review.ID,
+		&review.NextReviewAt,
+		&review.Stability,
+		&review.Difficulty,
+		&review.ElapsedDays,
+		&review.ScheduledDays,
+		&review.Reps,
+		&review.Lapses,
+		&review.State,
+		&lastReview,
+	)
+
+	if err == sql.ErrNoRows {
+		solvedAt := make([]time.Time, 0, len(all))
+		for _, solve := range all {
+			solvedAt = append(solvedAt, solve.submittedAt)
+		}
+
+		review = ReviewSchedule{SubmissionID: latest.submissionID, CreatedAt: time.Now().UTC()}
+		ConvertFSRSToReviewSchedule(InferCardFromHistory(solvedAt), &review)
+
+		_, err := tx.Exec(`
+			INSERT INTO review_schedules
+			(submission_id, next_review_at, created_at, stability, difficulty,
+			 elapsed_days, scheduled_days, reps, lapses, state, last_review)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`,
+			review.SubmissionID, review.NextReviewAt, review.CreatedAt, review.Stability, review.Difficulty,
+			review.ElapsedDays, review.ScheduledDays, review.Reps, review.Lapses, review.State, review.LastReview,
+		)
+		if err != nil {
+			return false, fmt.Errorf("error creating review for %s: %v", slug, err)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error fetching review for %s: %v", slug, err)
+	}
+
+	if lastReview.Valid {
+		review.LastReview = lastReview.Time
+	}
+
+	fsrsScheduler := fsrs.NewFSRS(fsrs.DefaultParam())
+	card := ConvertReviewScheduleToFSRS(&review)
+	sort.Slice(newSolves, func(i, j int) bool { return newSolves[i].submittedAt.Before(newSolves[j].submittedAt) })
+
+	lastDay := review.LastReview.UTC().Truncate(24 * time.Hour)
+	for _, solve := range newSolves {
+		day := solve.submittedAt.Truncate(24 * time.Hour)
+		if !day.After(lastDay) {
+			continue
+		}
+		lastDay = day
+		card = fsrsScheduler.Next(card, solve.submittedAt, fsrs.Good).Card
+	}
+
+	ConvertFSRSToReviewSchedule(card, &review)
+	review.SubmissionID = latest.submissionID
+
+	_, err = tx.Exec(`
+		UPDATE review_schedules
+		SET submission_id = $1, next_review_at = $2, stability = $3, difficulty = $4,
+		    elapsed_days = $5, scheduled_days = $6, reps = $7,
+		    lapses = $8, state = $9, last_review = $10
+		WHERE id = $11
+	`,
+		review.SubmissionID, review.NextReviewAt, review.Stability, review.Difficulty,
+		review.ElapsedDays, review.ScheduledDays, review.Reps, review.Lapses, review.State, review.LastReview,
+		review.ID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error updating review for %s: %v", slug, err)
+	}
+
+	return false, nil
+}
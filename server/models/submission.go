@@ -116,4 +116,57 @@ func (s *SubmissionStore) CheckSubmissionExists(submissionID string) (bool, erro
 	}
 
 	return exists, nil
-}
\ No newline at end of file
+}
+// CreateSubmissionIfNotExists inserts the submission unless one with the same
+// ID is already stored, reporting whether a row was written
+func (s *SubmissionStore) CreateSubmissionIfNotExists(sub Submission) (bool, error) {
+	query := `
+		INSERT INTO submissions
+		(id, user_id, title, title_slug, submitted_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := s.db.Exec(query, sub.ID, sub.UserID, sub.Title, sub.TitleSlug, sub.SubmittedAt, sub.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("error creating submission: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetSubmissionHistoryBySlug groups a user's submissions by problem, each list
+// ordered from oldest to newest
+func (s *SubmissionStore) GetSubmissionHistoryBySlug(userID uuid.UUID) (map[string][]Submission, error) {
+	query := `
+		SELECT id, user_id, title, title_slug, submitted_at, created_at
+		FROM submissions WHERE user_id = $1
+		ORDER BY title_slug, submitted_at
+	`
+
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching submission history: %v", err)
+	}
+	defer rows.Close()
+
+	history := make(map[string][]Submission)
+	for rows.Next() {
+		var sub Submission
+		if err := rows.Scan(&sub.ID, &sub.UserID, &sub.Title, &sub.TitleSlug, &sub.SubmittedAt, &sub.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning submission: %v", err)
+		}
+		history[sub.TitleSlug] = append(history[sub.TitleSlug], sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating submissions: %v", err)
+	}
+
+	return history, nil
+}
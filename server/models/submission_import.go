@@ -0,0 +1,238 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// ErrImportInProgress is returned when creating an import for a user who
+// already has a pending or running one
+var ErrImportInProgress = errors.New("an import is already in progress")
+
+const submissionImportColumns = `id, user_id, status, next_offset, last_key, fetched_count,
+	imported_count, seeded_reviews, error, created_at, finished_at`
+
+// ImportLease is how long a running import stays claimed without saving
+// progress before another instance may take it over
+const ImportLease = 10 * time.Minute
+
+// SubmissionImport tracks one run of the full submission history import
+type SubmissionImport struct {
+	ID            int        `json:"id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	Status        string     `json:"status"`
+	NextOffset    int        `json:"next_offset"`
+	LastKey       string     `json:"-"`
+	FetchedCount  int        `json:"fetched_count"`
+	ImportedCount int        `json:"imported_count"`
+	SeededReviews int        `json:"seeded_reviews"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+type SubmissionImportStore struct {
+	db *sql.DB
+}
+
+func NewSubmissionImportStore(db *sql.DB) *SubmissionImportStore {
+	return &SubmissionImportStore{db: db}
+}
+
+// CreateImport queues an import, failing with ErrImportInProgress when the
+// user already has an unfinished one
+func (s *SubmissionImportStore) CreateImport(imp *SubmissionImport) error {
+	query := `
+		INSERT INTO submission_imports (user_id, status)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`
+
+	if imp.Status == "" {
+		imp.Status = ImportStatusPending
+	}
+
+	err := s.db.QueryRow(query, imp.UserID, imp.Status).Scan(&imp.ID, &imp.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_submission_imports_active_user" {
+		return ErrImportInProgress
+	}
+	if err != nil {
+		return fmt.Errorf("error creating submission import: %v", err)
+	}
+
+	return nil
+}
+
+// ClaimImport marks a pending import, or a running one whose lease expired,
+// as running for the caller. The bool result is false when the import is
+// finished or already claimed.
+func (s *SubmissionImportStore) ClaimImport(id int) (SubmissionImport, bool, error) {
+	query := `
+		UPDATE submission_imports
+		SET status = 'running', heartbeat_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND (
+			status = 'pending' OR
+			(status = 'running' AND (heartbeat_at IS NULL OR heartbeat_at < CURRENT_TIMESTAMP - make_interval(secs => $2)))
+		)
+		RETURNING ` + submissionImportColumns
+
+	imp, err := scanImportRow(s.db.QueryRow(query, id, ImportLease.Seconds()))
+	if err == sql.ErrNoRows {
+		return SubmissionImport{}, false, nil
+	}
+	if err != nil {
+		return SubmissionImport{}, false, err
+	}
+	return imp, true, nil
+}
+
+// UpdateImport saves the progress of a run, renewing its claim
+func (s *SubmissionImportStore) UpdateImport(imp *SubmissionImport) error {
+	query := `
+		UPDATE submission_imports
+		SET status = $1, next_offset = $2, last_key = $3, fetched_count = $4,
+			imported_count = $5, seeded_reviews = $6, error = $7, finished_at = $8,
+			heartbeat_at = CURRENT_TIMESTAMP
+		WHERE id = $9
+	`
+
+	var lastKey, errMsg sql.NullString
+	if imp.LastKey != "" {
+		lastKey = sql.NullString{String: imp.LastKey, Valid: true}
+	}
+	if imp.Error != "" {
+		errMsg = sql.NullString{String: imp.Error, Valid: true}
+	}
+
+	result, err := s.db.Exec(
+		query,
+		imp.Status,
+		imp.NextOffset,
+		lastKey,
+		imp.FetchedCount,
+		imp.ImportedCount,
+		imp.SeededReviews,
+		errMsg,
+		imp.FinishedAt,
+		imp.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating submission import: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking update result: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("submission import with ID %d not found", imp.ID)
+	}
+
+	return nil
+}
+
+func (s *SubmissionImportStore) GetImportByID(id int) (SubmissionImport, error) {
+	query := `SELECT ` + submissionImportColumns + ` FROM submission_imports WHERE id = $1`
+
+	return s.scanImport(s.db.QueryRow(query, id), fmt.Sprintf("submission import with ID %d not found", id))
+}
+
+// GetActiveImport returns the user's pending or running import, if any
+func (s *SubmissionImportStore) GetActiveImport(userID uuid.UUID) (SubmissionImport, error) {
+	query := `
+		SELECT ` + submissionImportColumns + `
+		FROM submission_imports
+		WHERE user_id = $1 AND status IN ('pending', 'running')
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	return s.scanImport(s.db.QueryRow(query, userID), fmt.Sprintf("no active import for user %s", userID))
+}
+
+func (s *SubmissionImportStore) scanImport(row *sql.Row, notFound string) (SubmissionImport, error) {
+	imp, err := scanImportRow(row)
+	if err == sql.ErrNoRows {
+		return SubmissionImport{}, fmt.Errorf("%s", notFound)
+	}
+	return imp, err
+}
+
+func scanImportRow(row rowScanner) (SubmissionImport, error) {
+	var imp SubmissionImport
+	var lastKey, errMsg sql.NullString
+	var finishedAt sql.NullTime
+
+	err := row.Scan(
+		&imp.ID,
+		&imp.UserID,
+		&imp.Status,
+		&imp.NextOffset,
+		&lastKey,
+		&imp.FetchedCount,
+		&imp.ImportedCount,
+		&imp.SeededReviews,
+		&errMsg,
+		&imp.CreatedAt,
+		&finishedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return SubmissionImport{}, err
+		}
+		return SubmissionImport{}, fmt.Errorf("error fetching submission import: %v", err)
+	}
+
+	imp.LastKey = lastKey.String
+	imp.Error = errMsg.String
+	if finishedAt.Valid {
+		imp.FinishedAt = &finishedAt.Time
+	}
+
+	return imp, nil
+}
+
+// ListUnfinishedImports returns imports left pending or running, e.g. by a
+// server restart, so they can be resumed from their saved offset
+func (s *SubmissionImportStore) ListUnfinishedImports() ([]SubmissionImport, error) {
+	query := `
+		SELECT ` + submissionImportColumns + `
+		FROM submission_imports
+		WHERE status IN ('pending', 'running')
+		ORDER BY created_at
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error listing unfinished imports: %v", err)
+	}
+	defer rows.Close()
+
+	var imports []SubmissionImport
+	for rows.Next() {
+		imp, err := scanImportRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, imp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing unfinished imports: %v", err)
+	}
+
+	return imports, nil
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+)
+
+// ErrSubmissionTaken is returned when a pushed submission ID is already stored
+// for another user
+var ErrSubmissionTaken = errors.New("submission belongs to another user")
+
+// IngestSubmission stores a pushed submission and reviews its problem with
+// rating in one transaction, so a failed review leaves no submission behind
+// to be skipped as a duplicate on retry. A submission the user already pushed
+// is left alone and reported by the bool result.
+func (s *ReviewScheduleStore) IngestSubmission(sub *Submission, rating fsrs.Rating) (ReviewSchedule, bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return ReviewSchedule{}, false, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// concurrent pushes of the same ID wait here for the first to commit
+	err = tx.QueryRow(`
+		INSERT INTO submissions (id, user_id, title, title_slug, submitted_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO NOTHING
+		RETURNING id
+	`, sub.ID, sub.UserID, sub.Title, sub.TitleSlug, sub.SubmittedAt, sub.CreatedAt).Scan(&sub.ID)
+	if err == sql.ErrNoRows {
+		var owner string
+		if err := tx.QueryRow(`SELECT user_id FROM submissions WHERE id = $1`, sub.ID).Scan(&owner); err != nil {
+			return ReviewSchedule{}, false, fmt.Errorf("error checking submission: %v", err)
+		}
+		if owner != sub.UserID.String() {
+			return ReviewSchedule{}, false, ErrSubmissionTaken
+		}
+		return ReviewSchedule{}, true, nil
+	}
+	if err != nil {
+		return ReviewSchedule{}, false, fmt.Errorf("error creating submission: %v", err)
+	}
+
+	review, err := updateOrCreateReviewForSubmission(tx, sub, rating)
+	if err != nil {
+		return ReviewSchedule{}, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ReviewSchedule{}, false, fmt.Errorf("error committing submission: %v", err)
+	}
+	return review, false, nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-leetcode/backend/internal/database"
+	"go-leetcode/backend/internal/testutils"
+
+	"github.com/open-spaced-repetition/go-fsrs/v3"
+)
+
+func TestIngestSubmission(t *testing.T) {
+	testDB := database.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	userStore := NewUserStore(testDB.DB)
+	owner := User{Username: "testuser", LeetcodeUsername: "leetcode_testuser"}
+	testutils.CheckErr(t, userStore.CreateUser(&owner), "Failed to create test user")
+	other := User{Username: "otheruser", LeetcodeUsername: "leetcode_otheruser"}
+	testutils.CheckErr(t, userStore.CreateUser(&other), "Failed to create other user")
+
+	store := NewReviewScheduleStore(testDB.DB)
+	push := func(user User, id string) (ReviewSchedule, bool, error) {
+		return store.IngestSubmission(&Submission{
+			ID:          id,
+			UserID:      user.ID,
+			Title:       "Two Sum",
+			TitleSlug:   "two-sum",
+			SubmittedAt: time.Now().UTC(),
+			CreatedAt:   time.Now().UTC(),
+		}, fsrs.Good)
+	}
+
+	review, duplicate, err := push(owner, "leetcode-1")
+	testutils.CheckErr(t, err, "Failed to ingest submission")
+	if duplicate || review.ID == 0 || review.SubmissionID != "leetcode-1" {
+		t.Fatalf("expected a new review for leetcode-1, got %+v (duplicate %v)", review, duplicate)
+	}
+
+	_, duplicate, err = push(owner, "leetcode-1")
+	testutils.CheckErr(t, err, "Failed to ingest duplicate submission")
+	if !duplicate {
+		t.Error("expected the second push of leetcode-1 to be a duplicate")
+	}
+
+	if _, _, err := push(other, "leetcode-1"); !errors.Is(err, ErrSubmissionTaken) {
+		t.Errorf("expected ErrSubmissionTaken for another user's submission, got %v", err)
+	}
+
+	updated, duplicate, err := push(owner, "leetcode-2")
+	testutils.CheckErr(t, err, "Failed to ingest second submission")
+	if duplicate || updated.ID != review.ID || updated.Reps != review.Reps+1 {
+		t.Errorf("expected leetcode-2 to advance review %d, got %+v", review.ID, updated)
+	}
+}
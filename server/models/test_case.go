@@ -0,0 +1,251 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-leetcode/backend/internal/examples"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrTestCaseNotFound = errors.New("test case not found")
+	// ErrTooManyTestCases is returned when adding a test case to a problem
+	// that already has MaxTestCases of the user's
+	ErrTooManyTestCases = errors.New("too many test cases for this problem")
+)
+
+// MaxTestCases bounds the test cases a user keeps per problem
+const MaxTestCases = 50
+
+// TestCase is a user's own input for a problem, such as an empty array or an
+// overflow case, run alongside the problem's examples. Inputs are keyed by
+// parameter name like the examples' inputs. Without Expected the case only
+// checks that the solution runs.
+type TestCase struct {
+	ID        int             `json:"id"`
+	UserID    uuid.UUID       `json:"user_id"`
+	ProblemID int             `json:"problem_id"`
+	TitleSlug string          `json:"title_slug"`
+	Name      string          `json:"name"`
+	Inputs    TestCaseInputs  `json:"inputs"`
+	Expected  json.RawMessage `json:"expected,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Args orders the inputs of the case as the arguments of the solution,
+// reporting false when they do not match params, e.g. after the problem's
+// parameters were renamed
+func (c TestCase) Args(params []examples.Param) ([]json.RawMessage, bool) {
+	if len(params) == 0 || len(c.Inputs) != len(params) {
+		return nil, false
+	}
+	args := make([]json.RawMessage, 0, len(params))
+	for _, param := range params {
+		value, ok := c.Inputs[param.Name]
+		if !ok {
+			return nil, false
+		}
+		args = append(args, value)
+	}
+	return args, true
+}
+
+type TestCaseStore struct {
+	db *sql.DB
+}
+
+func NewTestCaseStore(db *sql.DB) *TestCaseStore {
+	return &TestCaseStore{db: db}
+}
+
+const testCaseColumns = `t.id, t.user_id, t.problem_id, p.title_slug, t."name", t.inputs, t.expected, t.created_at, t.updated_at`
+
+func scanTestCase(row rowScanner) (TestCase, error) {
+	var testCase TestCase
+	var expected []byte
+	err := row.Scan(&testCase.ID, &testCase.UserID, &testCase.ProblemID, &testCase.TitleSlug, &testCase.Name,
+		&testCase.Inputs, &expected, &testCase.CreatedAt, &testCase.UpdatedAt)
+	if len(expected) > 0 {
+		testCase.Expected = json.RawMessage(expected)
+	}
+	return testCase, err
+}
+
+// nullableJSON stores a missing JSON value as NULL
+func nullableJSON(value json.RawMessage) interface{} {
+	if len(value) == 0 || string(value) == "null" {
+		return nil
+	}
+	return string(value)
+}
+
+// ListTestCases returns the user's test cases of a problem, oldest first
+func (s *TestCaseStore) ListTestCases(userID uuid.UUID, problemID int) ([]TestCase, error) {
+	cases, err := testCasesByProblem(s.db, userID, []int{problemID})
+	if err != nil {
+		return nil, err
+	}
+	if cases[problemID] == nil {
+		return []TestCase{}, nil
+	}
+	return cases[problemID], nil
+}
+
+func (s *TestCaseStore) GetTestCase(userID uuid.UUID, id int) (TestCase, error) {
+	query := `
+		SELECT ` + testCaseColumns + `
+		FROM problem_test_cases t
+		JOIN problems p ON p.id = t.problem_id
+		WHERE t.id = $1 AND t.user_id = $2
+	`
+
+	testCase, err := scanTestCase(s.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return TestCase{}, ErrTestCaseNotFound
+		}
+		return TestCase{}, fmt.Errorf("error fetching test case: %v", err)
+	}
+	return testCase, nil
+}
+
+// CreateTestCase adds a test case, failing with ErrTooManyTestCases when the
+// user has MaxTestCases for the problem already
+func (s *TestCaseStore) CreateTestCase(testCase *TestCase) error {
+	query := `
+		WITH inserted AS (
+			INSERT INTO problem_test_cases (user_id, problem_id, "name", inputs, expected)
+			SELECT $1, $2, $3, $4, $5::jsonb
+			WHERE (SELECT COUNT(*) FROM problem_test_cases WHERE user_id = $1 AND problem_id = $2) < $6
+			RETURNING *
+		)
+		SELECT ` + testCaseColumns + `
+		FROM inserted t
+		JOIN problems p ON p.id = t.problem_id
+	`
+
+	created, err := scanTestCase(s.db.QueryRow(query, testCase.UserID, testCase.ProblemID, testCase.Name,
+		testCase.Inputs, nullableJSON(testCase.Expected), MaxTestCases))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrTooManyTestCases
+		}
+		return fmt.Errorf("error creating test case: %v", err)
+	}
+	*testCase = created
+	return nil
+}
+
+// UpdateTestCase replaces the name, inputs and expected value of one of the
+// user's test cases
+func (s *TestCaseStore) UpdateTestCase(userID uuid.UUID, id int, name string, inputs TestCaseInputs, expected json.RawMessage) (TestCase, error) {
+	query := `
+		WITH updated AS (
+			UPDATE problem_test_cases
+			SET "name" = $1, inputs = $2, expected = $3::jsonb, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $4 AND user_id = $5
+			RETURNING *
+		)
+		SELECT ` + testCaseColumns + `
+		FROM updated t
+		JOIN problems p ON p.id = t.problem_id
+	`
+
+	testCase, err := scanTestCase(s.db.QueryRow(query, name, inputs, nullableJSON(expected), id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return TestCase{}, ErrTestCaseNotFound
+		}
+		return TestCase{}, fmt.Errorf("error updating test case: %v", err)
+	}
+	return testCase, nil
+}
+
+func (s *TestCaseStore) DeleteTestCase(userID uuid.UUID, id int) error {
+	result, err := s.db.Exec(`DELETE FROM problem_test_cases WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting test case: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	} else if n == 0 {
+		return ErrTestCaseNotFound
+	}
+	return nil
+}
+
+// AttachTestCases sets the user's test cases on a problem
+func (s *TestCaseStore) AttachTestCases(userID uuid.UUID, problem *Problem) error {
+	cases, err := s.ListTestCases(userID, problem.ID)
+	if err != nil {
+		return err
+	}
+	problem.TestCases = cases
+	return nil
+}
+
+// testCasesByProblem loads the user's test cases of the problems, oldest
+// first, keyed by problem ID
+func testCasesByProblem(db *sql.DB, userID uuid.UUID, problemIDs []int) (map[int][]TestCase, error) {
+	cases := map[int][]TestCase{}
+	if len(problemIDs) == 0 {
+		return cases, nil
+	}
+
+	query := `
+		SELECT ` + testCaseColumns + `
+		FROM problem_test_cases t
+		JOIN problems p ON p.id = t.problem_id
+		WHERE t.user_id = $1 AND t.problem_id = ANY($2)
+		ORDER BY t.id
+	`
+
+	rows, err := db.Query(query, userID, pq.Array(problemIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching test cases: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		testCase, err := scanTestCase(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning test case: %v", err)
+		}
+		cases[testCase.ProblemID] = append(cases[testCase.ProblemID], testCase)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error fetching test cases: %v", err)
+	}
+	return cases, nil
+}
+
+// TestCasePrompt asks for the output of one of the user's test cases during a
+// flashcard review, leaving out the expected value
+type TestCasePrompt struct {
+	TestCaseID int            `json:"test_case_id"`
+	Name       string         `json:"name"`
+	Inputs     TestCaseInputs `json:"inputs"`
+}
+
+// PromptTestCase picks the test case a flashcard asks about, rotating through
+// the cases with an expected value as the card is reviewed. It returns nil
+// when none has one.
+func PromptTestCase(cases []TestCase, reps uint64) *TestCasePrompt {
+	var answerable []TestCase
+	for _, testCase := range cases {
+		if len(testCase.Expected) > 0 {
+			answerable = append(answerable, testCase)
+		}
+	}
+	if len(answerable) == 0 {
+		return nil
+	}
+	testCase := answerable[reps%uint64(len(answerable))]
+	return &TestCasePrompt{TestCaseID: testCase.ID, Name: testCase.Name, Inputs: testCase.Inputs}
+}
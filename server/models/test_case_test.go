@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"go-leetcode/backend/internal/examples"
+	"testing"
+)
+
+func TestTestCaseArgs(t *testing.T) {
+	params := []examples.Param{{Name: "nums", Type: "integer[]"}, {Name: "target", Type: "integer"}}
+	testCase := TestCase{Inputs: TestCaseInputs{
+		"target": json.RawMessage(`0`),
+		"nums":   json.RawMessage(`[]`),
+	}}
+
+	args, ok := testCase.Args(params)
+	if !ok || len(args) != 2 || string(args[0]) != `[]` || string(args[1]) != `0` {
+		t.Errorf("args = %s, %v, want [[] 0], true", args, ok)
+	}
+
+	renamed := []examples.Param{{Name: "arr"}, {Name: "target"}}
+	if _, ok := testCase.Args(renamed); ok {
+		t.Error("matched inputs against renamed parameters")
+	}
+	if _, ok := testCase.Args(params[:1]); ok {
+		t.Error("matched inputs against fewer parameters")
+	}
+	if _, ok := testCase.Args(nil); ok {
+		t.Error("matched inputs without parameters")
+	}
+}
+
+func TestPromptTestCase(t *testing.T) {
+	cases := []TestCase{
+		{ID: 1, Name: "no expected"},
+		{ID: 2, Name: "empty", Expected: json.RawMessage(`[]`)},
+		{ID: 3, Name: "overflow", Expected: json.RawMessage(`[0,1]`)},
+	}
+
+	for reps, want := range []int{2, 3, 2} {
+		prompt := PromptTestCase(cases, uint64(reps))
+		if prompt == nil || prompt.TestCaseID != want {
+			t.Errorf("reps %d: prompt = %+v, want test case %d", reps, prompt, want)
+		}
+	}
+
+	if prompt := PromptTestCase(cases[:1], 0); prompt != nil {
+		t.Errorf("prompt = %+v, want nil without an expected value", prompt)
+	}
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type TopicProgress struct {
+	Solved       int      `json:"solved"`
+	Due          int      `json:"due"`
+	AvgStability *float64 `json:"avg_stability"`
+}
+
+type Topic struct {
+	Slug         string         `json:"slug"`
+	Name         string         `json:"name"`
+	Total        int            `json:"total"`
+	Easy         int            `json:"easy"`
+	Medium       int            `json:"medium"`
+	Hard         int            `json:"hard"`
+	UserProgress *TopicProgress `json:"user_progress,omitempty"`
+}
+
+type TopicStore struct {
+	db *sql.DB
+}
+
+func NewTopicStore(db *sql.DB) *TopicStore {
+	return &TopicStore{db: db}
+}
+
+// ListTopics returns every topic in problems_topic with its problem counts,
+// most common topics first. Names come from the problems' topic_tags JSON
+// since problems_topic only stores slugs.
+func (s *TopicStore) ListTopics() ([]Topic, error) {
+	query := `
+		SELECT pt.topic_slug,
+		       COALESCE(MAX(tag->>'name'), pt.topic_slug),
+		       COUNT(DISTINCT p.id),
+		       COUNT(DISTINCT p.id) FILTER (WHERE LOWER(p.difficulty) = 'easy'),
+		       COUNT(DISTINCT p.id) FILTER (WHERE LOWER(p.difficulty) = 'medium'),
+		       COUNT(DISTINCT p.id) FILTER (WHERE LOWER(p.difficulty) = 'hard')
+		FROM problems_topic pt
+		JOIN problems p ON p.id = pt.problem_id
+		LEFT JOIN LATERAL jsonb_array_elements(COALESCE(p.topic_tags, '[]'::jsonb)) tag
+		       ON tag->>'slug' = pt.topic_slug
+		WHERE ` + listedProblem + `
+		GROUP BY pt.topic_slug
+		ORDER BY COUNT(DISTINCT p.id) DESC, pt.topic_slug
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error listing topics: %v", err)
+	}
+	defer rows.Close()
+
+	topics := []Topic{}
+	for rows.Next() {
+		var topic Topic
+		if err := rows.Scan(&topic.Slug, &topic.Name, &topic.Total, &topic.Easy, &topic.Medium, &topic.Hard); err != nil {
+			return nil, fmt.Errorf("error scanning topic: %v", err)
+		}
+		topics = append(topics, topic)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating topics: %v", err)
+	}
+
+	return topics, nil
+}
+
+// GetUserTopicProgress returns, per topic slug, how many problems the user
+// solved, how many of their reviews are due and the average FSRS stability
+// of those reviews. Topics the user never touched are omitted.
+func (s *TopicStore) GetUserTopicProgress(userID uuid.UUID) (map[string]TopicProgress, error) {
+	query := `
+		WITH solved AS (
+			SELECT DISTINCT title_slug FROM submissions WHERE user_id = $1
+		), user_reviews AS (
+			SELECT DISTINCT ON (s.title_slug) s.title_slug, r.next_review_at, r.stability
+			FROM review_schedules r
+			JOIN submissions s ON r.submission_id = s.id
+			WHERE s.user_id = $1
+			ORDER BY s.title_slug, s.submitted_at DESC
+		)
+		SELECT pt.topic_slug,
+		       COUNT(DISTINCT p.id) FILTER (WHERE sv.title_slug IS NOT NULL),
+		       COUNT(DISTINCT p.id) FILTER (WHERE ur.next_review_at <= NOW()),
+		       AVG(ur.stability)
+		FROM problems_topic pt
+		JOIN problems p ON p.id = pt.problem_id
+		JOIN solved sv ON sv.title_slug = p.title_slug
+		LEFT JOIN user_reviews ur ON ur.title_slug = p.title_slug
+		WHERE ` + listedProblem + `
+		GROUP BY pt.topic_slug
+	`
+
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching topic progress: %v", err)
+	}
+	defer rows.Close()
+
+	progress := make(map[string]TopicProgress)
+	for rows.Next() {
+		var slug string
+		var p TopicProgress
+		var avgStability sql.NullFloat64
+		if err := rows.Scan(&slug, &p.Solved, &p.Due, &avgStability); err != nil {
+			return nil, fmt.Errorf("error scanning topic progress: %v", err)
+		}
+		if avgStability.Valid {
+			p.AvgStability = &avgStability.Float64
+		}
+		progress[slug] = p
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating topic progress: %v", err)
+	}
+
+	return progress, nil
+}
@@ -13,6 +13,7 @@ type User struct {
 	Username         string `json:"username"`
 	Email            string
 	LeetcodeUsername string `json:"leetcode_username"`
+	LeetcodeRegion   string `json:"leetcode_region"`
 	CreatedAt        time.Time
 }
 
@@ -54,11 +55,16 @@ func (s *UserStore) CreateUserByAuth(user *User) error {
 
 	query := `
         INSERT INTO users
-        (id, email, username, leetcode_username, created_at)
-        VALUES ($1, $2, $3, $4, $5)
+        (id, email, username, leetcode_username, leetcode_region, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
         ON CONFLICT (id) DO NOTHING
     `
-	result, err := s.db.Exec(query, user.ID, user.Email, user.Username, user.LeetcodeUsername, user.CreatedAt)
+
+	if user.LeetcodeRegion == "" {
+		user.LeetcodeRegion = "com"
+	}
+
+	result, err := s.db.Exec(query, user.ID, user.Email, user.Username, user.LeetcodeUsername, user.LeetcodeRegion, user.CreatedAt)
 	if err != nil {
 		fmt.Printf("ERROR CreateUserByAuth: Database error: %v\n", err)
 		return fmt.Errorf("error creating user from auth: %w", err)
@@ -79,12 +85,12 @@ func (s *UserStore) GetUserByID(id uuid.UUID) (User, error) {
 	var user User
 
 	query := `
-		SELECT id, username, leetcode_username, created_at
+		SELECT id, username, leetcode_username, leetcode_region, created_at
 		FROM users WHERE ID = $1
 	`
 
 	err := s.db.QueryRow(query, id).Scan(
-		&user.ID, &user.Username, &user.LeetcodeUsername, &user.CreatedAt,
+		&user.ID, &user.Username, &user.LeetcodeUsername, &user.LeetcodeRegion, &user.CreatedAt,
 	)
 
 	if err != nil {
@@ -102,12 +108,12 @@ func (s *UserStore) GetUserByUsername(username string) (User, error) {
 	var user User
 
 	query := `
-		SELECT id, username, leetcode_username, created_at
+		SELECT id, username, leetcode_username, leetcode_region, created_at
 		FROM users WHERE username = $1
 	`
 
 	err := s.db.QueryRow(query, username).Scan(
-		&user.ID, &user.Username, &user.LeetcodeUsername, &user.CreatedAt,
+		&user.ID, &user.Username, &user.LeetcodeUsername, &user.LeetcodeRegion, &user.CreatedAt,
 	)
 
 	if err != nil {
@@ -205,7 +211,7 @@ func (s *UserStore) GetUserByLeetcodeUsername(leetcodeUsername string) (User, er
 	var user User
 
 	query := `
-        SELECT id, username, leetcode_username, created_at
+        SELECT id, username, leetcode_username, leetcode_region, created_at
         FROM users WHERE leetcode_username = $1
     `
 
@@ -213,6 +219,7 @@ func (s *UserStore) GetUserByLeetcodeUsername(leetcodeUsername string) (User, er
 		&user.ID,
 		&user.Username,
 		&user.LeetcodeUsername,
+		&user.LeetcodeRegion,
 		&user.CreatedAt,
 	)
 
@@ -225,3 +232,94 @@ func (s *UserStore) GetUserByLeetcodeUsername(leetcodeUsername string) (User, er
 
 	return user, nil
 }
+
+
+func (s *UserStore) UpdateLeetcodeRegion(id uuid.UUID, region string) error {
+	query := `UPDATE users SET leetcode_region = $1 WHERE id = $2`
+
+	result, err := s.db.Exec(query, region, id)
+	if err != nil {
+		return fmt.Errorf("error updating leetcode region: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking update result: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user with ID %s not found", id)
+	}
+
+	return nil
+}
+
+// ListUsersForSync returns every user with a linked LeetCode account,
+// used by the background sync worker
+func (s *UserStore) ListUsersForSync() ([]User, error) {
+	query := `
+		SELECT id, username, leetcode_username, leetcode_region, created_at
+		FROM users WHERE leetcode_username <> ''
+		ORDER BY created_at
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error listing users for sync: %v", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Username, &user.LeetcodeUsername, &user.LeetcodeRegion, &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning user: %v", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %v", err)
+	}
+
+	return users, nil
+}
+
+func (s *UserStore) SetDailyChallengeEnrollment(id uuid.UUID, enrolled bool) error {
+	// opting in again starts over from the current day
+	query := `
+		UPDATE users
+		SET daily_challenge_enrolled = $1,
+			daily_challenge_enrolled_at = CASE WHEN $1::boolean THEN COALESCE(daily_challenge_enrolled_at, CURRENT_TIMESTAMP) END
+		WHERE id = $2
+	`
+
+	result, err := s.db.Exec(query, enrolled, id)
+	if err != nil {
+		return fmt.Errorf("error updating daily challenge enrollment: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking update result: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user with ID %s not found", id)
+	}
+
+	return nil
+}
+
+func (s *UserStore) IsDailyChallengeEnrolled(id uuid.UUID) (bool, error) {
+	query := `SELECT daily_challenge_enrolled FROM users WHERE id = $1`
+
+	var enrolled bool
+	if err := s.db.QueryRow(query, id).Scan(&enrolled); err != nil {
+		if err == sql.ErrNoRows {
+			return false, fmt.Errorf("user with ID %s not found", id)
+		}
+		return false, fmt.Errorf("error fetching daily challenge enrollment: %v", err)
+	}
+
+	return enrolled, nil
+}
+
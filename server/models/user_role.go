@@ -0,0 +1,82 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// User roles, each granting everything the previous one does. Editors
+// maintain problem content and reference solutions, admins also create, hide
+// and retire problems and grant roles.
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+var roleRanks = map[string]int{RoleUser: 0, RoleEditor: 1, RoleAdmin: 2}
+
+func IsValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// RoleAtLeast reports whether role grants what required does
+func RoleAtLeast(role, required string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[required]
+}
+
+func (s *UserStore) GetUserRole(userID uuid.UUID) (string, error) {
+	var role string
+	err := s.db.QueryRow(`SELECT role FROM users WHERE id = $1`, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching user role: %v", err)
+	}
+	return role, nil
+}
+
+// SetUserRole changes the role of a user on behalf of actorID, recording the
+// change in the audit log
+func (s *UserStore) SetUserRole(actorID, userID uuid.UUID, role string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var old string
+	err = tx.QueryRow(`SELECT role FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&old)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching user role: %v", err)
+	}
+
+	if old == role {
+		return nil
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET role = $1 WHERE id = $2`, role, userID); err != nil {
+		return fmt.Errorf("error updating user role: %v", err)
+	}
+
+	changes := map[string]AuditChange{"role": {Old: old, New: role}}
+	if err := recordAudit(tx, actorID, AuditUserRole, "user", userID.String(), changes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing user role: %v", err)
+	}
+	return nil
+}
@@ -16,14 +16,33 @@ type Response struct {
 // MetaData contains metadata information
 type MetaData struct {
 	Pagination *Pagination `json:"pagination,omitempty"`
+	Facets     interface{} `json:"facets,omitempty"`
 	Timestamp  string      `json:"timestamp"`
 }
 
-// Pagination information
+// Pagination information. Offset pages carry a page number; cursor pages
+// carry the cursor of the following page instead, and a total only when it
+// was counted.
 type Pagination struct {
-	Total   int `json:"total"`
-	Page    int `json:"page"`
-	PerPage int `json:"per_page"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	PerPage    int    `json:"per_page"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+
+	uncounted bool // leaves total out
+}
+
+func (p Pagination) MarshalJSON() ([]byte, error) {
+	type pagination Pagination
+	if !p.uncounted {
+		return json.Marshal(pagination(p))
+	}
+	// the outer field hides the embedded total
+	return json.Marshal(struct {
+		pagination
+		Total *int `json:"total,omitempty"`
+	}{pagination: pagination(p)})
 }
 
 // ErrorDetail contains error information
@@ -57,7 +76,31 @@ func JSONWithPagination(w http.ResponseWriter, statusCode int, data interface{},
 				Total:   total,
 				Page:    page,
 				PerPage: perPage,
+				HasMore: page*perPage < total,
+			},
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+		},
+		Errors: []ErrorDetail{},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// JSONWithFacets sends a paginated JSON response that also carries facet
+// counts for the current filter in its metadata
+func JSONWithFacets(w http.ResponseWriter, statusCode int, data interface{}, total, page, perPage int, facets interface{}) {
+	resp := Response{
+		Data: data,
+		Meta: &MetaData{
+			Pagination: &Pagination{
+				Total:   total,
+				Page:    page,
+				PerPage: perPage,
+				HasMore: page*perPage < total,
 			},
+			Facets:    facets,
 			Timestamp: time.Now().UTC().Format(time.RFC3339),
 		},
 		Errors: []ErrorDetail{},
@@ -68,6 +111,34 @@ func JSONWithPagination(w http.ResponseWriter, statusCode int, data interface{},
 	json.NewEncoder(w).Encode(resp)
 }
 
+// JSONWithCursor sends a keyset paginated JSON response; total is left out
+// when nil and facets may be nil
+func JSONWithCursor(w http.ResponseWriter, statusCode int, data interface{}, total *int, perPage int, nextCursor string, hasMore bool, facets interface{}) {
+	pagination := &Pagination{
+		PerPage:    perPage,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+		uncounted:  total == nil,
+	}
+	if total != nil {
+		pagination.Total = *total
+	}
+
+	resp := Response{
+		Data: data,
+		Meta: &MetaData{
+			Pagination: pagination,
+			Facets:     facets,
+			Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		},
+		Errors: []ErrorDetail{},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
 // Error sends a standardized error response
 func Error(w http.ResponseWriter, statusCode int, code, message string) {
 	resp := Response{
@@ -107,4 +178,4 @@ func ValidationError(w http.ResponseWriter, field, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusBadRequest)
 	json.NewEncoder(w).Encode(resp)
-}
\ No newline at end of file
+}
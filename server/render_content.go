@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"go-leetcode/backend/internal/database"
+	"go-leetcode/backend/models"
+	"os"
+)
+
+// runRenderContent implements the render-content subcommand, which caches the
+// HTML, Markdown and summary renders and parses the structured examples of
+// every problem whose content changed, e.g. after running the scraper:
+//
+//	go run . render-content
+func runRenderContent(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "usage: render-content")
+		return 2
+	}
+
+	db, err := database.NewConnection(dbConfigFromEnv())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	store := models.NewProblemStore(db)
+
+	rendered, err := store.RenderStaleContent()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Rendering failed: %v\n", err)
+		return 1
+	}
+
+	parsed, err := store.ParseStaleExamples()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Parsing examples failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "rendered %d problems, parsed examples of %d problems\n", rendered, parsed)
+	return 0
+}
@@ -3,6 +3,7 @@ import json
 import time
 import psycopg2
 import os
+import subprocess
 from psycopg2.extras import Json
 
 # Database connection parameters
@@ -37,7 +38,14 @@ def insert_problem(conn, problem_data):
             'content': problem_data.get('question'),
             'topic_tags': Json(problem_data.get('topicTags', [])),
             'example_testcases': problem_data.get('exampleTestcases'),
-            'similar_questions': similar_questions
+            'similar_questions': similar_questions,
+            # starter code and function metadata, when the detail endpoint has
+            # them; `go run . import-snippets` fills in the rest
+            'code_snippets': Json([
+                {'lang': snippet['lang'], 'lang_slug': snippet['langSlug'], 'code': snippet['code']}
+                for snippet in (problem_data.get('codeSnippets') or [])
+            ]),
+            'metadata': problem_data.get('metaData') or None
         }
 
         if not problem['content']:
@@ -45,8 +53,8 @@ def insert_problem(conn, problem_data):
         
         cur.execute("""
         INSERT INTO problems 
-        (id, frontend_id, title, title_slug, difficulty, is_paid_only, content, topic_tags, example_testcases, similar_questions)
-        VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s)
+        (id, frontend_id, title, title_slug, difficulty, is_paid_only, content, topic_tags, example_testcases, similar_questions, code_snippets, metadata)
+        VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s)
         ON CONFLICT (title_slug) DO UPDATE SET
         title = EXCLUDED.title,
         difficulty = EXCLUDED.difficulty,
@@ -54,7 +62,10 @@ def insert_problem(conn, problem_data):
         content = EXCLUDED.content,
         topic_tags = EXCLUDED.topic_tags,
         example_testcases = EXCLUDED.example_testcases,
-        similar_questions = EXCLUDED.similar_questions
+        similar_questions = EXCLUDED.similar_questions,
+        code_snippets = CASE WHEN EXCLUDED.code_snippets = '[]'::jsonb THEN problems.code_snippets ELSE EXCLUDED.code_snippets END,
+        metadata = COALESCE(EXCLUDED.metadata, problems.metadata),
+        examples_source_md5 = CASE WHEN problems.metadata IS DISTINCT FROM COALESCE(EXCLUDED.metadata, problems.metadata) THEN NULL ELSE problems.examples_source_md5 END
         """, (
             problem['id'],
             problem['frontend_id'],
@@ -65,10 +76,21 @@ def insert_problem(conn, problem_data):
             problem['content'],
             problem['topic_tags'],
             problem['example_testcases'],
-            problem['similar_questions']
+            problem['similar_questions'],
+            problem['code_snippets'],
+            problem['metadata']
         ))
     conn.commit()
 
+# Cache the HTML, Markdown and summary renders of the scraped problems, so
+# problem reads never have to render them
+def render_content():
+    env = dict(os.environ, DB_HOST=db_params['host'], DB_PORT=db_params['port'],
+               DB_USER=db_params['user'], DB_PASSWORD=db_params['password'],
+               DB_NAME=db_params['database'])
+    subprocess.run(['go', 'run', '.', 'render-content'],
+                   cwd=os.path.dirname(os.path.abspath(__file__)), env=env, check=True)
+
 def problem_exists(conn, problem_id):
     with conn.cursor() as cur:
         cur.execute("SELECT 1 FROM problems WHERE id = %s", (problem_id,))
@@ -122,6 +144,9 @@ def main():
             time.sleep(10)
         
         print("All problems processed!")
+
+        print("Rendering content...")
+        render_content()
         
     except Exception as e:
         print(f"Error: {str(e)}")
@@ -0,0 +1,31 @@
+-- LEETCODE_SESSION cookies users hand us for the history import, encrypted at rest
+CREATE TABLE leetcode_sessions (
+	user_id uuid NOT NULL,
+	encrypted_session text NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT leetcode_sessions_pkey PRIMARY KEY (user_id)
+);
+
+ALTER TABLE leetcode_sessions ADD CONSTRAINT leetcode_sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+-- One row per history import run, so the client can poll progress and interrupted runs can resume
+CREATE TABLE submission_imports (
+	id serial4 NOT NULL,
+	user_id uuid NOT NULL,
+	status varchar DEFAULT 'pending' NOT NULL,
+	next_offset int4 DEFAULT 0 NOT NULL,
+	last_key varchar NULL,
+	fetched_count int4 DEFAULT 0 NOT NULL,
+	imported_count int4 DEFAULT 0 NOT NULL,
+	seeded_reviews int4 DEFAULT 0 NOT NULL,
+	error text NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	finished_at timestamp NULL,
+	CONSTRAINT submission_imports_pkey PRIMARY KEY (id),
+	CONSTRAINT submission_imports_status_check CHECK ((status = ANY (ARRAY['pending'::varchar, 'running'::varchar, 'completed'::varchar, 'failed'::varchar])))
+);
+
+ALTER TABLE submission_imports ADD CONSTRAINT submission_imports_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_submission_imports_user ON submission_imports(user_id, created_at);
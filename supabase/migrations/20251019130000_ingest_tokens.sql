@@ -0,0 +1,18 @@
+-- Long lived, revocable tokens used by browser extensions and userscripts to
+-- push submissions. Only the sha256 of the token is stored.
+CREATE TABLE ingest_tokens (
+	id serial4 NOT NULL,
+	user_id uuid NOT NULL,
+	name varchar NOT NULL,
+	token_prefix varchar NOT NULL,
+	token_hash varchar NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	last_used_at timestamp NULL,
+	revoked_at timestamp NULL,
+	CONSTRAINT ingest_tokens_pkey PRIMARY KEY (id),
+	CONSTRAINT ingest_tokens_token_hash_key UNIQUE (token_hash)
+);
+
+CREATE INDEX idx_ingest_tokens_user_id ON ingest_tokens USING btree (user_id);
+
+ALTER TABLE ingest_tokens ADD CONSTRAINT ingest_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+-- Optional details carried by imported solve histories
+ALTER TABLE submissions ADD COLUMN language varchar NULL;
+ALTER TABLE submissions ADD COLUMN runtime varchar NULL;
+
+CREATE INDEX idx_submissions_user_slug ON submissions USING btree (user_id, title_slug);
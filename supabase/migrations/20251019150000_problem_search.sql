@@ -0,0 +1,26 @@
+-- Full text search over problems. Titles weigh most, then topic tags, the
+-- solution approach and finally the problem statement with its HTML stripped.
+ALTER TABLE problems ADD COLUMN search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION problems_search_vector_update() RETURNS trigger AS $$
+BEGIN
+	NEW.search_vector :=
+		setweight(to_tsvector('english', coalesce(NEW.title, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce((
+			SELECT string_agg(tag->>'name', ' ')
+			FROM jsonb_array_elements(coalesce(NEW.topic_tags, '[]'::jsonb)) AS tag
+		), '')), 'B') ||
+		setweight(to_tsvector('english', coalesce(NEW.solution_approach, '')), 'C') ||
+		setweight(to_tsvector('english', regexp_replace(coalesce(NEW."content", ''), '<[^>]+>', ' ', 'g')), 'D');
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER problems_search_vector_trigger
+	BEFORE INSERT OR UPDATE OF title, "content", topic_tags, solution_approach ON problems
+	FOR EACH ROW EXECUTE FUNCTION problems_search_vector_update();
+
+-- backfill existing rows through the trigger
+UPDATE problems SET title = title;
+
+CREATE INDEX idx_problems_search_vector ON problems USING gin (search_vector);
@@ -0,0 +1,50 @@
+-- Similar problem graph, normalized from problems.similar_questions. An edge
+-- points from a problem to one it lists as similar; readers treat the graph as
+-- undirected since LeetCode does not always list both directions.
+CREATE TABLE problem_similarities (
+	problem_id int4 NOT NULL REFERENCES problems(id) ON DELETE CASCADE,
+	similar_problem_id int4 NOT NULL REFERENCES problems(id) ON DELETE CASCADE,
+	CONSTRAINT problem_similarities_pkey PRIMARY KEY (problem_id, similar_problem_id),
+	CONSTRAINT problem_similarities_no_self CHECK (problem_id <> similar_problem_id)
+);
+
+CREATE INDEX idx_problem_similarities_similar ON problem_similarities (similar_problem_id);
+
+-- keep edges in sync whenever similar_questions is written. A new problem also
+-- picks up edges from problems that listed it before it existed.
+CREATE OR REPLACE FUNCTION problems_similarities_sync() RETURNS trigger AS $$
+BEGIN
+	DELETE FROM problem_similarities WHERE problem_id = NEW.id;
+
+	INSERT INTO problem_similarities (problem_id, similar_problem_id)
+	SELECT NEW.id, sp.id
+	FROM jsonb_array_elements(coalesce(NEW.similar_questions, '[]'::jsonb)) AS q
+	JOIN problems sp ON sp.title_slug = q->>'titleSlug'
+	WHERE sp.id <> NEW.id
+	ON CONFLICT DO NOTHING;
+
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO problem_similarities (problem_id, similar_problem_id)
+		SELECT p.id, NEW.id
+		FROM problems p
+		WHERE p.id <> NEW.id
+		  AND p.similar_questions @> jsonb_build_array(jsonb_build_object('titleSlug', NEW.title_slug))
+		ON CONFLICT DO NOTHING;
+	END IF;
+
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER problems_similarities_trigger
+	AFTER INSERT OR UPDATE OF similar_questions ON problems
+	FOR EACH ROW EXECUTE FUNCTION problems_similarities_sync();
+
+-- backfill from the problems already stored
+INSERT INTO problem_similarities (problem_id, similar_problem_id)
+SELECT p.id, sp.id
+FROM problems p
+CROSS JOIN LATERAL jsonb_array_elements(coalesce(p.similar_questions, '[]'::jsonb)) AS q
+JOIN problems sp ON sp.title_slug = q->>'titleSlug'
+WHERE sp.id <> p.id
+ON CONFLICT DO NOTHING;
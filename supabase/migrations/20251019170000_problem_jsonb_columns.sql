@@ -0,0 +1,21 @@
+-- topic_tags and similar_questions are always JSON arrays: no NULLs, no other
+-- JSON types, so the API can scan them without special cases.
+ALTER TABLE problems ALTER COLUMN topic_tags TYPE jsonb USING topic_tags::jsonb;
+ALTER TABLE problems ALTER COLUMN similar_questions TYPE jsonb USING similar_questions::jsonb;
+
+UPDATE problems SET topic_tags = '[]'::jsonb
+WHERE topic_tags IS NULL OR jsonb_typeof(topic_tags) <> 'array';
+
+UPDATE problems SET similar_questions = '[]'::jsonb
+WHERE similar_questions IS NULL OR jsonb_typeof(similar_questions) <> 'array';
+
+ALTER TABLE problems
+	ALTER COLUMN topic_tags SET DEFAULT '[]'::jsonb,
+	ALTER COLUMN topic_tags SET NOT NULL,
+	ALTER COLUMN similar_questions SET DEFAULT '[]'::jsonb,
+	ALTER COLUMN similar_questions SET NOT NULL,
+	ADD CONSTRAINT problems_topic_tags_array CHECK (jsonb_typeof(topic_tags) = 'array'),
+	ADD CONSTRAINT problems_similar_questions_array CHECK (jsonb_typeof(similar_questions) = 'array');
+
+-- serves the tag filters of the problem list, e.g. topic_tags @> '[{"slug": "graph"}]'
+CREATE INDEX idx_problems_topic_tags ON problems USING gin (topic_tags jsonb_path_ops);
@@ -0,0 +1,8 @@
+-- Structured examples parsed by internal/examples from example_testcases and
+-- the Input/Output sections of content. They are stale once
+-- examples_source_md5 no longer matches both columns, or when they were
+-- parsed by an older parser version.
+ALTER TABLE problems
+	ADD COLUMN examples jsonb NOT NULL DEFAULT '[]'::jsonb CHECK (jsonb_typeof(examples) = 'array'),
+	ADD COLUMN examples_source_md5 text,
+	ADD COLUMN examples_version int4;
@@ -0,0 +1,30 @@
+-- Reference solutions remember who wrote them. Users propose solutions into
+-- solution_proposals; an editor approving one copies it into
+-- problem_solutions, replacing the solution in its language.
+ALTER TABLE problem_solutions ADD COLUMN author_id uuid NULL;
+ALTER TABLE problem_solutions ADD COLUMN updated_at timestamp NULL;
+ALTER TABLE problem_solutions ADD CONSTRAINT problem_solutions_author_id_fkey FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE TABLE solution_proposals (
+	id serial4 NOT NULL,
+	problem_id int4 NOT NULL,
+	"language" varchar NOT NULL,
+	solution_code text NOT NULL,
+	author_id uuid NOT NULL,
+	status varchar DEFAULT 'pending' NOT NULL,
+	solution_id int4 NULL,
+	reviewer_id uuid NULL,
+	review_note text DEFAULT '' NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	reviewed_at timestamp NULL,
+	CONSTRAINT solution_proposals_pkey PRIMARY KEY (id),
+	CONSTRAINT solution_proposals_status_check CHECK ((status = ANY (ARRAY['pending'::varchar, 'approved'::varchar, 'rejected'::varchar])))
+);
+
+ALTER TABLE solution_proposals ADD CONSTRAINT solution_proposals_problem_id_fkey FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE;
+ALTER TABLE solution_proposals ADD CONSTRAINT solution_proposals_author_id_fkey FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE solution_proposals ADD CONSTRAINT solution_proposals_solution_id_fkey FOREIGN KEY (solution_id) REFERENCES problem_solutions(id) ON DELETE SET NULL;
+ALTER TABLE solution_proposals ADD CONSTRAINT solution_proposals_reviewer_id_fkey FOREIGN KEY (reviewer_id) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_solution_proposals_status ON solution_proposals(status, created_at);
+CREATE INDEX idx_solution_proposals_author ON solution_proposals(author_id, created_at);
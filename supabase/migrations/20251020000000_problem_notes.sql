@@ -0,0 +1,37 @@
+-- A user's own notes on a problem: Markdown text, code per language and tags.
+-- Every save keeps the content as a numbered revision so edits can be diffed
+-- and restored.
+CREATE TABLE problem_notes (
+	id serial4 NOT NULL,
+	user_id uuid NOT NULL,
+	problem_id int4 NOT NULL,
+	body text DEFAULT '' NOT NULL,
+	code jsonb DEFAULT '{}'::jsonb NOT NULL,
+	tags _text DEFAULT '{}'::text[] NOT NULL,
+	revision int4 DEFAULT 0 NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT problem_notes_pkey PRIMARY KEY (id),
+	CONSTRAINT unique_user_problem_note UNIQUE (user_id, problem_id)
+);
+
+ALTER TABLE problem_notes ADD CONSTRAINT problem_notes_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE problem_notes ADD CONSTRAINT problem_notes_problem_id_fkey FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_problem_notes_user_updated ON problem_notes(user_id, updated_at DESC);
+CREATE INDEX idx_problem_notes_tags ON problem_notes USING gin (tags);
+
+CREATE TABLE problem_note_revisions (
+	id serial4 NOT NULL,
+	note_id int4 NOT NULL,
+	revision int4 NOT NULL,
+	body text NOT NULL,
+	code jsonb NOT NULL,
+	tags _text NOT NULL,
+	restored_from int4 NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT problem_note_revisions_pkey PRIMARY KEY (id),
+	CONSTRAINT unique_note_revision UNIQUE (note_id, revision)
+);
+
+ALTER TABLE problem_note_revisions ADD CONSTRAINT problem_note_revisions_note_id_fkey FOREIGN KEY (note_id) REFERENCES problem_notes(id) ON DELETE CASCADE;
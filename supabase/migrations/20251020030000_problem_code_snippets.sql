@@ -0,0 +1,6 @@
+-- LeetCode's starter code per language and the metadata describing the
+-- solution function (its name, parameter types and return type). Examples
+-- parsed before the metadata was known are parsed again with it.
+ALTER TABLE problems
+	ADD COLUMN code_snippets jsonb DEFAULT '[]'::jsonb NOT NULL,
+	ADD COLUMN metadata jsonb NULL;
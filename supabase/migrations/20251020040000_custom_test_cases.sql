@@ -0,0 +1,19 @@
+-- Users' own test cases of a problem, such as empty inputs or overflow cases.
+-- Inputs are keyed by parameter name like the parsed examples; a case without
+-- an expected value only checks that the solution runs.
+CREATE TABLE problem_test_cases (
+	id serial4 NOT NULL,
+	user_id uuid NOT NULL,
+	problem_id int4 NOT NULL,
+	"name" varchar DEFAULT '' NOT NULL,
+	inputs jsonb DEFAULT '{}'::jsonb NOT NULL,
+	expected jsonb NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT problem_test_cases_pkey PRIMARY KEY (id)
+);
+
+ALTER TABLE problem_test_cases ADD CONSTRAINT problem_test_cases_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE problem_test_cases ADD CONSTRAINT problem_test_cases_problem_id_fkey FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_problem_test_cases_user_problem ON problem_test_cases(user_id, problem_id, id);
@@ -0,0 +1,7 @@
+-- Ingest requests are signed with a secret issued next to the token rather
+-- than the bearer token itself, so a leaked Authorization header alone cannot
+-- sign requests. The secret is kept encrypted with SECRETS_ENCRYPTION_KEY.
+ALTER TABLE ingest_tokens ADD COLUMN encrypted_signing_secret varchar NULL;
+
+-- tokens issued before this have no signing secret and have to be replaced
+UPDATE ingest_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE revoked_at IS NULL;
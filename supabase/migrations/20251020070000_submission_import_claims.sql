@@ -0,0 +1,4 @@
+-- Imports are claimed by the server instance running them. Progress saves
+-- refresh heartbeat_at, a running import whose heartbeat is stale was left
+-- behind by a crashed instance and can be claimed again.
+ALTER TABLE submission_imports ADD COLUMN heartbeat_at timestamp NULL;
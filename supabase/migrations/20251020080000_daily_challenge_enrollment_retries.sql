@@ -0,0 +1,15 @@
+-- When users opted in, so the daily challenge job can retry every day since
+-- then that is not enrolled yet instead of only the current one
+ALTER TABLE users ADD COLUMN daily_challenge_enrolled_at timestamp NULL;
+
+UPDATE users u SET daily_challenge_enrolled_at = COALESCE(
+	(SELECT MIN(udc.created_at) FROM user_daily_challenges udc WHERE udc.user_id = u.id),
+	CURRENT_TIMESTAMP
+)
+WHERE u.daily_challenge_enrolled;
+
+-- days recorded while their problem was missing from the catalogue never got
+-- a flashcard; forget them so they are enrolled once the problem is added
+DELETE FROM user_daily_challenges udc
+USING daily_challenges dc
+WHERE dc.challenge_date = udc.challenge_date AND dc.region = udc.region AND dc.problem_id IS NULL;
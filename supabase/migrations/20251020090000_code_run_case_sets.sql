@@ -0,0 +1,13 @@
+-- Which cases a run was made against: the problem's examples, the user's own
+-- test cases or both. Ratings are only derived from runs that include the
+-- examples, as the user's cases may have no expected output.
+ALTER TABLE code_runs ADD COLUMN case_set varchar DEFAULT 'examples' NOT NULL;
+
+UPDATE code_runs
+SET case_set = CASE
+	WHEN NOT EXISTS (SELECT 1 FROM jsonb_array_elements(cases) c WHERE c ? 'test_case_id') THEN 'examples'
+	WHEN NOT EXISTS (SELECT 1 FROM jsonb_array_elements(cases) c WHERE NOT c ? 'test_case_id') THEN 'custom'
+	ELSE 'all'
+END;
+
+ALTER TABLE code_runs ADD CONSTRAINT code_runs_case_set_check CHECK ((case_set = ANY (ARRAY['examples'::varchar, 'custom'::varchar, 'all'::varchar])));
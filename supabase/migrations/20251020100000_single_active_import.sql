@@ -0,0 +1,13 @@
+-- A user has at most one pending or running history import, so concurrent
+-- requests cannot start two imports seeding the same reviews. Older duplicates
+-- are failed first.
+UPDATE submission_imports i
+SET status = 'failed', error = 'superseded by a newer import', finished_at = CURRENT_TIMESTAMP
+WHERE status IN ('pending', 'running')
+  AND EXISTS (
+	SELECT 1 FROM submission_imports newer
+	WHERE newer.user_id = i.user_id AND newer.status IN ('pending', 'running')
+	  AND (newer.created_at, newer.id) > (i.created_at, i.id)
+  );
+
+CREATE UNIQUE INDEX idx_submission_imports_active_user ON submission_imports(user_id) WHERE status IN ('pending', 'running');